@@ -3,6 +3,7 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
@@ -22,9 +23,9 @@ var cfg config.Config
 var rootCmd = &cobra.Command{
 	Use:     "idpscim",
 	Version: version.Version,
-	Short:   "Sync your AWS Single Sign-On (SSO) with Google Workspace",
+	Short:   "Sync your AWS Single Sign-On (SSO) with Google Workspace, Microsoft Entra ID, Okta or LDAP",
 	Long: `
-Sync your Google Workspace Groups and Users to AWS Single Sign-On using
+Sync your Google Workspace, Microsoft Entra ID, Okta or LDAP/Active Directory Groups and Users to AWS Single Sign-On using
 AWS SSO SCIM API (https://docs.aws.amazon.com/singlesignon/latest/developerguide/what-is-scim.html).`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return run(cmd.Context())
@@ -50,13 +51,6 @@ func init() {
 			os.Exit(1)
 		}
 		setup.Logger(cfg.LogLevel, cfg.LogFormat)
-
-		if cfg.IsLambda || cfg.UseSecretsManager {
-			if err := setup.Secrets(&cfg); err != nil {
-				slog.Error("cannot get secrets", "error", err)
-				os.Exit(1)
-			}
-		}
 	})
 
 	rootCmd.PersistentFlags().StringVarP(&cfg.ConfigFile, "config-file", "c", config.DefaultConfigFile, "configuration file")
@@ -67,40 +61,171 @@ func init() {
 	rootCmd.PersistentFlags().StringVarP(&cfg.AWSSCIMAccessTokenSecretName, "aws-scim-access-token-secret-name", "j", config.DefaultAWSSCIMAccessTokenSecretName, "AWS Secrets Manager secret name for AWS SSO SCIM API Access Token")
 	rootCmd.PersistentFlags().StringVarP(&cfg.AWSSCIMEndpoint, "aws-scim-endpoint", "e", "", "AWS SSO SCIM API Endpoint")
 	rootCmd.PersistentFlags().StringVarP(&cfg.AWSSCIMEndpointSecretName, "aws-scim-endpoint-secret-name", "n", config.DefaultAWSSCIMEndpointSecretName, "AWS Secrets Manager secret name for AWS SSO SCIM API Endpoint")
+	rootCmd.Flags().IntVar(&cfg.AWSSCIMConcurrency, "aws-scim-concurrency", config.DefaultAWSSCIMConcurrency, "AWS SCIM create, update and delete requests sent at the same time, 1 sends them one by one")
+	rootCmd.Flags().Float64Var(&cfg.AWSSCIMRateLimitQPS, "aws-scim-rate-limit-qps", config.DefaultAWSSCIMRateLimitQPS, "AWS SCIM API maximum requests per second, reduced while AWS throttles the requests, 0 disables the rate limit")
+	rootCmd.Flags().IntVar(&cfg.AWSSCIMRateLimitConcurrency, "aws-scim-rate-limit-concurrency", config.DefaultAWSSCIMRateLimitConcurrency, "AWS SCIM API maximum requests in flight, reduced while AWS throttles the requests")
+	rootCmd.PersistentFlags().StringVar(&cfg.TargetType, "target-type", config.DefaultTargetType, "SCIM service provider used as target of the sync [aws|scim]")
+	rootCmd.PersistentFlags().StringVar(&cfg.SCIMTargetURL, "scim-target-url", "", "target SCIM service base url, example: https://api.github.com/scim/v2/enterprises/example")
+	rootCmd.PersistentFlags().StringVar(&cfg.SCIMTargetToken, "scim-target-token", "", "target SCIM service bearer token")
+	rootCmd.PersistentFlags().StringVar(&cfg.SCIMTargetTokenSecretName, "scim-target-token-secret-name", config.DefaultSCIMTargetTokenSecretName, "AWS Secrets Manager secret name for target SCIM service bearer token")
+	rootCmd.PersistentFlags().IntVar(&cfg.SCIMTargetPageSize, "scim-target-page-size", 0, "target SCIM service page size, 100 when zero")
 	rootCmd.PersistentFlags().StringVarP(&cfg.AWSS3BucketName, "aws-s3-bucket-name", "b", "", "AWS S3 Bucket name to store the state")
 	rootCmd.PersistentFlags().StringVarP(&cfg.AWSS3BucketKey, "aws-s3-bucket-key", "k", config.DefaultAWSS3BucketKey, "AWS S3 Bucket key to store the state")
 	rootCmd.PersistentFlags().StringVarP(&cfg.GWSServiceAccountFile, "gws-service-account-file", "s", config.DefaultGWSServiceAccountFile, "Google Workspace service account file")
 	rootCmd.PersistentFlags().StringVarP(&cfg.GWSServiceAccountFileSecretName, "gws-service-account-file-secret-name", "o", config.DefaultGWSServiceAccountFileSecretName, "AWS Secrets Manager secret name for Google Workspace service account file")
 	rootCmd.PersistentFlags().StringVarP(&cfg.GWSUserEmail, "gws-user-email", "u", "", "GWS user email with allowed access to the Google Workspace Service Account")
 	rootCmd.PersistentFlags().StringVarP(&cfg.GWSUserEmailSecretName, "gws-user-email-secret-name", "p", config.DefaultGWSUserEmailSecretName, "AWS Secrets Manager secret name for GWS user email with allowed access to the Google Workspace Service Account")
+	rootCmd.PersistentFlags().StringVar(&cfg.GWSServiceAccountEmail, "gws-service-account-email", "", "Google Workspace service account delegated with a workload identity federation credential configuration as service account file")
 	rootCmd.Flags().StringSliceVarP(&cfg.GWSGroupsFilter, "gws-groups-filter", "q", []string{""}, "GWS Groups query parameter, example: --gws-groups-filter 'name:Admin* email:admin*' --gws-groups-filter 'name:Power* email:power*'")
+	rootCmd.Flags().StringSliceVar(&cfg.GWSOrgUnits, "gws-org-units", nil, "GWS organizational units synced as groups with the users of the unit and its children, example: --gws-org-units '/Engineering' --gws-org-units '/Contractors/AWS=AWS-Contractors'")
+	rootCmd.Flags().StringVar(&cfg.GWSNestingPolicy, "gws-nesting-policy", config.DefaultGWSNestingPolicy, "GWS nested groups sync policy [flatten|direct-only|mirror]")
+	rootCmd.Flags().StringVar(&cfg.GWSSuspendedUsersPolicy, "gws-suspended-users-policy", config.DefaultGWSSuspendedUsersPolicy, "GWS suspended and archived users sync policy [drop|deactivate|deactivate-ungroup]")
+	rootCmd.Flags().StringVar(&cfg.GWSExternalMembersPolicy, "gws-external-members-policy", config.DefaultGWSExternalMembersPolicy, "GWS group members not in the directory sync policy [fail|skip|allow]")
+	rootCmd.Flags().StringSliceVar(&cfg.GWSExternalDomains, "gws-external-domains", nil, "GWS external domains synced with the allow external members policy, example: --gws-external-domains 'partner.com,contractor.io'")
+	rootCmd.Flags().StringVar(&cfg.GWSDuplicateGroupNames, "gws-duplicate-group-names", config.DefaultGWSDuplicateGroupNames, "GWS groups with the same name sync strategy [fail|keep-first|suffix|email]")
+	rootCmd.Flags().StringSliceVar(&cfg.GWSCustomSchemaAttributes, "gws-custom-schema-attributes", nil, "GWS custom schema attributes synced with the users, example: --gws-custom-schema-attributes 'HR.CostCenter=costCenter,AWS.AllowedRegions'")
+	rootCmd.Flags().StringVar(&cfg.GWSGroupsSource, "gws-groups-source", config.DefaultGWSGroupsSource, "GWS API the groups are read from [directory|cloud-identity]")
+	rootCmd.Flags().StringVar(&cfg.GWSCustomerID, "gws-customer-id", "", "GWS customer id, required by the cloud-identity groups source, example: --gws-customer-id C046psxkn")
+	rootCmd.Flags().StringSliceVar(&cfg.GWSGroupLabels, "gws-group-labels", nil, "GWS labels of the groups read from the cloud-identity groups source, example: --gws-group-labels 'cloudidentity.googleapis.com/groups.security'")
+	rootCmd.Flags().Float64Var(&cfg.GWSRateLimitQPS, "gws-rate-limit-qps", config.DefaultGWSRateLimitQPS, "GWS API requests per second shared by all the calls, 0 disables the rate limit")
+	rootCmd.Flags().IntVar(&cfg.GWSRateLimitBurst, "gws-rate-limit-burst", config.DefaultGWSRateLimitBurst, "GWS API requests sent in a burst above the rate limit")
+	rootCmd.Flags().IntVar(&cfg.GWSBulkUsersThreshold, "gws-bulk-users-threshold", config.DefaultGWSBulkUsersThreshold, "GWS group members above which all the users are listed at once instead of read one by one, 0 disables it")
+	rootCmd.Flags().BoolVar(&cfg.GWSCache, "gws-cache", false, "GWS users and group members kept with their ETags next to the state, requested again only when modified")
+	rootCmd.Flags().StringArrayVar(&cfg.GWSMemberRoles, "gws-member-roles", nil, "GWS groups projected by member role, example: --gws-member-roles 'aws-admins@example.com:OWNER=aws-admins-owners' --gws-member-roles 'aws-admins@example.com:MANAGER,MEMBER=aws-admins-members'")
 	rootCmd.PersistentFlags().StringVarP(&cfg.SyncMethod, "sync-method", "m", config.DefaultSyncMethod, "Sync method to use [groups]")
 	rootCmd.PersistentFlags().BoolVarP(&cfg.UseSecretsManager, "use-secrets-manager", "g", config.DefaultUseSecretsManager, "use AWS Secrets Manager content or not (default false)")
 	rootCmd.Flags().StringSliceVar(&cfg.SyncUserFields, "sync-user-fields", nil, "optional user fields to sync (e.g., phoneNumbers,addresses,enterpriseData); default: all fields")
+	rootCmd.PersistentFlags().StringVar(&cfg.IDPType, "idp-type", config.DefaultIDPType, "identity provider used as source of the sync [google|entra|okta|ldap|file|scim]")
+	rootCmd.PersistentFlags().StringVar(&cfg.EntraTenantID, "entra-tenant-id", "", "Microsoft Entra ID tenant id")
+	rootCmd.PersistentFlags().StringVar(&cfg.EntraClientID, "entra-client-id", "", "Microsoft Entra ID app registration client id")
+	rootCmd.PersistentFlags().StringVar(&cfg.EntraClientSecret, "entra-client-secret", "", "Microsoft Entra ID app registration client secret")
+	rootCmd.PersistentFlags().StringVar(&cfg.EntraClientSecretSecretName, "entra-client-secret-secret-name", config.DefaultEntraClientSecretSecretName, "AWS Secrets Manager secret name for Microsoft Entra ID client secret")
+	rootCmd.Flags().StringSliceVar(&cfg.EntraGroupsFilter, "entra-groups-filter", nil, "Entra ID groups OData filter, example: --entra-groups-filter \"startswith(displayName,'AWS')\"")
+	rootCmd.Flags().BoolVar(&cfg.EntraUsersDelta, "entra-users-delta", false, "resolve the Entra ID users with delta queries instead of reading them one by one")
+
+	rootCmd.PersistentFlags().StringVar(&cfg.OktaOrgURL, "okta-org-url", "", "Okta organization url, example: https://example.okta.com")
+	rootCmd.PersistentFlags().StringVar(&cfg.OktaAPIToken, "okta-api-token", "", "Okta API token")
+	rootCmd.PersistentFlags().StringVar(&cfg.OktaAPITokenSecretName, "okta-api-token-secret-name", config.DefaultOktaAPITokenSecretName, "AWS Secrets Manager secret name for Okta API token")
+	rootCmd.Flags().StringSliceVar(&cfg.OktaGroupsFilter, "okta-groups-filter", nil, "Okta groups search expression, example: --okta-groups-filter 'profile.name sw \"AWS\"'")
+
+	rootCmd.PersistentFlags().StringVar(&cfg.LDAPURL, "ldap-url", "", "LDAP server url, example: ldaps://ldap.example.com:636")
+	rootCmd.PersistentFlags().StringVar(&cfg.LDAPBindDN, "ldap-bind-dn", "", "LDAP bind dn, anonymous bind when empty")
+	rootCmd.PersistentFlags().StringVar(&cfg.LDAPBindPassword, "ldap-bind-password", "", "LDAP bind password")
+	rootCmd.PersistentFlags().StringVar(&cfg.LDAPBindPasswordSecretName, "ldap-bind-password-secret-name", config.DefaultLDAPBindPasswordSecretName, "AWS Secrets Manager secret name for LDAP bind password")
+	rootCmd.PersistentFlags().StringVar(&cfg.LDAPBaseDN, "ldap-base-dn", "", "LDAP base dn of the groups and users searches, example: dc=example,dc=com")
+	rootCmd.Flags().StringSliceVar(&cfg.LDAPGroupsFilter, "ldap-groups-filter", nil, "LDAP groups filters or dns, example: --ldap-groups-filter '(cn=AWS-*)'")
+	rootCmd.Flags().StringVar(&cfg.LDAPMembersStrategy, "ldap-members-strategy", config.DefaultLDAPMembersStrategy, "LDAP nested group members expansion [member|memberof|in_chain]")
+	rootCmd.Flags().BoolVar(&cfg.LDAPStartTLS, "ldap-start-tls", false, "upgrade the ldap:// connection with StartTLS")
+	rootCmd.Flags().BoolVar(&cfg.LDAPInsecureSkipVerify, "ldap-insecure-skip-verify", false, "do not verify the LDAP server certificate")
+	rootCmd.Flags().IntVar(&cfg.LDAPPageSize, "ldap-page-size", 0, "LDAP paged results size, 500 when zero")
+
+	rootCmd.PersistentFlags().StringVar(&cfg.FilePath, "file-path", "", "directory file path or s3 location, example: s3://bucket/directory.yaml")
+	rootCmd.PersistentFlags().StringVar(&cfg.FileFormat, "file-format", "", "directory file format, from the file extension when empty [yaml|json|csv]")
+	rootCmd.Flags().StringSliceVar(&cfg.FileGroupsFilter, "file-groups-filter", nil, "directory file group name patterns, example: --file-groups-filter 'AWS-*'")
+
+	rootCmd.PersistentFlags().StringVar(&cfg.SCIMIdPURL, "scim-idp-url", "", "source SCIM service base url, example: https://scim.example.com/scim/v2")
+	rootCmd.PersistentFlags().StringVar(&cfg.SCIMIdPToken, "scim-idp-token", "", "source SCIM service bearer token")
+	rootCmd.PersistentFlags().StringVar(&cfg.SCIMIdPTokenSecretName, "scim-idp-token-secret-name", config.DefaultSCIMIdPTokenSecretName, "AWS Secrets Manager secret name for source SCIM service bearer token")
+	rootCmd.PersistentFlags().StringVar(&cfg.SCIMIdPUsername, "scim-idp-username", "", "source SCIM service basic auth username, bearer token is used when empty")
+	rootCmd.PersistentFlags().StringVar(&cfg.SCIMIdPPassword, "scim-idp-password", "", "source SCIM service basic auth password")
+	rootCmd.PersistentFlags().StringVar(&cfg.SCIMIdPPasswordSecretName, "scim-idp-password-secret-name", config.DefaultSCIMIdPPasswordSecretName, "AWS Secrets Manager secret name for source SCIM service basic auth password")
+	rootCmd.Flags().StringSliceVar(&cfg.SCIMIdPGroupsFilter, "scim-idp-groups-filter", nil, "source SCIM service groups filters, example: --scim-idp-groups-filter 'displayName sw \"AWS\"'")
+	rootCmd.Flags().IntVar(&cfg.SCIMIdPPageSize, "scim-idp-page-size", 0, "source SCIM service page size, 100 when zero")
+	rootCmd.Flags().StringVar(&cfg.Profile, "profile", "", "name of the profile defined in the configuration file to sync")
+	rootCmd.Flags().BoolVar(&cfg.AllProfiles, "all-profiles", false, "sync all the profiles defined in the configuration file")
 }
 
 func run(ctx context.Context) error {
 	slog.Debug("viper config", "config", viper.AllSettings())
 
-	if cfg.SyncMethod != "groups" {
-		return fmt.Errorf("unknown sync method: %s, only 'groups' are implemented", cfg.SyncMethod)
+	if err := cfg.ValidateProfiles(); err != nil {
+		return fmt.Errorf("invalid profiles configuration: %w", err)
+	}
+
+	var profiles []string
+	switch {
+	case cfg.AllProfiles:
+		profiles = cfg.ProfileNames()
+	case cfg.Profile != "":
+		profiles = []string{cfg.Profile}
+	default:
+		if err := validate(cfg); err != nil {
+			return fmt.Errorf("invalid configuration: %w", err)
+		}
+		return runSync(ctx, cfg)
+	}
+
+	// every profile is validated before syncing any of them, so a wrong profile
+	// is reported before the sync starts instead of halfway through it
+	pcfgs := make([]config.Config, 0, len(profiles))
+	var errs []error
+	for _, name := range profiles {
+		pcfg, err := cfg.ForProfile(name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if err := validate(pcfg); err != nil {
+			errs = append(errs, fmt.Errorf("invalid profile %q: %w", name, err))
+			continue
+		}
+
+		pcfgs = append(pcfgs, pcfg)
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	// profiles are independent, so a failure in one of them doesn't stop the others
+	for _, pcfg := range pcfgs {
+		name := pcfg.Profile
+
+		slog.Info("syncing profile", "profile", name)
+		if err := runSync(ctx, pcfg); err != nil {
+			slog.Error("cannot sync profile", "profile", name, "error", err)
+			errs = append(errs, fmt.Errorf("profile %q: %w", name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validate validates an effective configuration, the top-level one or a profile.
+func validate(syncCfg config.Config) error {
+	// the credentials are read from AWS Secrets Manager when the sync starts
+	syncCfg.UseSecretsManager = syncCfg.UseSecretsManager || syncCfg.IsLambda
+
+	return syncCfg.Validate()
+}
+
+// runSync runs the sync of a single effective configuration, the top-level one or a profile.
+func runSync(ctx context.Context, syncCfg config.Config) error {
+	if syncCfg.SyncMethod != "groups" {
+		return fmt.Errorf("unknown sync method: %s, only 'groups' are implemented", syncCfg.SyncMethod)
+	}
+
+	if syncCfg.IsLambda || syncCfg.UseSecretsManager {
+		if err := setup.Secrets(&syncCfg); err != nil {
+			return fmt.Errorf("cannot get secrets: %w", err)
+		}
 	}
 
-	slog.Info("starting sync groups", "codeVersion", version.Version)
+	slog.Info("starting sync groups", "codeVersion", version.Version, "profile", syncCfg.Profile)
 	timeStart := time.Now()
 
-	ss, err := setup.SyncService(ctx, &cfg)
+	ss, err := setup.SyncService(ctx, &syncCfg)
 	if err != nil {
 		return fmt.Errorf("cannot create sync service: %w", err)
 	}
 
-	slog.Debug("app config", "config", cfg)
+	slog.Debug("app config", "config", syncCfg)
 
 	if err := ss.SyncGroupsAndTheirMembers(ctx); err != nil {
 		return fmt.Errorf("cannot sync groups and their members: %w", err)
 	}
 
-	slog.Info("sync groups completed", "duration", time.Since(timeStart).String())
+	slog.Info("sync groups completed", "duration", time.Since(timeStart).String(), "profile", syncCfg.Profile)
 
 	return nil
 }
@@ -3,8 +3,11 @@ package config
 
 import (
 	"fmt"
+	"reflect"
+	"strings"
 
 	"github.com/slashdevops/idp-scim-sync/internal/model"
+	"github.com/slashdevops/idp-scim-sync/internal/repository"
 )
 
 const (
@@ -25,6 +28,12 @@ const (
 	// DefaultGWSServiceAccountFile is the name of the file containing the service account credentials.
 	DefaultGWSServiceAccountFile = "credentials.json"
 
+	// DefaultIDPType is the default identity provider type.
+	DefaultIDPType = IDPTypeGoogle
+
+	// DefaultTargetType is the default SCIM service provider type.
+	DefaultTargetType = TargetTypeAWS
+
 	// DefaultSyncMethod is the default sync method to use.
 	DefaultSyncMethod = "groups"
 
@@ -46,10 +55,105 @@ const (
 	// DefaultAWSSCIMAccessTokenSecretName is the name of the secret containing the SCIM access token.
 	DefaultAWSSCIMAccessTokenSecretName = "IDPSCIM_SCIMAccessToken"
 
+	// DefaultAWSSCIMConcurrency is the default number of AWS SCIM create, update and delete requests sent at the same time.
+	DefaultAWSSCIMConcurrency = 1
+
+	// DefaultAWSSCIMRateLimitQPS is the default maximum rate of the requests per second to the AWS SCIM API,
+	// reduced while AWS throttles the requests.
+	DefaultAWSSCIMRateLimitQPS = 20.0
+
+	// DefaultAWSSCIMRateLimitConcurrency is the default maximum number of requests in flight to the AWS SCIM API,
+	// reduced while AWS throttles the requests.
+	DefaultAWSSCIMRateLimitConcurrency = 10
+
+	// DefaultEntraClientSecretSecretName is the name of the secret containing the Microsoft Entra ID client secret.
+	DefaultEntraClientSecretSecretName = "IDPSCIM_EntraClientSecret"
+
+	// DefaultOktaAPITokenSecretName is the name of the secret containing the Okta API token.
+	DefaultOktaAPITokenSecretName = "IDPSCIM_OktaAPIToken"
+
+	// DefaultLDAPBindPasswordSecretName is the name of the secret containing the LDAP bind password.
+	DefaultLDAPBindPasswordSecretName = "IDPSCIM_LDAPBindPassword"
+
+	// DefaultGWSNestingPolicy is the default policy to sync the nested Google Workspace groups.
+	// possible values: "flatten", "direct-only", "mirror"
+	DefaultGWSNestingPolicy = "flatten"
+
+	// DefaultGWSSuspendedUsersPolicy is the default policy to sync the suspended and archived Google Workspace users.
+	// possible values: "drop", "deactivate", "deactivate-ungroup"
+	DefaultGWSSuspendedUsersPolicy = "drop"
+
+	// DefaultGWSExternalMembersPolicy is the default policy to sync the Google Workspace group members
+	// that are not users of the directory.
+	// possible values: "fail", "skip", "allow"
+	DefaultGWSExternalMembersPolicy = "fail"
+
+	// DefaultGWSDuplicateGroupNames is the default strategy to sync the Google Workspace groups with the same name.
+	// possible values: "fail", "keep-first", "suffix", "email"
+	DefaultGWSDuplicateGroupNames = "keep-first"
+
+	// DefaultGWSGroupsSource is the default Google API to read the Google Workspace groups and their members.
+	// possible values: "directory", "cloud-identity"
+	DefaultGWSGroupsSource = "directory"
+
+	// DefaultGWSRateLimitQPS is the default rate of the requests per second to the Google APIs,
+	// below the Directory API default quota of 2400 queries per minute per user.
+	DefaultGWSRateLimitQPS = 20.0
+
+	// DefaultGWSRateLimitBurst is the default number of requests to the Google APIs sent in a burst.
+	DefaultGWSRateLimitBurst = 10
+
+	// DefaultGWSBulkUsersThreshold is the default number of group members above which all the
+	// Google Workspace users are listed at once instead of read one by one.
+	DefaultGWSBulkUsersThreshold = 200
+
+	// DefaultLDAPMembersStrategy is the default strategy to expand the LDAP nested group memberships.
+	// possible values: "member", "memberof", "in_chain"
+	DefaultLDAPMembersStrategy = "member"
+
+	// DefaultSCIMIdPTokenSecretName is the name of the secret containing the source SCIM service bearer token.
+	DefaultSCIMIdPTokenSecretName = "IDPSCIM_SCIMIdPToken"
+
+	// DefaultSCIMIdPPasswordSecretName is the name of the secret containing the source SCIM service basic auth password.
+	DefaultSCIMIdPPasswordSecretName = "IDPSCIM_SCIMIdPPassword"
+
+	// DefaultSCIMTargetTokenSecretName is the name of the secret containing the target SCIM service bearer token.
+	DefaultSCIMTargetTokenSecretName = "IDPSCIM_SCIMTargetToken"
+
 	// DefaultUseSecretsManager determines if we will use the AWS Secrets Manager secrets or program parameter values
 	DefaultUseSecretsManager = false
 )
 
+// Identity provider types supported as source of the sync.
+const (
+	// IDPTypeGoogle is the Google Workspace identity provider.
+	IDPTypeGoogle = "google"
+
+	// IDPTypeEntra is the Microsoft Entra ID (Azure AD) identity provider.
+	IDPTypeEntra = "entra"
+
+	// IDPTypeOkta is the Okta identity provider.
+	IDPTypeOkta = "okta"
+
+	// IDPTypeLDAP is the LDAP or Active Directory identity provider.
+	IDPTypeLDAP = "ldap"
+
+	// IDPTypeFile is the static directory file identity provider.
+	IDPTypeFile = "file"
+
+	// IDPTypeSCIM is a SCIM 2.0 service provider used as identity provider.
+	IDPTypeSCIM = "scim"
+)
+
+// SCIM service provider types supported as target of the sync.
+const (
+	// TargetTypeAWS is the AWS IAM Identity Center SCIM service.
+	TargetTypeAWS = "aws"
+
+	// TargetTypeSCIM is any standards compliant SCIM 2.0 service provider, e.g. GitHub Enterprise.
+	TargetTypeSCIM = "scim"
+)
+
 var (
 	// ErrInvalidLogLevel is returned when the log level is invalid.
 	ErrInvalidLogLevel = fmt.Errorf("invalid log level")
@@ -59,10 +163,82 @@ var (
 	ErrMissingAWSSCIMEndpoint = fmt.Errorf("missing AWS SCIM endpoint")
 	// ErrMissingAWSSCIMAccessToken is returned when the AWS SCIM access token is missing.
 	ErrMissingAWSSCIMAccessToken = fmt.Errorf("missing AWS SCIM access token")
+	// ErrInvalidAWSSCIMConcurrency is returned when the AWS SCIM concurrency is negative.
+	ErrInvalidAWSSCIMConcurrency = fmt.Errorf("invalid AWS SCIM concurrency")
+	// ErrInvalidAWSSCIMRateLimit is returned when the AWS SCIM rate limit is not valid.
+	ErrInvalidAWSSCIMRateLimit = fmt.Errorf("invalid AWS SCIM rate limit")
 	// ErrMissingGWSServiceAccountFile is returned when the GWS service account file is missing.
 	ErrMissingGWSServiceAccountFile = fmt.Errorf("missing GWS service account file")
 	// ErrMissingGWSUserEmail is returned when the GWS user email is missing.
 	ErrMissingGWSUserEmail = fmt.Errorf("missing GWS user email")
+	// ErrInvalidGWSNestingPolicy is returned when the GWS nesting policy is not supported.
+	ErrInvalidGWSNestingPolicy = fmt.Errorf("invalid GWS nesting policy")
+	// ErrInvalidGWSSuspendedUsersPolicy is returned when the GWS suspended users policy is not supported.
+	ErrInvalidGWSSuspendedUsersPolicy = fmt.Errorf("invalid GWS suspended users policy")
+	// ErrInvalidGWSMemberRoles is returned when a GWS member roles projection is not valid.
+	ErrInvalidGWSMemberRoles = fmt.Errorf("invalid GWS member roles")
+	// ErrInvalidGWSExternalMembersPolicy is returned when the GWS external members policy is not supported.
+	ErrInvalidGWSExternalMembersPolicy = fmt.Errorf("invalid GWS external members policy")
+	// ErrInvalidGWSDuplicateGroupNames is returned when the GWS duplicate group names strategy is not supported.
+	ErrInvalidGWSDuplicateGroupNames = fmt.Errorf("invalid GWS duplicate group names strategy")
+	// ErrInvalidGWSCustomSchemaAttribute is returned when a GWS custom schema attribute is not valid.
+	ErrInvalidGWSCustomSchemaAttribute = fmt.Errorf("invalid GWS custom schema attribute")
+	// ErrInvalidGWSGroupsSource is returned when the GWS groups source is not supported.
+	ErrInvalidGWSGroupsSource = fmt.Errorf("invalid GWS groups source")
+	// ErrInvalidGWSRateLimit is returned when the GWS rate limit is not valid.
+	ErrInvalidGWSRateLimit = fmt.Errorf("invalid GWS rate limit")
+	// ErrInvalidGWSBulkUsersThreshold is returned when the GWS bulk users threshold is negative.
+	ErrInvalidGWSBulkUsersThreshold = fmt.Errorf("invalid GWS bulk users threshold")
+	// ErrMissingGWSCustomerID is returned when the GWS customer id is missing.
+	ErrMissingGWSCustomerID = fmt.Errorf("missing GWS customer id")
+	// ErrInvalidGWSOrgUnit is returned when a GWS organizational unit path is not absolute.
+	ErrInvalidGWSOrgUnit = fmt.Errorf("invalid GWS organizational unit")
+	// ErrInvalidIDPType is returned when the identity provider type is not supported.
+	ErrInvalidIDPType = fmt.Errorf("invalid identity provider type")
+	// ErrMissingEntraTenantID is returned when the Entra ID tenant id is missing.
+	ErrMissingEntraTenantID = fmt.Errorf("missing Entra ID tenant id")
+	// ErrMissingEntraClientID is returned when the Entra ID client id is missing.
+	ErrMissingEntraClientID = fmt.Errorf("missing Entra ID client id")
+	// ErrMissingEntraClientSecret is returned when the Entra ID client secret is missing.
+	ErrMissingEntraClientSecret = fmt.Errorf("missing Entra ID client secret")
+	// ErrMissingOktaOrgURL is returned when the Okta organization url is missing.
+	ErrMissingOktaOrgURL = fmt.Errorf("missing Okta organization url")
+	// ErrMissingOktaAPIToken is returned when the Okta API token is missing.
+	ErrMissingOktaAPIToken = fmt.Errorf("missing Okta API token")
+	// ErrMissingLDAPURL is returned when the LDAP url is missing.
+	ErrMissingLDAPURL = fmt.Errorf("missing LDAP url")
+	// ErrMissingLDAPBaseDN is returned when the LDAP base dn is missing.
+	ErrMissingLDAPBaseDN = fmt.Errorf("missing LDAP base dn")
+	// ErrMissingLDAPBindPassword is returned when the LDAP bind dn is defined without password.
+	ErrMissingLDAPBindPassword = fmt.Errorf("missing LDAP bind password")
+	// ErrInvalidLDAPMembersStrategy is returned when the LDAP members strategy is not supported.
+	ErrInvalidLDAPMembersStrategy = fmt.Errorf("invalid LDAP members strategy")
+	// ErrMissingFilePath is returned when the directory file path is missing.
+	ErrMissingFilePath = fmt.Errorf("missing directory file path")
+	// ErrInvalidFileFormat is returned when the directory file format is not supported.
+	ErrInvalidFileFormat = fmt.Errorf("invalid directory file format")
+	// ErrMissingSCIMIdPURL is returned when the source SCIM service url is missing.
+	ErrMissingSCIMIdPURL = fmt.Errorf("missing source SCIM url")
+	// ErrMissingSCIMIdPCredentials is returned when the source SCIM service token or password is missing.
+	ErrMissingSCIMIdPCredentials = fmt.Errorf("missing source SCIM credentials")
+	// ErrInvalidTargetType is returned when the SCIM service provider type is not supported.
+	ErrInvalidTargetType = fmt.Errorf("invalid target type")
+	// ErrMissingSCIMTargetURL is returned when the target SCIM service url is missing.
+	ErrMissingSCIMTargetURL = fmt.Errorf("missing target SCIM url")
+	// ErrMissingSCIMTargetToken is returned when the target SCIM service bearer token is missing.
+	ErrMissingSCIMTargetToken = fmt.Errorf("missing target SCIM token")
+	// ErrMissingProfileName is returned when a profile is defined without a name.
+	ErrMissingProfileName = fmt.Errorf("missing profile name")
+	// ErrDuplicateProfileName is returned when two profiles share the same name.
+	ErrDuplicateProfileName = fmt.Errorf("duplicate profile name")
+	// ErrDuplicateProfileState is returned when two profiles store their state in the same location.
+	ErrDuplicateProfileState = fmt.Errorf("duplicate profile state location")
+	// ErrProfileNotFound is returned when the requested profile is not defined.
+	ErrProfileNotFound = fmt.Errorf("profile not found")
+	// ErrNoProfiles is returned when all profiles are requested but none is defined.
+	ErrNoProfiles = fmt.Errorf("no profiles defined")
+	// ErrProfileAndAllProfiles is returned when a single profile and all profiles are requested at the same time.
+	ErrProfileAndAllProfiles = fmt.Errorf("profile and all profiles cannot be used together")
 )
 
 // Config represents the configuration of the application.
@@ -72,16 +248,91 @@ type Config struct {
 	LogLevel  string `mapstructure:"log_level" json:"log_level" yaml:"log_level"`
 	LogFormat string `mapstructure:"log_format" json:"log_format" yaml:"log_format"`
 
+	// IDPType is the identity provider used as source of the sync.
+	// possible values: "google", "entra", "okta", "ldap", "file", "scim"
+	IDPType string `mapstructure:"idp_type" json:"idp_type" yaml:"idp_type"`
+
 	GWSServiceAccountFile           string `mapstructure:"gws_service_account_file" json:"gws_service_account_file" yaml:"gws_service_account_file"`
 	GWSUserEmail                    string `mapstructure:"gws_user_email" json:"gws_user_email" yaml:"gws_user_email"`
 	GWSServiceAccountFileSecretName string `mapstructure:"gws_service_account_file_secret_name" json:"gws_service_account_file_secret_name" yaml:"gws_service_account_file_secret_name"`
 	GWSUserEmailSecretName          string `mapstructure:"gws_user_email_secret_name" json:"gws_user_email_secret_name" yaml:"gws_user_email_secret_name"`
 
+	// GWSServiceAccountEmail is the service account delegated when GWSServiceAccountFile is a workload identity
+	// federation credential configuration, optional when the configuration impersonates it.
+	GWSServiceAccountEmail string `mapstructure:"gws_service_account_email" json:"gws_service_account_email" yaml:"gws_service_account_email"`
+
+	EntraTenantID               string   `mapstructure:"entra_tenant_id" json:"entra_tenant_id" yaml:"entra_tenant_id"`
+	EntraClientID               string   `mapstructure:"entra_client_id" json:"entra_client_id" yaml:"entra_client_id"`
+	EntraClientSecret           string   `mapstructure:"entra_client_secret" json:"entra_client_secret" yaml:"entra_client_secret"`
+	EntraClientSecretSecretName string   `mapstructure:"entra_client_secret_secret_name" json:"entra_client_secret_secret_name" yaml:"entra_client_secret_secret_name"`
+	EntraGroupsFilter           []string `mapstructure:"entra_groups_filter" json:"entra_groups_filter" yaml:"entra_groups_filter"`
+
+	// EntraUsersDelta resolves the Entra ID users with delta queries instead of reading them one by one.
+	EntraUsersDelta bool `mapstructure:"entra_users_delta" json:"entra_users_delta" yaml:"entra_users_delta"`
+
+	OktaOrgURL             string   `mapstructure:"okta_org_url" json:"okta_org_url" yaml:"okta_org_url"`
+	OktaAPIToken           string   `mapstructure:"okta_api_token" json:"okta_api_token" yaml:"okta_api_token"`
+	OktaAPITokenSecretName string   `mapstructure:"okta_api_token_secret_name" json:"okta_api_token_secret_name" yaml:"okta_api_token_secret_name"`
+	OktaGroupsFilter       []string `mapstructure:"okta_groups_filter" json:"okta_groups_filter" yaml:"okta_groups_filter"`
+
+	LDAPURL                    string   `mapstructure:"ldap_url" json:"ldap_url" yaml:"ldap_url"`
+	LDAPBindDN                 string   `mapstructure:"ldap_bind_dn" json:"ldap_bind_dn" yaml:"ldap_bind_dn"`
+	LDAPBindPassword           string   `mapstructure:"ldap_bind_password" json:"ldap_bind_password" yaml:"ldap_bind_password"`
+	LDAPBindPasswordSecretName string   `mapstructure:"ldap_bind_password_secret_name" json:"ldap_bind_password_secret_name" yaml:"ldap_bind_password_secret_name"`
+	LDAPBaseDN                 string   `mapstructure:"ldap_base_dn" json:"ldap_base_dn" yaml:"ldap_base_dn"`
+	LDAPGroupsFilter           []string `mapstructure:"ldap_groups_filter" json:"ldap_groups_filter" yaml:"ldap_groups_filter"`
+
+	// LDAPMembersStrategy defines how the nested group memberships are expanded.
+	// possible values: "member", "memberof", "in_chain" (Active Directory)
+	LDAPMembersStrategy string `mapstructure:"ldap_members_strategy" json:"ldap_members_strategy" yaml:"ldap_members_strategy"`
+
+	LDAPStartTLS           bool `mapstructure:"ldap_start_tls" json:"ldap_start_tls" yaml:"ldap_start_tls"`
+	LDAPInsecureSkipVerify bool `mapstructure:"ldap_insecure_skip_verify" json:"ldap_insecure_skip_verify" yaml:"ldap_insecure_skip_verify"`
+	LDAPPageSize           int  `mapstructure:"ldap_page_size" json:"ldap_page_size" yaml:"ldap_page_size"`
+
+	// FilePath is the local path or the s3://bucket/key location of the directory file.
+	FilePath string `mapstructure:"file_path" json:"file_path" yaml:"file_path"`
+
+	// FileFormat is the format of the directory file, taken from the file extension when empty.
+	// possible values: "yaml", "json", "csv"
+	FileFormat       string   `mapstructure:"file_format" json:"file_format" yaml:"file_format"`
+	FileGroupsFilter []string `mapstructure:"file_groups_filter" json:"file_groups_filter" yaml:"file_groups_filter"`
+
+	// SCIMIdP* configure the SCIM 2.0 service provider read as identity provider, authenticated
+	// with a bearer token or, when the username is defined, with basic authentication.
+	SCIMIdPURL                string   `mapstructure:"scim_idp_url" json:"scim_idp_url" yaml:"scim_idp_url"`
+	SCIMIdPToken              string   `mapstructure:"scim_idp_token" json:"scim_idp_token" yaml:"scim_idp_token"`
+	SCIMIdPTokenSecretName    string   `mapstructure:"scim_idp_token_secret_name" json:"scim_idp_token_secret_name" yaml:"scim_idp_token_secret_name"`
+	SCIMIdPUsername           string   `mapstructure:"scim_idp_username" json:"scim_idp_username" yaml:"scim_idp_username"`
+	SCIMIdPPassword           string   `mapstructure:"scim_idp_password" json:"scim_idp_password" yaml:"scim_idp_password"`
+	SCIMIdPPasswordSecretName string   `mapstructure:"scim_idp_password_secret_name" json:"scim_idp_password_secret_name" yaml:"scim_idp_password_secret_name"`
+	SCIMIdPGroupsFilter       []string `mapstructure:"scim_idp_groups_filter" json:"scim_idp_groups_filter" yaml:"scim_idp_groups_filter"`
+	SCIMIdPPageSize           int      `mapstructure:"scim_idp_page_size" json:"scim_idp_page_size" yaml:"scim_idp_page_size"`
+
+	// TargetType is the SCIM service provider used as target of the sync.
+	// possible values: "aws", "scim"
+	TargetType string `mapstructure:"target_type" json:"target_type" yaml:"target_type"`
+
+	// SCIMTarget* configure the SCIM 2.0 service provider written as target, authenticated with a bearer token.
+	SCIMTargetURL             string `mapstructure:"scim_target_url" json:"scim_target_url" yaml:"scim_target_url"`
+	SCIMTargetToken           string `mapstructure:"scim_target_token" json:"scim_target_token" yaml:"scim_target_token"`
+	SCIMTargetTokenSecretName string `mapstructure:"scim_target_token_secret_name" json:"scim_target_token_secret_name" yaml:"scim_target_token_secret_name"`
+	SCIMTargetPageSize        int    `mapstructure:"scim_target_page_size" json:"scim_target_page_size" yaml:"scim_target_page_size"`
+
 	AWSSCIMEndpoint              string `mapstructure:"aws_scim_endpoint" json:"aws_scim_endpoint" yaml:"aws_scim_endpoint"`
 	AWSSCIMAccessToken           string `mapstructure:"aws_scim_access_token" json:"aws_scim_access_token" yaml:"aws_scim_access_token"`
 	AWSSCIMEndpointSecretName    string `mapstructure:"aws_scim_endpoint_secret_name" json:"aws_scim_endpoint_secret_name" yaml:"aws_scim_endpoint_secret_name"`
 	AWSSCIMAccessTokenSecretName string `mapstructure:"aws_scim_access_token_secret_name" json:"aws_scim_access_token_secret_name" yaml:"aws_scim_access_token_secret_name"`
 
+	// AWSSCIMConcurrency is the number of AWS SCIM create, update and delete requests sent at the same time,
+	// 1 sends them one by one.
+	AWSSCIMConcurrency int `mapstructure:"aws_scim_concurrency" json:"aws_scim_concurrency" yaml:"aws_scim_concurrency"`
+
+	// AWSSCIMRateLimit* are the maximum rate of requests per second and requests in flight to the AWS SCIM API,
+	// both are reduced while AWS throttles the requests and grow back while they succeed, a zero qps disables them.
+	AWSSCIMRateLimitQPS         float64 `mapstructure:"aws_scim_rate_limit_qps" json:"aws_scim_rate_limit_qps" yaml:"aws_scim_rate_limit_qps"`
+	AWSSCIMRateLimitConcurrency int     `mapstructure:"aws_scim_rate_limit_concurrency" json:"aws_scim_rate_limit_concurrency" yaml:"aws_scim_rate_limit_concurrency"`
+
 	AWSS3BucketName string `mapstructure:"aws_s3_bucket_name" json:"aws_s3_bucket_name" yaml:"aws_s3_bucket_name"`
 	AWSS3BucketKey  string `mapstructure:"aws_s3_bucket_key" json:"aws_s3_bucket_key" yaml:"aws_s3_bucket_key"`
 
@@ -92,6 +343,71 @@ type Config struct {
 	GWSUsersFilter          []string `mapstructure:"gws_users_filter" json:"gws_users_filter" yaml:"gws_users_filter"`
 	GWSServiceAccountScopes []string `mapstructure:"gws_service_account_scopes" json:"gws_service_account_scopes" yaml:"gws_service_account_scopes"`
 
+	// GWSOrgUnits maps Google organizational units, including their children, to groups.
+	// Each value is "<path>" or "<path>=<group name>", e.g. "/Contractors/AWS=AWS-Contractors".
+	GWSOrgUnits []string `mapstructure:"gws_org_units" json:"gws_org_units" yaml:"gws_org_units"`
+
+	// GWSNestingPolicy defines how the nested Google Workspace groups are synced:
+	// "flatten" includes the members of the nested groups, "direct-only" only the direct members and
+	// "mirror" syncs the nested groups too and includes their members recording the lineage in the state.
+	GWSNestingPolicy string `mapstructure:"gws_nesting_policy" json:"gws_nesting_policy" yaml:"gws_nesting_policy"`
+
+	// GWSSuspendedUsersPolicy defines how the suspended and archived Google Workspace users are synced:
+	// "drop" doesn't sync them, "deactivate" syncs them as inactive users keeping their groups and
+	// "deactivate-ungroup" syncs them as inactive users removing them from the groups.
+	GWSSuspendedUsersPolicy string `mapstructure:"gws_suspended_users_policy" json:"gws_suspended_users_policy" yaml:"gws_suspended_users_policy"`
+
+	// GWSMemberRoles projects the members of a Google Workspace group with the given roles to a group of its own.
+	// Each value is "<group email>:<role>[,<role>...][=<group name>]", e.g. "aws-admins@example.com:OWNER=aws-admins-owners",
+	// the roles are OWNER, MANAGER and MEMBER.
+	GWSMemberRoles []string `mapstructure:"gws_member_roles" json:"gws_member_roles" yaml:"gws_member_roles"`
+
+	// GWSExternalMembersPolicy defines how the Google Workspace group members that are not users of the directory,
+	// e.g. external email addresses, are synced: "fail" fails the sync, "skip" skips them with a warning and
+	// "allow" syncs the members of the GWSExternalDomains skipping the others.
+	GWSExternalMembersPolicy string `mapstructure:"gws_external_members_policy" json:"gws_external_members_policy" yaml:"gws_external_members_policy"`
+
+	// GWSExternalDomains are the external domains whose members are synced with the "allow" external members policy.
+	GWSExternalDomains []string `mapstructure:"gws_external_domains" json:"gws_external_domains" yaml:"gws_external_domains"`
+
+	// GWSDuplicateGroupNames defines how the Google Workspace groups with the same name are synced:
+	// "fail" fails the sync, "keep-first" syncs only the first group, "suffix" appends the local part of
+	// the group email to the name and "email" uses the group email as name. The group synced before
+	// with the name keeps it.
+	GWSDuplicateGroupNames string `mapstructure:"gws_duplicate_group_names" json:"gws_duplicate_group_names" yaml:"gws_duplicate_group_names"`
+
+	// GWSCustomSchemaAttributes are the Google Workspace custom schema attributes synced with the users.
+	// Each value is "<schema>.<field>[=<scim attribute>]", e.g. "HR.CostCenter=costCenter", the attributes
+	// are kept in the user extensions and mapped to the SCIM attribute when given.
+	GWSCustomSchemaAttributes []string `mapstructure:"gws_custom_schema_attributes" json:"gws_custom_schema_attributes" yaml:"gws_custom_schema_attributes"`
+
+	// GWSGroupsSource defines the Google API the groups and their members are read from: "directory" uses the
+	// Admin SDK Directory API and "cloud-identity" uses the Cloud Identity Groups API, which supports the
+	// dynamic and security groups. The users are always read from the Directory API.
+	GWSGroupsSource string `mapstructure:"gws_groups_source" json:"gws_groups_source" yaml:"gws_groups_source"`
+
+	// GWSCustomerID is the Google Workspace customer id, e.g. C046psxkn, required by the "cloud-identity" groups source.
+	GWSCustomerID string `mapstructure:"gws_customer_id" json:"gws_customer_id" yaml:"gws_customer_id"`
+
+	// GWSGroupLabels are the labels of the groups read from the "cloud-identity" groups source, the groups must have
+	// all of them, e.g. "cloudidentity.googleapis.com/groups.security". All the Google groups are read when empty.
+	GWSGroupLabels []string `mapstructure:"gws_group_labels" json:"gws_group_labels" yaml:"gws_group_labels"`
+
+	// GWSRateLimitQPS is the rate of the requests per second to the Google APIs shared by all the calls,
+	// 0 disables the rate limit.
+	GWSRateLimitQPS float64 `mapstructure:"gws_rate_limit_qps" json:"gws_rate_limit_qps" yaml:"gws_rate_limit_qps"`
+
+	// GWSRateLimitBurst is the number of requests to the Google APIs sent in a burst above the rate.
+	GWSRateLimitBurst int `mapstructure:"gws_rate_limit_burst" json:"gws_rate_limit_burst" yaml:"gws_rate_limit_burst"`
+
+	// GWSBulkUsersThreshold is the number of group members not read yet above which all the users of the
+	// directory are listed at once instead of read one by one, 0 always reads them one by one.
+	GWSBulkUsersThreshold int `mapstructure:"gws_bulk_users_threshold" json:"gws_bulk_users_threshold" yaml:"gws_bulk_users_threshold"`
+
+	// GWSCache keeps the Google Workspace users and group members with their ETags next to the state,
+	// they are requested again only when they are modified.
+	GWSCache bool `mapstructure:"gws_cache" json:"gws_cache" yaml:"gws_cache"`
+
 	// SyncUserFields controls which optional user attributes are synced from the identity provider.
 	// When empty (default), all fields are synced. When specified, only listed fields are included.
 	// Valid values: phoneNumbers, addresses, title, preferredLanguage, locale, timezone,
@@ -103,6 +419,60 @@ type Config struct {
 
 	// UseSecretsManager determines if we will use the AWS Secrets Manager secrets or program parameter values
 	UseSecretsManager bool `mapstructure:"use_secrets_manager" json:"use_secrets_manager" yaml:"use_secrets_manager"`
+
+	// Profile is the name of the profile to sync, when empty the top-level configuration is used.
+	Profile string `mapstructure:"profile" json:"profile" yaml:"profile"`
+
+	// AllProfiles syncs every profile defined in Profiles, one after the other.
+	AllProfiles bool `mapstructure:"all_profiles" json:"all_profiles" yaml:"all_profiles"`
+
+	// Profiles are named sync configurations, every setting not defined in a profile
+	// is inherited from the top-level configuration.
+	Profiles []Profile `mapstructure:"profiles" json:"profiles,omitempty" yaml:"profiles,omitempty"`
+}
+
+// Profile represents a named sync configuration defined in the profiles section
+// of the configuration file.
+// It accepts the same settings as the top-level configuration, e.g.:
+//
+//	profiles:
+//	  - name: engineering
+//	    gws_groups_filter: ['name:Eng*']
+//	    aws_s3_bucket_key: engineering/state.json
+type Profile struct {
+	Name   string `mapstructure:"name" json:"name" yaml:"name"`
+	Config `mapstructure:",squash" yaml:",inline"`
+
+	// defined are the settings present in the profile of the configuration file,
+	// recorded by DefineProfileSettings.
+	defined map[string]bool
+}
+
+// defines returns true when the profile defines the setting of the field.
+// The settings recorded from the configuration file are defined when present, even when
+// they are zero, e.g. "use_secrets_manager: false"; without them, the zero values and
+// the empty lists are considered not defined.
+func (p *Profile) defines(field reflect.StructField, value reflect.Value) bool {
+	if p.defined != nil {
+		key, _, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		return key != "" && p.defined[key]
+	}
+
+	// empty slices are considered not defined, the decoder initializes them
+	return !value.IsZero() && (value.Kind() != reflect.Slice || value.Len() > 0)
+}
+
+// profileIgnoredFields are the Config fields that cannot be overridden by a profile
+// because they are global to the program execution.
+var profileIgnoredFields = map[string]struct{}{
+	"ConfigFile":  {},
+	"LogLevel":    {},
+	"LogFormat":   {},
+	"IsLambda":    {},
+	"Debug":       {},
+	"Profile":     {},
+	"AllProfiles": {},
+	"Profiles":    {},
 }
 
 // New returns a new Config
@@ -113,6 +483,8 @@ func New() Config {
 		Debug:                           DefaultDebug,
 		LogLevel:                        DefaultLogLevel,
 		LogFormat:                       DefaultLogFormat,
+		IDPType:                         DefaultIDPType,
+		TargetType:                      DefaultTargetType,
 		GWSServiceAccountFile:           DefaultGWSServiceAccountFile,
 		SyncMethod:                      DefaultSyncMethod,
 		AWSS3BucketKey:                  DefaultAWSS3BucketKey,
@@ -120,6 +492,24 @@ func New() Config {
 		GWSUserEmailSecretName:          DefaultGWSUserEmailSecretName,
 		AWSSCIMEndpointSecretName:       DefaultAWSSCIMEndpointSecretName,
 		AWSSCIMAccessTokenSecretName:    DefaultAWSSCIMAccessTokenSecretName,
+		AWSSCIMConcurrency:              DefaultAWSSCIMConcurrency,
+		AWSSCIMRateLimitQPS:             DefaultAWSSCIMRateLimitQPS,
+		AWSSCIMRateLimitConcurrency:     DefaultAWSSCIMRateLimitConcurrency,
+		EntraClientSecretSecretName:     DefaultEntraClientSecretSecretName,
+		OktaAPITokenSecretName:          DefaultOktaAPITokenSecretName,
+		LDAPBindPasswordSecretName:      DefaultLDAPBindPasswordSecretName,
+		LDAPMembersStrategy:             DefaultLDAPMembersStrategy,
+		GWSNestingPolicy:                DefaultGWSNestingPolicy,
+		GWSSuspendedUsersPolicy:         DefaultGWSSuspendedUsersPolicy,
+		GWSExternalMembersPolicy:        DefaultGWSExternalMembersPolicy,
+		GWSDuplicateGroupNames:          DefaultGWSDuplicateGroupNames,
+		GWSGroupsSource:                 DefaultGWSGroupsSource,
+		GWSRateLimitQPS:                 DefaultGWSRateLimitQPS,
+		GWSRateLimitBurst:               DefaultGWSRateLimitBurst,
+		GWSBulkUsersThreshold:           DefaultGWSBulkUsersThreshold,
+		SCIMIdPTokenSecretName:          DefaultSCIMIdPTokenSecretName,
+		SCIMIdPPasswordSecretName:       DefaultSCIMIdPPasswordSecretName,
+		SCIMTargetTokenSecretName:       DefaultSCIMTargetTokenSecretName,
 		UseSecretsManager:               DefaultUseSecretsManager,
 		GWSServiceAccountScopes: []string{
 			"https://www.googleapis.com/auth/admin.directory.group.readonly",
@@ -145,19 +535,152 @@ func (c *Config) Validate() error {
 		return ErrInvalidLogFormat
 	}
 
-	if !c.UseSecretsManager {
-		if c.AWSSCIMEndpoint == "" {
-			return ErrMissingAWSSCIMEndpoint
+	switch c.TargetType {
+	case "", TargetTypeAWS:
+		if !c.UseSecretsManager {
+			if c.AWSSCIMEndpoint == "" {
+				return ErrMissingAWSSCIMEndpoint
+			}
+			if c.AWSSCIMAccessToken == "" {
+				return ErrMissingAWSSCIMAccessToken
+			}
+		}
+		if c.AWSSCIMConcurrency < 0 {
+			return fmt.Errorf("%w: %d", ErrInvalidAWSSCIMConcurrency, c.AWSSCIMConcurrency)
+		}
+		if c.AWSSCIMRateLimitQPS < 0 || c.AWSSCIMRateLimitConcurrency < 0 {
+			return fmt.Errorf("%w: qps %v, concurrency %d", ErrInvalidAWSSCIMRateLimit, c.AWSSCIMRateLimitQPS, c.AWSSCIMRateLimitConcurrency)
+		}
+	case TargetTypeSCIM:
+		if c.SCIMTargetURL == "" {
+			return ErrMissingSCIMTargetURL
+		}
+		if !c.UseSecretsManager && c.SCIMTargetToken == "" {
+			return ErrMissingSCIMTargetToken
+		}
+	default:
+		return fmt.Errorf("%w: %q", ErrInvalidTargetType, c.TargetType)
+	}
+
+	switch c.IDPType {
+	case "", IDPTypeGoogle:
+		if !c.UseSecretsManager {
+			if c.GWSServiceAccountFile == "" {
+				return ErrMissingGWSServiceAccountFile
+			}
+			if c.GWSUserEmail == "" {
+				return ErrMissingGWSUserEmail
+			}
+		}
+		switch c.GWSNestingPolicy {
+		case "", "flatten", "direct-only", "mirror":
+		default:
+			return fmt.Errorf("%w: %q", ErrInvalidGWSNestingPolicy, c.GWSNestingPolicy)
+		}
+		switch c.GWSSuspendedUsersPolicy {
+		case "", "drop", "deactivate", "deactivate-ungroup":
+		default:
+			return fmt.Errorf("%w: %q", ErrInvalidGWSSuspendedUsersPolicy, c.GWSSuspendedUsersPolicy)
+		}
+		switch c.GWSExternalMembersPolicy {
+		case "", "fail", "skip":
+		case "allow":
+			if len(c.GWSExternalDomains) == 0 {
+				return fmt.Errorf("%w: %q requires the external domains", ErrInvalidGWSExternalMembersPolicy, c.GWSExternalMembersPolicy)
+			}
+		default:
+			return fmt.Errorf("%w: %q", ErrInvalidGWSExternalMembersPolicy, c.GWSExternalMembersPolicy)
+		}
+		switch c.GWSDuplicateGroupNames {
+		case "", "fail", "keep-first", "suffix", "email":
+		default:
+			return fmt.Errorf("%w: %q", ErrInvalidGWSDuplicateGroupNames, c.GWSDuplicateGroupNames)
+		}
+		if c.GWSRateLimitQPS < 0 || c.GWSRateLimitBurst < 0 {
+			return fmt.Errorf("%w: qps %v, burst %d", ErrInvalidGWSRateLimit, c.GWSRateLimitQPS, c.GWSRateLimitBurst)
+		}
+		if c.GWSBulkUsersThreshold < 0 {
+			return fmt.Errorf("%w: %d", ErrInvalidGWSBulkUsersThreshold, c.GWSBulkUsersThreshold)
+		}
+		switch c.GWSGroupsSource {
+		case "", "directory":
+		case "cloud-identity":
+			if c.GWSCustomerID == "" {
+				return ErrMissingGWSCustomerID
+			}
+		default:
+			return fmt.Errorf("%w: %q", ErrInvalidGWSGroupsSource, c.GWSGroupsSource)
 		}
-		if c.AWSSCIMAccessToken == "" {
-			return ErrMissingAWSSCIMAccessToken
+		for _, ca := range c.GWSCustomSchemaAttributes {
+			if err := validateGWSCustomSchemaAttribute(ca); err != nil {
+				return err
+			}
 		}
-		if c.GWSServiceAccountFile == "" {
-			return ErrMissingGWSServiceAccountFile
+		for _, mr := range c.GWSMemberRoles {
+			if err := validateGWSMemberRoles(mr); err != nil {
+				return err
+			}
 		}
-		if c.GWSUserEmail == "" {
-			return ErrMissingGWSUserEmail
+		for _, ou := range c.GWSOrgUnits {
+			if p, _, _ := strings.Cut(ou, "="); !strings.HasPrefix(strings.TrimSpace(p), "/") {
+				return fmt.Errorf("%w: %q", ErrInvalidGWSOrgUnit, ou)
+			}
 		}
+	case IDPTypeEntra:
+		if c.EntraTenantID == "" {
+			return ErrMissingEntraTenantID
+		}
+		if c.EntraClientID == "" {
+			return ErrMissingEntraClientID
+		}
+		if !c.UseSecretsManager && c.EntraClientSecret == "" {
+			return ErrMissingEntraClientSecret
+		}
+	case IDPTypeOkta:
+		if c.OktaOrgURL == "" {
+			return ErrMissingOktaOrgURL
+		}
+		if !c.UseSecretsManager && c.OktaAPIToken == "" {
+			return ErrMissingOktaAPIToken
+		}
+	case IDPTypeLDAP:
+		if c.LDAPURL == "" {
+			return ErrMissingLDAPURL
+		}
+		if c.LDAPBaseDN == "" {
+			return ErrMissingLDAPBaseDN
+		}
+		if c.LDAPBindDN != "" && !c.UseSecretsManager && c.LDAPBindPassword == "" {
+			return ErrMissingLDAPBindPassword
+		}
+		switch c.LDAPMembersStrategy {
+		case "", "member", "memberof", "in_chain":
+		default:
+			return fmt.Errorf("%w: %q", ErrInvalidLDAPMembersStrategy, c.LDAPMembersStrategy)
+		}
+	case IDPTypeFile:
+		if c.FilePath == "" {
+			return ErrMissingFilePath
+		}
+		switch c.FileFormat {
+		case "", "yaml", "yml", "json", "csv":
+		default:
+			return fmt.Errorf("%w: %q", ErrInvalidFileFormat, c.FileFormat)
+		}
+	case IDPTypeSCIM:
+		if c.SCIMIdPURL == "" {
+			return ErrMissingSCIMIdPURL
+		}
+		if !c.UseSecretsManager {
+			if c.SCIMIdPUsername != "" && c.SCIMIdPPassword == "" {
+				return ErrMissingSCIMIdPCredentials
+			}
+			if c.SCIMIdPUsername == "" && c.SCIMIdPToken == "" {
+				return ErrMissingSCIMIdPCredentials
+			}
+		}
+	default:
+		return fmt.Errorf("%w: %q", ErrInvalidIDPType, c.IDPType)
 	}
 
 	for _, field := range c.SyncUserFields {
@@ -169,5 +692,184 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	if err := c.ValidateProfiles(); err != nil {
+		return fmt.Errorf("invalid profiles configuration: %w", err)
+	}
+
+	return nil
+}
+
+// validateGWSMemberRoles validates a GWS member roles projection, "<group email>:<role>[,<role>...][=<group name>]".
+func validateGWSMemberRoles(value string) error {
+	def, _, _ := strings.Cut(value, "=")
+	email, roles, ok := strings.Cut(def, ":")
+	if !ok || strings.TrimSpace(email) == "" {
+		return fmt.Errorf("%w: %q", ErrInvalidGWSMemberRoles, value)
+	}
+
+	for r := range strings.SplitSeq(roles, ",") {
+		switch strings.ToUpper(strings.TrimSpace(r)) {
+		case "OWNER", "MANAGER", "MEMBER":
+		default:
+			return fmt.Errorf("%w: %q", ErrInvalidGWSMemberRoles, value)
+		}
+	}
+
+	return nil
+}
+
+// validateGWSCustomSchemaAttribute validates a GWS custom schema attribute, "<schema>.<field>[=<scim attribute>]".
+func validateGWSCustomSchemaAttribute(value string) error {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+
+	def, target, _ := strings.Cut(value, "=")
+	schema, field, ok := strings.Cut(strings.TrimSpace(def), ".")
+	if !ok || schema == "" || field == "" {
+		return fmt.Errorf("%w: %q", ErrInvalidGWSCustomSchemaAttribute, value)
+	}
+
+	switch strings.TrimSpace(target) {
+	case "", "userType", "title", "nickName", "profileUrl", "employeeNumber", "costCenter", "organization", "division", "department":
+	default:
+		return fmt.Errorf("%w: %q", ErrInvalidGWSCustomSchemaAttribute, value)
+	}
+
+	return nil
+}
+
+// GroupsFilter returns the groups filter of the configured identity provider.
+func (c *Config) GroupsFilter() []string {
+	switch c.IDPType {
+	case IDPTypeEntra:
+		return c.EntraGroupsFilter
+	case IDPTypeOkta:
+		return c.OktaGroupsFilter
+	case IDPTypeLDAP:
+		return c.LDAPGroupsFilter
+	case IDPTypeFile:
+		return c.FileGroupsFilter
+	case IDPTypeSCIM:
+		return c.SCIMIdPGroupsFilter
+	default:
+		return c.GWSGroupsFilter
+	}
+}
+
+// ProfileNames returns the names of the profiles defined in the configuration in
+// the same order they were defined.
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for _, p := range c.Profiles {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// DefineProfileSettings records the settings present in every profile of the raw profiles
+// section of the configuration file, in the same order as Profiles, so ForProfile replaces
+// the top-level values with them even when they are zero, e.g. a false bool, a 0 or an empty list.
+func (c *Config) DefineProfileSettings(raw any) {
+	profiles, ok := raw.([]any)
+	if !ok {
+		return
+	}
+
+	for i, profile := range profiles {
+		if i >= len(c.Profiles) {
+			break
+		}
+
+		settings, ok := profile.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		defined := make(map[string]bool, len(settings))
+		for key := range settings {
+			defined[strings.ToLower(key)] = true
+		}
+		c.Profiles[i].defined = defined
+	}
+}
+
+// ForProfile returns the effective configuration of the given profile.
+// The result is a copy of the top-level configuration where every setting
+// defined in the profile replaces the top-level value.
+func (c *Config) ForProfile(name string) (Config, error) {
+	for _, p := range c.Profiles {
+		if p.Name != name {
+			continue
+		}
+
+		effective := *c
+		effective.Profile = name
+		effective.AllProfiles = false
+		effective.Profiles = nil
+
+		dst := reflect.ValueOf(&effective).Elem()
+		src := reflect.ValueOf(p.Config)
+		for i := 0; i < src.NumField(); i++ {
+			field := src.Type().Field(i)
+			if _, ignored := profileIgnoredFields[field.Name]; ignored {
+				continue
+			}
+			if !p.defines(field, src.Field(i)) {
+				continue
+			}
+			dst.Field(i).Set(src.Field(i))
+		}
+
+		return effective, nil
+	}
+
+	return Config{}, fmt.Errorf("%w: %q", ErrProfileNotFound, name)
+}
+
+// ValidateProfiles validates the profiles section and the profile selection.
+// Every profile must have a unique name and store its state in a different location,
+// otherwise the profiles would overwrite each other's state.
+func (c *Config) ValidateProfiles() error {
+	if c.Profile != "" && c.AllProfiles {
+		return ErrProfileAndAllProfiles
+	}
+
+	if c.AllProfiles && len(c.Profiles) == 0 {
+		return ErrNoProfiles
+	}
+
+	names := make(map[string]struct{}, len(c.Profiles))
+	states := make(map[string]string, len(c.Profiles))
+	for _, p := range c.Profiles {
+		if p.Name == "" {
+			return ErrMissingProfileName
+		}
+		if _, ok := names[p.Name]; ok {
+			return fmt.Errorf("%w: %q", ErrDuplicateProfileName, p.Name)
+		}
+		names[p.Name] = struct{}{}
+
+		pc, err := c.ForProfile(p.Name)
+		if err != nil {
+			return err
+		}
+
+		// the state and its cache must not be overwritten by another profile
+		state := pc.AWSS3BucketName + "/" + pc.AWSS3BucketKey
+		for _, key := range []string{state, repository.CacheKey(state)} {
+			if other, ok := states[key]; ok {
+				return fmt.Errorf("%w: profiles %q and %q use %q", ErrDuplicateProfileState, other, p.Name, key)
+			}
+			states[key] = p.Name
+		}
+	}
+
+	if c.Profile != "" {
+		if _, ok := names[c.Profile]; !ok {
+			return fmt.Errorf("%w: %q", ErrProfileNotFound, c.Profile)
+		}
+	}
+
 	return nil
 }
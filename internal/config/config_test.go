@@ -24,6 +24,26 @@ func TestNew(t *testing.T) {
 	assert.Equal(cfg.AWSSCIMEndpointSecretName, DefaultAWSSCIMEndpointSecretName)
 	assert.Equal(cfg.AWSSCIMAccessTokenSecretName, DefaultAWSSCIMAccessTokenSecretName)
 	assert.Equal(cfg.UseSecretsManager, DefaultUseSecretsManager)
+	assert.Equal(cfg.IDPType, DefaultIDPType)
+	assert.Equal(cfg.EntraClientSecretSecretName, DefaultEntraClientSecretSecretName)
+	assert.Equal(cfg.OktaAPITokenSecretName, DefaultOktaAPITokenSecretName)
+	assert.Equal(cfg.LDAPBindPasswordSecretName, DefaultLDAPBindPasswordSecretName)
+	assert.Equal(cfg.LDAPMembersStrategy, DefaultLDAPMembersStrategy)
+	assert.Equal(cfg.GWSNestingPolicy, DefaultGWSNestingPolicy)
+	assert.Equal(cfg.GWSSuspendedUsersPolicy, DefaultGWSSuspendedUsersPolicy)
+	assert.Equal(cfg.GWSExternalMembersPolicy, DefaultGWSExternalMembersPolicy)
+	assert.Equal(cfg.GWSDuplicateGroupNames, DefaultGWSDuplicateGroupNames)
+	assert.Equal(cfg.GWSGroupsSource, DefaultGWSGroupsSource)
+	assert.Equal(cfg.GWSRateLimitQPS, DefaultGWSRateLimitQPS)
+	assert.Equal(cfg.GWSRateLimitBurst, DefaultGWSRateLimitBurst)
+	assert.Equal(cfg.GWSBulkUsersThreshold, DefaultGWSBulkUsersThreshold)
+	assert.Equal(cfg.AWSSCIMConcurrency, DefaultAWSSCIMConcurrency)
+	assert.Equal(cfg.AWSSCIMRateLimitQPS, DefaultAWSSCIMRateLimitQPS)
+	assert.Equal(cfg.AWSSCIMRateLimitConcurrency, DefaultAWSSCIMRateLimitConcurrency)
+	assert.Equal(cfg.SCIMIdPTokenSecretName, DefaultSCIMIdPTokenSecretName)
+	assert.Equal(cfg.SCIMIdPPasswordSecretName, DefaultSCIMIdPPasswordSecretName)
+	assert.Equal(cfg.TargetType, DefaultTargetType)
+	assert.Equal(cfg.SCIMTargetTokenSecretName, DefaultSCIMTargetTokenSecretName)
 }
 
 func validConfig() Config {
@@ -101,6 +121,137 @@ func TestValidate(t *testing.T) {
 		assert.ErrorIs(t, err, ErrMissingGWSUserEmail)
 	})
 
+	t.Run("invalid GWS nesting policy", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.GWSNestingPolicy = "mirror"
+		assert.NoError(t, cfg.Validate())
+
+		cfg.GWSNestingPolicy = "nested"
+		assert.ErrorIs(t, cfg.Validate(), ErrInvalidGWSNestingPolicy)
+	})
+
+	t.Run("invalid GWS suspended users policy", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.GWSSuspendedUsersPolicy = "deactivate-ungroup"
+		assert.NoError(t, cfg.Validate())
+
+		cfg.GWSSuspendedUsersPolicy = "keep"
+		assert.ErrorIs(t, cfg.Validate(), ErrInvalidGWSSuspendedUsersPolicy)
+	})
+
+	t.Run("invalid GWS external members policy", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.GWSExternalMembersPolicy = "skip"
+		assert.NoError(t, cfg.Validate())
+
+		cfg.GWSExternalMembersPolicy = "allow"
+		assert.ErrorIs(t, cfg.Validate(), ErrInvalidGWSExternalMembersPolicy)
+
+		cfg.GWSExternalDomains = []string{"partner.com"}
+		assert.NoError(t, cfg.Validate())
+
+		cfg.GWSExternalMembersPolicy = "ignore"
+		assert.ErrorIs(t, cfg.Validate(), ErrInvalidGWSExternalMembersPolicy)
+	})
+
+	t.Run("invalid GWS duplicate group names", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.GWSDuplicateGroupNames = "suffix"
+		assert.NoError(t, cfg.Validate())
+
+		cfg.GWSDuplicateGroupNames = "rename"
+		assert.ErrorIs(t, cfg.Validate(), ErrInvalidGWSDuplicateGroupNames)
+	})
+
+	t.Run("invalid GWS rate limit", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.GWSRateLimitQPS = 0
+		assert.NoError(t, cfg.Validate())
+
+		cfg.GWSRateLimitQPS = -1
+		assert.ErrorIs(t, cfg.Validate(), ErrInvalidGWSRateLimit)
+
+		cfg.GWSRateLimitQPS = 10
+		cfg.GWSRateLimitBurst = -1
+		assert.ErrorIs(t, cfg.Validate(), ErrInvalidGWSRateLimit)
+	})
+
+	t.Run("invalid GWS bulk users threshold", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.GWSBulkUsersThreshold = 0
+		assert.NoError(t, cfg.Validate())
+
+		cfg.GWSBulkUsersThreshold = -1
+		assert.ErrorIs(t, cfg.Validate(), ErrInvalidGWSBulkUsersThreshold)
+	})
+
+	t.Run("invalid AWS SCIM concurrency", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.AWSSCIMConcurrency = 8
+		assert.NoError(t, cfg.Validate())
+
+		cfg.AWSSCIMConcurrency = -1
+		assert.ErrorIs(t, cfg.Validate(), ErrInvalidAWSSCIMConcurrency)
+	})
+
+	t.Run("invalid AWS SCIM rate limit", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.AWSSCIMRateLimitQPS = 0
+		assert.NoError(t, cfg.Validate())
+
+		cfg.AWSSCIMRateLimitQPS = -1
+		assert.ErrorIs(t, cfg.Validate(), ErrInvalidAWSSCIMRateLimit)
+
+		cfg.AWSSCIMRateLimitQPS = 10
+		cfg.AWSSCIMRateLimitConcurrency = -1
+		assert.ErrorIs(t, cfg.Validate(), ErrInvalidAWSSCIMRateLimit)
+	})
+
+	t.Run("invalid GWS groups source", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.GWSGroupsSource = "cloud-identity"
+		assert.ErrorIs(t, cfg.Validate(), ErrMissingGWSCustomerID)
+
+		cfg.GWSCustomerID = "C046psxkn"
+		assert.NoError(t, cfg.Validate())
+
+		cfg.GWSGroupsSource = "groups-settings"
+		assert.ErrorIs(t, cfg.Validate(), ErrInvalidGWSGroupsSource)
+	})
+
+	t.Run("invalid GWS custom schema attribute", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.GWSCustomSchemaAttributes = []string{"HR.CostCenter=costCenter", "AWS.AllowedRegions"}
+		assert.NoError(t, cfg.Validate())
+
+		cfg.GWSCustomSchemaAttributes = []string{"HR"}
+		assert.ErrorIs(t, cfg.Validate(), ErrInvalidGWSCustomSchemaAttribute)
+
+		cfg.GWSCustomSchemaAttributes = []string{"HR.CostCenter=emails"}
+		assert.ErrorIs(t, cfg.Validate(), ErrInvalidGWSCustomSchemaAttribute)
+	})
+
+	t.Run("invalid GWS member roles", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.GWSMemberRoles = []string{"aws-admins@example.com:OWNER=aws-admins-owners", "aws-admins@example.com:MANAGER,MEMBER"}
+		assert.NoError(t, cfg.Validate())
+
+		cfg.GWSMemberRoles = []string{"aws-admins@example.com=aws-admins"}
+		assert.ErrorIs(t, cfg.Validate(), ErrInvalidGWSMemberRoles)
+
+		cfg.GWSMemberRoles = []string{"aws-admins@example.com:ADMIN"}
+		assert.ErrorIs(t, cfg.Validate(), ErrInvalidGWSMemberRoles)
+	})
+
+	t.Run("invalid GWS org unit", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.GWSOrgUnits = []string{"/Engineering", "/Contractors/AWS=AWS-Contractors"}
+		assert.NoError(t, cfg.Validate())
+
+		cfg.GWSOrgUnits = []string{"Engineering=AWS-Engineering"}
+		assert.ErrorIs(t, cfg.Validate(), ErrInvalidGWSOrgUnit)
+	})
+
 	t.Run("secrets manager skips credential validation", func(t *testing.T) {
 		cfg := validConfig()
 		cfg.UseSecretsManager = true
@@ -136,4 +287,352 @@ func TestValidate(t *testing.T) {
 		cfg.SyncUserFields = []string{""}
 		assert.NoError(t, cfg.Validate())
 	})
+
+	t.Run("invalid idp type", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.IDPType = "invalid"
+		err := cfg.Validate()
+		assert.ErrorIs(t, err, ErrInvalidIDPType)
+	})
+
+	t.Run("entra idp type does not require GWS settings", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.IDPType = IDPTypeEntra
+		cfg.GWSServiceAccountFile = ""
+		cfg.GWSUserEmail = ""
+		cfg.EntraTenantID = "tenant"
+		cfg.EntraClientID = "client"
+		cfg.EntraClientSecret = "secret"
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("missing entra settings", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.IDPType = IDPTypeEntra
+		assert.ErrorIs(t, cfg.Validate(), ErrMissingEntraTenantID)
+
+		cfg.EntraTenantID = "tenant"
+		assert.ErrorIs(t, cfg.Validate(), ErrMissingEntraClientID)
+
+		cfg.EntraClientID = "client"
+		assert.ErrorIs(t, cfg.Validate(), ErrMissingEntraClientSecret)
+
+		cfg.UseSecretsManager = true
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("missing okta settings", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.IDPType = IDPTypeOkta
+		cfg.GWSServiceAccountFile = ""
+		cfg.GWSUserEmail = ""
+		assert.ErrorIs(t, cfg.Validate(), ErrMissingOktaOrgURL)
+
+		cfg.OktaOrgURL = "https://example.okta.com"
+		assert.ErrorIs(t, cfg.Validate(), ErrMissingOktaAPIToken)
+
+		cfg.OktaAPIToken = "token"
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("missing ldap settings", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.IDPType = IDPTypeLDAP
+		assert.ErrorIs(t, cfg.Validate(), ErrMissingLDAPURL)
+
+		cfg.LDAPURL = "ldaps://ldap.example.com:636"
+		assert.ErrorIs(t, cfg.Validate(), ErrMissingLDAPBaseDN)
+
+		cfg.LDAPBaseDN = "dc=example,dc=com"
+		assert.NoError(t, cfg.Validate(), "anonymous bind")
+
+		cfg.LDAPBindDN = "cn=sync,dc=example,dc=com"
+		assert.ErrorIs(t, cfg.Validate(), ErrMissingLDAPBindPassword)
+
+		cfg.LDAPBindPassword = "secret"
+		assert.NoError(t, cfg.Validate())
+
+		cfg.LDAPMembersStrategy = "invalid"
+		assert.ErrorIs(t, cfg.Validate(), ErrInvalidLDAPMembersStrategy)
+	})
+
+	t.Run("missing file settings", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.IDPType = IDPTypeFile
+		assert.ErrorIs(t, cfg.Validate(), ErrMissingFilePath)
+
+		cfg.FilePath = "s3://bucket/directory.yaml"
+		assert.NoError(t, cfg.Validate())
+
+		cfg.FileFormat = "xml"
+		assert.ErrorIs(t, cfg.Validate(), ErrInvalidFileFormat)
+	})
+
+	t.Run("missing scim settings", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.IDPType = IDPTypeSCIM
+		assert.ErrorIs(t, cfg.Validate(), ErrMissingSCIMIdPURL)
+
+		cfg.SCIMIdPURL = "https://scim.example.com/scim/v2"
+		assert.ErrorIs(t, cfg.Validate(), ErrMissingSCIMIdPCredentials)
+
+		cfg.SCIMIdPToken = "token"
+		assert.NoError(t, cfg.Validate())
+
+		cfg.SCIMIdPUsername = "sync"
+		assert.ErrorIs(t, cfg.Validate(), ErrMissingSCIMIdPCredentials, "basic auth without password")
+
+		cfg.SCIMIdPPassword = "secret"
+		assert.NoError(t, cfg.Validate())
+
+		cfg.SCIMIdPPassword = ""
+		cfg.UseSecretsManager = true
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("invalid target type", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.TargetType = "invalid"
+		assert.ErrorIs(t, cfg.Validate(), ErrInvalidTargetType)
+	})
+
+	t.Run("missing scim target settings", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.TargetType = TargetTypeSCIM
+		cfg.AWSSCIMEndpoint = ""
+		cfg.AWSSCIMAccessToken = ""
+		assert.ErrorIs(t, cfg.Validate(), ErrMissingSCIMTargetURL)
+
+		cfg.SCIMTargetURL = "https://api.github.com/scim/v2/enterprises/example"
+		assert.ErrorIs(t, cfg.Validate(), ErrMissingSCIMTargetToken)
+
+		cfg.SCIMTargetToken = "token"
+		assert.NoError(t, cfg.Validate(), "the AWS SCIM settings are not required")
+
+		cfg.SCIMTargetToken = ""
+		cfg.UseSecretsManager = true
+		assert.NoError(t, cfg.Validate())
+	})
+}
+
+func TestGroupsFilter(t *testing.T) {
+	cfg := validConfig()
+	cfg.GWSGroupsFilter = []string{"name:AWS*"}
+	cfg.EntraGroupsFilter = []string{"startswith(displayName,'AWS')"}
+
+	assert.Equal(t, []string{"name:AWS*"}, cfg.GroupsFilter())
+
+	cfg.IDPType = IDPTypeEntra
+	assert.Equal(t, []string{"startswith(displayName,'AWS')"}, cfg.GroupsFilter())
+
+	cfg.OktaGroupsFilter = []string{`profile.name sw "AWS"`}
+	cfg.IDPType = IDPTypeOkta
+	assert.Equal(t, []string{`profile.name sw "AWS"`}, cfg.GroupsFilter())
+
+	cfg.LDAPGroupsFilter = []string{"(cn=AWS-*)"}
+	cfg.IDPType = IDPTypeLDAP
+	assert.Equal(t, []string{"(cn=AWS-*)"}, cfg.GroupsFilter())
+
+	cfg.FileGroupsFilter = []string{"AWS-*"}
+	cfg.IDPType = IDPTypeFile
+	assert.Equal(t, []string{"AWS-*"}, cfg.GroupsFilter())
+
+	cfg.SCIMIdPGroupsFilter = []string{`displayName sw "AWS"`}
+	cfg.IDPType = IDPTypeSCIM
+	assert.Equal(t, []string{`displayName sw "AWS"`}, cfg.GroupsFilter())
+}
+
+func profilesConfig() Config {
+	cfg := validConfig()
+	cfg.AWSS3BucketName = "state-bucket"
+	cfg.AWSS3BucketKey = "state.json"
+	cfg.GWSGroupsFilter = []string{"name:AWS*"}
+	cfg.Profiles = []Profile{
+		{
+			Name: "engineering",
+			Config: Config{
+				GWSGroupsFilter: []string{"name:Eng*"},
+				AWSS3BucketKey:  "engineering/state.json",
+			},
+		},
+		{
+			Name: "finance",
+			Config: Config{
+				GWSUserEmail:       "finance-admin@example.com",
+				AWSSCIMEndpoint:    "https://finance.scim.example.com",
+				AWSSCIMAccessToken: "finance-token",
+				AWSS3BucketKey:     "finance/state.json",
+				SyncUserFields:     []string{"phoneNumbers"},
+			},
+		},
+	}
+	return cfg
+}
+
+func TestForProfile(t *testing.T) {
+	t.Run("profile settings override top-level settings", func(t *testing.T) {
+		cfg := profilesConfig()
+
+		got, err := cfg.ForProfile("finance")
+		assert.NoError(t, err)
+
+		assert.Equal(t, "finance", got.Profile)
+		assert.Equal(t, "finance-admin@example.com", got.GWSUserEmail)
+		assert.Equal(t, "https://finance.scim.example.com", got.AWSSCIMEndpoint)
+		assert.Equal(t, "finance-token", got.AWSSCIMAccessToken)
+		assert.Equal(t, "finance/state.json", got.AWSS3BucketKey)
+		assert.Equal(t, []string{"phoneNumbers"}, got.SyncUserFields)
+		assert.Nil(t, got.Profiles)
+	})
+
+	t.Run("settings not defined in the profile are inherited", func(t *testing.T) {
+		cfg := profilesConfig()
+
+		got, err := cfg.ForProfile("engineering")
+		assert.NoError(t, err)
+
+		assert.Equal(t, []string{"name:Eng*"}, got.GWSGroupsFilter)
+		assert.Equal(t, "state-bucket", got.AWSS3BucketName)
+		assert.Equal(t, cfg.GWSUserEmail, got.GWSUserEmail)
+		assert.Equal(t, cfg.AWSSCIMEndpoint, got.AWSSCIMEndpoint)
+		assert.Equal(t, cfg.LogLevel, got.LogLevel)
+	})
+
+	t.Run("empty lists are inherited", func(t *testing.T) {
+		cfg := profilesConfig()
+		cfg.GWSServiceAccountScopes = []string{"scope"}
+		cfg.Profiles[0].GWSServiceAccountScopes = []string{}
+
+		got, err := cfg.ForProfile("engineering")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"scope"}, got.GWSServiceAccountScopes)
+	})
+
+	t.Run("zero settings defined in the profile override top-level settings", func(t *testing.T) {
+		cfg := profilesConfig()
+		cfg.UseSecretsManager = true
+		cfg.GWSCache = true
+		cfg.GWSBulkUsersThreshold = 200
+		cfg.Profiles[0].GWSGroupsFilter = []string{}
+		cfg.DefineProfileSettings([]any{
+			map[string]any{
+				"name":                     "engineering",
+				"use_secrets_manager":      false,
+				"gws_cache":                false,
+				"gws_bulk_users_threshold": 0,
+				"gws_groups_filter":        []any{},
+				"aws_s3_bucket_key":        "engineering/state.json",
+			},
+		})
+
+		got, err := cfg.ForProfile("engineering")
+		assert.NoError(t, err)
+		assert.False(t, got.UseSecretsManager)
+		assert.False(t, got.GWSCache)
+		assert.Zero(t, got.GWSBulkUsersThreshold)
+		assert.Empty(t, got.GWSGroupsFilter)
+		assert.Equal(t, "engineering/state.json", got.AWSS3BucketKey)
+		assert.Equal(t, cfg.GWSUserEmail, got.GWSUserEmail, "the settings not present are inherited")
+
+		// the profiles without recorded settings keep the non-zero values only
+		got, err = cfg.ForProfile("finance")
+		assert.NoError(t, err)
+		assert.True(t, got.UseSecretsManager)
+	})
+
+	t.Run("global settings cannot be overridden", func(t *testing.T) {
+		cfg := profilesConfig()
+		cfg.Profiles[0].LogLevel = "debug"
+
+		got, err := cfg.ForProfile("engineering")
+		assert.NoError(t, err)
+		assert.Equal(t, "info", got.LogLevel)
+	})
+
+	t.Run("top-level configuration is not modified", func(t *testing.T) {
+		cfg := profilesConfig()
+
+		_, err := cfg.ForProfile("finance")
+		assert.NoError(t, err)
+		assert.Equal(t, "admin@example.com", cfg.GWSUserEmail)
+		assert.Len(t, cfg.Profiles, 2)
+	})
+
+	t.Run("unknown profile", func(t *testing.T) {
+		cfg := profilesConfig()
+
+		_, err := cfg.ForProfile("unknown")
+		assert.ErrorIs(t, err, ErrProfileNotFound)
+	})
+}
+
+func TestProfileNames(t *testing.T) {
+	cfg := profilesConfig()
+	assert.Equal(t, []string{"engineering", "finance"}, cfg.ProfileNames())
+
+	cfg = validConfig()
+	assert.Empty(t, cfg.ProfileNames())
+}
+
+func TestValidateProfiles(t *testing.T) {
+	t.Run("valid profiles", func(t *testing.T) {
+		cfg := profilesConfig()
+		assert.NoError(t, cfg.ValidateProfiles())
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("valid profile selection", func(t *testing.T) {
+		cfg := profilesConfig()
+		cfg.Profile = "finance"
+		assert.NoError(t, cfg.ValidateProfiles())
+
+		cfg = profilesConfig()
+		cfg.AllProfiles = true
+		assert.NoError(t, cfg.ValidateProfiles())
+	})
+
+	t.Run("missing profile name", func(t *testing.T) {
+		cfg := profilesConfig()
+		cfg.Profiles[1].Name = ""
+		assert.ErrorIs(t, cfg.ValidateProfiles(), ErrMissingProfileName)
+		assert.ErrorIs(t, cfg.Validate(), ErrMissingProfileName)
+	})
+
+	t.Run("duplicate profile name", func(t *testing.T) {
+		cfg := profilesConfig()
+		cfg.Profiles[1].Name = "engineering"
+		assert.ErrorIs(t, cfg.ValidateProfiles(), ErrDuplicateProfileName)
+	})
+
+	t.Run("profiles sharing the same state", func(t *testing.T) {
+		cfg := profilesConfig()
+		cfg.Profiles[1].AWSS3BucketKey = ""
+		cfg.Profiles[0].AWSS3BucketKey = ""
+		assert.ErrorIs(t, cfg.ValidateProfiles(), ErrDuplicateProfileState)
+	})
+
+	t.Run("state stored in the cache of another profile", func(t *testing.T) {
+		cfg := profilesConfig()
+		cfg.Profiles[1].AWSS3BucketKey = cfg.Profiles[0].AWSS3BucketKey + ".cache.json"
+		assert.ErrorIs(t, cfg.ValidateProfiles(), ErrDuplicateProfileState)
+	})
+
+	t.Run("unknown selected profile", func(t *testing.T) {
+		cfg := profilesConfig()
+		cfg.Profile = "unknown"
+		assert.ErrorIs(t, cfg.ValidateProfiles(), ErrProfileNotFound)
+	})
+
+	t.Run("all profiles without profiles", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.AllProfiles = true
+		assert.ErrorIs(t, cfg.ValidateProfiles(), ErrNoProfiles)
+	})
+
+	t.Run("profile and all profiles", func(t *testing.T) {
+		cfg := profilesConfig()
+		cfg.Profile = "finance"
+		cfg.AllProfiles = true
+		assert.ErrorIs(t, cfg.ValidateProfiles(), ErrProfileAndAllProfiles)
+	})
 }
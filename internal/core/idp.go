@@ -26,3 +26,20 @@ type IdentityProviderService interface {
 	// GetGroupsMembers returns the groups and their members from the Identity provider side.
 	GetGroupsMembers(ctx context.Context, gr *model.GroupsResult) (*model.GroupsMembersResult, error)
 }
+
+// GroupsNamesKeeper is implemented by the Identity Providers that keep the names of the groups synced before,
+// e.g. the names given to the groups with the same name in the Identity Provider side.
+type GroupsNamesKeeper interface {
+	// KeepGroupsNames receives the groups of the state before getting the groups.
+	KeepGroupsNames(gr *model.GroupsResult)
+}
+
+// CacheKeeper is implemented by the Identity Providers that keep a cache of the Identity Provider resources
+// between syncs, the cache is stored next to the state when the repository implements CacheRepository.
+type CacheKeeper interface {
+	// LoadCache receives the cache saved by the previous sync before getting the groups, nil when there is none.
+	LoadCache(data []byte) error
+
+	// SaveCache returns the cache to be stored after the sync, nil when there is nothing to store.
+	SaveCache() ([]byte, error)
+}
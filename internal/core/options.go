@@ -12,6 +12,14 @@ func WithIdentityProviderGroupsFilter(filter []string) SyncServiceOption {
 	}
 }
 
+// WithInactiveMembersRemoved is a SyncServiceOption that can be used to remove the
+// inactive users (e.g. suspended) from the groups, the users are still synced as inactive users.
+func WithInactiveMembersRemoved(remove bool) SyncServiceOption {
+	return func(ss *SyncService) {
+		ss.removeInactiveMembers = remove
+	}
+}
+
 // WithIdentityProviderUsersFilter is a SyncServiceOption that can be used to
 // provide a filter for the users that should be synced.
 func WithIdentityProviderUsersFilter(filter []string) SyncServiceOption {
@@ -52,7 +52,7 @@ func TestWithIdentityProviderGroupsFilter(t *testing.T) {
 		}
 
 		if !reflect.DeepEqual(got, want) {
-			t.Errorf("NewSyncService() got = %s, want %s", got, want)
+			t.Errorf("NewSyncService() got = %v, want %v", got, want)
 		}
 	})
 }
@@ -101,7 +101,22 @@ func TestWithIdentityProviderUsersFilter(t *testing.T) {
 		}
 
 		if !reflect.DeepEqual(got, want) {
-			t.Errorf("NewSyncService() got = %s, want %s", got, want)
+			t.Errorf("NewSyncService() got = %v, want %v", got, want)
 		}
 	})
 }
+
+func TestWithInactiveMembersRemoved(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	prov := mocks.NewMockIdentityProviderService(mockCtrl)
+	scim := mocks.NewMockSCIMService(mockCtrl)
+	repo := mocks.NewMockStateRepository(mockCtrl)
+
+	got, _ := NewSyncService(prov, scim, repo, WithInactiveMembersRemoved(true))
+
+	if !got.removeInactiveMembers {
+		t.Errorf("got.removeInactiveMembers = %v, want %v", got.removeInactiveMembers, true)
+	}
+}
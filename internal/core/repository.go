@@ -18,3 +18,13 @@ type StateRepository interface {
 	// SetState sets the state of the synchronization process.
 	SetState(ctx context.Context, state *model.State) error
 }
+
+// CacheRepository is implemented by the repositories that store the cache of the Identity Provider
+// next to the state.
+type CacheRepository interface {
+	// GetCache returns the stored cache, nil when there is none.
+	GetCache(ctx context.Context) ([]byte, error)
+
+	// SetCache stores the cache.
+	SetCache(ctx context.Context, data []byte) error
+}
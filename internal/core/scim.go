@@ -47,3 +47,10 @@ type SCIMService interface {
 	// DeleteGroupsMembers deletes groups members in the SCIM Service given a list of groups members.
 	DeleteGroupsMembers(ctx context.Context, gmr *model.GroupsMembersResult) error
 }
+
+// StatsLogger is implemented by the SCIM services logging their metrics, e.g. the rate of
+// their requests, once the sync is done.
+type StatsLogger interface {
+	// LogStats logs the metrics of the SCIM service.
+	LogStats()
+}
@@ -31,6 +31,9 @@ type SyncService struct {
 	repo             StateRepository
 	provGroupsFilter []string
 	provUsersFilter  []string
+
+	// removeInactiveMembers removes the inactive users from the groups, keeping them as inactive users
+	removeInactiveMembers bool
 }
 
 // NewSyncService creates a new sync service.
@@ -62,6 +65,29 @@ func NewSyncService(prov IdentityProviderService, scim SCIMService, repo StateRe
 
 // SyncGroupsAndTheirMembers the default sync method tha syncs groups and their members
 func (ss *SyncService) SyncGroupsAndTheirMembers(ctx context.Context) error {
+	defer ss.logStats()
+
+	slog.Info("getting state data")
+	state, err := ss.repo.GetState(ctx)
+	if err != nil {
+		if _, ok := errors.AsType[*types.NoSuchKey](err); ok {
+			slog.Warn("no state file found in the state repository, creating a new one")
+			state = model.StateBuilder().Build()
+		} else if _, ok := errors.AsType[*repository.ErrStateFileEmpty](err); ok {
+			slog.Warn("no state file found in the state repository, creating a new one")
+			state = model.StateBuilder().Build()
+		} else {
+			return fmt.Errorf("error getting state data from the repository: %w", err)
+		}
+	}
+
+	// the groups synced before are read first, the names the identity provider gave them are kept
+	if keeper, ok := ss.prov.(GroupsNamesKeeper); ok && state.Resources != nil {
+		keeper.KeepGroupsNames(state.Resources.Groups)
+	}
+
+	ss.loadCache(ctx)
+
 	slog.Info("getting identity provider data", "group_filter", ss.provGroupsFilter)
 
 	idpGroupsResult, err := ss.prov.GetGroups(ctx, ss.provGroupsFilter)
@@ -98,18 +124,9 @@ func (ss *SyncService) SyncGroupsAndTheirMembers(ctx context.Context) error {
 		"users", idpUsersResult.Items,
 	)
 
-	slog.Info("getting state data")
-	state, err := ss.repo.GetState(ctx)
-	if err != nil {
-		if _, ok := errors.AsType[*types.NoSuchKey](err); ok {
-			slog.Warn("no state file found in the state repository, creating a new one")
-			state = model.StateBuilder().Build()
-		} else if _, ok := errors.AsType[*repository.ErrStateFileEmpty](err); ok {
-			slog.Warn("no state file found in the state repository, creating a new one")
-			state = model.StateBuilder().Build()
-		} else {
-			return fmt.Errorf("error getting state data from the repository: %w", err)
-		}
+	// the users were read from the groups members, the inactive ones are synced even when they are removed from the groups
+	if ss.removeInactiveMembers {
+		idpGroupsMembersResult = activeGroupsMembers(idpGroupsMembersResult)
 	}
 
 	var (
@@ -171,8 +188,89 @@ func (ss *SyncService) SyncGroupsAndTheirMembers(ctx context.Context) error {
 		return fmt.Errorf("error storing the state: %w", err)
 	}
 
+	ss.saveCache(ctx)
+
 	slog.Info("sync completed",
 		"date", time.Now().Format(time.RFC3339),
 	)
 	return nil
 }
+
+// loadCache loads the identity provider cache stored in the repository, if any.
+// The cache only saves requests, the sync continues without it when it can't be loaded.
+func (ss *SyncService) loadCache(ctx context.Context) {
+	keeper, ok := ss.prov.(CacheKeeper)
+	if !ok {
+		return
+	}
+	cacheRepo, ok := ss.repo.(CacheRepository)
+	if !ok {
+		return
+	}
+
+	data, err := cacheRepo.GetCache(ctx)
+	if err != nil {
+		slog.Warn("error getting the identity provider cache from the repository, syncing without it", "error", err)
+		return
+	}
+
+	if err := keeper.LoadCache(data); err != nil {
+		slog.Warn("error loading the identity provider cache, syncing without it", "error", err)
+	}
+}
+
+// saveCache stores the identity provider cache in the repository, if any.
+func (ss *SyncService) saveCache(ctx context.Context) {
+	keeper, ok := ss.prov.(CacheKeeper)
+	if !ok {
+		return
+	}
+	cacheRepo, ok := ss.repo.(CacheRepository)
+	if !ok {
+		return
+	}
+
+	data, err := keeper.SaveCache()
+	if err != nil {
+		slog.Warn("error saving the identity provider cache", "error", err)
+		return
+	}
+	if data == nil {
+		return
+	}
+
+	if err := cacheRepo.SetCache(ctx, data); err != nil {
+		slog.Warn("error storing the identity provider cache in the repository", "error", err)
+	}
+}
+
+// logStats logs the metrics of the SCIM service, if any.
+func (ss *SyncService) logStats() {
+	if sl, ok := ss.scim.(StatsLogger); ok {
+		sl.LogStats()
+	}
+}
+
+// activeGroupsMembers returns the groups members without the inactive members.
+func activeGroupsMembers(gmr *model.GroupsMembersResult) *model.GroupsMembersResult {
+	groupsMembers := make([]*model.GroupMembers, 0, len(gmr.Resources))
+
+	for _, gm := range gmr.Resources {
+		members := make([]*model.Member, 0, len(gm.Resources))
+		for _, member := range gm.Resources {
+			if member.Status != "" && member.Status != "ACTIVE" {
+				slog.Warn("removing inactive member from group", "group", gm.Group.Name, "email", member.Email, "status", member.Status)
+				continue
+			}
+			members = append(members, member)
+		}
+
+		groupsMembers = append(groupsMembers, model.GroupMembersBuilder().
+			WithGroup(gm.Group).
+			WithResources(members).
+			Build(),
+		)
+	}
+
+	return model.GroupsMembersResultBuilder().WithResources(groupsMembers).Build()
+}
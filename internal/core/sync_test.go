@@ -531,3 +531,91 @@ func createService(
 
 	return svc
 }
+
+func TestActiveGroupsMembers(t *testing.T) {
+	group := model.GroupBuilder().WithIPID("group-1").WithName("group 1").Build()
+	active := model.MemberBuilder().WithIPID("user-1").WithEmail("user.1@mail.com").WithStatus("ACTIVE").Build()
+	suspended := model.MemberBuilder().WithIPID("user-2").WithEmail("user.2@mail.com").WithStatus("SUSPENDED").Build()
+
+	gmr := model.GroupsMembersResultBuilder().WithResources([]*model.GroupMembers{
+		model.GroupMembersBuilder().WithGroup(group).WithResources([]*model.Member{active, suspended}).Build(),
+	}).Build()
+
+	want := model.GroupsMembersResultBuilder().WithResources([]*model.GroupMembers{
+		model.GroupMembersBuilder().WithGroup(group).WithResources([]*model.Member{active}).Build(),
+	}).Build()
+
+	assert.Equal(t, want, activeGroupsMembers(gmr))
+}
+
+type cacheProvider struct {
+	*mocks.MockIdentityProviderService
+	cache []byte
+}
+
+func (p *cacheProvider) LoadCache(data []byte) error { p.cache = data; return nil }
+func (p *cacheProvider) SaveCache() ([]byte, error)  { return p.cache, nil }
+
+type cacheRepository struct {
+	*mocks.MockStateRepository
+	cache []byte
+}
+
+func (r *cacheRepository) GetCache(_ context.Context) ([]byte, error) { return r.cache, nil }
+func (r *cacheRepository) SetCache(_ context.Context, data []byte) error {
+	r.cache = data
+	return nil
+}
+
+type statsSCIMService struct {
+	*mocks.MockSCIMService
+	logged int
+}
+
+func (s *statsSCIMService) LogStats() { s.logged++ }
+
+func TestSyncService_logStats(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+
+	t.Run("should log the metrics of the scim service once the sync is done", func(t *testing.T) {
+		prov := mocks.NewMockIdentityProviderService(mockCtrl)
+		repo := mocks.NewMockStateRepository(mockCtrl)
+		scimService := &statsSCIMService{MockSCIMService: mocks.NewMockSCIMService(mockCtrl)}
+
+		repo.EXPECT().GetState(gomock.Any()).Return(model.StateBuilder().Build(), nil).Times(1)
+		prov.EXPECT().GetGroups(gomock.Any(), gomock.Any()).Return(nil, assert.AnError).Times(1)
+
+		ss, err := NewSyncService(prov, scimService, repo)
+		assert.NoError(t, err)
+
+		assert.Error(t, ss.SyncGroupsAndTheirMembers(context.TODO()))
+		assert.Equal(t, 1, scimService.logged, "the metrics are logged when the sync fails too")
+	})
+}
+
+func TestSyncService_cache(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+
+	t.Run("should load and store the identity provider cache", func(t *testing.T) {
+		prov := &cacheProvider{MockIdentityProviderService: mocks.NewMockIdentityProviderService(mockCtrl)}
+		repo := &cacheRepository{MockStateRepository: mocks.NewMockStateRepository(mockCtrl), cache: []byte(`{"version": 1}`)}
+
+		ss := &SyncService{prov: prov, repo: repo}
+
+		ss.loadCache(context.TODO())
+		assert.Equal(t, []byte(`{"version": 1}`), prov.cache)
+
+		prov.cache = []byte(`{"version": 2}`)
+		ss.saveCache(context.TODO())
+		assert.Equal(t, []byte(`{"version": 2}`), repo.cache)
+	})
+
+	t.Run("should do nothing when the repository doesn't store the cache", func(t *testing.T) {
+		prov := &cacheProvider{MockIdentityProviderService: mocks.NewMockIdentityProviderService(mockCtrl)}
+		ss := &SyncService{prov: prov, repo: mocks.NewMockStateRepository(mockCtrl)}
+
+		ss.loadCache(context.TODO())
+		ss.saveCache(context.TODO())
+		assert.Nil(t, prov.cache)
+	})
+}
@@ -0,0 +1,74 @@
+package idp
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	admin "google.golang.org/api/admin/directory/v1"
+)
+
+// WithBulkUsersThreshold configures the Google Workspace identity provider to list all the users of the
+// directory at once, instead of reading the users of the group members one by one, when more than
+// threshold members are not read yet. 0 always reads the users one by one.
+func WithBulkUsersThreshold(threshold int) IdentityProviderOption {
+	return func(po *providerOptions) {
+		po.bulkUsersThreshold = threshold
+	}
+}
+
+// uncachedEmails returns the emails not read yet, in lower case.
+func (i *IdentityProvider) uncachedEmails(emails []string) []string {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	uncached := make([]string, 0, len(emails))
+	for _, email := range emails {
+		if email == "" {
+			continue
+		}
+		if _, ok := i.users[strings.ToLower(email)]; !ok {
+			uncached = append(uncached, email)
+		}
+	}
+
+	return uncached
+}
+
+// bulkLoadUsers lists all the users of the directory and keeps them when more members than the
+// bulk users threshold are not read yet, the members left are read one by one.
+func (i *IdentityProvider) bulkLoadUsers(ctx context.Context, emails []string) error {
+	if i.bulkUsersThreshold <= 0 {
+		return nil
+	}
+
+	uncached := i.uncachedEmails(emails)
+	if len(uncached) <= i.bulkUsersThreshold {
+		return nil
+	}
+
+	slog.Debug("idp: listing all the users instead of reading the members one by one", "members", len(uncached), "threshold", i.bulkUsersThreshold)
+
+	users, err := i.ps.ListUsers(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("idp: error listing users: %w", err)
+	}
+
+	i.cacheUsers(users)
+
+	return nil
+}
+
+// cacheUsers keeps the users by email to avoid reading them again.
+func (i *IdentityProvider) cacheUsers(users []*admin.User) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.users == nil {
+		i.users = make(map[string]*admin.User, len(users))
+	}
+	for _, u := range users {
+		i.users[strings.ToLower(u.PrimaryEmail)] = u
+	}
+}
@@ -0,0 +1,113 @@
+package idp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	mocks "github.com/slashdevops/idp-scim-sync/mocks/idp"
+	"github.com/slashdevops/idp-scim-sync/pkg/google"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	admin "google.golang.org/api/admin/directory/v1"
+	"google.golang.org/api/option"
+)
+
+func TestBulkUsersThreshold(t *testing.T) {
+	g1 := model.GroupBuilder().WithIPID("g1").WithName("group 1").Build()
+	gmr := model.GroupsMembersResultBuilder().WithResources([]*model.GroupMembers{
+		model.GroupMembersBuilder().WithGroup(g1).WithResources([]*model.Member{
+			model.MemberBuilder().WithIPID("u1").WithEmail("user.1@mail.com").WithStatus("ACTIVE").Build(),
+			model.MemberBuilder().WithIPID("u2").WithEmail("User.2@mail.com").WithStatus("ACTIVE").Build(),
+			model.MemberBuilder().WithIPID("u3").WithEmail("user.3@mail.com").WithStatus("ACTIVE").Build(),
+		}).Build(),
+	}).Build()
+
+	user1 := &admin.User{Id: "u1", PrimaryEmail: "user.1@mail.com", Name: &admin.UserName{GivenName: "user", FamilyName: "1"}}
+	user2 := &admin.User{Id: "u2", PrimaryEmail: "user.2@mail.com", Name: &admin.UserName{GivenName: "user", FamilyName: "2"}}
+	user3 := &admin.User{Id: "u3", PrimaryEmail: "user.3@mail.com", Name: &admin.UserName{GivenName: "user", FamilyName: "3"}}
+	user4 := &admin.User{Id: "u4", PrimaryEmail: "user.4@mail.com", Name: &admin.UserName{GivenName: "user", FamilyName: "4"}}
+
+	t.Run("should list all the users when the members are above the threshold", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		mockDS := mocks.NewMockGoogleProviderService(mockCtrl)
+		mockDS.EXPECT().ListUsers(gomock.Any(), nil).Return([]*admin.User{user1, user2, user4}, nil).Times(1)
+		// user 3 is not listed, e.g. created after the listing
+		mockDS.EXPECT().GetUser(gomock.Any(), "user.3@mail.com").Return(user3, nil).Times(1)
+
+		ip, _ := NewIdentityProvider(mockDS, WithBulkUsersThreshold(2))
+
+		ur, err := ip.GetUsersByGroupsMembers(context.Background(), gmr)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, ur.Items)
+	})
+
+	t.Run("should read the users one by one when the members are not above the threshold", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		mockDS := mocks.NewMockGoogleProviderService(mockCtrl)
+		mockDS.EXPECT().GetUser(gomock.Any(), "user.1@mail.com").Return(user1, nil).Times(1)
+		mockDS.EXPECT().GetUser(gomock.Any(), "User.2@mail.com").Return(user2, nil).Times(1)
+		mockDS.EXPECT().GetUser(gomock.Any(), "user.3@mail.com").Return(user3, nil).Times(1)
+
+		ip, _ := NewIdentityProvider(mockDS, WithBulkUsersThreshold(3))
+
+		ur, err := ip.GetUsersByGroupsMembers(context.Background(), gmr)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, ur.Items)
+	})
+
+	t.Run("should return error when the users listing fails", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		mockDS := mocks.NewMockGoogleProviderService(mockCtrl)
+		mockDS.EXPECT().ListUsers(gomock.Any(), nil).Return(nil, errors.New("test error")).Times(1)
+
+		ip, _ := NewIdentityProvider(mockDS, WithBulkUsersThreshold(1))
+
+		ur, err := ip.GetUsersByGroupsMembers(context.Background(), gmr)
+		assert.Error(t, err)
+		assert.Nil(t, ur)
+	})
+
+	t.Run("should list all the users from the cache when they are not modified", func(t *testing.T) {
+		svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/admin/directory/v1/users", r.URL.Path, "the users are not read one by one")
+			if r.Header.Get("If-None-Match") == `"p1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(&admin.Users{Etag: `"p1"`, Users: []*admin.User{user1, user2, user3}})
+		}))
+		defer svr.Close()
+
+		svc, err := admin.NewService(context.Background(), option.WithHTTPClient(svr.Client()), option.WithEndpoint(svr.URL), option.WithUserAgent("test"))
+		assert.NoError(t, err)
+
+		sync := func(cache *google.Cache) {
+			ds, err := google.NewDirectoryService(svc, google.WithCache(cache))
+			assert.NoError(t, err)
+
+			ip, _ := NewIdentityProvider(ds, WithBulkUsersThreshold(2), WithCache(cache))
+			ur, err := ip.GetUsersByGroupsMembers(context.Background(), gmr)
+			assert.NoError(t, err)
+			assert.Equal(t, 3, ur.Items)
+		}
+
+		cache := google.NewCache()
+		sync(cache)
+		assert.Equal(t, google.CacheStats{Misses: 1, Entries: 1}, cache.Stats())
+
+		// the next sync lists the users with the saved ETag
+		data, err := cache.Save()
+		assert.NoError(t, err)
+
+		next := google.NewCache()
+		assert.NoError(t, next.Load(data))
+		sync(next)
+		assert.Equal(t, google.CacheStats{Hits: 1, Entries: 1}, next.Stats())
+	})
+}
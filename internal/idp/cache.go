@@ -0,0 +1,32 @@
+package idp
+
+import (
+	"log/slog"
+
+	"github.com/slashdevops/idp-scim-sync/pkg/google"
+)
+
+// WithCache configures the Google Workspace identity provider to keep the Google resources between syncs
+// in the cache, the cache must be the one of the Google services.
+func WithCache(cache *google.Cache) IdentityProviderOption {
+	return func(po *providerOptions) {
+		po.cache = cache
+	}
+}
+
+// LoadCache loads the Google resources cache saved by the previous sync.
+func (i *IdentityProvider) LoadCache(data []byte) error {
+	return i.cache.Load(data)
+}
+
+// SaveCache returns the Google resources cache to be loaded by the next sync, nil without cache.
+func (i *IdentityProvider) SaveCache() ([]byte, error) {
+	if i.cache == nil {
+		return nil, nil
+	}
+
+	stats := i.cache.Stats()
+	slog.Info("idp: google resources cache", "hits", stats.Hits, "misses", stats.Misses, "entries", stats.Entries)
+
+	return i.cache.Save()
+}
@@ -0,0 +1,42 @@
+package idp
+
+import (
+	"testing"
+
+	mocks "github.com/slashdevops/idp-scim-sync/mocks/idp"
+	"github.com/slashdevops/idp-scim-sync/pkg/google"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestIdentityProvider_Cache(t *testing.T) {
+	t.Run("should do nothing without cache", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		ip, _ := NewIdentityProvider(mocks.NewMockGoogleProviderService(mockCtrl))
+
+		assert.NoError(t, ip.LoadCache([]byte(`{"version": 1}`)))
+
+		data, err := ip.SaveCache()
+		assert.NoError(t, err)
+		assert.Nil(t, data)
+	})
+
+	t.Run("should load and save the cache", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		ip, _ := NewIdentityProvider(mocks.NewMockGoogleProviderService(mockCtrl), WithCache(google.NewCache()))
+
+		assert.NoError(t, ip.LoadCache([]byte(`{"version": 2, "entries": {"users/a": {"etag": "e1", "data": {}}}}`)))
+
+		// the resources not requested in the sync are dropped
+		data, err := ip.SaveCache()
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"version": 2, "entries": {}}`, string(data))
+	})
+
+	t.Run("should return error when the cache is not valid", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		ip, _ := NewIdentityProvider(mocks.NewMockGoogleProviderService(mockCtrl), WithCache(google.NewCache()))
+
+		assert.Error(t, ip.LoadCache([]byte(`not json`)))
+	})
+}
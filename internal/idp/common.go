@@ -0,0 +1,120 @@
+package idp
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	"golang.org/x/sync/errgroup"
+)
+
+// maxConcurrentRequests is the maximum number of concurrent requests sent to the identity providers,
+// the Google Workspace one follows the burst of its rate limiter with WithConcurrency.
+const maxConcurrentRequests = 10
+
+// buildGroupsResult returns a GroupsResult with the given groups, avoiding the second, third, etc
+// repetition of the same group name, AWS SSO group names are unique.
+func buildGroupsResult(groups []*model.Group) *model.GroupsResult {
+	uniqueGroups := make(map[string]struct{}, len(groups))
+	syncGroups := make([]*model.Group, 0, len(groups))
+
+	for _, grp := range groups {
+		if _, ok := uniqueGroups[grp.Name]; ok {
+			slog.Warn("idp: group already exists with the same name, this group will be avoided, please make your groups uniques by name!",
+				"id", grp.IPID,
+				"name", grp.Name,
+				"email", grp.Email,
+			)
+			continue
+		}
+		uniqueGroups[grp.Name] = struct{}{}
+		syncGroups = append(syncGroups, grp)
+	}
+
+	return model.GroupsResultBuilder().WithResources(syncGroups).Build()
+}
+
+// listGroupsMembers returns the members of every group in gr, calling listMembers concurrently
+// with a bounded number of goroutines. The result preserves the order of gr.Resources.
+func listGroupsMembers(ctx context.Context, gr *model.GroupsResult, listMembers func(ctx context.Context, group *model.Group) ([]*model.Member, error)) (*model.GroupsMembersResult, error) {
+	if gr == nil {
+		return nil, ErrGroupResultNil
+	}
+
+	groupMembers := make([]*model.GroupMembers, len(gr.Resources))
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(maxConcurrentRequests)
+
+	for idx, group := range gr.Resources {
+		g.Go(func() error {
+			members, err := listMembers(ctx, group)
+			if err != nil {
+				return fmt.Errorf("idp: error getting members for group %s: %w", group.IPID, err)
+			}
+
+			groupMembers[idx] = model.GroupMembersBuilder().
+				WithGroup(group).
+				WithResources(members).
+				Build()
+
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return model.GroupsMembersResultBuilder().WithResources(groupMembers).Build(), nil
+}
+
+// getUsersByGroupsMembers returns the unique users, by email, of the members in gmr, calling getUser
+// concurrently with a bounded number of goroutines.
+// Members the getUser function cannot map to a valid user (nil user) are not included.
+func getUsersByGroupsMembers(ctx context.Context, gmr *model.GroupsMembersResult, getUser func(ctx context.Context, member *model.Member) (*model.User, error)) (*model.UsersResult, error) {
+	if gmr == nil {
+		return nil, ErrGroupResultNil
+	}
+
+	uniqMembers := make(map[string]*model.Member)
+	for _, groupMembers := range gmr.Resources {
+		for _, member := range groupMembers.Resources {
+			if _, ok := uniqMembers[member.Email]; !ok {
+				uniqMembers[member.Email] = member
+			}
+		}
+	}
+
+	var mu sync.Mutex
+	users := make([]*model.User, 0, len(uniqMembers))
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(maxConcurrentRequests)
+
+	for email, member := range uniqMembers {
+		g.Go(func() error {
+			u, err := getUser(ctx, member)
+			if err != nil {
+				return fmt.Errorf("idp: error getting user: %+v, email: %s, error: %w", member.IPID, email, err)
+			}
+			if u == nil {
+				return nil
+			}
+
+			mu.Lock()
+			users = append(users, u)
+			mu.Unlock()
+
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return model.UsersResultBuilder().WithResources(users).Build(), nil
+}
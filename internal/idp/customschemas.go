@@ -0,0 +1,193 @@
+package idp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	admin "google.golang.org/api/admin/directory/v1"
+)
+
+// ErrInvalidCustomAttribute is returned when a custom schema attribute definition is not valid.
+var ErrInvalidCustomAttribute = errors.New("provider: invalid custom schema attribute")
+
+// customAttributeTargets are the SCIM attributes the custom schema attributes can be mapped to.
+var customAttributeTargets = []string{
+	"userType", "title", "nickName", "profileUrl",
+	"employeeNumber", "costCenter", "organization", "division", "department",
+}
+
+// CustomAttribute is an attribute of a Google Workspace custom schema synced with the users.
+type CustomAttribute struct {
+	// Schema is the name of the custom schema, e.g. HR.
+	Schema string
+
+	// Field is the name of the field of the custom schema, e.g. CostCenter.
+	Field string
+
+	// SCIMAttribute is the SCIM attribute the value is mapped to, e.g. costCenter, the value is only
+	// kept in the user extensions when empty.
+	SCIMAttribute string
+}
+
+// ParseCustomAttributes parses the custom schema attributes definitions, "<schema>.<field>[=<scim attribute>]",
+// e.g. "HR.CostCenter=costCenter".
+func ParseCustomAttributes(values []string) ([]CustomAttribute, error) {
+	attrs := make([]CustomAttribute, 0, len(values))
+
+	for _, v := range values {
+		if strings.TrimSpace(v) == "" {
+			continue
+		}
+
+		def, target, _ := strings.Cut(v, "=")
+		schema, field, ok := strings.Cut(strings.TrimSpace(def), ".")
+		if !ok || schema == "" || field == "" {
+			return nil, fmt.Errorf("%w: %q, the format is <schema>.<field>[=<scim attribute>]", ErrInvalidCustomAttribute, v)
+		}
+
+		target = strings.TrimSpace(target)
+		if target != "" && !slices.Contains(customAttributeTargets, target) {
+			return nil, fmt.Errorf("%w: %q, the SCIM attributes are %s", ErrInvalidCustomAttribute, v, strings.Join(customAttributeTargets, ", "))
+		}
+
+		attrs = append(attrs, CustomAttribute{Schema: schema, Field: field, SCIMAttribute: target})
+	}
+
+	return attrs, nil
+}
+
+// CustomSchemas returns the custom schemas of the attributes, to be requested to the Google API.
+func CustomSchemas(attrs []CustomAttribute) []string {
+	schemas := make([]string, 0, len(attrs))
+	for _, attr := range attrs {
+		if !slices.Contains(schemas, attr.Schema) {
+			schemas = append(schemas, attr.Schema)
+		}
+	}
+
+	return schemas
+}
+
+// WithCustomAttributes configures the Google Workspace identity provider to sync the custom schema
+// attributes of the users, the users must be read with their custom schemas.
+func WithCustomAttributes(attrs []CustomAttribute) IdentityProviderOption {
+	return func(po *providerOptions) {
+		po.customAttributes = attrs
+	}
+}
+
+// name returns the name of the attribute in the user extensions, e.g. HR.CostCenter.
+func (ca CustomAttribute) name() string {
+	return ca.Schema + "." + ca.Field
+}
+
+// withCustomAttributes returns the user with the custom schema attributes of the Google user
+// in its extensions and mapped to their SCIM attributes.
+func (i *IdentityProvider) withCustomAttributes(u *model.User, usr *admin.User) *model.User {
+	if u == nil || len(i.customAttributes) == 0 {
+		return u
+	}
+
+	for _, attr := range i.customAttributes {
+		raw, ok := usr.CustomSchemas[attr.Schema]
+		if !ok {
+			continue
+		}
+
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			slog.Error("idp: error converting custom schema", "schema", attr.Schema, "error", err)
+			continue
+		}
+
+		value := customAttributeValue(fields[attr.Field])
+		if value == "" {
+			continue
+		}
+
+		if u.Extensions == nil {
+			u.Extensions = make(map[string]string, len(i.customAttributes))
+		}
+		u.Extensions[attr.name()] = value
+
+		setSCIMAttribute(u, attr.SCIMAttribute, value)
+	}
+
+	u.SetHashCode()
+
+	return u
+}
+
+// customAttributeValue returns the value of a custom schema field, the values of the multi-valued
+// fields are separated by comma.
+func customAttributeValue(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return ""
+	}
+
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(value)
+	case []any:
+		values := make([]string, 0, len(value))
+		for _, item := range value {
+			// the multi-valued fields are returned as objects with the type and the value
+			if m, ok := item.(map[string]any); ok {
+				item = m["value"]
+			}
+			if item != nil {
+				values = append(values, strings.TrimSpace(fmt.Sprint(item)))
+			}
+		}
+		return strings.Join(values, ",")
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// setSCIMAttribute sets the SCIM attribute of the user to the value.
+func setSCIMAttribute(u *model.User, attribute, value string) {
+	switch attribute {
+	case "userType":
+		u.UserType = value
+	case "title":
+		u.Title = value
+	case "nickName":
+		u.NickName = value
+	case "profileUrl":
+		u.ProfileURL = value
+	case "employeeNumber", "costCenter", "organization", "division", "department":
+		if u.EnterpriseData == nil {
+			u.EnterpriseData = &model.EnterpriseData{}
+		}
+
+		switch attribute {
+		case "employeeNumber":
+			u.EnterpriseData.EmployeeNumber = value
+		case "costCenter":
+			u.EnterpriseData.CostCenter = value
+		case "organization":
+			u.EnterpriseData.Organization = value
+		case "division":
+			u.EnterpriseData.Division = value
+		case "department":
+			u.EnterpriseData.Department = value
+		}
+	}
+}
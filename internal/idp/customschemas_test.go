@@ -0,0 +1,109 @@
+package idp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	mocks "github.com/slashdevops/idp-scim-sync/mocks/idp"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	admin "google.golang.org/api/admin/directory/v1"
+	"google.golang.org/api/googleapi"
+)
+
+func TestParseCustomAttributes(t *testing.T) {
+	t.Run("should parse the attributes and their SCIM attributes", func(t *testing.T) {
+		got, err := ParseCustomAttributes([]string{"HR.CostCenter=costCenter", " AWS.AllowedRegions ", ""})
+		assert.NoError(t, err)
+		assert.Equal(t, []CustomAttribute{
+			{Schema: "HR", Field: "CostCenter", SCIMAttribute: "costCenter"},
+			{Schema: "AWS", Field: "AllowedRegions"},
+		}, got)
+		assert.Equal(t, []string{"HR", "AWS"}, CustomSchemas(got))
+	})
+
+	t.Run("should return error when the field is missing", func(t *testing.T) {
+		got, err := ParseCustomAttributes([]string{"HR=costCenter"})
+		assert.ErrorIs(t, err, ErrInvalidCustomAttribute)
+		assert.Nil(t, got)
+	})
+
+	t.Run("should return error when the SCIM attribute is not supported", func(t *testing.T) {
+		got, err := ParseCustomAttributes([]string{"HR.CostCenter=emails"})
+		assert.ErrorIs(t, err, ErrInvalidCustomAttribute)
+		assert.Nil(t, got)
+	})
+}
+
+func Test_customAttributeValue(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{name: "string", raw: `" 1234 "`, want: "1234"},
+		{name: "number", raw: `12345678901`, want: "12345678901"},
+		{name: "bool", raw: `true`, want: "true"},
+		{name: "multi-valued", raw: `[{"type": "work", "value": "eu-west-1"}, {"value": "us-east-1"}]`, want: "eu-west-1,us-east-1"},
+		{name: "null", raw: `null`, want: ""},
+		{name: "empty", raw: ``, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, customAttributeValue(json.RawMessage(tt.raw)))
+		})
+	}
+}
+
+func TestCustomAttributes(t *testing.T) {
+	attrs, err := ParseCustomAttributes([]string{"HR.CostCenter=costCenter", "AWS.AllowedRegions", "AWS.Role=userType"})
+	assert.NoError(t, err)
+
+	mockCtrl := gomock.NewController(t)
+	mockDS := mocks.NewMockGoogleProviderService(mockCtrl)
+	mockDS.EXPECT().ListUsers(gomock.Any(), gomock.Any()).Return([]*admin.User{
+		{
+			Id:           "u1",
+			PrimaryEmail: "user.1@mail.com",
+			Name:         &admin.UserName{GivenName: "user", FamilyName: "1"},
+			CustomSchemas: map[string]googleapi.RawMessage{
+				"HR":  googleapi.RawMessage(`{"CostCenter": "1234"}`),
+				"AWS": googleapi.RawMessage(`{"AllowedRegions": [{"value": "eu-west-1"}, {"value": "us-east-1"}], "Role": "admin"}`),
+			},
+		},
+		{
+			Id:           "u2",
+			PrimaryEmail: "user.2@mail.com",
+			Name:         &admin.UserName{GivenName: "user", FamilyName: "2"},
+		},
+	}, nil)
+
+	ip, _ := NewIdentityProvider(mockDS, WithCustomAttributes(attrs))
+
+	got, err := ip.GetUsers(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, got.Items)
+
+	u1 := got.Resources[0]
+	assert.Equal(t, map[string]string{"HR.CostCenter": "1234", "AWS.AllowedRegions": "eu-west-1,us-east-1", "AWS.Role": "admin"}, u1.Extensions)
+	assert.Equal(t, "1234", u1.EnterpriseData.CostCenter)
+	assert.Equal(t, "admin", u1.UserType)
+
+	want := *u1
+	want.SetHashCode()
+	assert.Equal(t, want.HashCode, u1.HashCode, "the hash code includes the custom attributes")
+
+	u2 := got.Resources[1]
+	assert.Nil(t, u2.Extensions)
+	assert.Equal(t, model.UserBuilder().
+		WithIPID("u2").
+		WithUserName("user.2@mail.com").
+		WithDisplayName("user 2").
+		WithActive(true).
+		WithEmails(u2.Emails).
+		WithName(u2.Name).
+		Build().HashCode, u2.HashCode)
+}
@@ -0,0 +1,163 @@
+package idp
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	admin "google.golang.org/api/admin/directory/v1"
+)
+
+// DuplicateGroupNamesStrategy defines how the Google Workspace groups with the same name are synced,
+// AWS SSO group names are unique.
+type DuplicateGroupNamesStrategy string
+
+const (
+	// DuplicateGroupNamesFail fails the sync when two groups have the same name.
+	DuplicateGroupNamesFail DuplicateGroupNamesStrategy = "fail"
+
+	// DuplicateGroupNamesKeepFirst syncs the first group with the name and skips the others.
+	DuplicateGroupNamesKeepFirst DuplicateGroupNamesStrategy = "keep-first"
+
+	// DuplicateGroupNamesSuffix syncs the groups with the name followed by the local part of their email.
+	DuplicateGroupNamesSuffix DuplicateGroupNamesStrategy = "suffix"
+
+	// DuplicateGroupNamesEmail syncs the groups with their email as name.
+	DuplicateGroupNamesEmail DuplicateGroupNamesStrategy = "email"
+)
+
+var (
+	// ErrInvalidDuplicateGroupNamesStrategy is returned when the duplicate group names strategy is not supported.
+	ErrInvalidDuplicateGroupNamesStrategy = errors.New("provider: invalid duplicate group names strategy")
+
+	// ErrDuplicateGroupName is returned by the fail strategy when two groups have the same name.
+	ErrDuplicateGroupName = errors.New("provider: duplicate group name")
+)
+
+// ParseDuplicateGroupNamesStrategy returns the duplicate group names strategy of the given name, keep-first when empty.
+func ParseDuplicateGroupNamesStrategy(name string) (DuplicateGroupNamesStrategy, error) {
+	switch s := DuplicateGroupNamesStrategy(name); s {
+	case "":
+		return DuplicateGroupNamesKeepFirst, nil
+	case DuplicateGroupNamesFail, DuplicateGroupNamesKeepFirst, DuplicateGroupNamesSuffix, DuplicateGroupNamesEmail:
+		return s, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidDuplicateGroupNamesStrategy, name)
+	}
+}
+
+// WithDuplicateGroupNamesStrategy configures how the Google Workspace identity provider syncs the groups
+// with the same name.
+func WithDuplicateGroupNamesStrategy(strategy DuplicateGroupNamesStrategy) IdentityProviderOption {
+	return func(po *providerOptions) {
+		po.duplicateGroupNames = strategy
+	}
+}
+
+// KeepGroupsNames receives the groups synced before, the groups with the same name keep the names
+// they were synced with, so the names don't move from one group to another between syncs.
+func (i *IdentityProvider) KeepGroupsNames(gr *model.GroupsResult) {
+	names := make(map[string]string)
+	if gr != nil {
+		for _, grp := range gr.Resources {
+			names[grp.IPID] = grp.Name
+		}
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.syncedGroupNames = names
+}
+
+// uniqueGroups returns the groups with unique names according to the duplicate group names strategy.
+// The group synced before with the name of several groups keeps it.
+func (i *IdentityProvider) uniqueGroups(groups []*admin.Group) ([]*model.Group, error) {
+	byName := make(map[string][]*admin.Group, len(groups))
+	for _, grp := range groups {
+		byName[grp.Name] = append(byName[grp.Name], grp)
+	}
+
+	i.mu.Lock()
+	synced := i.syncedGroupNames
+	i.mu.Unlock()
+
+	used := make(map[string]struct{}, len(groups))
+	syncGroups := make([]*model.Group, 0, len(groups))
+	for _, grp := range groups {
+		name := grp.Name
+
+		if dups := byName[grp.Name]; len(dups) > 1 {
+			// the group synced before with the name, if any, keeps it
+			var holder *admin.Group
+			for _, dup := range dups {
+				if syncedName, ok := synced[dup.Id]; ok && syncedName == dup.Name {
+					holder = dup
+					break
+				}
+			}
+
+			switch i.duplicateGroupNames {
+			case DuplicateGroupNamesFail:
+				ids := make([]string, 0, len(dups))
+				for _, dup := range dups {
+					ids = append(ids, dup.Id)
+				}
+				return nil, fmt.Errorf("%w: %q, groups: %s", ErrDuplicateGroupName, grp.Name, strings.Join(ids, ", "))
+			case DuplicateGroupNamesSuffix, DuplicateGroupNamesEmail:
+				if grp != holder {
+					name = i.disambiguatedName(grp)
+				}
+			default:
+				if holder == nil {
+					holder = dups[0]
+				}
+				if grp != holder {
+					slog.Warn("idp: group already exists with the same name, this group will be avoided, please make your groups uniques by name!",
+						"id", grp.Id,
+						"name", grp.Name,
+						"email", grp.Email,
+					)
+					continue
+				}
+			}
+		}
+
+		if _, ok := used[name]; ok {
+			slog.Warn("idp: group already exists with the same name, this group will be avoided, please make your groups uniques by name!",
+				"id", grp.Id,
+				"name", name,
+				"email", grp.Email,
+			)
+			continue
+		}
+		used[name] = struct{}{}
+
+		if name != grp.Name {
+			slog.Debug("idp: group with duplicate name renamed", "id", grp.Id, "name", grp.Name, "syncName", name)
+		}
+
+		syncGroups = append(syncGroups, model.GroupBuilder().
+			WithIPID(grp.Id).
+			WithName(name).
+			WithEmail(grp.Email).
+			Build(),
+		)
+	}
+
+	return syncGroups, nil
+}
+
+// disambiguatedName returns the name of the group according to the suffix and email strategies,
+// the groups without email, e.g. the org unit groups, use their id instead.
+func (i *IdentityProvider) disambiguatedName(grp *admin.Group) string {
+	if i.duplicateGroupNames == DuplicateGroupNamesEmail {
+		return cmp.Or(grp.Email, grp.Id)
+	}
+
+	local, _, _ := strings.Cut(grp.Email, "@")
+	return grp.Name + "-" + cmp.Or(local, grp.Id)
+}
@@ -0,0 +1,105 @@
+package idp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	mocks "github.com/slashdevops/idp-scim-sync/mocks/idp"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	admin "google.golang.org/api/admin/directory/v1"
+)
+
+func TestParseDuplicateGroupNamesStrategy(t *testing.T) {
+	got, err := ParseDuplicateGroupNamesStrategy("")
+	assert.NoError(t, err)
+	assert.Equal(t, DuplicateGroupNamesKeepFirst, got)
+
+	got, err = ParseDuplicateGroupNamesStrategy("suffix")
+	assert.NoError(t, err)
+	assert.Equal(t, DuplicateGroupNamesSuffix, got)
+
+	got, err = ParseDuplicateGroupNamesStrategy("rename")
+	assert.ErrorIs(t, err, ErrInvalidDuplicateGroupNamesStrategy)
+	assert.Empty(t, got)
+}
+
+func TestDuplicateGroupNamesStrategy(t *testing.T) {
+	groups := []*admin.Group{
+		{Id: "g1", Name: "Engineering", Email: "engineering-emea@example.com"},
+		{Id: "g2", Name: "Engineering", Email: "engineering-us@example.com"},
+		{Id: "g3", Name: "Sales", Email: "sales@example.com"},
+	}
+
+	// g2 was synced as Engineering before
+	synced := model.GroupsResultBuilder().WithResources([]*model.Group{
+		model.GroupBuilder().WithIPID("g2").WithName("Engineering").Build(),
+	}).Build()
+
+	tests := []struct {
+		name     string
+		strategy DuplicateGroupNamesStrategy
+		synced   *model.GroupsResult
+		want     map[string]string
+		wantErr  error
+	}{
+		{
+			name:     "keep-first",
+			strategy: DuplicateGroupNamesKeepFirst,
+			want:     map[string]string{"g1": "Engineering", "g3": "Sales"},
+		},
+		{
+			name:     "keep-first keeps the group synced before",
+			strategy: DuplicateGroupNamesKeepFirst,
+			synced:   synced,
+			want:     map[string]string{"g2": "Engineering", "g3": "Sales"},
+		},
+		{
+			name:     "suffix",
+			strategy: DuplicateGroupNamesSuffix,
+			want:     map[string]string{"g1": "Engineering-engineering-emea", "g2": "Engineering-engineering-us", "g3": "Sales"},
+		},
+		{
+			name:     "suffix keeps the name of the group synced before",
+			strategy: DuplicateGroupNamesSuffix,
+			synced:   synced,
+			want:     map[string]string{"g1": "Engineering-engineering-emea", "g2": "Engineering", "g3": "Sales"},
+		},
+		{
+			name:     "email",
+			strategy: DuplicateGroupNamesEmail,
+			want:     map[string]string{"g1": "engineering-emea@example.com", "g2": "engineering-us@example.com", "g3": "Sales"},
+		},
+		{
+			name:     "fail",
+			strategy: DuplicateGroupNamesFail,
+			wantErr:  ErrDuplicateGroupName,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			mockDS := mocks.NewMockGoogleProviderService(mockCtrl)
+			mockDS.EXPECT().ListGroups(gomock.Any(), gomock.Any()).Return(groups, nil)
+
+			ip, _ := NewIdentityProvider(mockDS, WithDuplicateGroupNamesStrategy(tt.strategy))
+			ip.KeepGroupsNames(tt.synced)
+
+			got, err := ip.GetGroups(context.Background(), nil)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, got)
+				return
+			}
+			assert.NoError(t, err)
+
+			names := make(map[string]string, got.Items)
+			for _, grp := range got.Resources {
+				names[grp.IPID] = grp.Name
+			}
+			assert.Equal(t, tt.want, names)
+		})
+	}
+}
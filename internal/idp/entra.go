@@ -0,0 +1,393 @@
+package idp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	"github.com/slashdevops/idp-scim-sync/pkg/entra"
+)
+
+// This implement core.IdentityProviderService interface for Microsoft Entra ID
+
+// ErrEntraServiceNil is returned when the EntraProviderService is nil.
+var ErrEntraServiceNil = errors.New("provider: entra service is nil")
+
+//go:generate go tool mockgen -package=mocks -destination=../../mocks/idp/entra_mocks.go -source=entra.go EntraProviderService
+
+// EntraProviderService is the interface that wraps the Microsoft Graph client methods.
+type EntraProviderService interface {
+	ListGroups(ctx context.Context, filter []string) ([]*entra.Group, error)
+	ListUsers(ctx context.Context, filter []string) ([]*entra.User, error)
+	ListGroupMembers(ctx context.Context, groupID string) ([]*entra.User, error)
+	GetUser(ctx context.Context, userID string) (*entra.User, error)
+	ListUsersDelta(ctx context.Context, deltaLink string) (*entra.UsersDelta, error)
+}
+
+// entraCacheVersion is the version of the users delta cache format, the caches of other versions are discarded.
+const entraCacheVersion = 1
+
+// entraCache is the users index and the delta link kept next to the state between syncs.
+type entraCache struct {
+	Version   int                    `json:"version"`
+	DeltaLink string                 `json:"deltaLink"`
+	Users     map[string]*entra.User `json:"users"`
+}
+
+// EntraIdentityProvider is the Identity Provider service that implements the core.IdentityProvider interface
+// and consumes the pkg.entra methods.
+type EntraIdentityProvider struct {
+	ps EntraProviderService
+	providerOptions
+
+	// users index maintained with delta queries when usersDelta is enabled
+	mu        sync.Mutex
+	users     map[string]*entra.User
+	deltaLink string
+}
+
+// NewEntraIdentityProvider returns a new instance of the Microsoft Entra ID Identity Provider service.
+func NewEntraIdentityProvider(eps EntraProviderService, opts ...IdentityProviderOption) (*EntraIdentityProvider, error) {
+	if eps == nil {
+		return nil, ErrEntraServiceNil
+	}
+
+	ip := &EntraIdentityProvider{
+		ps: eps,
+	}
+
+	for _, opt := range opts {
+		opt(&ip.providerOptions)
+	}
+
+	return ip, nil
+}
+
+// GetGroups returns a list of groups from Microsoft Entra ID.
+//
+// The filter parameter is a list of OData filters, e.g. "startswith(displayName,'AWS')".
+func (e *EntraIdentityProvider) GetGroups(ctx context.Context, filter []string) (*model.GroupsResult, error) {
+	eGroups, err := e.ps.ListGroups(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("idp: error getting groups: %w", err)
+	}
+
+	groups := make([]*model.Group, 0, len(eGroups))
+	for _, grp := range eGroups {
+		groups = append(groups, model.GroupBuilder().
+			WithIPID(grp.ID).
+			WithName(grp.DisplayName).
+			WithEmail(grp.Mail).
+			Build(),
+		)
+	}
+
+	syncResult := buildGroupsResult(groups)
+	slog.Debug("idp: entra GetGroups()", "groups", syncResult.Items)
+
+	return syncResult, nil
+}
+
+// GetUsers returns a list of users from Microsoft Entra ID.
+//
+// The filter parameter is a list of OData filters, e.g. "accountEnabled eq true".
+func (e *EntraIdentityProvider) GetUsers(ctx context.Context, filter []string) (*model.UsersResult, error) {
+	eUsers, err := e.ps.ListUsers(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("idp: error getting users: %w", err)
+	}
+
+	syncUsers := make([]*model.User, 0, len(eUsers))
+	for _, usr := range eUsers {
+		if u := buildEntraUser(usr, e.syncFieldSet); u != nil {
+			syncUsers = append(syncUsers, u)
+		}
+	}
+
+	uResult := model.UsersResultBuilder().WithResources(syncUsers).Build()
+	slog.Debug("idp: entra GetUsers()", "users", len(syncUsers))
+
+	return uResult, nil
+}
+
+// GetGroupMembers returns the members of the group, including the members of the nested groups.
+func (e *EntraIdentityProvider) GetGroupMembers(ctx context.Context, groupID string) (*model.MembersResult, error) {
+	if groupID == "" {
+		return nil, ErrGroupIDNil
+	}
+
+	members, err := e.listGroupMembers(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("idp: error getting group members: %w", err)
+	}
+
+	return model.MembersResultBuilder().WithResources(members).Build(), nil
+}
+
+// GetGroupsMembers returns the members of the groups, including the members of the nested groups.
+func (e *EntraIdentityProvider) GetGroupsMembers(ctx context.Context, gr *model.GroupsResult) (*model.GroupsMembersResult, error) {
+	gmr, err := listGroupsMembers(ctx, gr, func(ctx context.Context, group *model.Group) ([]*model.Member, error) {
+		return e.listGroupMembers(ctx, group.IPID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Debug("idp: entra GetGroupsMembers()", "groups", gmr.Items)
+
+	return gmr, nil
+}
+
+// GetUsersByGroupsMembers returns the users of the given groups members.
+//
+// When the users delta option is enabled, the users are resolved from an index maintained with
+// delta queries, kept next to the state with LoadCache and SaveCache, so only the changes since
+// the previous sync are read.
+func (e *EntraIdentityProvider) GetUsersByGroupsMembers(ctx context.Context, gmr *model.GroupsMembersResult) (*model.UsersResult, error) {
+	if e.usersDelta {
+		if err := e.refreshUsers(ctx); err != nil {
+			return nil, fmt.Errorf("idp: error refreshing users: %w", err)
+		}
+	}
+
+	ur, err := getUsersByGroupsMembers(ctx, gmr, func(ctx context.Context, member *model.Member) (*model.User, error) {
+		if usr, ok := e.indexedUser(member.IPID); ok {
+			return buildEntraUser(usr, e.syncFieldSet), nil
+		}
+
+		usr, err := e.ps.GetUser(ctx, member.IPID)
+		if err != nil {
+			return nil, err
+		}
+		return buildEntraUser(usr, e.syncFieldSet), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Debug("idp: entra GetUsersByGroupsMembers()", "users", ur.Items)
+
+	return ur, nil
+}
+
+// listGroupMembers returns the enabled users that are members of the given group.
+func (e *EntraIdentityProvider) listGroupMembers(ctx context.Context, groupID string) ([]*model.Member, error) {
+	eMembers, err := e.ps.ListGroupMembers(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]*model.Member, 0, len(eMembers))
+	for _, m := range eMembers {
+		members = append(members, model.MemberBuilder().
+			WithIPID(m.ID).
+			WithEmail(strings.TrimSpace(m.Email())).
+			WithStatus("ACTIVE").
+			Build(),
+		)
+	}
+
+	return members, nil
+}
+
+// refreshUsers applies the users changed since the last delta query to the users index,
+// the first call reads all the users.
+func (e *EntraIdentityProvider) refreshUsers(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	delta, err := e.ps.ListUsersDelta(ctx, e.deltaLink)
+	if err != nil && e.deltaLink != "" {
+		// the delta link of a previous sync can expire, all the users are read again
+		slog.Warn("idp: entra users delta link rejected, reading all the users", "error", err)
+		e.users, e.deltaLink = nil, ""
+		delta, err = e.ps.ListUsersDelta(ctx, "")
+	}
+	if err != nil {
+		return err
+	}
+
+	if e.users == nil {
+		e.users = make(map[string]*entra.User, len(delta.Users))
+	}
+
+	for _, usr := range delta.Users {
+		if usr.Removed != nil {
+			delete(e.users, usr.ID)
+			continue
+		}
+		e.users[usr.ID] = usr
+	}
+	e.deltaLink = delta.DeltaLink
+
+	slog.Debug("idp: entra users index refreshed", "changes", len(delta.Users), "users", len(e.users))
+
+	return nil
+}
+
+// LoadCache loads the users index and the delta link saved by the previous sync, so the first
+// delta query only reads the changes. It does nothing when the users delta option is disabled.
+func (e *EntraIdentityProvider) LoadCache(data []byte) error {
+	if !e.usersDelta || len(data) == 0 {
+		return nil
+	}
+
+	var c entraCache
+	if err := json.Unmarshal(data, &c); err != nil {
+		return fmt.Errorf("idp: error decoding entra users delta cache: %w", err)
+	}
+
+	if c.Version != entraCacheVersion || c.DeltaLink == "" {
+		return nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.users = c.Users
+	e.deltaLink = c.DeltaLink
+
+	return nil
+}
+
+// SaveCache returns the users index and the delta link to be loaded by the next sync,
+// nil when the users delta option is disabled or no delta query was done.
+func (e *EntraIdentityProvider) SaveCache() ([]byte, error) {
+	if !e.usersDelta {
+		return nil, nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.deltaLink == "" {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(entraCache{Version: entraCacheVersion, DeltaLink: e.deltaLink, Users: e.users})
+	if err != nil {
+		return nil, fmt.Errorf("idp: error encoding entra users delta cache: %w", err)
+	}
+
+	slog.Info("idp: entra users delta cache", "users", len(e.users))
+
+	return data, nil
+}
+
+// indexedUser returns the user from the users index maintained with delta queries.
+func (e *EntraIdentityProvider) indexedUser(id string) (*entra.User, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	usr, ok := e.users[id]
+	return usr, ok
+}
+
+// buildEntraUser builds a User model from a Microsoft Graph user.
+// The fields parameter controls which optional user attributes are included.
+func buildEntraUser(usr *entra.User, fields *model.SyncFieldSet) *model.User {
+	if usr == nil {
+		return nil
+	}
+
+	// these fields are required because the Constrains defined here:
+	// https://docs.aws.amazon.com/singlesignon/latest/developerguide/createuser.html
+	if usr.GivenName == "" {
+		slog.Warn("idp: User given name is empty", "id", usr.ID, "userPrincipalName", usr.UserPrincipalName)
+		return nil
+	}
+
+	if usr.Surname == "" {
+		slog.Warn("idp: User family name is empty", "id", usr.ID, "userPrincipalName", usr.UserPrincipalName)
+		return nil
+	}
+
+	email := strings.TrimSpace(usr.Email())
+	if email == "" {
+		slog.Warn("idp: User email is empty", "id", usr.ID)
+		return nil
+	}
+
+	displayName := usr.DisplayName
+	if displayName == "" {
+		displayName = fmt.Sprintf("%s %s", usr.GivenName, usr.Surname)
+	}
+
+	name := model.NameBuilder().
+		WithGivenName(strings.TrimSpace(usr.GivenName)).
+		WithFamilyName(strings.TrimSpace(usr.Surname)).
+		WithFormatted(strings.TrimSpace(usr.DisplayName)).
+		Build()
+
+	ub := model.UserBuilder().
+		WithIPID(strings.TrimSpace(usr.ID)).
+		WithUserName(strings.TrimSpace(usr.UserPrincipalName)).
+		WithDisplayName(strings.TrimSpace(displayName)).
+		WithActive(usr.AccountEnabled).
+		WithEmail(model.EmailBuilder().WithPrimary(true).WithType("work").WithValue(email).Build()).
+		WithName(name)
+
+	if fields.Includes(model.SyncUserFieldTitle) {
+		ub = ub.WithTitle(strings.TrimSpace(usr.JobTitle))
+	}
+	if fields.Includes(model.SyncUserFieldUserType) {
+		ub = ub.WithUserType(strings.TrimSpace(usr.UserType))
+	}
+	if fields.Includes(model.SyncUserFieldPreferredLanguage) {
+		ub = ub.WithPreferredLanguage(strings.TrimSpace(usr.PreferredLanguage))
+	}
+
+	if fields.Includes(model.SyncUserFieldAddresses) {
+		parts := make([]string, 0, 5)
+		for _, p := range []string{usr.StreetAddress, usr.City, usr.State, usr.PostalCode, usr.Country} {
+			if p = strings.TrimSpace(p); p != "" {
+				parts = append(parts, p)
+			}
+		}
+
+		if len(parts) > 0 {
+			ub = ub.WithAddress(model.AddressBuilder().
+				WithFormatted(strings.Join(parts, ", ")).
+				WithStreetAddress(strings.TrimSpace(usr.StreetAddress)).
+				WithLocality(strings.TrimSpace(usr.City)).
+				WithRegion(strings.TrimSpace(usr.State)).
+				WithPostalCode(strings.TrimSpace(usr.PostalCode)).
+				WithCountry(strings.TrimSpace(usr.Country)).
+				Build(),
+			)
+		}
+	}
+
+	if fields.Includes(model.SyncUserFieldPhoneNumbers) {
+		switch {
+		case len(usr.BusinessPhones) > 0 && strings.TrimSpace(usr.BusinessPhones[0]) != "":
+			ub = ub.WithPhoneNumber(model.PhoneNumberBuilder().WithValue(strings.TrimSpace(usr.BusinessPhones[0])).WithType("work").Build())
+		case strings.TrimSpace(usr.MobilePhone) != "":
+			ub = ub.WithPhoneNumber(model.PhoneNumberBuilder().WithValue(strings.TrimSpace(usr.MobilePhone)).WithType("mobile").Build())
+		}
+	}
+
+	if fields.Includes(model.SyncUserFieldEnterpriseData) {
+		var manager *model.Manager
+		if usr.Manager != nil && usr.Manager.ID != "" {
+			manager = model.ManagerBuilder().WithValue(usr.Manager.ID).Build()
+		}
+
+		if usr.EmployeeID != "" || usr.CompanyName != "" || usr.Department != "" || manager != nil {
+			ub = ub.WithEnterpriseData(model.EnterpriseDataBuilder().
+				WithEmployeeNumber(strings.TrimSpace(usr.EmployeeID)).
+				WithOrganization(strings.TrimSpace(usr.CompanyName)).
+				WithDepartment(strings.TrimSpace(usr.Department)).
+				WithManager(manager).
+				Build(),
+			)
+		}
+	}
+
+	return ub.Build()
+}
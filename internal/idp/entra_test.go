@@ -0,0 +1,288 @@
+package idp
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	mocks "github.com/slashdevops/idp-scim-sync/mocks/idp"
+	"github.com/slashdevops/idp-scim-sync/pkg/entra"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestNewEntraIdentityProvider(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+
+	t.Run("Should return EntraIdentityProvider and no error", func(t *testing.T) {
+		svc, err := NewEntraIdentityProvider(mocks.NewMockEntraProviderService(mockCtrl), WithUsersDelta(true))
+		assert.NoError(t, err)
+		assert.NotNil(t, svc)
+		assert.True(t, svc.usersDelta)
+	})
+
+	t.Run("Should return an error if no service is provided", func(t *testing.T) {
+		svc, err := NewEntraIdentityProvider(nil)
+		assert.ErrorIs(t, err, ErrEntraServiceNil)
+		assert.Nil(t, svc)
+	})
+}
+
+func TestEntraGetGroups(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+
+	t.Run("Should return the groups without repeated names", func(t *testing.T) {
+		mockSvc := mocks.NewMockEntraProviderService(mockCtrl)
+		mockSvc.EXPECT().ListGroups(gomock.Any(), []string{"startswith(displayName,'AWS')"}).Return([]*entra.Group{
+			{ID: "1", DisplayName: "AWS-1", Mail: "aws-1@example.com"},
+			{ID: "2", DisplayName: "AWS-1"},
+			{ID: "3", DisplayName: "AWS-3"},
+		}, nil)
+
+		ip, _ := NewEntraIdentityProvider(mockSvc)
+		got, err := ip.GetGroups(context.Background(), []string{"startswith(displayName,'AWS')"})
+		assert.NoError(t, err)
+
+		want := model.GroupsResultBuilder().WithResources([]*model.Group{
+			model.GroupBuilder().WithIPID("1").WithName("AWS-1").WithEmail("aws-1@example.com").Build(),
+			model.GroupBuilder().WithIPID("3").WithName("AWS-3").Build(),
+		}).Build()
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("Should return error", func(t *testing.T) {
+		mockSvc := mocks.NewMockEntraProviderService(mockCtrl)
+		mockSvc.EXPECT().ListGroups(gomock.Any(), nil).Return(nil, errors.New("test error"))
+
+		ip, _ := NewEntraIdentityProvider(mockSvc)
+		got, err := ip.GetGroups(context.Background(), nil)
+		assert.Error(t, err)
+		assert.Nil(t, got)
+	})
+}
+
+func TestEntraGetGroupsMembers(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+
+	g1 := model.GroupBuilder().WithIPID("g1").WithName("group 1").Build()
+	g2 := model.GroupBuilder().WithIPID("g2").WithName("group 2").Build()
+	gr := model.GroupsResultBuilder().WithResources([]*model.Group{g1, g2}).Build()
+
+	t.Run("Should return the members of every group in order", func(t *testing.T) {
+		mockSvc := mocks.NewMockEntraProviderService(mockCtrl)
+		mockSvc.EXPECT().ListGroupMembers(gomock.Any(), "g1").Return([]*entra.User{{ID: "u1", UserPrincipalName: "u1@example.com", AccountEnabled: true}}, nil)
+		mockSvc.EXPECT().ListGroupMembers(gomock.Any(), "g2").Return([]*entra.User{{ID: "u2", UserPrincipalName: "u2@example.com", Mail: "user2@example.com", AccountEnabled: true}}, nil)
+
+		ip, _ := NewEntraIdentityProvider(mockSvc)
+		got, err := ip.GetGroupsMembers(context.Background(), gr)
+		assert.NoError(t, err)
+
+		m1 := model.MemberBuilder().WithIPID("u1").WithEmail("u1@example.com").WithStatus("ACTIVE").Build()
+		m2 := model.MemberBuilder().WithIPID("u2").WithEmail("user2@example.com").WithStatus("ACTIVE").Build()
+		want := model.GroupsMembersResultBuilder().WithResources([]*model.GroupMembers{
+			model.GroupMembersBuilder().WithGroup(g1).WithResource(m1).Build(),
+			model.GroupMembersBuilder().WithGroup(g2).WithResource(m2).Build(),
+		}).Build()
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("Should return error", func(t *testing.T) {
+		mockSvc := mocks.NewMockEntraProviderService(mockCtrl)
+		mockSvc.EXPECT().ListGroupMembers(gomock.Any(), gomock.Any()).Return(nil, errors.New("test error")).AnyTimes()
+
+		ip, _ := NewEntraIdentityProvider(mockSvc)
+		got, err := ip.GetGroupsMembers(context.Background(), gr)
+		assert.Error(t, err)
+		assert.Nil(t, got)
+	})
+
+	t.Run("Should return error when groups result is nil", func(t *testing.T) {
+		ip, _ := NewEntraIdentityProvider(mocks.NewMockEntraProviderService(mockCtrl))
+		got, err := ip.GetGroupsMembers(context.Background(), nil)
+		assert.ErrorIs(t, err, ErrGroupResultNil)
+		assert.Nil(t, got)
+	})
+}
+
+func TestEntraGetUsersByGroupsMembers(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+
+	u1 := &entra.User{ID: "u1", UserPrincipalName: "u1@example.com", GivenName: "User", Surname: "One", AccountEnabled: true}
+	u2 := &entra.User{ID: "u2", UserPrincipalName: "u2@example.com", GivenName: "User", Surname: "Two", AccountEnabled: true}
+
+	m1 := model.MemberBuilder().WithIPID("u1").WithEmail("u1@example.com").WithStatus("ACTIVE").Build()
+	m2 := model.MemberBuilder().WithIPID("u2").WithEmail("u2@example.com").WithStatus("ACTIVE").Build()
+	gmr := model.GroupsMembersResultBuilder().WithResources([]*model.GroupMembers{
+		model.GroupMembersBuilder().WithGroup(model.GroupBuilder().WithIPID("g1").Build()).WithResources([]*model.Member{m1, m2}).Build(),
+		model.GroupMembersBuilder().WithGroup(model.GroupBuilder().WithIPID("g2").Build()).WithResources([]*model.Member{m1}).Build(),
+	}).Build()
+
+	t.Run("Should get every unique user once", func(t *testing.T) {
+		mockSvc := mocks.NewMockEntraProviderService(mockCtrl)
+		mockSvc.EXPECT().GetUser(gomock.Any(), "u1").Return(u1, nil)
+		mockSvc.EXPECT().GetUser(gomock.Any(), "u2").Return(u2, nil)
+
+		ip, _ := NewEntraIdentityProvider(mockSvc)
+		got, err := ip.GetUsersByGroupsMembers(context.Background(), gmr)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []*model.User{buildEntraUser(u1, nil), buildEntraUser(u2, nil)}, got.Resources)
+	})
+
+	t.Run("Should resolve users from the delta index and only read the changes", func(t *testing.T) {
+		mockSvc := mocks.NewMockEntraProviderService(mockCtrl)
+		gomock.InOrder(
+			mockSvc.EXPECT().ListUsersDelta(gomock.Any(), "").Return(&entra.UsersDelta{Users: []*entra.User{u1}, DeltaLink: "delta-1"}, nil),
+			mockSvc.EXPECT().ListUsersDelta(gomock.Any(), "delta-1").Return(&entra.UsersDelta{Users: []*entra.User{u2, {ID: "u1", Removed: &entra.Removed{Reason: "deleted"}}}, DeltaLink: "delta-2"}, nil),
+		)
+		// u2 is not in the index in the first sync, u1 was removed before the second sync
+		mockSvc.EXPECT().GetUser(gomock.Any(), "u2").Return(u2, nil)
+		mockSvc.EXPECT().GetUser(gomock.Any(), "u1").Return(u1, nil)
+
+		ip, _ := NewEntraIdentityProvider(mockSvc, WithUsersDelta(true))
+
+		got, err := ip.GetUsersByGroupsMembers(context.Background(), gmr)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, got.Items)
+
+		got, err = ip.GetUsersByGroupsMembers(context.Background(), gmr)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, got.Items)
+		assert.Equal(t, "delta-2", ip.deltaLink)
+	})
+
+	t.Run("Should return error", func(t *testing.T) {
+		mockSvc := mocks.NewMockEntraProviderService(mockCtrl)
+		mockSvc.EXPECT().GetUser(gomock.Any(), gomock.Any()).Return(nil, errors.New("test error")).AnyTimes()
+
+		ip, _ := NewEntraIdentityProvider(mockSvc)
+		got, err := ip.GetUsersByGroupsMembers(context.Background(), gmr)
+		assert.Error(t, err)
+		assert.Nil(t, got)
+	})
+}
+
+func TestBuildEntraUser(t *testing.T) {
+	usr := &entra.User{
+		ID:                "u1",
+		UserPrincipalName: "u1@example.com",
+		Mail:              "user.one@example.com",
+		DisplayName:       "User One",
+		GivenName:         "User",
+		Surname:           "One",
+		AccountEnabled:    true,
+		UserType:          "Member",
+		JobTitle:          "Engineer",
+		Department:        "R&D",
+		CompanyName:       "Example",
+		EmployeeID:        "42",
+		PreferredLanguage: "en-US",
+		MobilePhone:       "+1 555 0101",
+		City:              "Madrid",
+		Country:           "ES",
+		Manager:           &entra.Manager{ID: "m1"},
+	}
+
+	t.Run("Should map all the fields", func(t *testing.T) {
+		got := buildEntraUser(usr, nil)
+
+		want := model.UserBuilder().
+			WithIPID("u1").
+			WithUserName("u1@example.com").
+			WithDisplayName("User One").
+			WithActive(true).
+			WithEmail(model.EmailBuilder().WithPrimary(true).WithType("work").WithValue("user.one@example.com").Build()).
+			WithName(model.NameBuilder().WithGivenName("User").WithFamilyName("One").WithFormatted("User One").Build()).
+			WithTitle("Engineer").
+			WithUserType("Member").
+			WithPreferredLanguage("en-US").
+			WithAddress(model.AddressBuilder().WithFormatted("Madrid, ES").WithLocality("Madrid").WithCountry("ES").Build()).
+			WithPhoneNumber(model.PhoneNumberBuilder().WithValue("+1 555 0101").WithType("mobile").Build()).
+			WithEnterpriseData(model.EnterpriseDataBuilder().
+				WithEmployeeNumber("42").
+				WithOrganization("Example").
+				WithDepartment("R&D").
+				WithManager(model.ManagerBuilder().WithValue("m1").Build()).
+				Build()).
+			Build()
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("Should only map the configured fields", func(t *testing.T) {
+		got := buildEntraUser(usr, model.NewSyncFieldSet([]string{"title"}))
+		assert.Equal(t, "Engineer", got.Title)
+		assert.Empty(t, got.UserType)
+		assert.Nil(t, got.Addresses)
+		assert.Nil(t, got.PhoneNumbers)
+		assert.Nil(t, got.EnterpriseData)
+	})
+
+	t.Run("Should return nil when required fields are missing", func(t *testing.T) {
+		assert.Nil(t, buildEntraUser(nil, nil))
+		assert.Nil(t, buildEntraUser(&entra.User{ID: "u1", UserPrincipalName: "u1@example.com", Surname: "One"}, nil))
+		assert.Nil(t, buildEntraUser(&entra.User{ID: "u1", UserPrincipalName: "u1@example.com", GivenName: "User"}, nil))
+		assert.Nil(t, buildEntraUser(&entra.User{ID: "u1", GivenName: "User", Surname: "One"}, nil))
+	})
+}
+
+func TestEntraUsersDeltaCache(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+
+	u1 := &entra.User{ID: "u1", UserPrincipalName: "u1@example.com", GivenName: "User", Surname: "One", AccountEnabled: true}
+	u2 := &entra.User{ID: "u2", UserPrincipalName: "u2@example.com", GivenName: "User", Surname: "Two", AccountEnabled: true}
+
+	m1 := model.MemberBuilder().WithIPID("u1").WithEmail("u1@example.com").WithStatus("ACTIVE").Build()
+	gmr := model.GroupsMembersResultBuilder().WithResources([]*model.GroupMembers{
+		model.GroupMembersBuilder().WithGroup(model.GroupBuilder().WithIPID("g1").Build()).WithResources([]*model.Member{m1}).Build(),
+	}).Build()
+
+	t.Run("Should only read the changes since the sync that saved the cache", func(t *testing.T) {
+		first := mocks.NewMockEntraProviderService(mockCtrl)
+		first.EXPECT().ListUsersDelta(gomock.Any(), "").Return(&entra.UsersDelta{Users: []*entra.User{u1, u2}, DeltaLink: "delta-1"}, nil)
+
+		ip, _ := NewEntraIdentityProvider(first, WithUsersDelta(true))
+		_, err := ip.GetUsersByGroupsMembers(context.Background(), gmr)
+		assert.NoError(t, err)
+
+		data, err := ip.SaveCache()
+		assert.NoError(t, err)
+
+		// a new provider, as built by every sync, resumes from the saved delta link
+		second := mocks.NewMockEntraProviderService(mockCtrl)
+		second.EXPECT().ListUsersDelta(gomock.Any(), "delta-1").Return(&entra.UsersDelta{DeltaLink: "delta-2"}, nil)
+
+		ip, _ = NewEntraIdentityProvider(second, WithUsersDelta(true))
+		assert.NoError(t, ip.LoadCache(data))
+
+		got, err := ip.GetUsersByGroupsMembers(context.Background(), gmr)
+		assert.NoError(t, err)
+		assert.Equal(t, []*model.User{buildEntraUser(u1, nil)}, got.Resources)
+		assert.Equal(t, "delta-2", ip.deltaLink)
+	})
+
+	t.Run("Should read all the users when the saved delta link is rejected", func(t *testing.T) {
+		mockSvc := mocks.NewMockEntraProviderService(mockCtrl)
+		gomock.InOrder(
+			mockSvc.EXPECT().ListUsersDelta(gomock.Any(), "expired").Return(nil, errors.New("410 gone")),
+			mockSvc.EXPECT().ListUsersDelta(gomock.Any(), "").Return(&entra.UsersDelta{Users: []*entra.User{u1}, DeltaLink: "delta-1"}, nil),
+		)
+
+		ip, _ := NewEntraIdentityProvider(mockSvc, WithUsersDelta(true))
+		assert.NoError(t, ip.LoadCache([]byte(`{"version":1,"deltaLink":"expired","users":{"u9":{"id":"u9"}}}`)))
+
+		_, err := ip.GetUsersByGroupsMembers(context.Background(), gmr)
+		assert.NoError(t, err)
+		_, stale := ip.indexedUser("u9")
+		assert.False(t, stale)
+	})
+
+	t.Run("Should not keep a cache without users delta", func(t *testing.T) {
+		ip, _ := NewEntraIdentityProvider(mocks.NewMockEntraProviderService(mockCtrl))
+		assert.NoError(t, ip.LoadCache([]byte(`{"version":1,"deltaLink":"delta-1"}`)))
+		assert.Empty(t, ip.deltaLink)
+
+		data, err := ip.SaveCache()
+		assert.NoError(t, err)
+		assert.Nil(t, data)
+	})
+}
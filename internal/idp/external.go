@@ -0,0 +1,183 @@
+package idp
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	"github.com/slashdevops/idp-scim-sync/pkg/google"
+	"golang.org/x/sync/errgroup"
+	admin "google.golang.org/api/admin/directory/v1"
+)
+
+// ExternalMembersPolicy defines how the members of the Google Workspace groups that are not users
+// of the directory, e.g. external email addresses or CUSTOMER members, are synced.
+type ExternalMembersPolicy string
+
+const (
+	// ExternalMembersPolicyFail fails the sync when a group has an external member.
+	ExternalMembersPolicyFail ExternalMembersPolicy = "fail"
+
+	// ExternalMembersPolicySkip doesn't sync the external members, they are reported as skipped.
+	ExternalMembersPolicySkip ExternalMembersPolicy = "skip"
+
+	// ExternalMembersPolicyAllow syncs the external members of the allowed domains as users named
+	// after their email address, the external members of other domains are reported as skipped.
+	ExternalMembersPolicyAllow ExternalMembersPolicy = "allow"
+)
+
+// ErrInvalidExternalMembersPolicy is returned when the external members policy is not supported.
+var ErrInvalidExternalMembersPolicy = errors.New("provider: invalid external members policy")
+
+// ParseExternalMembersPolicy returns the external members policy of the given name, fail when empty.
+func ParseExternalMembersPolicy(name string) (ExternalMembersPolicy, error) {
+	switch p := ExternalMembersPolicy(name); p {
+	case "":
+		return ExternalMembersPolicyFail, nil
+	case ExternalMembersPolicyFail, ExternalMembersPolicySkip, ExternalMembersPolicyAllow:
+		return p, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidExternalMembersPolicy, name)
+	}
+}
+
+// WithExternalMembersPolicy configures how the Google Workspace identity provider syncs the group
+// members that are not users of the directory, the domains are the external domains allowed
+// by the allow policy.
+func WithExternalMembersPolicy(policy ExternalMembersPolicy, domains []string) IdentityProviderOption {
+	return func(po *providerOptions) {
+		po.externalMembersPolicy = policy
+		po.externalDomains = make([]string, 0, len(domains))
+		for _, d := range domains {
+			if d = strings.ToLower(strings.TrimSpace(d)); d != "" {
+				po.externalDomains = append(po.externalDomains, d)
+			}
+		}
+	}
+}
+
+// skipExternalMembers returns true when the external members are not failing the sync.
+func (i *IdentityProvider) skipExternalMembers() bool {
+	return i.externalMembersPolicy == ExternalMembersPolicySkip || i.externalMembersPolicy == ExternalMembersPolicyAllow
+}
+
+// externalUser returns the user synced for the external member email when its domain is allowed.
+func (i *IdentityProvider) externalUser(ipid, email string) (*admin.User, bool) {
+	if i.externalMembersPolicy != ExternalMembersPolicyAllow {
+		return nil, false
+	}
+
+	local, domain, ok := strings.Cut(strings.ToLower(email), "@")
+	if !ok || local == "" || !slices.Contains(i.externalDomains, domain) {
+		return nil, false
+	}
+
+	return &admin.User{
+		Id:           ipid,
+		PrimaryEmail: email,
+		Name: &admin.UserName{
+			GivenName:  local,
+			FamilyName: domain,
+			FullName:   email,
+		},
+	}, true
+}
+
+// removeExternalMembers returns the groups members without the members that are not users of the directory,
+// reading the users of the members not read before. The external members of the allowed domains are kept,
+// the removed members are reported with the groups they are removed from.
+func (i *IdentityProvider) removeExternalMembers(ctx context.Context, groupMembers []*model.GroupMembers) ([]*model.GroupMembers, error) {
+	emails := make(map[string]string) // email -> IPID
+	for _, gm := range groupMembers {
+		for _, member := range gm.Resources {
+			if _, ok := i.cachedUser(member.Email); !ok && member.Email != "" {
+				emails[strings.ToLower(member.Email)] = member.IPID
+			}
+		}
+	}
+
+	// List all the users at once when there are too many members to read them one by one
+	if err := i.bulkLoadUsers(ctx, slices.Collect(maps.Keys(emails))); err != nil {
+		return nil, err
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(i.concurrentRequests())
+
+	for email, ipid := range emails {
+		if _, ok := i.cachedUser(email); ok {
+			continue
+		}
+
+		g.Go(func() error {
+			u, err := i.ps.GetUser(gctx, email)
+			if err != nil {
+				if !errors.Is(err, google.ErrUserNotFound) {
+					return fmt.Errorf("idp: error getting user: %+v, email: %s, error: %w", ipid, email, err)
+				}
+
+				var ok bool
+				if u, ok = i.externalUser(ipid, email); !ok {
+					return nil
+				}
+			}
+
+			i.mu.Lock()
+			defer i.mu.Unlock()
+
+			if i.users == nil {
+				i.users = make(map[string]*admin.User)
+			}
+			i.users[email] = u
+
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	skipped := make(map[string][]string) // email, or id of the members without email (CUSTOMER) -> group names
+	syncGroupMembers := make([]*model.GroupMembers, 0, len(groupMembers))
+	for _, gm := range groupMembers {
+		syncMembers := make([]*model.Member, 0, len(gm.Resources))
+		for _, member := range gm.Resources {
+			if _, ok := i.cachedUser(member.Email); !ok || member.Email == "" {
+				key := cmp.Or(member.Email, member.IPID)
+				skipped[key] = append(skipped[key], gm.Group.Name)
+				continue
+			}
+			syncMembers = append(syncMembers, member)
+		}
+
+		if len(syncMembers) == len(gm.Resources) {
+			syncGroupMembers = append(syncGroupMembers, gm)
+			continue
+		}
+
+		syncGroupMembers = append(syncGroupMembers, model.GroupMembersBuilder().
+			WithGroup(gm.Group).
+			WithResources(syncMembers).
+			Build(),
+		)
+	}
+
+	if len(skipped) > 0 {
+		for _, member := range slices.Sorted(maps.Keys(skipped)) {
+			slog.Warn("idp: skipping external member, it is not a user of the directory",
+				"member", member,
+				"groups", skipped[member],
+			)
+		}
+		slog.Warn("idp: external members skipped", "policy", i.externalMembersPolicy, "members", len(skipped))
+	}
+
+	return syncGroupMembers, nil
+}
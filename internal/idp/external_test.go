@@ -0,0 +1,124 @@
+package idp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	mocks "github.com/slashdevops/idp-scim-sync/mocks/idp"
+	"github.com/slashdevops/idp-scim-sync/pkg/google"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	admin "google.golang.org/api/admin/directory/v1"
+)
+
+func TestParseExternalMembersPolicy(t *testing.T) {
+	got, err := ParseExternalMembersPolicy("")
+	assert.NoError(t, err)
+	assert.Equal(t, ExternalMembersPolicyFail, got)
+
+	got, err = ParseExternalMembersPolicy("allow")
+	assert.NoError(t, err)
+	assert.Equal(t, ExternalMembersPolicyAllow, got)
+
+	got, err = ParseExternalMembersPolicy("ignore")
+	assert.ErrorIs(t, err, ErrInvalidExternalMembersPolicy)
+	assert.Empty(t, got)
+}
+
+func TestExternalMembersPolicy(t *testing.T) {
+	g1 := model.GroupBuilder().WithIPID("g1").WithName("group 1").Build()
+	gr := model.GroupsResultBuilder().WithResources([]*model.Group{g1}).Build()
+
+	members := map[string][]*admin.Member{
+		"g1": {
+			{Id: "u1", Email: "user.1@mail.com", Status: "ACTIVE", Type: "USER"},
+			{Id: "u2", Email: "contractor@gmail.com", Status: "ACTIVE", Type: "USER"},
+			{Id: "c1", Type: "CUSTOMER"},
+		},
+	}
+	user1 := &admin.User{Id: "u1", PrimaryEmail: "user.1@mail.com", Name: &admin.UserName{GivenName: "user", FamilyName: "1"}}
+	notFound := fmt.Errorf("%w: %s", google.ErrUserNotFound, "contractor@gmail.com")
+
+	t.Run("should skip the external members", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		mockDS := mocks.NewMockGoogleProviderService(mockCtrl)
+		mockDS.EXPECT().ListGroupMembersBatch(gomock.Any(), []string{"g1"}, gomock.Any()).Return(members, nil)
+		mockDS.EXPECT().GetUser(gomock.Any(), "user.1@mail.com").Return(user1, nil).Times(1)
+		mockDS.EXPECT().GetUser(gomock.Any(), "contractor@gmail.com").Return(nil, notFound).Times(1)
+
+		ip, _ := NewIdentityProvider(mockDS, WithExternalMembersPolicy(ExternalMembersPolicySkip, nil))
+
+		gmr, err := ip.GetGroupsMembers(context.Background(), gr)
+		assert.NoError(t, err)
+
+		want := model.GroupsMembersResultBuilder().WithResources([]*model.GroupMembers{
+			model.GroupMembersBuilder().WithGroup(g1).WithResources([]*model.Member{
+				model.MemberBuilder().WithIPID("u1").WithEmail("user.1@mail.com").WithStatus("ACTIVE").Build(),
+			}).Build(),
+		}).Build()
+		assert.Equal(t, want, gmr)
+
+		// the users were read with the members
+		ur, err := ip.GetUsersByGroupsMembers(context.Background(), gmr)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, ur.Items)
+	})
+
+	t.Run("should sync the external members of the allowed domains", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		mockDS := mocks.NewMockGoogleProviderService(mockCtrl)
+		mockDS.EXPECT().ListGroupMembersBatch(gomock.Any(), []string{"g1"}, gomock.Any()).Return(members, nil)
+		mockDS.EXPECT().GetUser(gomock.Any(), "user.1@mail.com").Return(user1, nil)
+		mockDS.EXPECT().GetUser(gomock.Any(), "contractor@gmail.com").Return(nil, notFound)
+
+		ip, _ := NewIdentityProvider(mockDS, WithExternalMembersPolicy(ExternalMembersPolicyAllow, []string{" Gmail.com "}))
+
+		gmr, err := ip.GetGroupsMembers(context.Background(), gr)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, gmr.Resources[0].Items)
+
+		ur, err := ip.GetUsersByGroupsMembers(context.Background(), gmr)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, ur.Items)
+		for _, u := range ur.Resources {
+			if u.UserName == "contractor@gmail.com" {
+				assert.Equal(t, "contractor", u.Name.GivenName)
+				assert.Equal(t, "gmail.com", u.Name.FamilyName)
+			}
+		}
+	})
+
+	t.Run("should fail when the user can't be read", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		mockDS := mocks.NewMockGoogleProviderService(mockCtrl)
+		mockDS.EXPECT().ListGroupMembersBatch(gomock.Any(), []string{"g1"}, gomock.Any()).Return(members, nil)
+		mockDS.EXPECT().GetUser(gomock.Any(), gomock.Any()).Return(nil, errors.New("test error")).AnyTimes()
+
+		ip, _ := NewIdentityProvider(mockDS, WithExternalMembersPolicy(ExternalMembersPolicySkip, nil))
+
+		gmr, err := ip.GetGroupsMembers(context.Background(), gr)
+		assert.Error(t, err)
+		assert.Nil(t, gmr)
+	})
+
+	t.Run("should fail with the external members with the fail policy", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		mockDS := mocks.NewMockGoogleProviderService(mockCtrl)
+		mockDS.EXPECT().GetUser(gomock.Any(), "contractor@gmail.com").Return(nil, notFound)
+
+		ip, _ := NewIdentityProvider(mockDS, WithExternalMembersPolicy(ExternalMembersPolicyFail, nil))
+
+		gmr := model.GroupsMembersResultBuilder().WithResources([]*model.GroupMembers{
+			model.GroupMembersBuilder().WithGroup(g1).WithResources([]*model.Member{
+				model.MemberBuilder().WithIPID("u2").WithEmail("contractor@gmail.com").WithStatus("ACTIVE").Build(),
+			}).Build(),
+		}).Build()
+
+		ur, err := ip.GetUsersByGroupsMembers(context.Background(), gmr)
+		assert.ErrorIs(t, err, google.ErrUserNotFound)
+		assert.Nil(t, ur)
+	})
+}
@@ -0,0 +1,335 @@
+package idp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	"github.com/slashdevops/idp-scim-sync/pkg/file"
+)
+
+// This implement core.IdentityProviderService interface for a declarative directory file
+
+var (
+	// ErrFileServiceNil is returned when the FileProviderService is nil.
+	ErrFileServiceNil = errors.New("provider: file service is nil")
+
+	// ErrFileGroupNotFound is returned when the group is not defined in the directory file.
+	ErrFileGroupNotFound = errors.New("provider: group not found in the directory file")
+)
+
+//go:generate go tool mockgen -package=mocks -destination=../../mocks/idp/file_mocks.go -source=file.go FileProviderService
+
+// FileProviderService is the interface that wraps the directory file source methods.
+type FileProviderService interface {
+	Load(ctx context.Context) (*file.Directory, error)
+}
+
+// FileIdentityProvider is the Identity Provider service that implements the core.IdentityProvider interface
+// and consumes the pkg.file directory documents.
+type FileIdentityProvider struct {
+	ps FileProviderService
+	providerOptions
+
+	// the directory is loaded by GetGroups, at the beginning of each sync, and reused by the other methods
+	mu  sync.Mutex
+	dir *file.Directory
+}
+
+// NewFileIdentityProvider returns a new instance of the file Identity Provider service.
+func NewFileIdentityProvider(fps FileProviderService, opts ...IdentityProviderOption) (*FileIdentityProvider, error) {
+	if fps == nil {
+		return nil, ErrFileServiceNil
+	}
+
+	ip := &FileIdentityProvider{ps: fps}
+
+	for _, opt := range opts {
+		opt(&ip.providerOptions)
+	}
+
+	return ip, nil
+}
+
+// GetGroups returns the groups of the directory file.
+//
+// The filter parameter is a list of group name patterns, e.g. AWS-*, with the path.Match syntax.
+func (f *FileIdentityProvider) GetGroups(ctx context.Context, filter []string) (*model.GroupsResult, error) {
+	dir, err := f.directory(ctx, true)
+	if err != nil {
+		return nil, fmt.Errorf("idp: error getting groups: %w", err)
+	}
+
+	groups := make([]*model.Group, 0, len(dir.Groups))
+	for _, grp := range dir.Groups {
+		ok, err := matchAny(filter, grp.Name)
+		if err != nil {
+			return nil, fmt.Errorf("idp: error getting groups: %w", err)
+		}
+		if !ok {
+			continue
+		}
+
+		groups = append(groups, model.GroupBuilder().
+			WithIPID(grp.ID).
+			WithName(grp.Name).
+			WithEmail(grp.Email).
+			Build(),
+		)
+	}
+
+	syncResult := buildGroupsResult(groups)
+	slog.Debug("idp: file GetGroups()", "groups", syncResult.Items)
+
+	return syncResult, nil
+}
+
+// GetUsers returns the users of the directory file.
+//
+// The filter parameter is a list of user email patterns, e.g. *@vendor.example, with the path.Match syntax.
+func (f *FileIdentityProvider) GetUsers(ctx context.Context, filter []string) (*model.UsersResult, error) {
+	dir, err := f.directory(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("idp: error getting users: %w", err)
+	}
+
+	syncUsers := make([]*model.User, 0, len(dir.Users))
+	for _, usr := range dir.Users {
+		ok, err := matchAny(filter, usr.Email)
+		if err != nil {
+			return nil, fmt.Errorf("idp: error getting users: %w", err)
+		}
+		if !ok {
+			continue
+		}
+
+		syncUsers = append(syncUsers, buildFileUser(usr, f.syncFieldSet))
+	}
+
+	uResult := model.UsersResultBuilder().WithResources(syncUsers).Build()
+	slog.Debug("idp: file GetUsers()", "users", len(syncUsers))
+
+	return uResult, nil
+}
+
+// GetGroupMembers returns the active members of the group.
+func (f *FileIdentityProvider) GetGroupMembers(ctx context.Context, groupID string) (*model.MembersResult, error) {
+	if groupID == "" {
+		return nil, ErrGroupIDNil
+	}
+
+	members, err := f.listGroupMembers(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("idp: error getting group members: %w", err)
+	}
+
+	return model.MembersResultBuilder().WithResources(members).Build(), nil
+}
+
+// GetGroupsMembers returns the active members of the groups.
+func (f *FileIdentityProvider) GetGroupsMembers(ctx context.Context, gr *model.GroupsResult) (*model.GroupsMembersResult, error) {
+	gmr, err := listGroupsMembers(ctx, gr, func(ctx context.Context, group *model.Group) ([]*model.Member, error) {
+		return f.listGroupMembers(ctx, group.IPID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Debug("idp: file GetGroupsMembers()", "groups", gmr.Items)
+
+	return gmr, nil
+}
+
+// GetUsersByGroupsMembers returns the users of the given groups members.
+func (f *FileIdentityProvider) GetUsersByGroupsMembers(ctx context.Context, gmr *model.GroupsMembersResult) (*model.UsersResult, error) {
+	dir, err := f.directory(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("idp: error getting users: %w", err)
+	}
+
+	ur, err := getUsersByGroupsMembers(ctx, gmr, func(_ context.Context, member *model.Member) (*model.User, error) {
+		usr := dir.User(member.IPID)
+		if usr == nil {
+			return nil, fmt.Errorf("user %s not found in the directory file", member.IPID)
+		}
+
+		return buildFileUser(usr, f.syncFieldSet), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Debug("idp: file GetUsersByGroupsMembers()", "users", ur.Items)
+
+	return ur, nil
+}
+
+// directory returns the loaded directory, it is loaded again when reload is true.
+func (f *FileIdentityProvider) directory(ctx context.Context, reload bool) (*file.Directory, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.dir != nil && !reload {
+		return f.dir, nil
+	}
+
+	dir, err := f.ps.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	f.dir = dir
+
+	return dir, nil
+}
+
+// listGroupMembers returns the active users that are members of the given group.
+func (f *FileIdentityProvider) listGroupMembers(ctx context.Context, groupID string) ([]*model.Member, error) {
+	dir, err := f.directory(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var group *file.Group
+	for _, g := range dir.Groups {
+		if g.ID == groupID {
+			group = g
+			break
+		}
+	}
+
+	if group == nil {
+		return nil, fmt.Errorf("%w: %s", ErrFileGroupNotFound, groupID)
+	}
+
+	members := make([]*model.Member, 0, len(group.Members))
+	for _, ref := range group.Members {
+		usr := dir.User(ref)
+		if usr == nil || !usr.IsActive() {
+			continue
+		}
+
+		members = append(members, model.MemberBuilder().
+			WithIPID(usr.ID).
+			WithEmail(usr.Email).
+			WithStatus("ACTIVE").
+			Build(),
+		)
+	}
+
+	return members, nil
+}
+
+// matchAny returns true when the value matches any of the patterns, or when there are no patterns.
+func matchAny(patterns []string, value string) (bool, error) {
+	if len(patterns) == 0 {
+		return true, nil
+	}
+
+	for _, p := range patterns {
+		ok, err := path.Match(p, value)
+		if err != nil {
+			return false, fmt.Errorf("invalid filter %q: %w", p, err)
+		}
+		if ok {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// buildFileUser builds a User model from a directory file user.
+// The fields parameter controls which optional user attributes are included.
+func buildFileUser(usr *file.User, fields *model.SyncFieldSet) *model.User {
+	displayName := usr.DisplayName
+	if displayName == "" {
+		displayName = fmt.Sprintf("%s %s", usr.GivenName, usr.FamilyName)
+	}
+
+	ub := model.UserBuilder().
+		WithIPID(usr.ID).
+		WithUserName(usr.UserName).
+		WithDisplayName(displayName).
+		WithActive(usr.IsActive()).
+		WithEmail(model.EmailBuilder().WithPrimary(true).WithType("work").WithValue(usr.Email).Build()).
+		WithName(model.NameBuilder().
+			WithGivenName(usr.GivenName).
+			WithFamilyName(usr.FamilyName).
+			WithMiddleName(usr.MiddleName).
+			WithFormatted(usr.DisplayName).
+			Build(),
+		)
+
+	if fields.Includes(model.SyncUserFieldTitle) {
+		ub = ub.WithTitle(usr.Title)
+	}
+	if fields.Includes(model.SyncUserFieldUserType) {
+		ub = ub.WithUserType(usr.UserType)
+	}
+	if fields.Includes(model.SyncUserFieldPreferredLanguage) {
+		ub = ub.WithPreferredLanguage(usr.PreferredLanguage)
+	}
+	if fields.Includes(model.SyncUserFieldLocale) {
+		ub = ub.WithLocale(usr.Locale)
+	}
+	if fields.Includes(model.SyncUserFieldTimezone) {
+		ub = ub.WithTimezone(usr.Timezone)
+	}
+	if fields.Includes(model.SyncUserFieldNickName) {
+		ub = ub.WithNickName(usr.NickName)
+	}
+	if fields.Includes(model.SyncUserFieldProfileURL) {
+		ub = ub.WithProfileURL(usr.ProfileURL)
+	}
+
+	if fields.Includes(model.SyncUserFieldAddresses) {
+		parts := make([]string, 0, 5)
+		for _, part := range []string{usr.StreetAddress, usr.Locality, usr.Region, usr.PostalCode, usr.Country} {
+			if part != "" {
+				parts = append(parts, part)
+			}
+		}
+
+		if len(parts) > 0 {
+			ub = ub.WithAddress(model.AddressBuilder().
+				WithFormatted(strings.Join(parts, ", ")).
+				WithStreetAddress(usr.StreetAddress).
+				WithLocality(usr.Locality).
+				WithRegion(usr.Region).
+				WithPostalCode(usr.PostalCode).
+				WithCountry(usr.Country).
+				Build(),
+			)
+		}
+	}
+
+	if fields.Includes(model.SyncUserFieldPhoneNumbers) && usr.PhoneNumber != "" {
+		ub = ub.WithPhoneNumber(model.PhoneNumberBuilder().WithValue(usr.PhoneNumber).WithType("work").Build())
+	}
+
+	if fields.Includes(model.SyncUserFieldEnterpriseData) {
+		var manager *model.Manager
+		if usr.Manager != "" {
+			manager = model.ManagerBuilder().WithValue(usr.Manager).Build()
+		}
+
+		if usr.EmployeeNumber != "" || usr.CostCenter != "" || usr.Organization != "" || usr.Division != "" || usr.Department != "" || manager != nil {
+			ub = ub.WithEnterpriseData(model.EnterpriseDataBuilder().
+				WithEmployeeNumber(usr.EmployeeNumber).
+				WithCostCenter(usr.CostCenter).
+				WithOrganization(usr.Organization).
+				WithDivision(usr.Division).
+				WithDepartment(usr.Department).
+				WithManager(manager).
+				Build(),
+			)
+		}
+	}
+
+	return ub.Build()
+}
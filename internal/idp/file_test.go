@@ -0,0 +1,205 @@
+package idp
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	mocks "github.com/slashdevops/idp-scim-sync/mocks/idp"
+	"github.com/slashdevops/idp-scim-sync/pkg/file"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+const testFileDirectory = `
+groups:
+  - name: AWS-Admins
+    email: aws-admins@example.com
+    members: [alice]
+  - id: vendors
+    name: AWS-Vendors
+users:
+  - id: alice
+    email: alice@example.com
+    givenName: Alice
+    familyName: Smith
+  - email: bob@vendor.example
+    givenName: Bob
+    familyName: Jones
+    groups: [AWS-Vendors]
+  - email: carol@vendor.example
+    givenName: Carol
+    familyName: Brown
+    active: false
+    groups: [AWS-Vendors]
+`
+
+func testFileDir(t *testing.T) *file.Directory {
+	t.Helper()
+
+	dir, err := file.Parse(strings.NewReader(testFileDirectory), file.FormatYAML)
+	assert.NoError(t, err)
+
+	return dir
+}
+
+func TestNewFileIdentityProvider(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+
+	t.Run("Should return FileIdentityProvider and no error", func(t *testing.T) {
+		svc, err := NewFileIdentityProvider(mocks.NewMockFileProviderService(mockCtrl))
+		assert.NoError(t, err)
+		assert.NotNil(t, svc)
+	})
+
+	t.Run("Should return an error if no service is provided", func(t *testing.T) {
+		svc, err := NewFileIdentityProvider(nil)
+		assert.ErrorIs(t, err, ErrFileServiceNil)
+		assert.Nil(t, svc)
+	})
+}
+
+func TestFileGetGroups(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+
+	t.Run("Should return the filtered groups", func(t *testing.T) {
+		mockSvc := mocks.NewMockFileProviderService(mockCtrl)
+		mockSvc.EXPECT().Load(gomock.Any()).Return(testFileDir(t), nil)
+
+		ip, _ := NewFileIdentityProvider(mockSvc)
+		got, err := ip.GetGroups(context.Background(), []string{"AWS-V*"})
+		assert.NoError(t, err)
+
+		want := model.GroupsResultBuilder().WithResources([]*model.Group{
+			model.GroupBuilder().WithIPID("vendors").WithName("AWS-Vendors").Build(),
+		}).Build()
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("Should return all the groups without filter", func(t *testing.T) {
+		mockSvc := mocks.NewMockFileProviderService(mockCtrl)
+		mockSvc.EXPECT().Load(gomock.Any()).Return(testFileDir(t), nil)
+
+		ip, _ := NewFileIdentityProvider(mockSvc)
+		got, err := ip.GetGroups(context.Background(), nil)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, got.Items)
+	})
+
+	t.Run("Should return error when the filter is invalid", func(t *testing.T) {
+		mockSvc := mocks.NewMockFileProviderService(mockCtrl)
+		mockSvc.EXPECT().Load(gomock.Any()).Return(testFileDir(t), nil)
+
+		ip, _ := NewFileIdentityProvider(mockSvc)
+		got, err := ip.GetGroups(context.Background(), []string{"AWS-["})
+		assert.Error(t, err)
+		assert.Nil(t, got)
+	})
+
+	t.Run("Should return error when the file can't be loaded", func(t *testing.T) {
+		mockSvc := mocks.NewMockFileProviderService(mockCtrl)
+		mockSvc.EXPECT().Load(gomock.Any()).Return(nil, errors.New("test error"))
+
+		ip, _ := NewFileIdentityProvider(mockSvc)
+		got, err := ip.GetGroups(context.Background(), nil)
+		assert.Error(t, err)
+		assert.Nil(t, got)
+	})
+}
+
+func TestFileGetUsers(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+
+	mockSvc := mocks.NewMockFileProviderService(mockCtrl)
+	mockSvc.EXPECT().Load(gomock.Any()).Return(testFileDir(t), nil).Times(1)
+
+	ip, _ := NewFileIdentityProvider(mockSvc)
+	got, err := ip.GetUsers(context.Background(), []string{"*@vendor.example"})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, got.Items)
+	assert.Equal(t, "bob@vendor.example", got.Resources[0].IPID)
+	assert.False(t, got.Resources[1].Active)
+}
+
+func TestFileGroupsMembersAndUsers(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+
+	mockSvc := mocks.NewMockFileProviderService(mockCtrl)
+	mockSvc.EXPECT().Load(gomock.Any()).Return(testFileDir(t), nil).Times(1)
+
+	ip, _ := NewFileIdentityProvider(mockSvc)
+
+	gr, err := ip.GetGroups(context.Background(), nil)
+	assert.NoError(t, err)
+
+	t.Run("Should return the active members of the groups", func(t *testing.T) {
+		gmr, err := ip.GetGroupsMembers(context.Background(), gr)
+		assert.NoError(t, err)
+
+		want := model.GroupsMembersResultBuilder().WithResources([]*model.GroupMembers{
+			model.GroupMembersBuilder().WithGroup(gr.Resources[0]).WithResources([]*model.Member{
+				model.MemberBuilder().WithIPID("alice").WithEmail("alice@example.com").WithStatus("ACTIVE").Build(),
+			}).Build(),
+			model.GroupMembersBuilder().WithGroup(gr.Resources[1]).WithResources([]*model.Member{
+				model.MemberBuilder().WithIPID("bob@vendor.example").WithEmail("bob@vendor.example").WithStatus("ACTIVE").Build(),
+			}).Build(),
+		}).Build()
+		assert.Equal(t, want, gmr)
+
+		got, err := ip.GetUsersByGroupsMembers(context.Background(), gmr)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, got.Items)
+	})
+
+	t.Run("Should return error when the group id is empty", func(t *testing.T) {
+		got, err := ip.GetGroupMembers(context.Background(), "")
+		assert.ErrorIs(t, err, ErrGroupIDNil)
+		assert.Nil(t, got)
+	})
+
+	t.Run("Should return error when the group doesn't exist", func(t *testing.T) {
+		got, err := ip.GetGroupMembers(context.Background(), "missing")
+		assert.ErrorIs(t, err, ErrFileGroupNotFound)
+		assert.Nil(t, got)
+	})
+}
+
+func TestBuildFileUser(t *testing.T) {
+	usr := &file.User{
+		ID:             "alice",
+		UserName:       "alice@example.com",
+		Email:          "alice@example.com",
+		GivenName:      "Alice",
+		FamilyName:     "Smith",
+		Title:          "Engineer",
+		PhoneNumber:    "+1 555 0100",
+		Locality:       "Madrid",
+		Country:        "ES",
+		EmployeeNumber: "42",
+		Manager:        "bob",
+	}
+
+	t.Run("Should include all the fields", func(t *testing.T) {
+		got := buildFileUser(usr, nil)
+
+		assert.Equal(t, "alice", got.IPID)
+		assert.Equal(t, "Alice Smith", got.DisplayName)
+		assert.True(t, got.Active)
+		assert.Equal(t, "Engineer", got.Title)
+		assert.Equal(t, "+1 555 0100", got.PhoneNumbers[0].Value)
+		assert.Equal(t, "Madrid, ES", got.Addresses[0].Formatted)
+		assert.Equal(t, "42", got.EnterpriseData.EmployeeNumber)
+		assert.Equal(t, "bob", got.EnterpriseData.Manager.Value)
+	})
+
+	t.Run("Should include only the given fields", func(t *testing.T) {
+		got := buildFileUser(usr, model.NewSyncFieldSet([]string{"title"}))
+
+		assert.Equal(t, "Engineer", got.Title)
+		assert.Nil(t, got.PhoneNumbers)
+		assert.Nil(t, got.Addresses)
+		assert.Nil(t, got.EnterpriseData)
+	})
+}
@@ -144,7 +144,7 @@ func buildUser(usr *admin.User, fields *model.SyncFieldSet) *model.User {
 		WithIPID(strings.TrimSpace(usr.Id)).
 		WithUserName(strings.TrimSpace(usr.PrimaryEmail)).
 		WithDisplayName(strings.TrimSpace(displayName)).
-		WithActive(!usr.Suspended).
+		WithActive(!usr.Suspended && !usr.Archived).
 		WithEmails(emails).
 		WithName(name)
 
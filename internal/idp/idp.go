@@ -5,10 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
+	"slices"
 	"sync"
 
 	"github.com/slashdevops/idp-scim-sync/internal/model"
 	"github.com/slashdevops/idp-scim-sync/pkg/google"
+	"golang.org/x/sync/errgroup"
 	admin "google.golang.org/api/admin/directory/v1"
 )
 
@@ -33,6 +36,8 @@ type GoogleProviderService interface {
 	ListGroups(ctx context.Context, query []string) ([]*admin.Group, error)
 	ListGroupMembers(ctx context.Context, groupID string, queries ...google.GetGroupMembersOption) ([]*admin.Member, error)
 	GetUser(ctx context.Context, userID string) (*admin.User, error)
+	GetGroup(ctx context.Context, groupID string) (*admin.Group, error)
+	ListOrgUnitUsers(ctx context.Context, orgUnitPath string) ([]*admin.User, error)
 
 	// Batch operations for performance optimization
 	ListGroupMembersBatch(ctx context.Context, groupIDs []string, queries ...google.GetGroupMembersOption) (map[string][]*admin.Member, error)
@@ -40,18 +45,70 @@ type GoogleProviderService interface {
 
 // IdentityProvider is the Identity Provider service that implements the core.IdentityProvider interface and consumes the pkg.google methods.
 type IdentityProvider struct {
-	ps           GoogleProviderService
-	syncFieldSet *model.SyncFieldSet
+	ps GoogleProviderService
+	providerOptions
+
+	// the org unit groups members are read as full users, they are kept by email to avoid reading them again,
+	// with the mirror nesting policy the direct members of the groups are kept by group id for the same reason,
+	// the names of the groups synced before are kept by group id to disambiguate the duplicate group names
+	mu               sync.Mutex
+	users            map[string]*admin.User
+	directMembers    map[string][]*admin.Member
+	syncedGroupNames map[string]string
 }
 
-// IdentityProviderOption is a function that configures an IdentityProvider.
-type IdentityProviderOption func(*IdentityProvider)
+// providerOptions are the settings shared by all the identity providers.
+type providerOptions struct {
+	syncFieldSet  *model.SyncFieldSet
+	usersDelta    bool
+	orgUnitGroups []OrgUnitGroup
+	nestingPolicy NestingPolicy
+
+	suspendedUsersPolicy SuspendedUsersPolicy
+
+	roleProjections []RoleProjection
+
+	externalMembersPolicy ExternalMembersPolicy
+	externalDomains       []string
+
+	duplicateGroupNames DuplicateGroupNamesStrategy
+
+	customAttributes []CustomAttribute
+
+	bulkUsersThreshold int
+
+	cache *google.Cache
+
+	concurrency int
+}
+
+// IdentityProviderOption is a function that configures an identity provider.
+type IdentityProviderOption func(*providerOptions)
+
+// WithConcurrency sets the maximum number of concurrent requests of the Google Workspace identity
+// provider, e.g. reading the users of the members, maxConcurrentRequests by default. It should be
+// the Concurrency of the rate limiter of the Google client.
+func WithConcurrency(concurrency int) IdentityProviderOption {
+	return func(po *providerOptions) {
+		po.concurrency = concurrency
+	}
+}
 
 // WithSyncFieldSet configures which optional user fields are included in the sync.
 // When the field set is nil or empty, all fields are synced (default behavior).
 func WithSyncFieldSet(fields *model.SyncFieldSet) IdentityProviderOption {
-	return func(ip *IdentityProvider) {
-		ip.syncFieldSet = fields
+	return func(po *providerOptions) {
+		po.syncFieldSet = fields
+	}
+}
+
+// WithUsersDelta configures the providers supporting delta queries (Microsoft Entra ID) to resolve
+// the users from an index maintained with delta queries instead of reading them one by one.
+// The first sync reads all the users of the directory, the index and the delta link are kept
+// next to the state, when the repository stores a cache, so the following syncs only read the changes.
+func WithUsersDelta(enabled bool) IdentityProviderOption {
+	return func(po *providerOptions) {
+		po.usersDelta = enabled
 	}
 }
 
@@ -66,22 +123,58 @@ func NewIdentityProvider(gps GoogleProviderService, opts ...IdentityProviderOpti
 	}
 
 	for _, opt := range opts {
-		opt(ip)
+		opt(&ip.providerOptions)
 	}
 
 	return ip, nil
 }
 
+// concurrentRequests returns the maximum number of concurrent requests to Google Workspace.
+func (i *IdentityProvider) concurrentRequests() int {
+	if i.concurrency > 0 {
+		return i.concurrency
+	}
+
+	return maxConcurrentRequests
+}
+
 // GetGroups returns a list of groups from the Identity Provider API.
 //
 // The filter parameter is a list of strings that can be used to filter the groups
 // according to the Identity Provider API.
 //
-// This method checks the names of the groups and avoid the second, third, etc repetition of the same group name.
+// This method checks the names of the groups, the groups with the same name are synced according to
+// the duplicate group names strategy, by default the second, third, etc repetition of the same group name is avoided.
+//
+// The org unit groups are returned after the Google groups, the Google groups are not read when
+// the org unit groups are configured without filter.
+//
+// With the mirror nesting policy the groups nested in the groups, at any depth, are returned too.
 func (i *IdentityProvider) GetGroups(ctx context.Context, filter []string) (*model.GroupsResult, error) {
-	pGroups, err := i.ps.ListGroups(ctx, filter)
-	if err != nil {
-		return nil, fmt.Errorf("idp: error getting groups: %w", err)
+	// every sync starts reading the groups, the data kept from the previous sync is discarded
+	i.mu.Lock()
+	i.users, i.directMembers = nil, nil
+	i.mu.Unlock()
+
+	// with org unit groups the Google groups are read only when they are filtered
+	var pGroups []*admin.Group
+	if hasFilter(filter) || len(i.orgUnitGroups) == 0 {
+		var err error
+		if pGroups, err = i.ps.ListGroups(ctx, filter); err != nil {
+			return nil, fmt.Errorf("idp: error getting groups: %w", err)
+		}
+		pGroups = i.projectGroups(pGroups)
+	}
+
+	for _, oug := range i.orgUnitGroups {
+		pGroups = append(pGroups, &admin.Group{Id: oug.groupID(), Name: oug.groupName()})
+	}
+
+	if i.nestingPolicy == NestingPolicyMirror {
+		var err error
+		if pGroups, err = i.mirrorNestedGroups(ctx, pGroups); err != nil {
+			return nil, err
+		}
 	}
 
 	if len(pGroups) == 0 {
@@ -90,27 +183,9 @@ func (i *IdentityProvider) GetGroups(ctx context.Context, filter []string) (*mod
 		return gResult, nil
 	}
 
-	uniqueGroups := make(map[string]struct{}, len(pGroups))
-	syncGroups := make([]*model.Group, 0, len(pGroups))
-	for _, grp := range pGroups {
-		// this is a hack to avoid the second, third, etc repetition of the same group name
-		if _, ok := uniqueGroups[grp.Name]; !ok {
-			uniqueGroups[grp.Name] = struct{}{}
-
-			gg := model.GroupBuilder().
-				WithIPID(grp.Id).
-				WithName(grp.Name).
-				WithEmail(grp.Email).
-				Build()
-
-			syncGroups = append(syncGroups, gg)
-		} else {
-			slog.Warn("idp: group already exists with the same name, this group will be avoided, please make your groups uniques by name!",
-				"id", grp.Id,
-				"name", grp.Name,
-				"email", grp.Email,
-			)
-		}
+	syncGroups, err := i.uniqueGroups(pGroups)
+	if err != nil {
+		return nil, err
 	}
 
 	syncResult := model.GroupsResultBuilder().WithResources(syncGroups).Build()
@@ -137,7 +212,7 @@ func (i *IdentityProvider) GetUsers(ctx context.Context, filter []string) (*mode
 
 	syncUsers := make([]*model.User, len(pUsers))
 	for idx, usr := range pUsers {
-		gu := buildUser(usr, i.syncFieldSet)
+		gu := i.withCustomAttributes(buildUser(usr, i.syncFieldSet), usr)
 		syncUsers[idx] = gu
 	}
 	uResult := model.UsersResultBuilder().WithResources(syncUsers).Build()
@@ -152,9 +227,37 @@ func (i *IdentityProvider) GetGroupMembers(ctx context.Context, groupID string)
 		return nil, ErrGroupIDNil
 	}
 
-	pMembers, err := i.ps.ListGroupMembers(ctx, groupID, google.WithIncludeDerivedMembership(true))
-	if err != nil {
-		return nil, fmt.Errorf("idp: error getting group members: %w", err)
+	if oug, ok := i.orgUnitGroup(groupID); ok {
+		syncMembers, err := i.listOrgUnitMembers(ctx, oug)
+		if err != nil {
+			return nil, fmt.Errorf("idp: error getting group members: %w", err)
+		}
+
+		return model.MembersResultBuilder().WithResources(syncMembers).Build(), nil
+	}
+
+	_, _, projected := roleProjection(groupID)
+	if !projected && i.nestingPolicy == NestingPolicyMirror {
+		directMembers, err := i.listNestedMembers(ctx, []string{groupID})
+		if err != nil {
+			return nil, fmt.Errorf("idp: error getting group members: %w", err)
+		}
+
+		return model.MembersResultBuilder().WithResources(expandMembers(groupID, directMembers, nil)).Build(), nil
+	}
+
+	var pMembers []*admin.Member
+	if projected {
+		projectedMembers, err := i.listRoleProjectionsMembers(ctx, []string{groupID})
+		if err != nil {
+			return nil, fmt.Errorf("idp: error getting group members: %w", err)
+		}
+		pMembers = projectedMembers[groupID]
+	} else {
+		var err error
+		if pMembers, err = i.ps.ListGroupMembers(ctx, groupID, i.membersOptions()...); err != nil {
+			return nil, fmt.Errorf("idp: error getting group members: %w", err)
+		}
 	}
 
 	if len(pMembers) == 0 {
@@ -165,12 +268,10 @@ func (i *IdentityProvider) GetGroupMembers(ctx context.Context, groupID string)
 
 	syncMembers := make([]*model.Member, 0, len(pMembers))
 	for _, member := range pMembers {
-		// avoid nested groups, but members are included thanks to the google.WithIncludeDerivedMembership option above
+		// avoid nested groups, with the flatten nesting policy their members are included thanks to the
+		// google.WithIncludeDerivedMembership option above
 		if member.Type == "GROUP" {
-			slog.Warn("skipping member because is a group, but group members will be included",
-				"id", member.Id,
-				"email", member.Email,
-			)
+			i.skipGroupMember(member)
 			continue
 		}
 
@@ -211,40 +312,47 @@ func (i *IdentityProvider) GetUsersByGroupsMembers(ctx context.Context, gmr *mod
 		}
 	}
 
-	// Fetch users concurrently
-	const maxConcurrent = 10
-	sem := make(chan struct{}, maxConcurrent)
+	// List all the users at once when there are too many members to read them one by one
+	if err := i.bulkLoadUsers(ctx, slices.Collect(maps.Keys(uniqEmails))); err != nil {
+		return nil, err
+	}
+
+	// Fetch users concurrently, the requests are rate limited by the Google client,
+	// the first error cancels the other requests
 	var mu sync.Mutex
-	var wg sync.WaitGroup
 
 	pUsers := make([]*model.User, 0, len(uniqEmails))
-	errChan := make(chan error, len(uniqEmails))
-
-	for email, ipid := range uniqEmails {
-		wg.Add(1)
-		go func(email, ipid string) {
-			defer wg.Done()
 
-			sem <- struct{}{}        // Acquire semaphore
-			defer func() { <-sem }() // Release semaphore
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(i.concurrentRequests())
 
-			u, err := i.ps.GetUser(ctx, email)
-			if err != nil {
-				errChan <- fmt.Errorf("idp: error getting user: %+v, email: %s, error: %w", ipid, email, err)
-				return
+	for email, ipid := range uniqEmails {
+		g.Go(func() error {
+			u, ok := i.cachedUser(email)
+			if !ok {
+				var err error
+				if u, err = i.ps.GetUser(gctx, email); err != nil {
+					if !errors.Is(err, google.ErrUserNotFound) || !i.skipExternalMembers() {
+						return fmt.Errorf("idp: error getting user: %+v, email: %s, error: %w", ipid, email, err)
+					}
+
+					if u, ok = i.externalUser(ipid, email); !ok {
+						slog.Warn("idp: skipping external member, it is not a user of the directory", "member", email)
+						return nil
+					}
+				}
 			}
-			gu := buildUser(u, i.syncFieldSet)
+			gu := i.withCustomAttributes(buildUser(u, i.syncFieldSet), u)
 
 			mu.Lock()
 			pUsers = append(pUsers, gu)
 			mu.Unlock()
-		}(email, ipid)
-	}
 
-	wg.Wait()
-	close(errChan)
+			return nil
+		})
+	}
 
-	if err := <-errChan; err != nil {
+	if err := g.Wait(); err != nil {
 		return nil, err
 	}
 
@@ -272,18 +380,64 @@ func (i *IdentityProvider) GetGroupsMembers(ctx context.Context, gr *model.Group
 		return groupsMembersResult, nil
 	}
 
-	// Collect all group IDs for batch operation
-	groupIDs := make([]string, l)
+	// Collect all group IDs for batch operation, the org unit groups members are read from the org units
+	// and the role projections members by role
+	groupIDs := make([]string, 0, l)
+	projectedIDs := make([]string, 0)
 	groupsByID := make(map[string]*model.Group, l)
-	for i, group := range gr.Resources {
-		groupIDs[i] = group.IPID
+	membersMap := make(map[string][]*admin.Member)
+	for _, group := range gr.Resources {
 		groupsByID[group.IPID] = group
+
+		if oug, ok := i.orgUnitGroup(group.IPID); ok {
+			users, err := i.listOrgUnitUsers(ctx, oug)
+			if err != nil {
+				return nil, fmt.Errorf("idp: error getting org unit group members: %w", err)
+			}
+			membersMap[group.IPID] = orgUnitMembers(users, i.includeInactiveMembers())
+			continue
+		}
+
+		if _, _, ok := roleProjection(group.IPID); ok {
+			projectedIDs = append(projectedIDs, group.IPID)
+			continue
+		}
+
+		groupIDs = append(groupIDs, group.IPID)
 	}
 
-	// Use batch operation to get all group members at once
-	membersMap, err := i.ps.ListGroupMembersBatch(ctx, groupIDs, google.WithIncludeDerivedMembership(true))
-	if err != nil {
-		return nil, fmt.Errorf("idp: error getting group members batch: %w", err)
+	if len(projectedIDs) > 0 {
+		projected, err := i.listRoleProjectionsMembers(ctx, projectedIDs)
+		if err != nil {
+			return nil, err
+		}
+		maps.Copy(membersMap, projected)
+	}
+
+	// Use batch operation to get all group members at once, with the mirror nesting policy
+	// the members of the nested groups are expanded here
+	expandedMap := make(map[string][]*model.Member)
+	if len(groupIDs) > 0 && i.nestingPolicy == NestingPolicyMirror {
+		directMembers, err := i.listNestedMembers(ctx, groupIDs)
+		if err != nil {
+			return nil, fmt.Errorf("idp: error getting group members batch: %w", err)
+		}
+
+		groupNames := make(map[string]string, l)
+		for _, group := range gr.Resources {
+			groupNames[group.IPID] = group.Name
+		}
+
+		for _, id := range groupIDs {
+			membersMap[id] = nil
+			expandedMap[id] = expandMembers(id, directMembers, groupNames)
+		}
+	} else if len(groupIDs) > 0 {
+		batch, err := i.ps.ListGroupMembersBatch(ctx, groupIDs, i.membersOptions()...)
+		if err != nil {
+			return nil, fmt.Errorf("idp: error getting group members batch: %w", err)
+		}
+		maps.Copy(membersMap, batch)
 	}
 
 	// Process the results
@@ -291,14 +445,16 @@ func (i *IdentityProvider) GetGroupsMembers(ctx context.Context, gr *model.Group
 	for groupID, pMembers := range membersMap {
 		group := groupsByID[groupID]
 
-		syncMembers := make([]*model.Member, 0, len(pMembers))
+		syncMembers, ok := expandedMap[groupID]
+		if !ok {
+			syncMembers = make([]*model.Member, 0, len(pMembers))
+		}
+
 		for _, member := range pMembers {
-			// avoid nested groups, but members are included thanks to the google.WithIncludeDerivedMembership option above
+			// avoid nested groups, with the flatten nesting policy their members are included thanks to the
+			// google.WithIncludeDerivedMembership option above
 			if member.Type == "GROUP" {
-				slog.Warn("skipping member because is a group, but group members will be included",
-					"id", member.Id,
-					"email", member.Email,
-				)
+				i.skipGroupMember(member)
 				continue
 			}
 
@@ -325,6 +481,13 @@ func (i *IdentityProvider) GetGroupsMembers(ctx context.Context, gr *model.Group
 		groupMembers = append(groupMembers, groupMember)
 	}
 
+	if i.skipExternalMembers() {
+		var err error
+		if groupMembers, err = i.removeExternalMembers(ctx, groupMembers); err != nil {
+			return nil, err
+		}
+	}
+
 	groupsMembersResult := &model.GroupsMembersResult{
 		Items:     len(groupMembers),
 		Resources: groupMembers,
@@ -0,0 +1,293 @@
+package idp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	"github.com/slashdevops/idp-scim-sync/pkg/ldap"
+)
+
+// This implement core.IdentityProviderService interface for LDAP and Active Directory
+
+// ErrLDAPServiceNil is returned when the LDAPProviderService is nil.
+var ErrLDAPServiceNil = errors.New("provider: ldap service is nil")
+
+//go:generate go tool mockgen -package=mocks -destination=../../mocks/idp/ldap_mocks.go -source=ldap.go LDAPProviderService
+
+// LDAPProviderService is the interface that wraps the LDAP client methods.
+type LDAPProviderService interface {
+	ListGroups(ctx context.Context, filter []string) ([]*ldap.Group, error)
+	ListUsers(ctx context.Context, filter []string) ([]*ldap.User, error)
+	ListGroupMembers(ctx context.Context, groupDN string) ([]*ldap.User, error)
+	GetUser(ctx context.Context, userDN string) (*ldap.User, error)
+}
+
+// LDAPIdentityProvider is the Identity Provider service that implements the core.IdentityProvider interface
+// and consumes the pkg.ldap methods.
+//
+// The entries distinguished names are used as identity provider ids of the groups and users.
+type LDAPIdentityProvider struct {
+	ps LDAPProviderService
+	providerOptions
+
+	// the group members searches return the full users, they are kept to avoid reading them again
+	mu    sync.Mutex
+	users map[string]*ldap.User
+}
+
+// NewLDAPIdentityProvider returns a new instance of the LDAP Identity Provider service.
+func NewLDAPIdentityProvider(lps LDAPProviderService, opts ...IdentityProviderOption) (*LDAPIdentityProvider, error) {
+	if lps == nil {
+		return nil, ErrLDAPServiceNil
+	}
+
+	ip := &LDAPIdentityProvider{
+		ps:    lps,
+		users: make(map[string]*ldap.User),
+	}
+
+	for _, opt := range opts {
+		opt(&ip.providerOptions)
+	}
+
+	return ip, nil
+}
+
+// GetGroups returns a list of groups from the LDAP directory.
+//
+// The filter parameter is a list of LDAP filters, e.g. (cn=AWS-*), or group distinguished names.
+func (l *LDAPIdentityProvider) GetGroups(ctx context.Context, filter []string) (*model.GroupsResult, error) {
+	lGroups, err := l.ps.ListGroups(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("idp: error getting groups: %w", err)
+	}
+
+	groups := make([]*model.Group, 0, len(lGroups))
+	for _, grp := range lGroups {
+		groups = append(groups, model.GroupBuilder().
+			WithIPID(grp.DN).
+			WithName(grp.Name).
+			WithEmail(grp.Email).
+			Build(),
+		)
+	}
+
+	syncResult := buildGroupsResult(groups)
+	slog.Debug("idp: ldap GetGroups()", "groups", syncResult.Items)
+
+	return syncResult, nil
+}
+
+// GetUsers returns a list of users from the LDAP directory.
+//
+// The filter parameter is a list of LDAP filters, e.g. (department=Engineering), or user distinguished names.
+func (l *LDAPIdentityProvider) GetUsers(ctx context.Context, filter []string) (*model.UsersResult, error) {
+	lUsers, err := l.ps.ListUsers(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("idp: error getting users: %w", err)
+	}
+
+	syncUsers := make([]*model.User, 0, len(lUsers))
+	for _, usr := range lUsers {
+		if u := buildLDAPUser(usr, l.syncFieldSet); u != nil {
+			syncUsers = append(syncUsers, u)
+		}
+	}
+
+	uResult := model.UsersResultBuilder().WithResources(syncUsers).Build()
+	slog.Debug("idp: ldap GetUsers()", "users", len(syncUsers))
+
+	return uResult, nil
+}
+
+// GetGroupMembers returns the enabled members of the group, including the members of its nested groups.
+func (l *LDAPIdentityProvider) GetGroupMembers(ctx context.Context, groupID string) (*model.MembersResult, error) {
+	if groupID == "" {
+		return nil, ErrGroupIDNil
+	}
+
+	members, err := l.listGroupMembers(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("idp: error getting group members: %w", err)
+	}
+
+	return model.MembersResultBuilder().WithResources(members).Build(), nil
+}
+
+// GetGroupsMembers returns the enabled members of the groups, including the members of their nested groups.
+func (l *LDAPIdentityProvider) GetGroupsMembers(ctx context.Context, gr *model.GroupsResult) (*model.GroupsMembersResult, error) {
+	gmr, err := listGroupsMembers(ctx, gr, func(ctx context.Context, group *model.Group) ([]*model.Member, error) {
+		return l.listGroupMembers(ctx, group.IPID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Debug("idp: ldap GetGroupsMembers()", "groups", gmr.Items)
+
+	return gmr, nil
+}
+
+// GetUsersByGroupsMembers returns the users of the given groups members.
+// The users already returned by the group members searches are not read again.
+func (l *LDAPIdentityProvider) GetUsersByGroupsMembers(ctx context.Context, gmr *model.GroupsMembersResult) (*model.UsersResult, error) {
+	ur, err := getUsersByGroupsMembers(ctx, gmr, func(ctx context.Context, member *model.Member) (*model.User, error) {
+		l.mu.Lock()
+		usr, ok := l.users[strings.ToLower(member.IPID)]
+		l.mu.Unlock()
+
+		if !ok {
+			var err error
+			if usr, err = l.ps.GetUser(ctx, member.IPID); err != nil {
+				return nil, err
+			}
+		}
+
+		return buildLDAPUser(usr, l.syncFieldSet), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Debug("idp: ldap GetUsersByGroupsMembers()", "users", ur.Items)
+
+	return ur, nil
+}
+
+// listGroupMembers returns the enabled users that are members of the given group.
+func (l *LDAPIdentityProvider) listGroupMembers(ctx context.Context, groupDN string) ([]*model.Member, error) {
+	lMembers, err := l.ps.ListGroupMembers(ctx, groupDN)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]*model.Member, 0, len(lMembers))
+	for _, m := range lMembers {
+		l.mu.Lock()
+		l.users[strings.ToLower(m.DN)] = m
+		l.mu.Unlock()
+
+		members = append(members, model.MemberBuilder().
+			WithIPID(m.DN).
+			WithEmail(strings.TrimSpace(m.Email)).
+			WithStatus("ACTIVE").
+			Build(),
+		)
+	}
+
+	return members, nil
+}
+
+// buildLDAPUser builds a User model from an LDAP user.
+// The fields parameter controls which optional user attributes are included.
+func buildLDAPUser(usr *ldap.User, fields *model.SyncFieldSet) *model.User {
+	if usr == nil {
+		return nil
+	}
+
+	// these fields are required because the Constrains defined here:
+	// https://docs.aws.amazon.com/singlesignon/latest/developerguide/createuser.html
+	if usr.GivenName == "" {
+		slog.Warn("idp: User given name is empty", "dn", usr.DN)
+		return nil
+	}
+
+	if usr.FamilyName == "" {
+		slog.Warn("idp: User family name is empty", "dn", usr.DN)
+		return nil
+	}
+
+	if usr.Email == "" {
+		slog.Warn("idp: User email is empty", "dn", usr.DN)
+		return nil
+	}
+
+	displayName := usr.DisplayName
+	if displayName == "" {
+		displayName = fmt.Sprintf("%s %s", usr.GivenName, usr.FamilyName)
+	}
+
+	name := model.NameBuilder().
+		WithGivenName(usr.GivenName).
+		WithFamilyName(usr.FamilyName).
+		WithMiddleName(usr.MiddleName).
+		WithFormatted(usr.DisplayName).
+		Build()
+
+	ub := model.UserBuilder().
+		WithIPID(usr.DN).
+		WithUserName(usr.UserName).
+		WithDisplayName(displayName).
+		WithActive(!usr.Disabled).
+		WithEmail(model.EmailBuilder().WithPrimary(true).WithType("work").WithValue(usr.Email).Build()).
+		WithName(name)
+
+	if fields.Includes(model.SyncUserFieldTitle) {
+		ub = ub.WithTitle(usr.Title)
+	}
+	if fields.Includes(model.SyncUserFieldUserType) {
+		ub = ub.WithUserType(usr.UserType)
+	}
+	if fields.Includes(model.SyncUserFieldPreferredLanguage) {
+		ub = ub.WithPreferredLanguage(usr.PreferredLanguage)
+	}
+
+	if fields.Includes(model.SyncUserFieldAddresses) {
+		parts := make([]string, 0, 5)
+		for _, part := range []string{usr.StreetAddress, usr.Locality, usr.Region, usr.PostalCode, usr.Country} {
+			if part != "" {
+				parts = append(parts, part)
+			}
+		}
+
+		formatted := usr.PostalAddress
+		if formatted == "" {
+			formatted = strings.Join(parts, ", ")
+		}
+
+		if formatted != "" {
+			ub = ub.WithAddress(model.AddressBuilder().
+				WithFormatted(formatted).
+				WithStreetAddress(usr.StreetAddress).
+				WithLocality(usr.Locality).
+				WithRegion(usr.Region).
+				WithPostalCode(usr.PostalCode).
+				WithCountry(usr.Country).
+				Build(),
+			)
+		}
+	}
+
+	if fields.Includes(model.SyncUserFieldPhoneNumbers) {
+		switch {
+		case usr.TelephoneNumber != "":
+			ub = ub.WithPhoneNumber(model.PhoneNumberBuilder().WithValue(usr.TelephoneNumber).WithType("work").Build())
+		case usr.Mobile != "":
+			ub = ub.WithPhoneNumber(model.PhoneNumberBuilder().WithValue(usr.Mobile).WithType("mobile").Build())
+		}
+	}
+
+	if fields.Includes(model.SyncUserFieldEnterpriseData) {
+		var manager *model.Manager
+		if usr.ManagerDN != "" {
+			manager = model.ManagerBuilder().WithValue(usr.ManagerDN).Build()
+		}
+
+		if usr.EmployeeNumber != "" || usr.Department != "" || usr.Organization != "" || manager != nil {
+			ub = ub.WithEnterpriseData(model.EnterpriseDataBuilder().
+				WithEmployeeNumber(usr.EmployeeNumber).
+				WithDepartment(usr.Department).
+				WithOrganization(usr.Organization).
+				WithManager(manager).
+				Build(),
+			)
+		}
+	}
+
+	return ub.Build()
+}
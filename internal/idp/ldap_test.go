@@ -0,0 +1,180 @@
+package idp
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	mocks "github.com/slashdevops/idp-scim-sync/mocks/idp"
+	"github.com/slashdevops/idp-scim-sync/pkg/ldap"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestNewLDAPIdentityProvider(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+
+	t.Run("Should return LDAPIdentityProvider and no error", func(t *testing.T) {
+		svc, err := NewLDAPIdentityProvider(mocks.NewMockLDAPProviderService(mockCtrl))
+		assert.NoError(t, err)
+		assert.NotNil(t, svc)
+	})
+
+	t.Run("Should return an error if no service is provided", func(t *testing.T) {
+		svc, err := NewLDAPIdentityProvider(nil)
+		assert.ErrorIs(t, err, ErrLDAPServiceNil)
+		assert.Nil(t, svc)
+	})
+}
+
+func TestLDAPGetGroups(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+
+	t.Run("Should return the groups identified by dn", func(t *testing.T) {
+		mockSvc := mocks.NewMockLDAPProviderService(mockCtrl)
+		mockSvc.EXPECT().ListGroups(gomock.Any(), []string{"(cn=AWS-*)"}).Return([]*ldap.Group{
+			{DN: "cn=AWS-1,ou=groups,dc=example,dc=com", Name: "AWS-1", Email: "aws-1@example.com"},
+			{DN: "cn=AWS-2,ou=groups,dc=example,dc=com", Name: "AWS-2"},
+		}, nil)
+
+		ip, _ := NewLDAPIdentityProvider(mockSvc)
+		got, err := ip.GetGroups(context.Background(), []string{"(cn=AWS-*)"})
+		assert.NoError(t, err)
+
+		want := model.GroupsResultBuilder().WithResources([]*model.Group{
+			model.GroupBuilder().WithIPID("cn=AWS-1,ou=groups,dc=example,dc=com").WithName("AWS-1").WithEmail("aws-1@example.com").Build(),
+			model.GroupBuilder().WithIPID("cn=AWS-2,ou=groups,dc=example,dc=com").WithName("AWS-2").Build(),
+		}).Build()
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("Should return error", func(t *testing.T) {
+		mockSvc := mocks.NewMockLDAPProviderService(mockCtrl)
+		mockSvc.EXPECT().ListGroups(gomock.Any(), nil).Return(nil, errors.New("test error"))
+
+		ip, _ := NewLDAPIdentityProvider(mockSvc)
+		got, err := ip.GetGroups(context.Background(), nil)
+		assert.Error(t, err)
+		assert.Nil(t, got)
+	})
+}
+
+func TestLDAPGroupsMembersAndUsers(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+
+	alice := &ldap.User{DN: "uid=alice,ou=users,dc=example,dc=com", UserName: "alice", Email: "alice@example.com", GivenName: "Alice", FamilyName: "Smith"}
+	bob := &ldap.User{DN: "uid=bob,ou=users,dc=example,dc=com", UserName: "bob", Email: "bob@example.com", GivenName: "Bob", FamilyName: "Jones"}
+
+	g1 := model.GroupBuilder().WithIPID("cn=AWS-1,ou=groups,dc=example,dc=com").WithName("AWS-1").Build()
+	gr := model.GroupsResultBuilder().WithResources([]*model.Group{g1}).Build()
+
+	t.Run("Should not read again the users returned as group members", func(t *testing.T) {
+		mockSvc := mocks.NewMockLDAPProviderService(mockCtrl)
+		mockSvc.EXPECT().ListGroupMembers(gomock.Any(), g1.IPID).Return([]*ldap.User{alice, bob}, nil)
+
+		ip, _ := NewLDAPIdentityProvider(mockSvc)
+		gmr, err := ip.GetGroupsMembers(context.Background(), gr)
+		assert.NoError(t, err)
+
+		want := model.GroupsMembersResultBuilder().WithResources([]*model.GroupMembers{
+			model.GroupMembersBuilder().WithGroup(g1).WithResources([]*model.Member{
+				model.MemberBuilder().WithIPID(alice.DN).WithEmail("alice@example.com").WithStatus("ACTIVE").Build(),
+				model.MemberBuilder().WithIPID(bob.DN).WithEmail("bob@example.com").WithStatus("ACTIVE").Build(),
+			}).Build(),
+		}).Build()
+		assert.Equal(t, want, gmr)
+
+		got, err := ip.GetUsersByGroupsMembers(context.Background(), gmr)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []*model.User{buildLDAPUser(alice, nil), buildLDAPUser(bob, nil)}, got.Resources)
+	})
+
+	t.Run("Should get the users not returned as group members", func(t *testing.T) {
+		mockSvc := mocks.NewMockLDAPProviderService(mockCtrl)
+		mockSvc.EXPECT().GetUser(gomock.Any(), alice.DN).Return(alice, nil)
+
+		ip, _ := NewLDAPIdentityProvider(mockSvc)
+		gmr := model.GroupsMembersResultBuilder().WithResources([]*model.GroupMembers{
+			model.GroupMembersBuilder().WithGroup(g1).WithResource(model.MemberBuilder().WithIPID(alice.DN).WithEmail("alice@example.com").Build()).Build(),
+		}).Build()
+
+		got, err := ip.GetUsersByGroupsMembers(context.Background(), gmr)
+		assert.NoError(t, err)
+		assert.Equal(t, []*model.User{buildLDAPUser(alice, nil)}, got.Resources)
+	})
+
+	t.Run("Should return error", func(t *testing.T) {
+		mockSvc := mocks.NewMockLDAPProviderService(mockCtrl)
+		mockSvc.EXPECT().ListGroupMembers(gomock.Any(), gomock.Any()).Return(nil, errors.New("test error"))
+
+		ip, _ := NewLDAPIdentityProvider(mockSvc)
+		got, err := ip.GetGroupsMembers(context.Background(), gr)
+		assert.Error(t, err)
+		assert.Nil(t, got)
+	})
+}
+
+func TestBuildLDAPUser(t *testing.T) {
+	usr := &ldap.User{
+		DN:              "uid=alice,ou=users,dc=example,dc=com",
+		UserName:        "alice",
+		Email:           "alice@example.com",
+		GivenName:       "Alice",
+		FamilyName:      "Smith",
+		DisplayName:     "Alice Smith",
+		Title:           "Engineer",
+		UserType:        "Contractor",
+		TelephoneNumber: "+1 555 0101",
+		Locality:        "Springfield",
+		Country:         "US",
+		EmployeeNumber:  "42",
+		Department:      "R&D",
+		ManagerDN:       "uid=bob,ou=users,dc=example,dc=com",
+	}
+
+	t.Run("Should map all the fields", func(t *testing.T) {
+		got := buildLDAPUser(usr, nil)
+
+		want := model.UserBuilder().
+			WithIPID("uid=alice,ou=users,dc=example,dc=com").
+			WithUserName("alice").
+			WithDisplayName("Alice Smith").
+			WithActive(true).
+			WithEmail(model.EmailBuilder().WithPrimary(true).WithType("work").WithValue("alice@example.com").Build()).
+			WithName(model.NameBuilder().WithGivenName("Alice").WithFamilyName("Smith").WithFormatted("Alice Smith").Build()).
+			WithTitle("Engineer").
+			WithUserType("Contractor").
+			WithAddress(model.AddressBuilder().WithFormatted("Springfield, US").WithLocality("Springfield").WithCountry("US").Build()).
+			WithPhoneNumber(model.PhoneNumberBuilder().WithValue("+1 555 0101").WithType("work").Build()).
+			WithEnterpriseData(model.EnterpriseDataBuilder().
+				WithEmployeeNumber("42").
+				WithDepartment("R&D").
+				WithManager(model.ManagerBuilder().WithValue("uid=bob,ou=users,dc=example,dc=com").Build()).
+				Build()).
+			Build()
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("Should map disabled users as inactive", func(t *testing.T) {
+		disabled := *usr
+		disabled.Disabled = true
+		assert.False(t, buildLDAPUser(&disabled, nil).Active)
+	})
+
+	t.Run("Should only map the configured fields", func(t *testing.T) {
+		got := buildLDAPUser(usr, model.NewSyncFieldSet([]string{"title"}))
+		assert.Equal(t, "Engineer", got.Title)
+		assert.Empty(t, got.UserType)
+		assert.Nil(t, got.Addresses)
+		assert.Nil(t, got.PhoneNumbers)
+		assert.Nil(t, got.EnterpriseData)
+	})
+
+	t.Run("Should return nil when required fields are missing", func(t *testing.T) {
+		assert.Nil(t, buildLDAPUser(nil, nil))
+		assert.Nil(t, buildLDAPUser(&ldap.User{DN: "uid=a", Email: "a@example.com", FamilyName: "A"}, nil))
+		assert.Nil(t, buildLDAPUser(&ldap.User{DN: "uid=a", Email: "a@example.com", GivenName: "A"}, nil))
+		assert.Nil(t, buildLDAPUser(&ldap.User{DN: "uid=a", GivenName: "A", FamilyName: "A"}, nil))
+	})
+}
@@ -0,0 +1,225 @@
+package idp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	"github.com/slashdevops/idp-scim-sync/pkg/google"
+	admin "google.golang.org/api/admin/directory/v1"
+)
+
+// NestingPolicy defines how the nested groups of the Google Workspace groups are synced.
+type NestingPolicy string
+
+const (
+	// NestingPolicyFlatten includes the members of the nested groups as members of the group.
+	NestingPolicyFlatten NestingPolicy = "flatten"
+
+	// NestingPolicyDirectOnly includes only the direct members of the group.
+	NestingPolicyDirectOnly NestingPolicy = "direct-only"
+
+	// NestingPolicyMirror syncs the nested groups as groups too and includes the members of the
+	// nested groups as members of the group recording the nested groups they are inherited from.
+	NestingPolicyMirror NestingPolicy = "mirror"
+)
+
+// ErrInvalidNestingPolicy is returned when the nesting policy is not supported.
+var ErrInvalidNestingPolicy = errors.New("provider: invalid nesting policy")
+
+// ParseNestingPolicy returns the nesting policy of the given name, flatten when empty.
+func ParseNestingPolicy(name string) (NestingPolicy, error) {
+	switch p := NestingPolicy(name); p {
+	case "":
+		return NestingPolicyFlatten, nil
+	case NestingPolicyFlatten, NestingPolicyDirectOnly, NestingPolicyMirror:
+		return p, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidNestingPolicy, name)
+	}
+}
+
+// WithNestingPolicy configures how the Google Workspace identity provider syncs the nested groups.
+func WithNestingPolicy(policy NestingPolicy) IdentityProviderOption {
+	return func(po *providerOptions) {
+		po.nestingPolicy = policy
+	}
+}
+
+// membersOptions returns the options used to list the members of the groups according to the
+// nesting and the suspended users policies.
+func (i *IdentityProvider) membersOptions() []google.GetGroupMembersOption {
+	opts := make([]google.GetGroupMembersOption, 0, 2)
+
+	switch i.nestingPolicy {
+	case NestingPolicyDirectOnly, NestingPolicyMirror:
+	default:
+		opts = append(opts, google.WithIncludeDerivedMembership(true))
+	}
+
+	if i.includeInactiveMembers() {
+		opts = append(opts, google.WithIncludeInactiveMembers(true))
+	}
+
+	return opts
+}
+
+// skipGroupMember logs the group members not included by the nesting policy.
+func (i *IdentityProvider) skipGroupMember(member *admin.Member) {
+	if i.nestingPolicy == NestingPolicyDirectOnly {
+		slog.Warn("skipping member because is a group, group members are not included with the direct-only nesting policy",
+			"id", member.Id,
+			"email", member.Email,
+		)
+		return
+	}
+
+	slog.Warn("skipping member because is a group, but group members will be included",
+		"id", member.Id,
+		"email", member.Email,
+	)
+}
+
+// mirrorNestedGroups returns the groups with the groups nested in them, at any depth, appended.
+func (i *IdentityProvider) mirrorNestedGroups(ctx context.Context, groups []*admin.Group) ([]*admin.Group, error) {
+	groupIDs := make([]string, 0, len(groups))
+	for _, grp := range groups {
+		if _, ok := i.orgUnitGroup(grp.Id); ok {
+			continue
+		}
+		if _, _, ok := roleProjection(grp.Id); ok {
+			continue
+		}
+		groupIDs = append(groupIDs, grp.Id)
+	}
+
+	directMembers, err := i.listNestedMembers(ctx, groupIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	nestedIDs := make([]string, 0)
+	for id := range directMembers {
+		if !slices.Contains(groupIDs, id) {
+			nestedIDs = append(nestedIDs, id)
+		}
+	}
+	slices.Sort(nestedIDs)
+
+	for _, id := range nestedIDs {
+		grp, err := i.ps.GetGroup(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("idp: error getting nested group: %w", err)
+		}
+
+		slog.Debug("idp: nested group mirrored", "id", grp.Id, "name", grp.Name)
+		groups = append(groups, grp)
+	}
+
+	return groups, nil
+}
+
+// listNestedMembers returns the direct members of the groups and of the groups nested in them, at any depth.
+// The members read before, by GetGroups, are not read again.
+func (i *IdentityProvider) listNestedMembers(ctx context.Context, groupIDs []string) (map[string][]*admin.Member, error) {
+	i.mu.Lock()
+	directMembers := maps.Clone(i.directMembers)
+	i.mu.Unlock()
+
+	if directMembers == nil {
+		directMembers = make(map[string][]*admin.Member, len(groupIDs))
+	}
+
+	for pending := groupIDs; len(pending) > 0; {
+		missing := make([]string, 0, len(pending))
+		for _, id := range pending {
+			if _, ok := directMembers[id]; !ok {
+				missing = append(missing, id)
+			}
+		}
+
+		if len(missing) > 0 {
+			batch, err := i.ps.ListGroupMembersBatch(ctx, missing, i.membersOptions()...)
+			if err != nil {
+				return nil, fmt.Errorf("idp: error getting group members batch: %w", err)
+			}
+
+			// keep the groups without members to avoid reading them again
+			for _, id := range missing {
+				directMembers[id] = batch[id]
+			}
+		}
+
+		next := make([]string, 0)
+		for _, id := range pending {
+			for _, member := range directMembers[id] {
+				if member.Type != "GROUP" {
+					continue
+				}
+				if _, ok := directMembers[member.Id]; !ok && !slices.Contains(next, member.Id) {
+					next = append(next, member.Id)
+				}
+			}
+		}
+		pending = next
+	}
+
+	i.mu.Lock()
+	i.directMembers = directMembers
+	i.mu.Unlock()
+
+	return directMembers, nil
+}
+
+// expandMembers returns the members of the group and of the groups nested in it, at any depth.
+// The members inherited from nested groups record the names of these groups, a member
+// of several nested groups is returned once with the shortest lineage.
+func expandMembers(groupID string, directMembers map[string][]*admin.Member, names map[string]string) []*model.Member {
+	type nestedGroup struct {
+		id  string
+		via []string
+	}
+
+	visited := map[string]struct{}{groupID: {}}
+	emails := make(map[string]struct{})
+	syncMembers := make([]*model.Member, 0, len(directMembers[groupID]))
+
+	for queue := []nestedGroup{{id: groupID}}; len(queue) > 0; queue = queue[1:] {
+		current := queue[0]
+
+		for _, member := range directMembers[current.id] {
+			if member.Type == "GROUP" {
+				if _, ok := visited[member.Id]; ok {
+					continue
+				}
+				visited[member.Id] = struct{}{}
+
+				name, ok := names[member.Id]
+				if !ok {
+					name = member.Email
+				}
+				queue = append(queue, nestedGroup{id: member.Id, via: append(slices.Clone(current.via), name)})
+				continue
+			}
+
+			if _, ok := emails[member.Email]; ok {
+				continue
+			}
+			emails[member.Email] = struct{}{}
+
+			syncMembers = append(syncMembers, model.MemberBuilder().
+				WithIPID(member.Id).
+				WithEmail(member.Email).
+				WithStatus(member.Status).
+				WithVia(current.via).
+				Build(),
+			)
+		}
+	}
+
+	return syncMembers
+}
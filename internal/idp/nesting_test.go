@@ -0,0 +1,120 @@
+package idp
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	mocks "github.com/slashdevops/idp-scim-sync/mocks/idp"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	admin "google.golang.org/api/admin/directory/v1"
+)
+
+func TestParseNestingPolicy(t *testing.T) {
+	got, err := ParseNestingPolicy("")
+	assert.NoError(t, err)
+	assert.Equal(t, NestingPolicyFlatten, got)
+
+	got, err = ParseNestingPolicy("mirror")
+	assert.NoError(t, err)
+	assert.Equal(t, NestingPolicyMirror, got)
+
+	got, err = ParseNestingPolicy("nested")
+	assert.ErrorIs(t, err, ErrInvalidNestingPolicy)
+	assert.Empty(t, got)
+}
+
+func TestNestingPolicyDirectOnly(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	mockDS := mocks.NewMockGoogleProviderService(mockCtrl)
+	mockDS.EXPECT().ListGroupMembersBatch(gomock.Any(), []string{"g1"}).Return(map[string][]*admin.Member{
+		"g1": {
+			{Id: "u1", Email: "user.1@mail.com", Status: "ACTIVE", Type: "USER"},
+			{Id: "g2", Email: "group.2@mail.com", Type: "GROUP"},
+		},
+	}, nil)
+
+	ip, _ := NewIdentityProvider(mockDS, WithNestingPolicy(NestingPolicyDirectOnly))
+
+	g1 := model.GroupBuilder().WithIPID("g1").WithName("group 1").Build()
+	got, err := ip.GetGroupsMembers(context.Background(), model.GroupsResultBuilder().WithResources([]*model.Group{g1}).Build())
+	assert.NoError(t, err)
+
+	want := model.GroupsMembersResultBuilder().WithResources([]*model.GroupMembers{
+		model.GroupMembersBuilder().WithGroup(g1).WithResources([]*model.Member{
+			model.MemberBuilder().WithIPID("u1").WithEmail("user.1@mail.com").WithStatus("ACTIVE").Build(),
+		}).Build(),
+	}).Build()
+	assert.Equal(t, want, got)
+}
+
+func TestNestingPolicyMirror(t *testing.T) {
+	u1 := &admin.Member{Id: "u1", Email: "user.1@mail.com", Status: "ACTIVE", Type: "USER"}
+	u2 := &admin.Member{Id: "u2", Email: "user.2@mail.com", Status: "ACTIVE", Type: "USER"}
+	u3 := &admin.Member{Id: "u3", Email: "user.3@mail.com", Status: "ACTIVE", Type: "USER"}
+
+	// g1 -> g2 -> g3, and g2 -> g1
+	directMembers := map[string][]*admin.Member{
+		"g1": {u1, {Id: "g2", Email: "group.2@mail.com", Type: "GROUP"}},
+		"g2": {u2, {Id: "g3", Email: "group.3@mail.com", Type: "GROUP"}, {Id: "g1", Email: "group.1@mail.com", Type: "GROUP"}},
+		"g3": {u1, u3},
+	}
+
+	t.Run("should return the nested groups and expand their members", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		mockDS := mocks.NewMockGoogleProviderService(mockCtrl)
+		mockDS.EXPECT().ListGroups(gomock.Any(), []string{"name:AWS*"}).Return([]*admin.Group{{Id: "g1", Name: "group 1", Email: "group.1@mail.com"}}, nil)
+		for _, id := range []string{"g1", "g2", "g3"} {
+			mockDS.EXPECT().ListGroupMembersBatch(gomock.Any(), []string{id}).Return(map[string][]*admin.Member{id: directMembers[id]}, nil).Times(1)
+		}
+		mockDS.EXPECT().GetGroup(gomock.Any(), "g2").Return(&admin.Group{Id: "g2", Name: "group 2", Email: "group.2@mail.com"}, nil)
+		mockDS.EXPECT().GetGroup(gomock.Any(), "g3").Return(&admin.Group{Id: "g3", Name: "group 3", Email: "group.3@mail.com"}, nil)
+
+		ip, _ := NewIdentityProvider(mockDS, WithNestingPolicy(NestingPolicyMirror))
+
+		gr, err := ip.GetGroups(context.Background(), []string{"name:AWS*"})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, gr.Items)
+		assert.Equal(t, []string{"group 1", "group 2", "group 3"}, []string{gr.Resources[0].Name, gr.Resources[1].Name, gr.Resources[2].Name})
+
+		gmr, err := ip.GetGroupsMembers(context.Background(), gr)
+		assert.NoError(t, err)
+
+		got := make(map[string][]*model.Member, gmr.Items)
+		for _, gm := range gmr.Resources {
+			got[gm.Group.Name] = gm.Resources
+		}
+
+		assert.Equal(t, []*model.Member{
+			model.MemberBuilder().WithIPID("u1").WithEmail("user.1@mail.com").WithStatus("ACTIVE").Build(),
+			model.MemberBuilder().WithIPID("u2").WithEmail("user.2@mail.com").WithStatus("ACTIVE").WithVia([]string{"group 2"}).Build(),
+			model.MemberBuilder().WithIPID("u3").WithEmail("user.3@mail.com").WithStatus("ACTIVE").WithVia([]string{"group 2", "group 3"}).Build(),
+		}, got["group 1"])
+		assert.Equal(t, []*model.Member{
+			model.MemberBuilder().WithIPID("u2").WithEmail("user.2@mail.com").WithStatus("ACTIVE").Build(),
+			model.MemberBuilder().WithIPID("u1").WithEmail("user.1@mail.com").WithStatus("ACTIVE").WithVia([]string{"group 3"}).Build(),
+			model.MemberBuilder().WithIPID("u3").WithEmail("user.3@mail.com").WithStatus("ACTIVE").WithVia([]string{"group 3"}).Build(),
+		}, got["group 2"])
+		assert.Equal(t, []*model.Member{
+			model.MemberBuilder().WithIPID("u1").WithEmail("user.1@mail.com").WithStatus("ACTIVE").Build(),
+			model.MemberBuilder().WithIPID("u3").WithEmail("user.3@mail.com").WithStatus("ACTIVE").Build(),
+		}, got["group 3"])
+	})
+
+	t.Run("should return error when the nested group can't be read", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		mockDS := mocks.NewMockGoogleProviderService(mockCtrl)
+		mockDS.EXPECT().ListGroups(gomock.Any(), nil).Return([]*admin.Group{{Id: "g1", Name: "group 1"}}, nil)
+		mockDS.EXPECT().ListGroupMembersBatch(gomock.Any(), []string{"g1"}).Return(map[string][]*admin.Member{"g1": directMembers["g1"]}, nil)
+		mockDS.EXPECT().ListGroupMembersBatch(gomock.Any(), []string{"g2"}).Return(map[string][]*admin.Member{"g2": {u2}}, nil)
+		mockDS.EXPECT().GetGroup(gomock.Any(), "g2").Return(nil, errors.New("test error"))
+
+		ip, _ := NewIdentityProvider(mockDS, WithNestingPolicy(NestingPolicyMirror))
+
+		got, err := ip.GetGroups(context.Background(), nil)
+		assert.Error(t, err)
+		assert.Nil(t, got)
+	})
+}
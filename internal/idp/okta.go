@@ -0,0 +1,309 @@
+package idp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	"github.com/slashdevops/idp-scim-sync/pkg/okta"
+)
+
+// This implement core.IdentityProviderService interface for Okta
+
+// ErrOktaServiceNil is returned when the OktaProviderService is nil.
+var ErrOktaServiceNil = errors.New("provider: okta service is nil")
+
+//go:generate go tool mockgen -package=mocks -destination=../../mocks/idp/okta_mocks.go -source=okta.go OktaProviderService
+
+// OktaProviderService is the interface that wraps the Okta Management API client methods.
+type OktaProviderService interface {
+	ListGroups(ctx context.Context, filter []string) ([]*okta.Group, error)
+	ListUsers(ctx context.Context, filter []string) ([]*okta.User, error)
+	ListGroupMembers(ctx context.Context, groupID string) ([]*okta.User, error)
+	GetUser(ctx context.Context, userID string) (*okta.User, error)
+}
+
+// OktaIdentityProvider is the Identity Provider service that implements the core.IdentityProvider interface
+// and consumes the pkg.okta methods.
+type OktaIdentityProvider struct {
+	ps OktaProviderService
+	providerOptions
+
+	// the group members endpoint returns the full users, they are kept to avoid reading them again
+	mu    sync.Mutex
+	users map[string]*okta.User
+}
+
+// NewOktaIdentityProvider returns a new instance of the Okta Identity Provider service.
+func NewOktaIdentityProvider(ops OktaProviderService, opts ...IdentityProviderOption) (*OktaIdentityProvider, error) {
+	if ops == nil {
+		return nil, ErrOktaServiceNil
+	}
+
+	ip := &OktaIdentityProvider{
+		ps:    ops,
+		users: make(map[string]*okta.User),
+	}
+
+	for _, opt := range opts {
+		opt(&ip.providerOptions)
+	}
+
+	return ip, nil
+}
+
+// GetGroups returns a list of groups from Okta.
+//
+// The filter parameter is a list of search expressions, e.g. `profile.name sw "AWS"`.
+func (o *OktaIdentityProvider) GetGroups(ctx context.Context, filter []string) (*model.GroupsResult, error) {
+	oGroups, err := o.ps.ListGroups(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("idp: error getting groups: %w", err)
+	}
+
+	groups := make([]*model.Group, 0, len(oGroups))
+	for _, grp := range oGroups {
+		groups = append(groups, model.GroupBuilder().
+			WithIPID(grp.ID).
+			WithName(grp.Profile.Name).
+			Build(),
+		)
+	}
+
+	syncResult := buildGroupsResult(groups)
+	slog.Debug("idp: okta GetGroups()", "groups", syncResult.Items)
+
+	return syncResult, nil
+}
+
+// GetUsers returns a list of users from Okta.
+//
+// The filter parameter is a list of search expressions, e.g. `status eq "ACTIVE"`.
+func (o *OktaIdentityProvider) GetUsers(ctx context.Context, filter []string) (*model.UsersResult, error) {
+	oUsers, err := o.ps.ListUsers(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("idp: error getting users: %w", err)
+	}
+
+	syncUsers := make([]*model.User, 0, len(oUsers))
+	for _, usr := range oUsers {
+		if u := buildOktaUser(usr, o.syncFieldSet); u != nil {
+			syncUsers = append(syncUsers, u)
+		}
+	}
+
+	uResult := model.UsersResultBuilder().WithResources(syncUsers).Build()
+	slog.Debug("idp: okta GetUsers()", "users", len(syncUsers))
+
+	return uResult, nil
+}
+
+// GetGroupMembers returns the active members of the group.
+func (o *OktaIdentityProvider) GetGroupMembers(ctx context.Context, groupID string) (*model.MembersResult, error) {
+	if groupID == "" {
+		return nil, ErrGroupIDNil
+	}
+
+	members, err := o.listGroupMembers(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("idp: error getting group members: %w", err)
+	}
+
+	return model.MembersResultBuilder().WithResources(members).Build(), nil
+}
+
+// GetGroupsMembers returns the active members of the groups.
+func (o *OktaIdentityProvider) GetGroupsMembers(ctx context.Context, gr *model.GroupsResult) (*model.GroupsMembersResult, error) {
+	gmr, err := listGroupsMembers(ctx, gr, func(ctx context.Context, group *model.Group) ([]*model.Member, error) {
+		return o.listGroupMembers(ctx, group.IPID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Debug("idp: okta GetGroupsMembers()", "groups", gmr.Items)
+
+	return gmr, nil
+}
+
+// GetUsersByGroupsMembers returns the users of the given groups members.
+// The users already returned by the group members endpoint are not read again.
+func (o *OktaIdentityProvider) GetUsersByGroupsMembers(ctx context.Context, gmr *model.GroupsMembersResult) (*model.UsersResult, error) {
+	ur, err := getUsersByGroupsMembers(ctx, gmr, func(ctx context.Context, member *model.Member) (*model.User, error) {
+		o.mu.Lock()
+		usr, ok := o.users[member.IPID]
+		o.mu.Unlock()
+
+		if !ok {
+			var err error
+			if usr, err = o.ps.GetUser(ctx, member.IPID); err != nil {
+				return nil, err
+			}
+		}
+
+		return buildOktaUser(usr, o.syncFieldSet), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Debug("idp: okta GetUsersByGroupsMembers()", "users", ur.Items)
+
+	return ur, nil
+}
+
+// listGroupMembers returns the active users that are members of the given group.
+func (o *OktaIdentityProvider) listGroupMembers(ctx context.Context, groupID string) ([]*model.Member, error) {
+	oMembers, err := o.ps.ListGroupMembers(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]*model.Member, 0, len(oMembers))
+	for _, m := range oMembers {
+		o.mu.Lock()
+		o.users[m.ID] = m
+		o.mu.Unlock()
+
+		members = append(members, model.MemberBuilder().
+			WithIPID(m.ID).
+			WithEmail(strings.TrimSpace(m.Email())).
+			WithStatus("ACTIVE").
+			Build(),
+		)
+	}
+
+	return members, nil
+}
+
+// buildOktaUser builds a User model from an Okta user profile.
+// The fields parameter controls which optional user attributes are included.
+func buildOktaUser(usr *okta.User, fields *model.SyncFieldSet) *model.User {
+	if usr == nil {
+		return nil
+	}
+
+	p := usr.Profile
+
+	// these fields are required because the Constrains defined here:
+	// https://docs.aws.amazon.com/singlesignon/latest/developerguide/createuser.html
+	if p.FirstName == "" {
+		slog.Warn("idp: User given name is empty", "id", usr.ID, "login", p.Login)
+		return nil
+	}
+
+	if p.LastName == "" {
+		slog.Warn("idp: User family name is empty", "id", usr.ID, "login", p.Login)
+		return nil
+	}
+
+	email := strings.TrimSpace(usr.Email())
+	if email == "" {
+		slog.Warn("idp: User email is empty", "id", usr.ID)
+		return nil
+	}
+
+	displayName := p.DisplayName
+	if displayName == "" {
+		displayName = fmt.Sprintf("%s %s", p.FirstName, p.LastName)
+	}
+
+	name := model.NameBuilder().
+		WithGivenName(strings.TrimSpace(p.FirstName)).
+		WithFamilyName(strings.TrimSpace(p.LastName)).
+		WithMiddleName(strings.TrimSpace(p.MiddleName)).
+		WithHonorificPrefix(strings.TrimSpace(p.HonorificPrefix)).
+		WithHonorificSuffix(strings.TrimSpace(p.HonorificSuffix)).
+		WithFormatted(strings.TrimSpace(p.DisplayName)).
+		Build()
+
+	ub := model.UserBuilder().
+		WithIPID(strings.TrimSpace(usr.ID)).
+		WithUserName(strings.TrimSpace(p.Login)).
+		WithDisplayName(strings.TrimSpace(displayName)).
+		WithActive(usr.Active()).
+		WithEmail(model.EmailBuilder().WithPrimary(true).WithType("work").WithValue(email).Build()).
+		WithName(name)
+
+	if fields.Includes(model.SyncUserFieldTitle) {
+		ub = ub.WithTitle(strings.TrimSpace(p.Title))
+	}
+	if fields.Includes(model.SyncUserFieldUserType) {
+		ub = ub.WithUserType(strings.TrimSpace(p.UserType))
+	}
+	if fields.Includes(model.SyncUserFieldPreferredLanguage) {
+		ub = ub.WithPreferredLanguage(strings.TrimSpace(p.PreferredLanguage))
+	}
+	if fields.Includes(model.SyncUserFieldLocale) {
+		ub = ub.WithLocale(strings.TrimSpace(p.Locale))
+	}
+	if fields.Includes(model.SyncUserFieldTimezone) {
+		ub = ub.WithTimezone(strings.TrimSpace(p.Timezone))
+	}
+	if fields.Includes(model.SyncUserFieldNickName) {
+		ub = ub.WithNickName(strings.TrimSpace(p.NickName))
+	}
+	if fields.Includes(model.SyncUserFieldProfileURL) {
+		ub = ub.WithProfileURL(strings.TrimSpace(p.ProfileURL))
+	}
+
+	if fields.Includes(model.SyncUserFieldAddresses) {
+		parts := make([]string, 0, 5)
+		for _, part := range []string{p.StreetAddress, p.City, p.State, p.ZipCode, p.CountryCode} {
+			if part = strings.TrimSpace(part); part != "" {
+				parts = append(parts, part)
+			}
+		}
+
+		formatted := strings.TrimSpace(p.PostalAddress)
+		if formatted == "" {
+			formatted = strings.Join(parts, ", ")
+		}
+
+		if formatted != "" {
+			ub = ub.WithAddress(model.AddressBuilder().
+				WithFormatted(formatted).
+				WithStreetAddress(strings.TrimSpace(p.StreetAddress)).
+				WithLocality(strings.TrimSpace(p.City)).
+				WithRegion(strings.TrimSpace(p.State)).
+				WithPostalCode(strings.TrimSpace(p.ZipCode)).
+				WithCountry(strings.TrimSpace(p.CountryCode)).
+				Build(),
+			)
+		}
+	}
+
+	if fields.Includes(model.SyncUserFieldPhoneNumbers) {
+		switch {
+		case strings.TrimSpace(p.PrimaryPhone) != "":
+			ub = ub.WithPhoneNumber(model.PhoneNumberBuilder().WithValue(strings.TrimSpace(p.PrimaryPhone)).WithType("work").Build())
+		case strings.TrimSpace(p.MobilePhone) != "":
+			ub = ub.WithPhoneNumber(model.PhoneNumberBuilder().WithValue(strings.TrimSpace(p.MobilePhone)).WithType("mobile").Build())
+		}
+	}
+
+	if fields.Includes(model.SyncUserFieldEnterpriseData) {
+		var manager *model.Manager
+		if p.ManagerID != "" {
+			manager = model.ManagerBuilder().WithValue(strings.TrimSpace(p.ManagerID)).Build()
+		}
+
+		if p.EmployeeNumber != "" || p.CostCenter != "" || p.Organization != "" || p.Division != "" || p.Department != "" || manager != nil {
+			ub = ub.WithEnterpriseData(model.EnterpriseDataBuilder().
+				WithEmployeeNumber(strings.TrimSpace(p.EmployeeNumber)).
+				WithCostCenter(strings.TrimSpace(p.CostCenter)).
+				WithOrganization(strings.TrimSpace(p.Organization)).
+				WithDivision(strings.TrimSpace(p.Division)).
+				WithDepartment(strings.TrimSpace(p.Department)).
+				WithManager(manager).
+				Build(),
+			)
+		}
+	}
+
+	return ub.Build()
+}
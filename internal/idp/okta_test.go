@@ -0,0 +1,202 @@
+package idp
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	mocks "github.com/slashdevops/idp-scim-sync/mocks/idp"
+	"github.com/slashdevops/idp-scim-sync/pkg/okta"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestNewOktaIdentityProvider(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+
+	t.Run("Should return OktaIdentityProvider and no error", func(t *testing.T) {
+		svc, err := NewOktaIdentityProvider(mocks.NewMockOktaProviderService(mockCtrl))
+		assert.NoError(t, err)
+		assert.NotNil(t, svc)
+	})
+
+	t.Run("Should return an error if no service is provided", func(t *testing.T) {
+		svc, err := NewOktaIdentityProvider(nil)
+		assert.ErrorIs(t, err, ErrOktaServiceNil)
+		assert.Nil(t, svc)
+	})
+}
+
+func TestOktaGetGroups(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+
+	t.Run("Should return the groups without repeated names", func(t *testing.T) {
+		mockSvc := mocks.NewMockOktaProviderService(mockCtrl)
+		mockSvc.EXPECT().ListGroups(gomock.Any(), []string{`profile.name sw "AWS"`}).Return([]*okta.Group{
+			{ID: "1", Profile: okta.GroupProfile{Name: "AWS-1"}},
+			{ID: "2", Profile: okta.GroupProfile{Name: "AWS-1"}},
+			{ID: "3", Profile: okta.GroupProfile{Name: "AWS-3"}},
+		}, nil)
+
+		ip, _ := NewOktaIdentityProvider(mockSvc)
+		got, err := ip.GetGroups(context.Background(), []string{`profile.name sw "AWS"`})
+		assert.NoError(t, err)
+
+		want := model.GroupsResultBuilder().WithResources([]*model.Group{
+			model.GroupBuilder().WithIPID("1").WithName("AWS-1").Build(),
+			model.GroupBuilder().WithIPID("3").WithName("AWS-3").Build(),
+		}).Build()
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("Should return error", func(t *testing.T) {
+		mockSvc := mocks.NewMockOktaProviderService(mockCtrl)
+		mockSvc.EXPECT().ListGroups(gomock.Any(), nil).Return(nil, errors.New("test error"))
+
+		ip, _ := NewOktaIdentityProvider(mockSvc)
+		got, err := ip.GetGroups(context.Background(), nil)
+		assert.Error(t, err)
+		assert.Nil(t, got)
+	})
+}
+
+func TestOktaGroupsMembersAndUsers(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+
+	u1 := &okta.User{ID: "u1", Status: okta.UserStatusActive, Profile: okta.UserProfile{Login: "u1@example.com", FirstName: "User", LastName: "One"}}
+	u2 := &okta.User{ID: "u2", Status: okta.UserStatusActive, Profile: okta.UserProfile{Login: "u2@example.com", Email: "user2@example.com", FirstName: "User", LastName: "Two"}}
+
+	g1 := model.GroupBuilder().WithIPID("g1").WithName("group 1").Build()
+	g2 := model.GroupBuilder().WithIPID("g2").WithName("group 2").Build()
+	gr := model.GroupsResultBuilder().WithResources([]*model.Group{g1, g2}).Build()
+
+	t.Run("Should not read again the users returned as group members", func(t *testing.T) {
+		mockSvc := mocks.NewMockOktaProviderService(mockCtrl)
+		mockSvc.EXPECT().ListGroupMembers(gomock.Any(), "g1").Return([]*okta.User{u1}, nil)
+		mockSvc.EXPECT().ListGroupMembers(gomock.Any(), "g2").Return([]*okta.User{u1, u2}, nil)
+
+		ip, _ := NewOktaIdentityProvider(mockSvc)
+		gmr, err := ip.GetGroupsMembers(context.Background(), gr)
+		assert.NoError(t, err)
+
+		m1 := model.MemberBuilder().WithIPID("u1").WithEmail("u1@example.com").WithStatus("ACTIVE").Build()
+		m2 := model.MemberBuilder().WithIPID("u2").WithEmail("user2@example.com").WithStatus("ACTIVE").Build()
+		want := model.GroupsMembersResultBuilder().WithResources([]*model.GroupMembers{
+			model.GroupMembersBuilder().WithGroup(g1).WithResource(m1).Build(),
+			model.GroupMembersBuilder().WithGroup(g2).WithResources([]*model.Member{m1, m2}).Build(),
+		}).Build()
+		assert.Equal(t, want, gmr)
+
+		got, err := ip.GetUsersByGroupsMembers(context.Background(), gmr)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []*model.User{buildOktaUser(u1, nil), buildOktaUser(u2, nil)}, got.Resources)
+	})
+
+	t.Run("Should get the users not returned as group members", func(t *testing.T) {
+		mockSvc := mocks.NewMockOktaProviderService(mockCtrl)
+		mockSvc.EXPECT().GetUser(gomock.Any(), "u1").Return(u1, nil)
+
+		ip, _ := NewOktaIdentityProvider(mockSvc)
+		gmr := model.GroupsMembersResultBuilder().WithResources([]*model.GroupMembers{
+			model.GroupMembersBuilder().WithGroup(g1).WithResource(model.MemberBuilder().WithIPID("u1").WithEmail("u1@example.com").Build()).Build(),
+		}).Build()
+
+		got, err := ip.GetUsersByGroupsMembers(context.Background(), gmr)
+		assert.NoError(t, err)
+		assert.Equal(t, []*model.User{buildOktaUser(u1, nil)}, got.Resources)
+	})
+
+	t.Run("Should return error", func(t *testing.T) {
+		mockSvc := mocks.NewMockOktaProviderService(mockCtrl)
+		mockSvc.EXPECT().ListGroupMembers(gomock.Any(), gomock.Any()).Return(nil, errors.New("test error")).AnyTimes()
+
+		ip, _ := NewOktaIdentityProvider(mockSvc)
+		got, err := ip.GetGroupsMembers(context.Background(), gr)
+		assert.Error(t, err)
+		assert.Nil(t, got)
+	})
+}
+
+func TestBuildOktaUser(t *testing.T) {
+	usr := &okta.User{
+		ID:     "u1",
+		Status: okta.UserStatusActive,
+		Profile: okta.UserProfile{
+			Login:             "u1@example.com",
+			Email:             "user.one@example.com",
+			FirstName:         "User",
+			LastName:          "One",
+			DisplayName:       "User One",
+			NickName:          "uno",
+			ProfileURL:        "https://example.com/u1",
+			Title:             "Engineer",
+			UserType:          "Employee",
+			PreferredLanguage: "en",
+			Locale:            "en_US",
+			Timezone:          "America/New_York",
+			PrimaryPhone:      "+1 555 0101",
+			City:              "Boston",
+			CountryCode:       "US",
+			EmployeeNumber:    "42",
+			CostCenter:        "CC1",
+			Organization:      "Example",
+			Division:          "Cloud",
+			Department:        "R&D",
+			ManagerID:         "m1",
+		},
+	}
+
+	t.Run("Should map all the fields", func(t *testing.T) {
+		got := buildOktaUser(usr, nil)
+
+		want := model.UserBuilder().
+			WithIPID("u1").
+			WithUserName("u1@example.com").
+			WithDisplayName("User One").
+			WithActive(true).
+			WithEmail(model.EmailBuilder().WithPrimary(true).WithType("work").WithValue("user.one@example.com").Build()).
+			WithName(model.NameBuilder().WithGivenName("User").WithFamilyName("One").WithFormatted("User One").Build()).
+			WithTitle("Engineer").
+			WithUserType("Employee").
+			WithPreferredLanguage("en").
+			WithLocale("en_US").
+			WithTimezone("America/New_York").
+			WithNickName("uno").
+			WithProfileURL("https://example.com/u1").
+			WithAddress(model.AddressBuilder().WithFormatted("Boston, US").WithLocality("Boston").WithCountry("US").Build()).
+			WithPhoneNumber(model.PhoneNumberBuilder().WithValue("+1 555 0101").WithType("work").Build()).
+			WithEnterpriseData(model.EnterpriseDataBuilder().
+				WithEmployeeNumber("42").
+				WithCostCenter("CC1").
+				WithOrganization("Example").
+				WithDivision("Cloud").
+				WithDepartment("R&D").
+				WithManager(model.ManagerBuilder().WithValue("m1").Build()).
+				Build()).
+			Build()
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("Should map suspended users as inactive", func(t *testing.T) {
+		suspended := *usr
+		suspended.Status = okta.UserStatusSuspended
+		assert.False(t, buildOktaUser(&suspended, nil).Active)
+	})
+
+	t.Run("Should only map the configured fields", func(t *testing.T) {
+		got := buildOktaUser(usr, model.NewSyncFieldSet([]string{"enterpriseData"}))
+		assert.NotNil(t, got.EnterpriseData)
+		assert.Empty(t, got.Title)
+		assert.Empty(t, got.NickName)
+		assert.Nil(t, got.Addresses)
+		assert.Nil(t, got.PhoneNumbers)
+	})
+
+	t.Run("Should return nil when required fields are missing", func(t *testing.T) {
+		assert.Nil(t, buildOktaUser(nil, nil))
+		assert.Nil(t, buildOktaUser(&okta.User{ID: "u1", Profile: okta.UserProfile{Login: "u1@example.com", LastName: "One"}}, nil))
+		assert.Nil(t, buildOktaUser(&okta.User{ID: "u1", Profile: okta.UserProfile{Login: "u1@example.com", FirstName: "User"}}, nil))
+		assert.Nil(t, buildOktaUser(&okta.User{ID: "u1", Profile: okta.UserProfile{FirstName: "User", LastName: "One"}}, nil))
+	})
+}
@@ -0,0 +1,163 @@
+package idp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	admin "google.golang.org/api/admin/directory/v1"
+)
+
+// orgUnitGroupIDPrefix prefixes the identity provider id of the groups mapped from Google organizational units.
+const orgUnitGroupIDPrefix = "orgunit:"
+
+// ErrInvalidOrgUnitGroup is returned when an org unit group definition is not valid.
+var ErrInvalidOrgUnitGroup = errors.New("provider: invalid org unit group")
+
+// OrgUnitGroup maps the users of a Google organizational unit, and of its children
+// organizational units, to a synthetic group.
+type OrgUnitGroup struct {
+	// Path is the organizational unit path, e.g. /Contractors/AWS.
+	Path string
+
+	// Name is the name of the group, the organizational unit path when empty.
+	Name string
+}
+
+// ParseOrgUnitGroups parses the org unit groups definitions, "<path>" or "<path>=<group name>",
+// e.g. "/Contractors/AWS=AWS-Contractors".
+func ParseOrgUnitGroups(values []string) ([]OrgUnitGroup, error) {
+	ougs := make([]OrgUnitGroup, 0, len(values))
+
+	for _, v := range values {
+		if strings.TrimSpace(v) == "" {
+			continue
+		}
+
+		p, name, _ := strings.Cut(v, "=")
+		oug := OrgUnitGroup{Path: strings.TrimSpace(p), Name: strings.TrimSpace(name)}
+
+		if !strings.HasPrefix(oug.Path, "/") {
+			return nil, fmt.Errorf("%w: %q, the path must start with /", ErrInvalidOrgUnitGroup, v)
+		}
+
+		ougs = append(ougs, oug)
+	}
+
+	return ougs, nil
+}
+
+// WithOrgUnitGroups configures the Google Workspace identity provider to return a group per
+// organizational unit with the users of the organizational unit and of its children as members.
+func WithOrgUnitGroups(ougs []OrgUnitGroup) IdentityProviderOption {
+	return func(po *providerOptions) {
+		po.orgUnitGroups = ougs
+	}
+}
+
+// groupID returns the identity provider id of the org unit group.
+func (o OrgUnitGroup) groupID() string {
+	return orgUnitGroupIDPrefix + o.Path
+}
+
+// groupName returns the name of the org unit group.
+func (o OrgUnitGroup) groupName() string {
+	if o.Name != "" {
+		return o.Name
+	}
+	return o.Path
+}
+
+// orgUnitGroup returns the org unit group of the given group id, if any.
+func (i *IdentityProvider) orgUnitGroup(groupID string) (OrgUnitGroup, bool) {
+	if !strings.HasPrefix(groupID, orgUnitGroupIDPrefix) {
+		return OrgUnitGroup{}, false
+	}
+
+	for _, oug := range i.orgUnitGroups {
+		if oug.groupID() == groupID {
+			return oug, true
+		}
+	}
+
+	return OrgUnitGroup{}, false
+}
+
+// listOrgUnitUsers returns the users of the org unit group and keeps them to avoid reading them again.
+func (i *IdentityProvider) listOrgUnitUsers(ctx context.Context, oug OrgUnitGroup) ([]*admin.User, error) {
+	users, err := i.ps.ListOrgUnitUsers(ctx, oug.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	i.cacheUsers(users)
+
+	return users, nil
+}
+
+// listOrgUnitMembers returns the members of the org unit group.
+func (i *IdentityProvider) listOrgUnitMembers(ctx context.Context, oug OrgUnitGroup) ([]*model.Member, error) {
+	users, err := i.listOrgUnitUsers(ctx, oug)
+	if err != nil {
+		return nil, err
+	}
+
+	syncMembers := make([]*model.Member, 0, len(users))
+	for _, m := range orgUnitMembers(users, i.includeInactiveMembers()) {
+		syncMembers = append(syncMembers, model.MemberBuilder().
+			WithIPID(m.Id).
+			WithEmail(m.Email).
+			WithStatus(m.Status).
+			Build(),
+		)
+	}
+
+	return syncMembers, nil
+}
+
+// cachedUser returns the user read as org unit group member, if any.
+func (i *IdentityProvider) cachedUser(email string) (*admin.User, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	u, ok := i.users[strings.ToLower(email)]
+	return u, ok
+}
+
+// hasFilter returns true when any of the filter values is not blank.
+func hasFilter(filter []string) bool {
+	return slices.ContainsFunc(filter, func(f string) bool {
+		return strings.TrimSpace(f) != ""
+	})
+}
+
+// orgUnitMembers returns the users as group members with the status Google uses for the group members,
+// the suspended and archived users are returned only when includeInactive is true.
+func orgUnitMembers(users []*admin.User, includeInactive bool) []*admin.Member {
+	members := make([]*admin.Member, 0, len(users))
+	for _, u := range users {
+		status := "ACTIVE"
+		switch {
+		case u.Archived:
+			status = "ARCHIVED"
+		case u.Suspended:
+			status = "SUSPENDED"
+		}
+
+		if status != "ACTIVE" && !includeInactive {
+			continue
+		}
+
+		members = append(members, &admin.Member{
+			Id:     u.Id,
+			Email:  u.PrimaryEmail,
+			Status: status,
+			Type:   "USER",
+		})
+	}
+
+	return members
+}
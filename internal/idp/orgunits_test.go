@@ -0,0 +1,130 @@
+package idp
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	mocks "github.com/slashdevops/idp-scim-sync/mocks/idp"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	admin "google.golang.org/api/admin/directory/v1"
+)
+
+func TestParseOrgUnitGroups(t *testing.T) {
+	t.Run("should parse the paths and the group names", func(t *testing.T) {
+		got, err := ParseOrgUnitGroups([]string{"/Engineering", " /Contractors/AWS = AWS-Contractors ", ""})
+		assert.NoError(t, err)
+		assert.Equal(t, []OrgUnitGroup{
+			{Path: "/Engineering"},
+			{Path: "/Contractors/AWS", Name: "AWS-Contractors"},
+		}, got)
+	})
+
+	t.Run("should return error when the path is not absolute", func(t *testing.T) {
+		got, err := ParseOrgUnitGroups([]string{"Engineering=AWS"})
+		assert.ErrorIs(t, err, ErrInvalidOrgUnitGroup)
+		assert.Nil(t, got)
+	})
+}
+
+func TestOrgUnitGroups(t *testing.T) {
+	ougs := []OrgUnitGroup{{Path: "/Contractors/AWS", Name: "AWS-Contractors"}}
+
+	alice := &admin.User{
+		Id: "1", PrimaryEmail: "alice@mail.com", OrgUnitPath: "/Contractors/AWS",
+		Name: &admin.UserName{GivenName: "Alice", FamilyName: "Smith"},
+	}
+	bob := &admin.User{
+		Id: "2", PrimaryEmail: "bob@mail.com", OrgUnitPath: "/Contractors/AWS/EU", Suspended: true,
+		Name: &admin.UserName{GivenName: "Bob", FamilyName: "Jones"},
+	}
+
+	t.Run("should return only the org unit groups without filter", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		mockDS := mocks.NewMockGoogleProviderService(mockCtrl)
+
+		ip, _ := NewIdentityProvider(mockDS, WithOrgUnitGroups(ougs))
+		got, err := ip.GetGroups(context.Background(), []string{""})
+		assert.NoError(t, err)
+
+		want := model.GroupsResultBuilder().WithResources([]*model.Group{
+			model.GroupBuilder().WithIPID("orgunit:/Contractors/AWS").WithName("AWS-Contractors").Build(),
+		}).Build()
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("should return the google groups and the org unit groups", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		mockDS := mocks.NewMockGoogleProviderService(mockCtrl)
+		mockDS.EXPECT().ListGroups(gomock.Any(), []string{"name:AWS*"}).Return([]*admin.Group{{Id: "g1", Name: "AWS-Admins", Email: "aws-admins@mail.com"}}, nil)
+
+		ip, _ := NewIdentityProvider(mockDS, WithOrgUnitGroups(ougs))
+		got, err := ip.GetGroups(context.Background(), []string{"name:AWS*"})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, got.Items)
+		assert.Equal(t, "g1", got.Resources[0].IPID)
+		assert.Equal(t, "orgunit:/Contractors/AWS", got.Resources[1].IPID)
+	})
+
+	t.Run("should return the org unit users as members and not read them again", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		mockDS := mocks.NewMockGoogleProviderService(mockCtrl)
+		mockDS.EXPECT().ListOrgUnitUsers(gomock.Any(), "/Contractors/AWS").Return([]*admin.User{alice, bob}, nil)
+		mockDS.EXPECT().ListGroupMembersBatch(gomock.Any(), []string{"g1"}, gomock.Any()).Return(map[string][]*admin.Member{
+			"g1": {{Id: "1", Email: "alice@mail.com", Status: "ACTIVE", Type: "USER"}},
+		}, nil)
+
+		ip, _ := NewIdentityProvider(mockDS, WithOrgUnitGroups(ougs), WithSuspendedUsersPolicy(SuspendedUsersPolicyDeactivate))
+
+		ouGroup := model.GroupBuilder().WithIPID("orgunit:/Contractors/AWS").WithName("AWS-Contractors").Build()
+		g1 := model.GroupBuilder().WithIPID("g1").WithName("AWS-Admins").Build()
+		gr := model.GroupsResultBuilder().WithResources([]*model.Group{g1, ouGroup}).Build()
+
+		gmr, err := ip.GetGroupsMembers(context.Background(), gr)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, gmr.Items)
+
+		for _, gm := range gmr.Resources {
+			if gm.Group.IPID != ouGroup.IPID {
+				continue
+			}
+			assert.Equal(t, []*model.Member{
+				model.MemberBuilder().WithIPID("1").WithEmail("alice@mail.com").WithStatus("ACTIVE").Build(),
+				model.MemberBuilder().WithIPID("2").WithEmail("bob@mail.com").WithStatus("SUSPENDED").Build(),
+			}, gm.Resources)
+		}
+
+		got, err := ip.GetUsersByGroupsMembers(context.Background(), gmr)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, got.Items)
+	})
+
+	t.Run("should return the members of a single org unit group", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		mockDS := mocks.NewMockGoogleProviderService(mockCtrl)
+		mockDS.EXPECT().ListOrgUnitUsers(gomock.Any(), "/Contractors/AWS").Return([]*admin.User{alice}, nil)
+
+		ip, _ := NewIdentityProvider(mockDS, WithOrgUnitGroups(ougs))
+		got, err := ip.GetGroupMembers(context.Background(), "orgunit:/Contractors/AWS")
+		assert.NoError(t, err)
+		assert.Equal(t, 1, got.Items)
+	})
+
+	t.Run("should return error when the org unit users can't be read", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		mockDS := mocks.NewMockGoogleProviderService(mockCtrl)
+		mockDS.EXPECT().ListOrgUnitUsers(gomock.Any(), "/Contractors/AWS").Return(nil, errors.New("test error"))
+
+		ip, _ := NewIdentityProvider(mockDS, WithOrgUnitGroups(ougs))
+
+		gr := model.GroupsResultBuilder().WithResources([]*model.Group{
+			model.GroupBuilder().WithIPID("orgunit:/Contractors/AWS").WithName("AWS-Contractors").Build(),
+		}).Build()
+
+		got, err := ip.GetGroupsMembers(context.Background(), gr)
+		assert.Error(t, err)
+		assert.Nil(t, got)
+	})
+}
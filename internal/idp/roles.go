@@ -0,0 +1,159 @@
+package idp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/slashdevops/idp-scim-sync/pkg/google"
+	admin "google.golang.org/api/admin/directory/v1"
+)
+
+// roleProjectionIDSeparator separates the Google group id and the roles in the identity provider id
+// of the groups projected by role, Google group ids don't contain it.
+const roleProjectionIDSeparator = "#"
+
+// ErrInvalidRoleProjection is returned when a role projection definition is not valid.
+var ErrInvalidRoleProjection = errors.New("provider: invalid role projection")
+
+// memberRoles are the roles of the Google Workspace group members.
+var memberRoles = []string{"OWNER", "MANAGER", "MEMBER"}
+
+// RoleProjection projects the members of a Google Workspace group with the given roles
+// to a group of its own.
+type RoleProjection struct {
+	// GroupEmail is the email of the Google group, e.g. aws-admins@example.com.
+	GroupEmail string
+
+	// Roles are the roles of the members included, OWNER, MANAGER or MEMBER.
+	Roles []string
+
+	// Name is the name of the group, the Google group name followed by the roles when empty.
+	Name string
+}
+
+// ParseRoleProjections parses the role projections definitions, "<group email>:<role>[,<role>...][=<group name>]",
+// e.g. "aws-admins@example.com:OWNER=aws-admins-owners".
+func ParseRoleProjections(values []string) ([]RoleProjection, error) {
+	rps := make([]RoleProjection, 0, len(values))
+
+	for _, v := range values {
+		if strings.TrimSpace(v) == "" {
+			continue
+		}
+
+		def, name, _ := strings.Cut(v, "=")
+		email, roles, ok := strings.Cut(def, ":")
+		if !ok || strings.TrimSpace(email) == "" {
+			return nil, fmt.Errorf("%w: %q, the format is <group email>:<role>[,<role>...][=<group name>]", ErrInvalidRoleProjection, v)
+		}
+
+		rp := RoleProjection{GroupEmail: strings.ToLower(strings.TrimSpace(email)), Name: strings.TrimSpace(name)}
+		for r := range strings.SplitSeq(roles, ",") {
+			role := strings.ToUpper(strings.TrimSpace(r))
+			if !slices.Contains(memberRoles, role) {
+				return nil, fmt.Errorf("%w: %q, the roles are %s", ErrInvalidRoleProjection, v, strings.Join(memberRoles, ", "))
+			}
+			if !slices.Contains(rp.Roles, role) {
+				rp.Roles = append(rp.Roles, role)
+			}
+		}
+
+		rps = append(rps, rp)
+	}
+
+	return rps, nil
+}
+
+// WithRoleProjections configures the Google Workspace identity provider to replace the groups with
+// role projections by a group per projection with the members with the projection roles.
+func WithRoleProjections(rps []RoleProjection) IdentityProviderOption {
+	return func(po *providerOptions) {
+		po.roleProjections = rps
+	}
+}
+
+// roles returns the roles of the projection as expected by the Google API.
+func (rp RoleProjection) roles() string {
+	return strings.Join(rp.Roles, ",")
+}
+
+// group returns the group projected from the Google group.
+func (rp RoleProjection) group(grp *admin.Group) *admin.Group {
+	name := rp.Name
+	if name == "" {
+		name = grp.Name + "-" + strings.ToLower(strings.Join(rp.Roles, "-"))
+	}
+
+	return &admin.Group{
+		Id:    grp.Id + roleProjectionIDSeparator + strings.ToLower(rp.roles()),
+		Name:  name,
+		Email: grp.Email,
+	}
+}
+
+// projectGroups returns the groups replacing the groups with role projections by their projections.
+func (i *IdentityProvider) projectGroups(groups []*admin.Group) []*admin.Group {
+	if len(i.roleProjections) == 0 {
+		return groups
+	}
+
+	projected := make([]*admin.Group, 0, len(groups))
+	for _, grp := range groups {
+		found := false
+		for _, rp := range i.roleProjections {
+			if strings.EqualFold(rp.GroupEmail, grp.Email) {
+				projected = append(projected, rp.group(grp))
+				found = true
+			}
+		}
+
+		if !found {
+			projected = append(projected, grp)
+		}
+	}
+
+	return projected
+}
+
+// roleProjection returns the Google group id and the roles of the given group id when it is a role projection.
+func roleProjection(groupID string) (string, string, bool) {
+	id, roles, ok := strings.Cut(groupID, roleProjectionIDSeparator)
+	if !ok {
+		return "", "", false
+	}
+
+	return id, strings.ToUpper(roles), true
+}
+
+// listRoleProjectionsMembers returns the members of the role projections groups by group id,
+// the members of the Google groups projected with the same roles are read at once.
+func (i *IdentityProvider) listRoleProjectionsMembers(ctx context.Context, groupIDs []string) (map[string][]*admin.Member, error) {
+	byRoles := make(map[string][]string)
+	for _, groupID := range groupIDs {
+		_, roles, _ := roleProjection(groupID)
+		byRoles[roles] = append(byRoles[roles], groupID)
+	}
+
+	members := make(map[string][]*admin.Member, len(groupIDs))
+	for roles, projected := range byRoles {
+		ids := make([]string, 0, len(projected))
+		for _, groupID := range projected {
+			id, _, _ := roleProjection(groupID)
+			ids = append(ids, id)
+		}
+
+		batch, err := i.ps.ListGroupMembersBatch(ctx, ids, append(i.membersOptions(), google.WithRoles(roles))...)
+		if err != nil {
+			return nil, fmt.Errorf("idp: error getting group members by role %s: %w", roles, err)
+		}
+
+		for j, groupID := range projected {
+			members[groupID] = batch[ids[j]]
+		}
+	}
+
+	return members, nil
+}
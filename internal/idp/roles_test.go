@@ -0,0 +1,105 @@
+package idp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	mocks "github.com/slashdevops/idp-scim-sync/mocks/idp"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	admin "google.golang.org/api/admin/directory/v1"
+)
+
+func TestParseRoleProjections(t *testing.T) {
+	t.Run("should parse the groups, the roles and the names", func(t *testing.T) {
+		got, err := ParseRoleProjections([]string{
+			"AWS-Admins@example.com:owner=aws-admins-owners",
+			" aws-admins@example.com : MEMBER, manager, member ",
+			"",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []RoleProjection{
+			{GroupEmail: "aws-admins@example.com", Roles: []string{"OWNER"}, Name: "aws-admins-owners"},
+			{GroupEmail: "aws-admins@example.com", Roles: []string{"MEMBER", "MANAGER"}},
+		}, got)
+	})
+
+	t.Run("should return error when the roles are missing", func(t *testing.T) {
+		got, err := ParseRoleProjections([]string{"aws-admins@example.com=aws-admins"})
+		assert.ErrorIs(t, err, ErrInvalidRoleProjection)
+		assert.Nil(t, got)
+	})
+
+	t.Run("should return error when the role is not valid", func(t *testing.T) {
+		got, err := ParseRoleProjections([]string{"aws-admins@example.com:ADMIN"})
+		assert.ErrorIs(t, err, ErrInvalidRoleProjection)
+		assert.Nil(t, got)
+	})
+}
+
+func TestRoleProjections(t *testing.T) {
+	rps := []RoleProjection{
+		{GroupEmail: "aws-admins@example.com", Roles: []string{"OWNER"}, Name: "aws-admins-owners"},
+		{GroupEmail: "aws-admins@example.com", Roles: []string{"MEMBER"}},
+	}
+
+	t.Run("should project the group by role", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		mockDS := mocks.NewMockGoogleProviderService(mockCtrl)
+		mockDS.EXPECT().ListGroups(gomock.Any(), []string{"name:AWS*"}).Return([]*admin.Group{
+			{Id: "g1", Name: "aws-admins", Email: "aws-admins@example.com"},
+			{Id: "g2", Name: "aws-readers", Email: "aws-readers@example.com"},
+		}, nil)
+
+		ip, _ := NewIdentityProvider(mockDS, WithRoleProjections(rps))
+		got, err := ip.GetGroups(context.Background(), []string{"name:AWS*"})
+		assert.NoError(t, err)
+
+		want := model.GroupsResultBuilder().WithResources([]*model.Group{
+			model.GroupBuilder().WithIPID("g1#owner").WithName("aws-admins-owners").WithEmail("aws-admins@example.com").Build(),
+			model.GroupBuilder().WithIPID("g1#member").WithName("aws-admins-member").WithEmail("aws-admins@example.com").Build(),
+			model.GroupBuilder().WithIPID("g2").WithName("aws-readers").WithEmail("aws-readers@example.com").Build(),
+		}).Build()
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("should return the members with the projection roles", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		mockDS := mocks.NewMockGoogleProviderService(mockCtrl)
+		mockDS.EXPECT().ListGroupMembersBatch(gomock.Any(), []string{"g1"}, gomock.Any(), gomock.Any()).Return(map[string][]*admin.Member{
+			"g1": {{Id: "u1", Email: "user.1@mail.com", Status: "ACTIVE", Type: "USER"}},
+		}, nil).Times(2)
+		mockDS.EXPECT().ListGroupMembersBatch(gomock.Any(), []string{"g2"}, gomock.Any()).Return(map[string][]*admin.Member{
+			"g2": {{Id: "u2", Email: "user.2@mail.com", Status: "ACTIVE", Type: "USER"}},
+		}, nil)
+
+		ip, _ := NewIdentityProvider(mockDS, WithRoleProjections(rps))
+
+		gr := model.GroupsResultBuilder().WithResources([]*model.Group{
+			model.GroupBuilder().WithIPID("g1#owner").WithName("aws-admins-owners").Build(),
+			model.GroupBuilder().WithIPID("g1#member").WithName("aws-admins-member").Build(),
+			model.GroupBuilder().WithIPID("g2").WithName("aws-readers").Build(),
+		}).Build()
+
+		got, err := ip.GetGroupsMembers(context.Background(), gr)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, got.Items)
+		for _, gm := range got.Resources {
+			assert.Equal(t, 1, gm.Items, gm.Group.Name)
+		}
+	})
+
+	t.Run("should return the members of a single projection", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		mockDS := mocks.NewMockGoogleProviderService(mockCtrl)
+		mockDS.EXPECT().ListGroupMembersBatch(gomock.Any(), []string{"g1"}, gomock.Any(), gomock.Any()).Return(map[string][]*admin.Member{
+			"g1": {{Id: "u1", Email: "user.1@mail.com", Status: "ACTIVE", Type: "USER"}},
+		}, nil)
+
+		ip, _ := NewIdentityProvider(mockDS, WithRoleProjections(rps))
+		got, err := ip.GetGroupMembers(context.Background(), "g1#owner")
+		assert.NoError(t, err)
+		assert.Equal(t, 1, got.Items)
+	})
+}
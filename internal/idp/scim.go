@@ -0,0 +1,371 @@
+package idp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	"github.com/slashdevops/idp-scim-sync/pkg/scimclient"
+)
+
+// This implement core.IdentityProviderService interface for a SCIM 2.0 service provider
+
+// ErrSCIMServiceNil is returned when the SCIMProviderService is nil.
+var ErrSCIMServiceNil = errors.New("provider: scim service is nil")
+
+//go:generate go tool mockgen -package=mocks -destination=../../mocks/idp/scim_mocks.go -source=scim.go SCIMProviderService
+
+// SCIMProviderService is the interface that wraps the SCIM 2.0 client methods.
+type SCIMProviderService interface {
+	ListGroups(ctx context.Context, filter []string) ([]*scimclient.Group, error)
+	ListUsers(ctx context.Context, filter []string) ([]*scimclient.User, error)
+	ListGroupMembers(ctx context.Context, groupID string) ([]*scimclient.Member, error)
+	GetUser(ctx context.Context, userID string) (*scimclient.User, error)
+}
+
+// SCIMIdentityProvider is the Identity Provider service that implements the core.IdentityProvider interface
+// and consumes the pkg.scimclient methods.
+type SCIMIdentityProvider struct {
+	ps SCIMProviderService
+	providerOptions
+
+	// the group members only include the user ids, the users read to get their emails
+	// are kept to avoid reading them again
+	mu    sync.Mutex
+	users map[string]*scimclient.User
+}
+
+// NewSCIMIdentityProvider returns a new instance of the SCIM Identity Provider service.
+func NewSCIMIdentityProvider(sps SCIMProviderService, opts ...IdentityProviderOption) (*SCIMIdentityProvider, error) {
+	if sps == nil {
+		return nil, ErrSCIMServiceNil
+	}
+
+	ip := &SCIMIdentityProvider{
+		ps:    sps,
+		users: make(map[string]*scimclient.User),
+	}
+
+	for _, opt := range opts {
+		opt(&ip.providerOptions)
+	}
+
+	return ip, nil
+}
+
+// GetGroups returns a list of groups from the SCIM service provider.
+//
+// The filter parameter is a list of SCIM filter expressions, e.g. `displayName sw "AWS"`.
+func (s *SCIMIdentityProvider) GetGroups(ctx context.Context, filter []string) (*model.GroupsResult, error) {
+	sGroups, err := s.ps.ListGroups(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("idp: error getting groups: %w", err)
+	}
+
+	groups := make([]*model.Group, 0, len(sGroups))
+	for _, grp := range sGroups {
+		groups = append(groups, model.GroupBuilder().
+			WithIPID(grp.ID).
+			WithName(grp.DisplayName).
+			Build(),
+		)
+	}
+
+	syncResult := buildGroupsResult(groups)
+	slog.Debug("idp: scim GetGroups()", "groups", syncResult.Items)
+
+	return syncResult, nil
+}
+
+// GetUsers returns a list of users from the SCIM service provider.
+//
+// The filter parameter is a list of SCIM filter expressions, e.g. `active eq true`.
+func (s *SCIMIdentityProvider) GetUsers(ctx context.Context, filter []string) (*model.UsersResult, error) {
+	sUsers, err := s.ps.ListUsers(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("idp: error getting users: %w", err)
+	}
+
+	syncUsers := make([]*model.User, 0, len(sUsers))
+	for _, usr := range sUsers {
+		if u := buildSCIMUser(usr, s.syncFieldSet); u != nil {
+			syncUsers = append(syncUsers, u)
+		}
+	}
+
+	uResult := model.UsersResultBuilder().WithResources(syncUsers).Build()
+	slog.Debug("idp: scim GetUsers()", "users", len(syncUsers))
+
+	return uResult, nil
+}
+
+// GetGroupMembers returns the active members of the group.
+func (s *SCIMIdentityProvider) GetGroupMembers(ctx context.Context, groupID string) (*model.MembersResult, error) {
+	if groupID == "" {
+		return nil, ErrGroupIDNil
+	}
+
+	members, err := s.listGroupMembers(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("idp: error getting group members: %w", err)
+	}
+
+	return model.MembersResultBuilder().WithResources(members).Build(), nil
+}
+
+// GetGroupsMembers returns the active members of the groups.
+func (s *SCIMIdentityProvider) GetGroupsMembers(ctx context.Context, gr *model.GroupsResult) (*model.GroupsMembersResult, error) {
+	gmr, err := listGroupsMembers(ctx, gr, func(ctx context.Context, group *model.Group) ([]*model.Member, error) {
+		return s.listGroupMembers(ctx, group.IPID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Debug("idp: scim GetGroupsMembers()", "groups", gmr.Items)
+
+	return gmr, nil
+}
+
+// GetUsersByGroupsMembers returns the users of the given groups members.
+// The users already read to resolve the group members are not read again.
+func (s *SCIMIdentityProvider) GetUsersByGroupsMembers(ctx context.Context, gmr *model.GroupsMembersResult) (*model.UsersResult, error) {
+	ur, err := getUsersByGroupsMembers(ctx, gmr, func(ctx context.Context, member *model.Member) (*model.User, error) {
+		usr, err := s.getUser(ctx, member.IPID)
+		if err != nil {
+			return nil, err
+		}
+
+		return buildSCIMUser(usr, s.syncFieldSet), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Debug("idp: scim GetUsersByGroupsMembers()", "users", ur.Items)
+
+	return ur, nil
+}
+
+// getUser returns the user from the cache or reads it from the SCIM service provider.
+func (s *SCIMIdentityProvider) getUser(ctx context.Context, userID string) (*scimclient.User, error) {
+	s.mu.Lock()
+	usr, ok := s.users[userID]
+	s.mu.Unlock()
+
+	if ok {
+		return usr, nil
+	}
+
+	usr, err := s.ps.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.users[userID] = usr
+	s.mu.Unlock()
+
+	return usr, nil
+}
+
+// listGroupMembers returns the active users that are members of the given group.
+func (s *SCIMIdentityProvider) listGroupMembers(ctx context.Context, groupID string) ([]*model.Member, error) {
+	sMembers, err := s.ps.ListGroupMembers(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]*model.Member, 0, len(sMembers))
+	for _, m := range sMembers {
+		usr, err := s.getUser(ctx, m.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		if !usr.IsActive() {
+			slog.Warn("idp: member not included in group because user is not active", "id", usr.ID, "userName", usr.UserName, "groupID", groupID)
+			continue
+		}
+
+		members = append(members, model.MemberBuilder().
+			WithIPID(usr.ID).
+			WithEmail(strings.TrimSpace(usr.PrimaryEmail())).
+			WithStatus("ACTIVE").
+			Build(),
+		)
+	}
+
+	return members, nil
+}
+
+// buildSCIMUser builds a User model from a SCIM user.
+// The fields parameter controls which optional user attributes are included.
+func buildSCIMUser(usr *scimclient.User, fields *model.SyncFieldSet) *model.User {
+	if usr == nil {
+		return nil
+	}
+
+	n := usr.Name
+	if n == nil {
+		n = &scimclient.Name{}
+	}
+
+	// these fields are required because the Constrains defined here:
+	// https://docs.aws.amazon.com/singlesignon/latest/developerguide/createuser.html
+	if n.GivenName == "" {
+		slog.Warn("idp: User given name is empty", "id", usr.ID, "userName", usr.UserName)
+		return nil
+	}
+
+	if n.FamilyName == "" {
+		slog.Warn("idp: User family name is empty", "id", usr.ID, "userName", usr.UserName)
+		return nil
+	}
+
+	email := strings.TrimSpace(usr.PrimaryEmail())
+	if email == "" {
+		slog.Warn("idp: User email is empty", "id", usr.ID, "userName", usr.UserName)
+		return nil
+	}
+
+	displayName := usr.DisplayName
+	if displayName == "" {
+		displayName = fmt.Sprintf("%s %s", n.GivenName, n.FamilyName)
+	}
+
+	name := model.NameBuilder().
+		WithGivenName(strings.TrimSpace(n.GivenName)).
+		WithFamilyName(strings.TrimSpace(n.FamilyName)).
+		WithMiddleName(strings.TrimSpace(n.MiddleName)).
+		WithHonorificPrefix(strings.TrimSpace(n.HonorificPrefix)).
+		WithHonorificSuffix(strings.TrimSpace(n.HonorificSuffix)).
+		WithFormatted(strings.TrimSpace(n.Formatted)).
+		Build()
+
+	ub := model.UserBuilder().
+		WithIPID(strings.TrimSpace(usr.ID)).
+		WithUserName(strings.TrimSpace(usr.UserName)).
+		WithDisplayName(strings.TrimSpace(displayName)).
+		WithActive(usr.IsActive()).
+		WithEmail(model.EmailBuilder().WithPrimary(true).WithType("work").WithValue(email).Build()).
+		WithName(name)
+
+	if fields.Includes(model.SyncUserFieldTitle) {
+		ub = ub.WithTitle(strings.TrimSpace(usr.Title))
+	}
+	if fields.Includes(model.SyncUserFieldUserType) {
+		ub = ub.WithUserType(strings.TrimSpace(usr.UserType))
+	}
+	if fields.Includes(model.SyncUserFieldPreferredLanguage) {
+		ub = ub.WithPreferredLanguage(strings.TrimSpace(usr.PreferredLanguage))
+	}
+	if fields.Includes(model.SyncUserFieldLocale) {
+		ub = ub.WithLocale(strings.TrimSpace(usr.Locale))
+	}
+	if fields.Includes(model.SyncUserFieldTimezone) {
+		ub = ub.WithTimezone(strings.TrimSpace(usr.Timezone))
+	}
+	if fields.Includes(model.SyncUserFieldNickName) {
+		ub = ub.WithNickName(strings.TrimSpace(usr.NickName))
+	}
+	if fields.Includes(model.SyncUserFieldProfileURL) {
+		ub = ub.WithProfileURL(strings.TrimSpace(usr.ProfileURL))
+	}
+
+	if fields.Includes(model.SyncUserFieldAddresses) {
+		if addr := primarySCIMAddress(usr.Addresses); addr != nil {
+			formatted := strings.TrimSpace(addr.Formatted)
+			if formatted == "" {
+				parts := make([]string, 0, 5)
+				for _, part := range []string{addr.StreetAddress, addr.Locality, addr.Region, addr.PostalCode, addr.Country} {
+					if part = strings.TrimSpace(part); part != "" {
+						parts = append(parts, part)
+					}
+				}
+				formatted = strings.Join(parts, ", ")
+			}
+
+			if formatted != "" {
+				ub = ub.WithAddress(model.AddressBuilder().
+					WithFormatted(formatted).
+					WithStreetAddress(strings.TrimSpace(addr.StreetAddress)).
+					WithLocality(strings.TrimSpace(addr.Locality)).
+					WithRegion(strings.TrimSpace(addr.Region)).
+					WithPostalCode(strings.TrimSpace(addr.PostalCode)).
+					WithCountry(strings.TrimSpace(addr.Country)).
+					Build(),
+				)
+			}
+		}
+	}
+
+	if fields.Includes(model.SyncUserFieldPhoneNumbers) {
+		if phone := primarySCIMPhoneNumber(usr.PhoneNumbers); phone != nil {
+			phoneType := phone.Type
+			if phoneType == "" {
+				phoneType = "work"
+			}
+			ub = ub.WithPhoneNumber(model.PhoneNumberBuilder().WithValue(strings.TrimSpace(phone.Value)).WithType(phoneType).Build())
+		}
+	}
+
+	if fields.Includes(model.SyncUserFieldEnterpriseData) && usr.EnterpriseUser != nil {
+		eu := usr.EnterpriseUser
+
+		var manager *model.Manager
+		if eu.Manager != nil && eu.Manager.Value != "" {
+			manager = model.ManagerBuilder().WithValue(strings.TrimSpace(eu.Manager.Value)).WithRef(strings.TrimSpace(eu.Manager.Ref)).Build()
+		}
+
+		if eu.EmployeeNumber != "" || eu.CostCenter != "" || eu.Organization != "" || eu.Division != "" || eu.Department != "" || manager != nil {
+			ub = ub.WithEnterpriseData(model.EnterpriseDataBuilder().
+				WithEmployeeNumber(strings.TrimSpace(eu.EmployeeNumber)).
+				WithCostCenter(strings.TrimSpace(eu.CostCenter)).
+				WithOrganization(strings.TrimSpace(eu.Organization)).
+				WithDivision(strings.TrimSpace(eu.Division)).
+				WithDepartment(strings.TrimSpace(eu.Department)).
+				WithManager(manager).
+				Build(),
+			)
+		}
+	}
+
+	return ub.Build()
+}
+
+// primarySCIMAddress returns the primary address, the first one when none is primary.
+func primarySCIMAddress(addresses []*scimclient.Address) *scimclient.Address {
+	for _, a := range addresses {
+		if a.Primary {
+			return a
+		}
+	}
+
+	if len(addresses) > 0 {
+		return addresses[0]
+	}
+
+	return nil
+}
+
+// primarySCIMPhoneNumber returns the primary phone number, the first one with value when none is primary.
+func primarySCIMPhoneNumber(phones []*scimclient.PhoneNumber) *scimclient.PhoneNumber {
+	for _, p := range phones {
+		if p.Primary && strings.TrimSpace(p.Value) != "" {
+			return p
+		}
+	}
+
+	for _, p := range phones {
+		if strings.TrimSpace(p.Value) != "" {
+			return p
+		}
+	}
+
+	return nil
+}
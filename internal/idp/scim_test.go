@@ -0,0 +1,164 @@
+package idp
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	mocks "github.com/slashdevops/idp-scim-sync/mocks/idp"
+	"github.com/slashdevops/idp-scim-sync/pkg/scimclient"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestNewSCIMIdentityProvider(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+
+	t.Run("Should return SCIMIdentityProvider and no error", func(t *testing.T) {
+		svc, err := NewSCIMIdentityProvider(mocks.NewMockSCIMProviderService(mockCtrl))
+		assert.NoError(t, err)
+		assert.NotNil(t, svc)
+	})
+
+	t.Run("Should return an error if no service is provided", func(t *testing.T) {
+		svc, err := NewSCIMIdentityProvider(nil)
+		assert.ErrorIs(t, err, ErrSCIMServiceNil)
+		assert.Nil(t, svc)
+	})
+}
+
+func TestSCIMGetGroups(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+
+	t.Run("Should return the groups", func(t *testing.T) {
+		mockSvc := mocks.NewMockSCIMProviderService(mockCtrl)
+		mockSvc.EXPECT().ListGroups(gomock.Any(), []string{`displayName sw "AWS"`}).Return([]*scimclient.Group{
+			{ID: "g1", DisplayName: "AWS-1"},
+			{ID: "g2", DisplayName: "AWS-2"},
+		}, nil)
+
+		ip, _ := NewSCIMIdentityProvider(mockSvc)
+		got, err := ip.GetGroups(context.Background(), []string{`displayName sw "AWS"`})
+		assert.NoError(t, err)
+
+		want := model.GroupsResultBuilder().WithResources([]*model.Group{
+			model.GroupBuilder().WithIPID("g1").WithName("AWS-1").Build(),
+			model.GroupBuilder().WithIPID("g2").WithName("AWS-2").Build(),
+		}).Build()
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("Should return error", func(t *testing.T) {
+		mockSvc := mocks.NewMockSCIMProviderService(mockCtrl)
+		mockSvc.EXPECT().ListGroups(gomock.Any(), nil).Return(nil, errors.New("test error"))
+
+		ip, _ := NewSCIMIdentityProvider(mockSvc)
+		got, err := ip.GetGroups(context.Background(), nil)
+		assert.Error(t, err)
+		assert.Nil(t, got)
+	})
+}
+
+func TestSCIMGroupsMembersAndUsers(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+
+	inactive := false
+	alice := &scimclient.User{ID: "u1", UserName: "alice", Name: &scimclient.Name{GivenName: "Alice", FamilyName: "Smith"}, Emails: []*scimclient.Email{{Value: "alice@example.com", Primary: true}}}
+	bob := &scimclient.User{ID: "u2", UserName: "bob@example.com", Name: &scimclient.Name{GivenName: "Bob", FamilyName: "Jones"}}
+	carol := &scimclient.User{ID: "u3", UserName: "carol@example.com", Name: &scimclient.Name{GivenName: "Carol", FamilyName: "Brown"}, Active: &inactive}
+
+	g1 := model.GroupBuilder().WithIPID("g1").WithName("AWS-1").Build()
+	gr := model.GroupsResultBuilder().WithResources([]*model.Group{g1}).Build()
+
+	t.Run("Should return the active members and not read the users again", func(t *testing.T) {
+		mockSvc := mocks.NewMockSCIMProviderService(mockCtrl)
+		mockSvc.EXPECT().ListGroupMembers(gomock.Any(), "g1").Return([]*scimclient.Member{{Value: "u1"}, {Value: "u2"}, {Value: "u3"}}, nil)
+		mockSvc.EXPECT().GetUser(gomock.Any(), "u1").Return(alice, nil).Times(1)
+		mockSvc.EXPECT().GetUser(gomock.Any(), "u2").Return(bob, nil).Times(1)
+		mockSvc.EXPECT().GetUser(gomock.Any(), "u3").Return(carol, nil).Times(1)
+
+		ip, _ := NewSCIMIdentityProvider(mockSvc)
+		gmr, err := ip.GetGroupsMembers(context.Background(), gr)
+		assert.NoError(t, err)
+
+		want := model.GroupsMembersResultBuilder().WithResources([]*model.GroupMembers{
+			model.GroupMembersBuilder().WithGroup(g1).WithResources([]*model.Member{
+				model.MemberBuilder().WithIPID("u1").WithEmail("alice@example.com").WithStatus("ACTIVE").Build(),
+				model.MemberBuilder().WithIPID("u2").WithEmail("bob@example.com").WithStatus("ACTIVE").Build(),
+			}).Build(),
+		}).Build()
+		assert.Equal(t, want, gmr)
+
+		got, err := ip.GetUsersByGroupsMembers(context.Background(), gmr)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []*model.User{buildSCIMUser(alice, nil), buildSCIMUser(bob, nil)}, got.Resources)
+	})
+
+	t.Run("Should return error", func(t *testing.T) {
+		mockSvc := mocks.NewMockSCIMProviderService(mockCtrl)
+		mockSvc.EXPECT().ListGroupMembers(gomock.Any(), "g1").Return([]*scimclient.Member{{Value: "u1"}}, nil)
+		mockSvc.EXPECT().GetUser(gomock.Any(), "u1").Return(nil, errors.New("test error"))
+
+		ip, _ := NewSCIMIdentityProvider(mockSvc)
+		got, err := ip.GetGroupsMembers(context.Background(), gr)
+		assert.Error(t, err)
+		assert.Nil(t, got)
+	})
+
+	t.Run("Should return error when the group id is empty", func(t *testing.T) {
+		ip, _ := NewSCIMIdentityProvider(mocks.NewMockSCIMProviderService(mockCtrl))
+		got, err := ip.GetGroupMembers(context.Background(), "")
+		assert.ErrorIs(t, err, ErrGroupIDNil)
+		assert.Nil(t, got)
+	})
+}
+
+func TestBuildSCIMUser(t *testing.T) {
+	usr := &scimclient.User{
+		ID:       "u1",
+		UserName: "alice",
+		Name:     &scimclient.Name{GivenName: "Alice", FamilyName: "Smith"},
+		Title:    "Engineer",
+		Emails: []*scimclient.Email{
+			{Value: "alice@home.example", Type: "home"},
+			{Value: "alice@example.com", Type: "work", Primary: true},
+		},
+		PhoneNumbers: []*scimclient.PhoneNumber{{Value: "+1 555 0100"}},
+		Addresses:    []*scimclient.Address{{Locality: "Madrid", Country: "ES"}},
+		EnterpriseUser: &scimclient.EnterpriseUser{
+			EmployeeNumber: "42",
+			Manager:        &scimclient.Manager{Value: "u2"},
+		},
+	}
+
+	t.Run("Should include all the fields", func(t *testing.T) {
+		got := buildSCIMUser(usr, nil)
+
+		assert.Equal(t, "u1", got.IPID)
+		assert.Equal(t, "alice", got.UserName)
+		assert.Equal(t, "Alice Smith", got.DisplayName)
+		assert.Equal(t, "alice@example.com", got.Emails[0].Value)
+		assert.True(t, got.Active)
+		assert.Equal(t, "Engineer", got.Title)
+		assert.Equal(t, model.PhoneNumber{Value: "+1 555 0100", Type: "work"}, got.PhoneNumbers[0])
+		assert.Equal(t, "Madrid, ES", got.Addresses[0].Formatted)
+		assert.Equal(t, "42", got.EnterpriseData.EmployeeNumber)
+		assert.Equal(t, "u2", got.EnterpriseData.Manager.Value)
+	})
+
+	t.Run("Should include only the given fields", func(t *testing.T) {
+		got := buildSCIMUser(usr, model.NewSyncFieldSet([]string{"title"}))
+
+		assert.Equal(t, "Engineer", got.Title)
+		assert.Nil(t, got.PhoneNumbers)
+		assert.Nil(t, got.Addresses)
+		assert.Nil(t, got.EnterpriseData)
+	})
+
+	t.Run("Should return nil when the required fields are missing", func(t *testing.T) {
+		assert.Nil(t, buildSCIMUser(nil, nil))
+		assert.Nil(t, buildSCIMUser(&scimclient.User{ID: "u1", UserName: "alice"}, nil))
+		assert.Nil(t, buildSCIMUser(&scimclient.User{ID: "u1", UserName: "alice", Name: &scimclient.Name{GivenName: "Alice", FamilyName: "Smith"}}, nil))
+	})
+}
@@ -0,0 +1,51 @@
+package idp
+
+import (
+	"errors"
+	"fmt"
+)
+
+// SuspendedUsersPolicy defines how the suspended and archived Google Workspace users are synced.
+type SuspendedUsersPolicy string
+
+const (
+	// SuspendedUsersPolicyDrop doesn't sync the suspended and archived users, they are removed from AWS.
+	SuspendedUsersPolicyDrop SuspendedUsersPolicy = "drop"
+
+	// SuspendedUsersPolicyDeactivate syncs the suspended and archived users as inactive users
+	// keeping their group memberships.
+	SuspendedUsersPolicyDeactivate SuspendedUsersPolicy = "deactivate"
+
+	// SuspendedUsersPolicyDeactivateUngroup syncs the suspended and archived users as inactive users
+	// and removes them from the groups, the sync service removes the memberships.
+	SuspendedUsersPolicyDeactivateUngroup SuspendedUsersPolicy = "deactivate-ungroup"
+)
+
+// ErrInvalidSuspendedUsersPolicy is returned when the suspended users policy is not supported.
+var ErrInvalidSuspendedUsersPolicy = errors.New("provider: invalid suspended users policy")
+
+// ParseSuspendedUsersPolicy returns the suspended users policy of the given name, drop when empty.
+func ParseSuspendedUsersPolicy(name string) (SuspendedUsersPolicy, error) {
+	switch p := SuspendedUsersPolicy(name); p {
+	case "":
+		return SuspendedUsersPolicyDrop, nil
+	case SuspendedUsersPolicyDrop, SuspendedUsersPolicyDeactivate, SuspendedUsersPolicyDeactivateUngroup:
+		return p, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidSuspendedUsersPolicy, name)
+	}
+}
+
+// WithSuspendedUsersPolicy configures how the Google Workspace identity provider syncs the suspended
+// and archived users.
+func WithSuspendedUsersPolicy(policy SuspendedUsersPolicy) IdentityProviderOption {
+	return func(po *providerOptions) {
+		po.suspendedUsersPolicy = policy
+	}
+}
+
+// includeInactiveMembers returns true when the suspended and archived users are synced as group members.
+func (i *IdentityProvider) includeInactiveMembers() bool {
+	return i.suspendedUsersPolicy == SuspendedUsersPolicyDeactivate ||
+		i.suspendedUsersPolicy == SuspendedUsersPolicyDeactivateUngroup
+}
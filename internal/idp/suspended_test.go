@@ -0,0 +1,85 @@
+package idp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	mocks "github.com/slashdevops/idp-scim-sync/mocks/idp"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	admin "google.golang.org/api/admin/directory/v1"
+)
+
+func TestParseSuspendedUsersPolicy(t *testing.T) {
+	got, err := ParseSuspendedUsersPolicy("")
+	assert.NoError(t, err)
+	assert.Equal(t, SuspendedUsersPolicyDrop, got)
+
+	got, err = ParseSuspendedUsersPolicy("deactivate-ungroup")
+	assert.NoError(t, err)
+	assert.Equal(t, SuspendedUsersPolicyDeactivateUngroup, got)
+
+	got, err = ParseSuspendedUsersPolicy("keep")
+	assert.ErrorIs(t, err, ErrInvalidSuspendedUsersPolicy)
+	assert.Empty(t, got)
+}
+
+func TestSuspendedUsersPolicy(t *testing.T) {
+	ougs := []OrgUnitGroup{{Path: "/Staff", Name: "Staff"}}
+	users := []*admin.User{
+		{Id: "1", PrimaryEmail: "alice@mail.com", Name: &admin.UserName{GivenName: "Alice", FamilyName: "Smith"}},
+		{Id: "2", PrimaryEmail: "bob@mail.com", Suspended: true, Name: &admin.UserName{GivenName: "Bob", FamilyName: "Jones"}},
+		{Id: "3", PrimaryEmail: "carol@mail.com", Archived: true, Name: &admin.UserName{GivenName: "Carol", FamilyName: "Brown"}},
+	}
+
+	group := model.GroupBuilder().WithIPID("orgunit:/Staff").WithName("Staff").Build()
+	gr := model.GroupsResultBuilder().WithResources([]*model.Group{group}).Build()
+
+	t.Run("drop should not return the suspended and archived users", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		mockDS := mocks.NewMockGoogleProviderService(mockCtrl)
+		mockDS.EXPECT().ListOrgUnitUsers(gomock.Any(), "/Staff").Return(users, nil)
+
+		ip, _ := NewIdentityProvider(mockDS, WithOrgUnitGroups(ougs))
+
+		gmr, err := ip.GetGroupsMembers(context.Background(), gr)
+		assert.NoError(t, err)
+		assert.Equal(t, []*model.Member{
+			model.MemberBuilder().WithIPID("1").WithEmail("alice@mail.com").WithStatus("ACTIVE").Build(),
+		}, gmr.Resources[0].Resources)
+	})
+
+	t.Run("deactivate should return the suspended and archived users as inactive", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		mockDS := mocks.NewMockGoogleProviderService(mockCtrl)
+		mockDS.EXPECT().ListOrgUnitUsers(gomock.Any(), "/Staff").Return(users, nil)
+
+		ip, _ := NewIdentityProvider(mockDS, WithOrgUnitGroups(ougs), WithSuspendedUsersPolicy(SuspendedUsersPolicyDeactivate))
+
+		gmr, err := ip.GetGroupsMembers(context.Background(), gr)
+		assert.NoError(t, err)
+		assert.Equal(t, []*model.Member{
+			model.MemberBuilder().WithIPID("1").WithEmail("alice@mail.com").WithStatus("ACTIVE").Build(),
+			model.MemberBuilder().WithIPID("2").WithEmail("bob@mail.com").WithStatus("SUSPENDED").Build(),
+			model.MemberBuilder().WithIPID("3").WithEmail("carol@mail.com").WithStatus("ARCHIVED").Build(),
+		}, gmr.Resources[0].Resources)
+
+		ur, err := ip.GetUsersByGroupsMembers(context.Background(), gmr)
+		assert.NoError(t, err)
+
+		active := make(map[string]bool, ur.Items)
+		for _, u := range ur.Resources {
+			active[u.UserName] = u.Active
+		}
+		assert.Equal(t, map[string]bool{"alice@mail.com": true, "bob@mail.com": false, "carol@mail.com": false}, active)
+	})
+
+	t.Run("deactivate should list the inactive members of the google groups", func(t *testing.T) {
+		ip, _ := NewIdentityProvider(mocks.NewMockGoogleProviderService(gomock.NewController(t)), WithSuspendedUsersPolicy(SuspendedUsersPolicyDeactivateUngroup))
+		assert.Len(t, ip.membersOptions(), 2)
+
+		ip, _ = NewIdentityProvider(mocks.NewMockGoogleProviderService(gomock.NewController(t)))
+		assert.Len(t, ip.membersOptions(), 1)
+	})
+}
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"encoding/gob"
 	"encoding/json"
+	"errors"
+	"io"
 	"sort"
 
 	"github.com/slashdevops/idp-scim-sync/internal/deepcopy"
@@ -16,6 +18,10 @@ type Member struct {
 	Email    string `json:"email,omitempty"`
 	Status   string `json:"status,omitempty"`
 	HashCode string `json:"hashCode,omitempty"`
+
+	// Via is the lineage of the membership when it is inherited from nested groups,
+	// the names of the nested groups from the outermost to the one the member belongs to.
+	Via []string `json:"via,omitempty"`
 }
 
 // MarshalBinary implements the gob.GobEncoder interface for Member entity.
@@ -38,6 +44,13 @@ func (m Member) MarshalBinary() ([]byte, error) {
 		return nil, err
 	}
 
+	// the lineage is encoded only when exists to keep the hash code of the direct members
+	if len(m.Via) > 0 {
+		if err := enc.Encode(m.Via); err != nil {
+			return nil, err
+		}
+	}
+
 	return buf.Bytes(), nil
 }
 
@@ -61,6 +74,10 @@ func (m *Member) UnmarshalBinary(data []byte) error {
 		return err
 	}
 
+	if err := dec.Decode(&m.Via); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
 	return nil
 }
 
@@ -36,6 +36,12 @@ func (b *MemberBuilderChoice) WithStatus(status string) *MemberBuilderChoice {
 	return b
 }
 
+// WithVia sets the Via field of the Member entity.
+func (b *MemberBuilderChoice) WithVia(via []string) *MemberBuilderChoice {
+	b.m.Via = via
+	return b
+}
+
 // Build returns the Member entity.
 func (b *MemberBuilderChoice) Build() *Member {
 	b.m.SetHashCode()
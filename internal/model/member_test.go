@@ -36,6 +36,15 @@ func TestMember_GobEncode(t *testing.T) {
 				HashCode: "",
 			},
 		},
+		{
+			name: "nested",
+			toTest: Member{
+				IPID:   "1",
+				Email:  "user.1@mail.com",
+				Status: "ACTIVE",
+				Via:    []string{"group 2", "group 3"},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -59,6 +68,7 @@ func TestMember_GobEncode(t *testing.T) {
 				IPID:   tt.toTest.IPID,
 				Email:  tt.toTest.Email,
 				Status: tt.toTest.Status,
+				Via:    tt.toTest.Via,
 			}
 
 			sort := func(x, y string) bool { return x > y }
@@ -103,6 +113,17 @@ func TestMember_SetHashCode(t *testing.T) {
 			}
 		})
 	}
+
+	t.Run("lineage changes the hash code", func(t *testing.T) {
+		direct := Member{IPID: "1", Email: "user.1@mail.com", Status: "ACTIVE"}
+		nested := Member{IPID: "1", Email: "user.1@mail.com", Status: "ACTIVE", Via: []string{"group 2"}}
+		direct.SetHashCode()
+		nested.SetHashCode()
+
+		if direct.HashCode == nested.HashCode {
+			t.Errorf("Member.SetHashCode() = %s, the lineage must change the hash code", nested.HashCode)
+		}
+	})
 }
 
 func TestMembersResult_GobEncode(t *testing.T) {
@@ -52,11 +52,11 @@ func MembersOperations(idp, scim *GroupsMembersResult) (create, equal, remove *G
 }
 
 // GroupsOperations returns the differences between the groups in the
-// this use the Groups Name as the key.
-// SCIM Groups cannot be updated.
+// this use the Groups IPID as the key and the Groups Name when the IPID does not match,
+// so the groups renamed in the idp keep their SCIM ID and are updated.
 // return 4 objet of GroupsResult
 // create: groups that exist in "idp" but not in "scim" or "state"
-// update: groups that exist in "idp" and in "scim" or "state" but attributes changed in idp
+// update: groups that exist in "idp" and in "scim" or "state" but attributes changed in idp, e.g. renamed
 // equal: groups that exist in both "idp" and "scim" or "state" and their attributes are equal
 // remove: groups that exist in "scim" or "state" but not in "idp"
 //
@@ -72,38 +72,70 @@ func GroupsOperations(idp, scim *GroupsResult) (create, update, equal, remove *G
 	}
 
 	idpGroups := make(map[string]struct{})
-	scimGroups := make(map[string]Group)
+	scimGroups := make(map[string]*Group)
+	scimGroupsByIPID := make(map[string]*Group)
+
+	// scim groups matched by IPID, they are not matched by name with other groups
+	matchedByIPID := make(map[*Group]struct{})
+	idpMatches := make([]*Group, len(idp.Resources))
 
 	toCreate := make([]*Group, 0)
 	toUpdate := make([]*Group, 0)
 	toEqual := make([]*Group, 0)
 	toRemove := make([]*Group, 0)
 
-	for _, gr := range idp.Resources {
-		idpGroups[gr.Name] = struct{}{}
+	for _, gr := range scim.Resources {
+		scimGroups[gr.Name] = gr
+		if gr.IPID != "" {
+			scimGroupsByIPID[gr.IPID] = gr
+		}
 	}
 
-	for _, gr := range scim.Resources {
-		scimGroups[gr.Name] = *gr
+	for i, gr := range idp.Resources {
+		if gr.IPID == "" {
+			continue
+		}
+		if sg, ok := scimGroupsByIPID[gr.IPID]; ok {
+			idpMatches[i] = sg
+			matchedByIPID[sg] = struct{}{}
+		}
+	}
+
+	for i, gr := range idp.Resources {
+		if idpMatches[i] != nil {
+			continue
+		}
+
+		idpGroups[gr.Name] = struct{}{}
+		if sg, ok := scimGroups[gr.Name]; ok {
+			if _, matched := matchedByIPID[sg]; !matched {
+				idpMatches[i] = sg
+			}
+		}
 	}
 
 	// loop over idp to see what to create and what to update
-	for _, group := range idp.Resources {
-		if _, ok := scimGroups[group.Name]; !ok {
+	for i, group := range idp.Resources {
+		scimGroup := idpMatches[i]
+		if scimGroup == nil {
 			toCreate = append(toCreate, group)
-		} else {
-			group.SCIMID = scimGroups[group.Name].SCIMID
+			continue
+		}
 
-			if group.IPID != scimGroups[group.Name].IPID {
-				toUpdate = append(toUpdate, group)
-			} else {
-				toEqual = append(toEqual, group)
-			}
+		group.SCIMID = scimGroup.SCIMID
+
+		if group.IPID != scimGroup.IPID || group.Name != scimGroup.Name {
+			toUpdate = append(toUpdate, group)
+		} else {
+			toEqual = append(toEqual, group)
 		}
 	}
 
 	// loop over scim to see what to remove
 	for _, group := range scim.Resources {
+		if _, ok := matchedByIPID[group]; ok {
+			continue
+		}
 		if _, ok := idpGroups[group.Name]; !ok {
 			toRemove = append(toRemove, group)
 		}
@@ -118,9 +150,11 @@ func GroupsOperations(idp, scim *GroupsResult) (create, update, equal, remove *G
 }
 
 // UsersOperations returns datasets used to perform different operations over the SCIM side
+// this use the Users IPID as the key and the Users primary email when the IPID does not match,
+// so the users renamed in the idp, e.g. with a new email, keep their SCIM ID and are updated.
 // return 4 objet of UsersResult
 // create: users that exist in "idp" but not in "scim" or "state"
-// update: users that exist in "idp" and in "scim" or "state" but attributes changed in idp
+// update: users that exist in "idp" and in "scim" or "state" but attributes changed in idp, e.g. renamed
 // equal: users that exist in both "idp" and "scim" or "state" and their attributes are equal
 // remove: users that exist in "scim" or "state" but not in "idp"
 func UsersOperations(idp, scim *UsersResult) (create, update, equal, remove *UsersResult, err error) {
@@ -134,39 +168,70 @@ func UsersOperations(idp, scim *UsersResult) (create, update, equal, remove *Use
 	}
 
 	idpUsers := make(map[string]struct{})
-	scimUsers := make(map[string]User)
+	scimUsers := make(map[string]*User)
+	scimUsersByIPID := make(map[string]*User)
+
+	// scim users matched by IPID, they are not matched by email with other users
+	matchedByIPID := make(map[*User]struct{})
+	idpMatches := make([]*User, len(idp.Resources))
 
 	toCreate := make([]*User, 0)
 	toUpdate := make([]*User, 0)
 	toEqual := make([]*User, 0)
 	toRemove := make([]*User, 0)
 
-	for _, usr := range idp.Resources {
-		idpUsers[usr.GetPrimaryEmailAddress()] = struct{}{}
+	for _, usr := range scim.Resources {
+		scimUsers[usr.GetPrimaryEmailAddress()] = usr
+		if usr.IPID != "" {
+			scimUsersByIPID[usr.IPID] = usr
+		}
 	}
 
-	for _, usr := range scim.Resources {
-		scimUsers[usr.GetPrimaryEmailAddress()] = *usr
+	for i, usr := range idp.Resources {
+		if usr.IPID == "" {
+			continue
+		}
+		if su, ok := scimUsersByIPID[usr.IPID]; ok {
+			idpMatches[i] = su
+			matchedByIPID[su] = struct{}{}
+		}
 	}
 
-	// new users and what equal to them
-	for _, usr := range idp.Resources {
+	for i, usr := range idp.Resources {
+		if idpMatches[i] != nil {
+			continue
+		}
+
 		primaryEmail := usr.GetPrimaryEmailAddress()
+		idpUsers[primaryEmail] = struct{}{}
+		if su, ok := scimUsers[primaryEmail]; ok {
+			if _, matched := matchedByIPID[su]; !matched {
+				idpMatches[i] = su
+			}
+		}
+	}
 
-		if _, ok := scimUsers[primaryEmail]; !ok {
+	// new users and what equal to them
+	for i, usr := range idp.Resources {
+		scimUser := idpMatches[i]
+		if scimUser == nil {
 			toCreate = append(toCreate, usr)
-		} else {
-			usr.SCIMID = scimUsers[primaryEmail].SCIMID
+			continue
+		}
 
-			if usr.HashCode != scimUsers[primaryEmail].HashCode {
-				toUpdate = append(toUpdate, usr)
-			} else {
-				toEqual = append(toEqual, usr)
-			}
+		usr.SCIMID = scimUser.SCIMID
+
+		if usr.HashCode != scimUser.HashCode {
+			toUpdate = append(toUpdate, usr)
+		} else {
+			toEqual = append(toEqual, usr)
 		}
 	}
 
 	for _, usr := range scim.Resources {
+		if _, ok := matchedByIPID[usr]; ok {
+			continue
+		}
 		if _, ok := idpUsers[usr.GetPrimaryEmailAddress()]; !ok {
 			toRemove = append(toRemove, usr)
 		}
@@ -271,6 +336,7 @@ func UpdateGroupsMembersSCIMID(idp *GroupsMembersResult, scimGroups *GroupsResul
 				WithSCIMID(users[member.Email].SCIMID).
 				WithEmail(member.Email).
 				WithStatus(member.Status).
+				WithVia(member.Via).
 				Build()
 
 			mbs = append(mbs, m)
@@ -298,6 +364,38 @@ func membersDataSets(idp, scim []*GroupMembers) (create, equal, remove []*GroupM
 	scimMemberSet := make(map[string]map[string]Member)
 	scimGroupsSet := make(map[string]Group)
 
+	// the groups renamed in the idp keep their SCIM ID, their scim members
+	// are compared with the members of the group with the new name
+	idpNames := make(map[string]string)
+	for _, grpMembers := range idp {
+		if grpMembers.Group.SCIMID != "" {
+			idpNames[grpMembers.Group.SCIMID] = grpMembers.Group.Name
+		}
+	}
+	scimName := func(g *Group) string {
+		if name, ok := idpNames[g.SCIMID]; ok && g.SCIMID != "" {
+			return name
+		}
+		return g.Name
+	}
+
+	// the same for the users renamed in the idp, e.g. with a new email,
+	// their scim memberships are compared with the memberships of the new email
+	idpEmails := make(map[string]string)
+	for _, grpMembers := range idp {
+		for _, member := range grpMembers.Resources {
+			if member.SCIMID != "" {
+				idpEmails[member.SCIMID] = member.Email
+			}
+		}
+	}
+	scimEmail := func(m *Member) string {
+		if email, ok := idpEmails[m.SCIMID]; ok && m.SCIMID != "" {
+			return email
+		}
+		return m.Email
+	}
+
 	for _, grpMembers := range idp {
 		idpMemberSet[grpMembers.Group.Name] = make(map[string]Member)
 		for _, member := range grpMembers.Resources {
@@ -306,10 +404,11 @@ func membersDataSets(idp, scim []*GroupMembers) (create, equal, remove []*GroupM
 	}
 
 	for _, grpMembers := range scim {
-		scimGroupsSet[grpMembers.Group.Name] = *grpMembers.Group
-		scimMemberSet[grpMembers.Group.Name] = make(map[string]Member)
+		name := scimName(grpMembers.Group)
+		scimGroupsSet[name] = *grpMembers.Group
+		scimMemberSet[name] = make(map[string]Member)
 		for _, member := range grpMembers.Resources {
-			scimMemberSet[grpMembers.Group.Name][member.Email] = *member
+			scimMemberSet[name][scimEmail(member)] = *member
 		}
 	}
 
@@ -379,7 +478,7 @@ func membersDataSets(idp, scim []*GroupMembers) (create, equal, remove []*GroupM
 		toD[grpMembers.Group.Name] = make([]*Member, 0)
 
 		for _, member := range grpMembers.Resources {
-			if _, ok := idpMemberSet[grpMembers.Group.Name][member.Email]; !ok {
+			if _, ok := idpMemberSet[scimName(grpMembers.Group)][scimEmail(member)]; !ok {
 				toD[grpMembers.Group.Name] = append(toD[grpMembers.Group.Name], member)
 			}
 		}
@@ -214,6 +214,35 @@ func TestGroupsOperations(t *testing.T) {
 			wantDelete: GroupsResultBuilder().Build(),
 			wantErr:    false,
 		},
+		{
+			name: "1 rename, matched by IPID",
+			args: args{
+				idp: GroupsResultBuilder().WithResources(
+					[]*Group{
+						GroupBuilder().WithIPID("1").WithName("new name1").WithEmail("1@mail.com").Build(),
+						GroupBuilder().WithIPID("2").WithName("name1").WithEmail("2@mail.com").Build(),
+					},
+				).Build(),
+				state: GroupsResultBuilder().WithResources(
+					[]*Group{
+						GroupBuilder().WithIPID("1").WithSCIMID("11").WithName("name1").WithEmail("1@mail.com").Build(),
+					},
+				).Build(),
+			},
+			wantCreate: GroupsResultBuilder().WithResources(
+				[]*Group{
+					GroupBuilder().WithIPID("2").WithName("name1").WithEmail("2@mail.com").Build(),
+				},
+			).Build(),
+			wantUpdate: GroupsResultBuilder().WithResources(
+				[]*Group{
+					GroupBuilder().WithIPID("1").WithSCIMID("11").WithName("new name1").WithEmail("1@mail.com").Build(),
+				},
+			).Build(),
+			wantEqual:  GroupsResultBuilder().Build(),
+			wantDelete: GroupsResultBuilder().Build(),
+			wantErr:    false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -1850,3 +1879,114 @@ func TestUpdateGroupsMembersSCIMID(t *testing.T) {
 		assert.Equal(t, "ACTIVE", got.Resources[1].Resources[2].Status)
 	})
 }
+
+func TestMembersDataSets_RenamedGroup(t *testing.T) {
+	idp := []*GroupMembers{
+		{
+			Group: &Group{IPID: "1", SCIMID: "11", Name: "new group 1"},
+			Resources: []*Member{
+				MemberBuilder().WithIPID("1").WithSCIMID("1").WithEmail("user.1@mail.com").Build(),
+				MemberBuilder().WithIPID("2").WithSCIMID("2").WithEmail("user.2@mail.com").Build(),
+			},
+		},
+	}
+	state := []*GroupMembers{
+		{
+			Group: &Group{IPID: "1", SCIMID: "11", Name: "group 1"},
+			Resources: []*Member{
+				MemberBuilder().WithIPID("1").WithSCIMID("1").WithEmail("user.1@mail.com").Build(),
+				MemberBuilder().WithIPID("3").WithSCIMID("3").WithEmail("user.3@mail.com").Build(),
+			},
+		},
+	}
+
+	// the members of the renamed group are compared by its SCIM ID, not removed and added again
+	gotCreate, gotEqual, gotDelete := membersDataSets(idp, state)
+
+	emails := func(gms []*GroupMembers) []string {
+		var e []string
+		for _, gm := range gms {
+			for _, m := range gm.Resources {
+				e = append(e, gm.Group.SCIMID+":"+m.Email)
+			}
+		}
+		return e
+	}
+
+	if diff := cmp.Diff([]string{"11:user.2@mail.com"}, emails(gotCreate)); diff != "" {
+		t.Errorf("membersDataSets() gotCreate (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"11:user.1@mail.com"}, emails(gotEqual)); diff != "" {
+		t.Errorf("membersDataSets() gotEqual (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"11:user.3@mail.com"}, emails(gotDelete)); diff != "" {
+		t.Errorf("membersDataSets() gotDelete (-want +got):\n%s", diff)
+	}
+}
+
+func TestUsersOperations_Renamed(t *testing.T) {
+	user := func(ipid, scimid, email string) *User {
+		return UserBuilder().
+			WithIPID(ipid).
+			WithSCIMID(scimid).
+			WithUserName(email).
+			WithEmail(EmailBuilder().WithValue(email).WithType("Work").WithPrimary(true).Build()).
+			WithName(&Name{FamilyName: "1", GivenName: "user"}).
+			WithDisplayName("user 1").
+			WithActive(true).
+			Build()
+	}
+
+	idp := UsersResultBuilder().WithResources([]*User{
+		user("1", "", "user.new@mail.com"),
+		user("2", "", "user.old@mail.com"),
+	}).Build()
+	state := UsersResultBuilder().WithResources([]*User{
+		user("1", "11", "user.old@mail.com"),
+	}).Build()
+
+	gotCreate, gotUpdate, gotEqual, gotDelete, err := UsersOperations(idp, state)
+	if err != nil {
+		t.Fatalf("UsersOperations() error = %v", err)
+	}
+
+	// the renamed user keeps its SCIM ID, the new user with its previous email is created
+	if gotUpdate.Items != 1 || gotUpdate.Resources[0].SCIMID != "11" || gotUpdate.Resources[0].GetPrimaryEmailAddress() != "user.new@mail.com" {
+		t.Errorf("UsersOperations() gotUpdate = %+v", gotUpdate.Resources)
+	}
+	if gotCreate.Items != 1 || gotCreate.Resources[0].IPID != "2" {
+		t.Errorf("UsersOperations() gotCreate = %+v", gotCreate.Resources)
+	}
+	if gotEqual.Items != 0 || gotDelete.Items != 0 {
+		t.Errorf("UsersOperations() gotEqual = %d, gotDelete = %d, want 0", gotEqual.Items, gotDelete.Items)
+	}
+}
+
+func TestMembersDataSets_RenamedUser(t *testing.T) {
+	idp := []*GroupMembers{
+		{
+			Group: &Group{IPID: "1", SCIMID: "11", Name: "group 1"},
+			Resources: []*Member{
+				MemberBuilder().WithIPID("1").WithSCIMID("1").WithEmail("user.new@mail.com").Build(),
+			},
+		},
+	}
+	state := []*GroupMembers{
+		{
+			Group: &Group{IPID: "1", SCIMID: "11", Name: "group 1"},
+			Resources: []*Member{
+				MemberBuilder().WithIPID("1").WithSCIMID("1").WithEmail("user.old@mail.com").Build(),
+			},
+		},
+	}
+
+	// the membership of the renamed user is kept with its new email
+	gotCreate, gotEqual, gotDelete := membersDataSets(idp, state)
+
+	if len(gotCreate) != 0 || len(gotDelete) != 0 {
+		t.Errorf("membersDataSets() gotCreate = %d, gotDelete = %d, want 0", len(gotCreate), len(gotDelete))
+	}
+	if len(gotEqual) != 1 || gotEqual[0].Resources[0].Email != "user.new@mail.com" {
+		t.Errorf("membersDataSets() gotEqual = %+v", gotEqual)
+	}
+}
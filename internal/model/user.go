@@ -4,6 +4,10 @@ import (
 	"bytes"
 	"encoding/gob"
 	"encoding/json"
+	"errors"
+	"io"
+	"maps"
+	"slices"
 	"sort"
 
 	"github.com/slashdevops/idp-scim-sync/internal/deepcopy"
@@ -341,6 +345,10 @@ type User struct {
 	Addresses    []Address     `json:"addresses,omitempty"`
 	PhoneNumbers []PhoneNumber `json:"phoneNumbers,omitempty"`
 	Active       bool          `json:"active,omitempty"`
+
+	// Extensions are the attributes of the user not in the SCIM schema, e.g. the Google Workspace
+	// custom schemas attributes, by attribute name, e.g. HR.CostCenter.
+	Extensions map[string]string `json:"extensions,omitempty"`
 }
 
 // MarshalBinary implements the gob.GobEncoder interface for User entity.
@@ -400,12 +408,43 @@ func (u User) MarshalBinary() ([]byte, error) {
 		}
 	}
 
+	// the extensions are encoded only when the user has them, keeping the hash code of the users without them,
+	// the optional values encoded before are encoded empty to keep their position
+	if len(u.Extensions) > 0 {
+		if u.Name == nil {
+			if err := enc.Encode(Name{}); err != nil {
+				return nil, err
+			}
+		}
+		if u.EnterpriseData == nil {
+			if err := enc.Encode(EnterpriseData{}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	if u.EnterpriseData != nil {
 		if err := enc.Encode(u.EnterpriseData); err != nil {
 			return nil, err
 		}
 	}
 
+	if len(u.Extensions) > 0 {
+		// the map is encoded sorted by key, the hash code doesn't depend on the map order
+		keys := slices.Sorted(maps.Keys(u.Extensions))
+		values := make([]string, 0, len(keys))
+		for _, k := range keys {
+			values = append(values, u.Extensions[k])
+		}
+
+		if err := enc.Encode(keys); err != nil {
+			return nil, err
+		}
+		if err := enc.Encode(values); err != nil {
+			return nil, err
+		}
+	}
+
 	return buf.Bytes(), nil
 }
 
@@ -470,6 +509,30 @@ func (u *User) UnmarshalBinary(data []byte) error {
 		}
 	}
 
+	var keys, values []string
+	if err := dec.Decode(&keys); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return err
+	}
+	if err := dec.Decode(&values); err != nil {
+		return err
+	}
+
+	u.Extensions = make(map[string]string, len(keys))
+	for i, k := range keys {
+		u.Extensions[k] = values[i]
+	}
+
+	// the empty values encoded to keep the position of the extensions
+	if u.Name != nil && *u.Name == (Name{}) {
+		u.Name = nil
+	}
+	if u.EnterpriseData != nil && *u.EnterpriseData == (EnterpriseData{}) {
+		u.EnterpriseData = nil
+	}
+
 	return nil
 }
 
@@ -150,6 +150,21 @@ func (b *UserBuilderChoice) WithActive(active bool) *UserBuilderChoice {
 	return b
 }
 
+// WithExtensions sets the Extensions field of the User entity.
+func (b *UserBuilderChoice) WithExtensions(extensions map[string]string) *UserBuilderChoice {
+	b.u.Extensions = extensions
+	return b
+}
+
+// WithExtension adds an extension attribute to the Extensions field of the User entity.
+func (b *UserBuilderChoice) WithExtension(name, value string) *UserBuilderChoice {
+	if b.u.Extensions == nil {
+		b.u.Extensions = make(map[string]string)
+	}
+	b.u.Extensions[name] = value
+	return b
+}
+
 // Build returns the User entity.
 func (b *UserBuilderChoice) Build() *User {
 	b.u.SetHashCode()
@@ -330,6 +330,28 @@ func TestUserBuilder_WithPhoneNumber(t *testing.T) {
 	})
 }
 
+func TestUserBuilder_WithExtension(t *testing.T) {
+	t.Run("add extensions to empty user", func(t *testing.T) {
+		u := UserBuilder().
+			WithIPID("ipid").
+			WithExtension("HR.CostCenter", "1234").
+			WithExtension("AWS.Role", "admin").
+			Build()
+
+		assert.Equal(t, map[string]string{"HR.CostCenter": "1234", "AWS.Role": "admin"}, u.Extensions)
+	})
+
+	t.Run("replace existing extension", func(t *testing.T) {
+		u := UserBuilder().
+			WithIPID("ipid").
+			WithExtensions(map[string]string{"HR.CostCenter": "first"}).
+			WithExtension("HR.CostCenter", "replaced").
+			Build()
+
+		assert.Equal(t, map[string]string{"HR.CostCenter": "replaced"}, u.Extensions)
+	})
+}
+
 func TestUserBuilder_AllOptionalFields(t *testing.T) {
 	t.Run("nickname, profileURL, locale, timezone", func(t *testing.T) {
 		u := UserBuilder().
@@ -368,6 +368,16 @@ func TestUser_GobEncode(t *testing.T) {
 				HashCode:     "this should not be encoded",
 			},
 		},
+		{
+			name: "User with extensions",
+			toTest: User{
+				IPID:       "1",
+				UserName:   "user1",
+				Active:     true,
+				Emails:     []Email{{Value: "user.1@mail.com", Type: "work", Primary: true}},
+				Extensions: map[string]string{"HR.CostCenter": "1234", "AWS.AllowedRegions": "eu-west-1,us-east-1"},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -404,6 +414,7 @@ func TestUser_GobEncode(t *testing.T) {
 				Name:              tt.toTest.Name,
 				EnterpriseData:    tt.toTest.EnterpriseData,
 				Active:            tt.toTest.Active,
+				Extensions:        tt.toTest.Extensions,
 			}
 
 			sort := func(x, y string) bool { return x > y }
@@ -488,6 +499,39 @@ func TestUser_SetHashCode(t *testing.T) {
 	}
 }
 
+func TestUser_SetHashCode_extensions(t *testing.T) {
+	user := User{IPID: "1", UserName: "user1", Name: &Name{GivenName: "user", FamilyName: "1"}}
+	user.SetHashCode()
+	withoutExtensions := user.HashCode
+
+	user.Extensions = map[string]string{}
+	user.SetHashCode()
+	if user.HashCode != withoutExtensions {
+		t.Errorf("empty extensions changed the hash code, got: %s, want: %s", user.HashCode, withoutExtensions)
+	}
+
+	user.Extensions = map[string]string{"HR.CostCenter": "1234", "HR.Division": "R&D", "AWS.Role": "admin"}
+	user.SetHashCode()
+	withExtensions := user.HashCode
+	if withExtensions == withoutExtensions {
+		t.Errorf("extensions didn't change the hash code")
+	}
+
+	// the hash code doesn't depend on the map order
+	for range 10 {
+		user.SetHashCode()
+		if user.HashCode != withExtensions {
+			t.Errorf("hash code not consistent, got: %s, want: %s", user.HashCode, withExtensions)
+		}
+	}
+
+	user.Extensions["HR.CostCenter"] = "5678"
+	user.SetHashCode()
+	if user.HashCode == withExtensions {
+		t.Errorf("extension value change didn't change the hash code")
+	}
+}
+
 func TestUser_SetHashCode_consistency(t *testing.T) {
 	t.Run("struct", func(t *testing.T) {
 		user := User{
@@ -6,14 +6,19 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/slashdevops/idp-scim-sync/internal/model"
 )
 
 // Consume s3.Client
 
+// CacheKeySuffix is the suffix of the identity provider cache object, stored next to the state.
+const CacheKeySuffix = ".cache.json"
+
 var (
 	// ErrS3ClientNil is returned when s3 client is nil
 	ErrS3ClientNil = errors.New("s3: AWS S3 Client is nil")
@@ -30,9 +35,10 @@ var (
 
 // S3Repository represent a repository that stores state in S3 and implements model.Repository interface
 type S3Repository struct {
-	client S3ClientAPI
-	bucket string
-	key    string
+	client   S3ClientAPI
+	bucket   string
+	key      string
+	cacheKey string
 }
 
 // NewS3Repository returns a new S3Repository
@@ -57,9 +63,19 @@ func NewS3Repository(client S3ClientAPI, opts ...S3RepositoryOption) (*S3Reposit
 		return nil, ErrOptionWithKeyNil
 	}
 
+	if s3r.cacheKey == "" {
+		s3r.cacheKey = CacheKey(s3r.key)
+	}
+
 	return s3r, nil
 }
 
+// CacheKey returns the key of the identity provider cache of the state stored in key, so every
+// state has its own cache.
+func CacheKey(key string) string {
+	return key + CacheKeySuffix
+}
+
 // GetState returns the state from the repository
 func (r *S3Repository) GetState(ctx context.Context) (*model.State, error) {
 	resp, err := r.client.GetObject(ctx, &s3.GetObjectInput{
@@ -103,3 +119,39 @@ func (r *S3Repository) SetState(ctx context.Context, state *model.State) error {
 
 	return nil
 }
+
+// GetCache returns the identity provider cache from the repository, nil when there is none.
+func (r *S3Repository) GetCache(ctx context.Context) ([]byte, error) {
+	resp, err := r.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(r.bucket),
+		Key:    aws.String(r.cacheKey),
+	})
+	if err != nil {
+		if _, ok := errors.AsType[*types.NoSuchKey](err); ok {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("s3: error getting S3 cache object: bucket: %s, error: %w", r.bucket, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("s3: error reading S3 cache object: %w", err)
+	}
+
+	return data, nil
+}
+
+// SetCache sets the identity provider cache in the repository.
+func (r *S3Repository) SetCache(ctx context.Context, data []byte) error {
+	_, err := r.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(r.bucket),
+		Key:    aws.String(r.cacheKey),
+		Body:   bytes.NewReader(data),
+	})
+	if err != nil {
+		return fmt.Errorf("s3: error putting S3 cache object: %w", err)
+	}
+
+	return nil
+}
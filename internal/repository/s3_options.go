@@ -17,3 +17,10 @@ func WithKey(key string) S3RepositoryOption {
 		r.key = key
 	}
 }
+
+// WithCacheKey sets the key of the identity provider cache, the key of the state with CacheKeySuffix by default.
+func WithCacheKey(key string) S3RepositoryOption {
+	return func(r *S3Repository) {
+		r.cacheKey = key
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/slashdevops/idp-scim-sync/internal/model"
 	"go.uber.org/mock/gomock"
 
@@ -187,3 +188,43 @@ func TestS3SetState(t *testing.T) {
 		assert.Error(t, err)
 	})
 }
+
+func TestS3Repository_cache(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	t.Run("Should store the cache of every state next to it", func(t *testing.T) {
+		mockS3Repository := mocks.NewMockS3ClientAPI(mockCtrl)
+
+		svc, err := NewS3Repository(mockS3Repository, WithBucket("MyBucket"), WithKey("data/state.json"))
+		assert.NoError(t, err)
+
+		mockS3Repository.EXPECT().PutObject(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, in *s3.PutObjectInput, _ ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+			assert.Equal(t, "data/state.json.cache.json", *in.Key)
+			return &s3.PutObjectOutput{}, nil
+		})
+		assert.NoError(t, svc.SetCache(context.TODO(), []byte(`{}`)))
+
+		mockS3Repository.EXPECT().GetObject(gomock.Any(), gomock.Any()).Return(&s3.GetObjectOutput{Body: io.NopCloser(bytes.NewBufferString(`{}`))}, nil)
+		got, err := svc.GetCache(context.TODO())
+		assert.NoError(t, err)
+		assert.Equal(t, []byte(`{}`), got)
+	})
+
+	t.Run("Should return no cache when it doesn't exist", func(t *testing.T) {
+		mockS3Repository := mocks.NewMockS3ClientAPI(mockCtrl)
+
+		svc, err := NewS3Repository(mockS3Repository, WithBucket("MyBucket"), WithKey("state.json"), WithCacheKey("cache.json"))
+		assert.NoError(t, err)
+
+		mockS3Repository.EXPECT().GetObject(gomock.Any(), gomock.Any()).Return(nil, &types.NoSuchKey{})
+		got, err := svc.GetCache(context.TODO())
+		assert.NoError(t, err)
+		assert.Nil(t, got)
+
+		mockS3Repository.EXPECT().GetObject(gomock.Any(), gomock.Any()).Return(nil, errors.New("test error"))
+		got, err = svc.GetCache(context.TODO())
+		assert.Error(t, err)
+		assert.Nil(t, got)
+	})
+}
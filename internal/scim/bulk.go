@@ -0,0 +1,408 @@
+package scim
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path"
+	"strconv"
+
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	"github.com/slashdevops/idp-scim-sync/pkg/aws"
+)
+
+// bulkRequestOverhead is the size reserved in every bulk request for the
+// envelope of the operations, e.g. schemas and separators.
+const bulkRequestOverhead = 128
+
+// bulkOperation is a single operation of a bulk request together with the
+// function that handles its result.
+type bulkOperation struct {
+	op     *aws.BulkOperation
+	result func(*aws.BulkOperationResponse) error
+}
+
+// WithBulk enables the batching of the changes in bulk requests, the limits
+// are the ones advertised by the service provider in its ServiceProviderConfig.
+// A maxPayloadSize of zero means no payload limit.
+func WithBulk(maxOperations, maxPayloadSize int) ProviderOption {
+	return func(p *Provider) {
+		p.bulkMaxOperations = maxOperations
+		p.bulkMaxPayloadSize = maxPayloadSize
+	}
+}
+
+// bulkEnabled returns true when the changes must be sent in bulk requests.
+func (s *Provider) bulkEnabled() bool {
+	return s.bulkMaxOperations > 0
+}
+
+// runBulk sends the operations in as many bulk requests as needed to respect
+// the limits of the service provider and calls the result function of every
+// operation with its response. The results of the POST operations are mapped
+// by bulkId, the rest by its position in the request. A failed request does not
+// stop the next ones, so the results of every request sent are handled, and the
+// errors of all of them are returned together.
+func (s *Provider) runBulk(ctx context.Context, ops []*bulkOperation) error {
+	var errs []error
+
+	for batch, err := range s.bulkBatches(ops) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		br := &aws.BulkRequest{Operations: make([]*aws.BulkOperation, len(batch))}
+		for i, bo := range batch {
+			br.Operations[i] = bo.op
+		}
+
+		slog.Debug("scim: sending bulk request", "operations", len(batch))
+
+		resp, err := s.scim.Bulk(ctx, br)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("scim: error sending bulk request: %w", err))
+			continue
+		}
+
+		byBulkID := make(map[string]*aws.BulkOperationResponse, len(resp.Operations))
+		for _, r := range resp.Operations {
+			if r.BulkID != "" {
+				byBulkID[r.BulkID] = r
+			}
+		}
+
+		for i, bo := range batch {
+			var r *aws.BulkOperationResponse
+			if bo.op.BulkID != "" {
+				r = byBulkID[bo.op.BulkID]
+			} else if len(resp.Operations) == len(batch) {
+				r = resp.Operations[i]
+			}
+
+			if r == nil {
+				errs = append(errs, fmt.Errorf("scim: missing bulk result for operation: %s %s", bo.op.Method, bo.op.Path))
+				continue
+			}
+
+			if err := bo.result(r); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// bulkBatches splits the operations in batches of at most bulkMaxOperations
+// operations and bulkMaxPayloadSize bytes, the operations that cannot be sent
+// are yielded as errors.
+func (s *Provider) bulkBatches(ops []*bulkOperation) func(yield func([]*bulkOperation, error) bool) {
+	return func(yield func([]*bulkOperation, error) bool) {
+		var batch []*bulkOperation
+		size := bulkRequestOverhead
+
+		for _, bo := range ops {
+			data, err := json.Marshal(bo.op)
+			if err != nil {
+				if !yield(nil, fmt.Errorf("scim: error encoding bulk operation: %w", err)) {
+					return
+				}
+				continue
+			}
+
+			if s.bulkMaxPayloadSize > 0 && bulkRequestOverhead+len(data) > s.bulkMaxPayloadSize {
+				if !yield(nil, fmt.Errorf("scim: bulk operation %s %s exceeds the max payload size: %d", bo.op.Method, bo.op.Path, s.bulkMaxPayloadSize)) {
+					return
+				}
+				continue
+			}
+
+			full := len(batch) == s.bulkMaxOperations
+			tooBig := s.bulkMaxPayloadSize > 0 && size+len(data)+1 > s.bulkMaxPayloadSize
+			if len(batch) > 0 && (full || tooBig) {
+				if !yield(batch, nil) {
+					return
+				}
+				batch, size = nil, bulkRequestOverhead
+			}
+
+			batch = append(batch, bo)
+			size += len(data) + 1
+		}
+
+		if len(batch) > 0 {
+			yield(batch, nil)
+		}
+	}
+}
+
+func (s *Provider) createGroupsBulk(ctx context.Context, gr *model.GroupsResult) (*model.GroupsResult, error) {
+	if gr == nil {
+		return nil, fmt.Errorf("scim: groups result is nil")
+	}
+
+	groups := make([]*model.Group, len(gr.Resources))
+	ops := make([]*bulkOperation, len(gr.Resources))
+
+	for i, group := range gr.Resources {
+		groupRequest := &aws.CreateGroupRequest{
+			DisplayName: group.Name,
+			ExternalID:  group.IPID,
+		}
+		if err := groupRequest.Validate(); err != nil {
+			return nil, fmt.Errorf("scim: error creating group: %w", err)
+		}
+
+		slog.Warn("creating group", "group", group.Name)
+
+		ops[i] = &bulkOperation{
+			op: &aws.BulkOperation{
+				Method: http.MethodPost,
+				BulkID: "group-" + strconv.Itoa(i),
+				Path:   aws.GroupsPath,
+				Data:   groupRequest,
+			},
+			result: func(r *aws.BulkOperationResponse) error {
+				// the group already exists, take it as the non bulk path does
+				if r.Status == http.StatusConflict {
+					g, err := s.createGroup(ctx, group)
+					groups[i] = g
+					return err
+				}
+
+				if err := r.Err(); err != nil {
+					return fmt.Errorf("scim: error creating group: %s, %w", group.Name, err)
+				}
+
+				groups[i] = model.GroupBuilder().
+					WithSCIMID(r.ResourceID()).
+					WithName(group.Name).
+					WithIPID(group.IPID).
+					WithEmail(group.Email).
+					Build()
+				return nil
+			},
+		}
+	}
+
+	if err := s.runBulk(ctx, ops); err != nil {
+		return nil, err
+	}
+
+	return model.GroupsResultBuilder().WithResources(groups).Build(), nil
+}
+
+func (s *Provider) updateGroupsBulk(ctx context.Context, gr *model.GroupsResult) (*model.GroupsResult, error) {
+	if gr == nil {
+		return nil, fmt.Errorf("scim: groups result is nil")
+	}
+
+	ops := make([]*bulkOperation, len(gr.Resources))
+	for i, group := range gr.Resources {
+		slog.Warn("updating group", "group", group.Name, "email", group.Email)
+
+		ops[i] = &bulkOperation{
+			op: &aws.BulkOperation{
+				Method: http.MethodPatch,
+				Path:   path.Join(aws.GroupsPath, group.SCIMID),
+				Data: &aws.Patch{
+					Schemas: []string{"urn:ietf:params:scim:api:messages:2.0:PatchOp"},
+					Operations: []*aws.Operation{
+						{
+							OP: "replace",
+							Value: map[string]string{
+								"id":         group.SCIMID,
+								"externalId": group.IPID,
+							},
+						},
+						{
+							OP:    "replace",
+							Path:  "displayName",
+							Value: group.Name,
+						},
+					},
+				},
+			},
+			result: func(r *aws.BulkOperationResponse) error {
+				if err := r.Err(); err != nil {
+					return fmt.Errorf("scim: error updating group: %s, %w", group.Name, err)
+				}
+				return nil
+			},
+		}
+	}
+
+	if err := s.runBulk(ctx, ops); err != nil {
+		return nil, err
+	}
+
+	return model.GroupsResultBuilder().WithResources(gr.Resources).Build(), nil
+}
+
+func (s *Provider) createUsersBulk(ctx context.Context, ur *model.UsersResult) (*model.UsersResult, error) {
+	if ur == nil {
+		return nil, fmt.Errorf("scim: users result is nil")
+	}
+
+	users := make([]*model.User, len(ur.Resources))
+	ops := make([]*bulkOperation, len(ur.Resources))
+
+	for i, user := range ur.Resources {
+		userRequest := buildCreateUserRequest(user)
+		if err := userRequest.Validate(); err != nil {
+			return nil, fmt.Errorf("scim: error creating user: %w", err)
+		}
+
+		slog.Warn("creating user", "user", user.DisplayName, "email", user.GetPrimaryEmailAddress())
+
+		ops[i] = &bulkOperation{
+			op: &aws.BulkOperation{
+				Method: http.MethodPost,
+				BulkID: "user-" + strconv.Itoa(i),
+				Path:   aws.UsersPath,
+				Data:   userRequest,
+			},
+			result: func(r *aws.BulkOperationResponse) error {
+				// the user already exists, take it as the non bulk path does
+				if r.Status == http.StatusConflict {
+					u, err := s.createUser(ctx, user)
+					users[i] = u
+					return err
+				}
+
+				if err := r.Err(); err != nil {
+					return fmt.Errorf("scim: error creating user: %s, %w", user.GetPrimaryEmailAddress(), err)
+				}
+
+				user.SCIMID = r.ResourceID()
+				user.SetHashCode()
+				users[i] = user
+				return nil
+			},
+		}
+	}
+
+	if err := s.runBulk(ctx, ops); err != nil {
+		return nil, err
+	}
+
+	return model.UsersResultBuilder().WithResources(users).Build(), nil
+}
+
+func (s *Provider) updateUsersBulk(ctx context.Context, ur *model.UsersResult) (*model.UsersResult, error) {
+	if ur == nil {
+		return nil, fmt.Errorf("scim: users result is nil")
+	}
+
+	var ops []*bulkOperation
+	for _, user := range ur.Resources {
+		if user.SCIMID == "" {
+			return nil, fmt.Errorf("scim: error updating user, user ID is empty: %s", user.SCIMID)
+		}
+
+		userRequest := buildPutUserRequest(user)
+		if err := userRequest.Validate(); err != nil {
+			return nil, fmt.Errorf("scim: error updating user: %w", err)
+		}
+
+		slog.Warn("updating user", "user", user.DisplayName, "email", user.GetPrimaryEmailAddress())
+
+		op := &aws.BulkOperation{
+			Method: http.MethodPut,
+			Path:   path.Join(aws.UsersPath, user.SCIMID),
+			Data:   userRequest,
+		}
+
+		// the users read by GetUsers are patched with the attributes changed only
+		if current, ok := s.knownUser(user.SCIMID); ok {
+			if patchOps, ok := userPatchOperations(current, (*aws.User)(userRequest), s.syncFieldSet); ok {
+				if len(patchOps) == 0 {
+					user.SetHashCode()
+					continue
+				}
+
+				op.Method = http.MethodPatch
+				op.Data = &aws.Patch{
+					Schemas:    []string{"urn:ietf:params:scim:api:messages:2.0:PatchOp"},
+					Operations: patchOps,
+				}
+			}
+		}
+
+		ops = append(ops, &bulkOperation{
+			op: op,
+			result: func(r *aws.BulkOperationResponse) error {
+				if op.Method == http.MethodPatch && r.Status == http.StatusBadRequest {
+					slog.Warn("scim user patch rejected, replacing it", "user", user.DisplayName, "error", r.Err())
+					_, err := s.replaceUser(ctx, user, userRequest)
+					return err
+				}
+
+				if err := r.Err(); err != nil {
+					return fmt.Errorf("scim: error updating user: %s, %w", user.GetPrimaryEmailAddress(), err)
+				}
+
+				if id := r.ResourceID(); id != "" {
+					user.SCIMID = id
+				}
+				s.rememberUser((*aws.User)(userRequest))
+				user.SetHashCode()
+				return nil
+			},
+		})
+	}
+
+	if err := s.runBulk(ctx, ops); err != nil {
+		return nil, err
+	}
+
+	return model.UsersResultBuilder().WithResources(ur.Resources).Build(), nil
+}
+
+// deleteBulk deletes the resources with the given ids under resourcePath,
+// the resources not found fail as without bulk requests.
+func (s *Provider) deleteBulk(ctx context.Context, resourcePath string, ids []string) error {
+	ops := make([]*bulkOperation, len(ids))
+	for i, id := range ids {
+		ops[i] = &bulkOperation{
+			op: &aws.BulkOperation{
+				Method: http.MethodDelete,
+				Path:   path.Join(resourcePath, id),
+			},
+			result: func(r *aws.BulkOperationResponse) error {
+				if err := r.Err(); err != nil {
+					return fmt.Errorf("scim: error deleting: %s, %w", path.Join(resourcePath, id), err)
+				}
+				return nil
+			},
+		}
+	}
+
+	return s.runBulk(ctx, ops)
+}
+
+// patchGroupsBulk sends the patch group requests in bulk requests.
+func (s *Provider) patchGroupsBulk(ctx context.Context, pgrs []*aws.PatchGroupRequest) error {
+	ops := make([]*bulkOperation, len(pgrs))
+	for i, pgr := range pgrs {
+		ops[i] = &bulkOperation{
+			op: &aws.BulkOperation{
+				Method: http.MethodPatch,
+				Path:   path.Join(aws.GroupsPath, pgr.Group.ID),
+				Data:   &pgr.Patch,
+			},
+			result: func(r *aws.BulkOperationResponse) error {
+				if err := r.Err(); err != nil {
+					return fmt.Errorf("scim: error patching group: %s, %w", pgr.Group.DisplayName, err)
+				}
+				return nil
+			},
+		}
+	}
+
+	return s.runBulk(ctx, ops)
+}
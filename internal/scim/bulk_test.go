@@ -0,0 +1,228 @@
+package scim
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	mock_scim "github.com/slashdevops/idp-scim-sync/mocks/scim"
+	"github.com/slashdevops/idp-scim-sync/pkg/aws"
+	"go.uber.org/mock/gomock"
+)
+
+func TestProvider_CreateUsersBulk(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockSCIM := mock_scim.NewMockAWSSCIMProvider(ctrl)
+
+	users := []*model.User{
+		bulkTestUser("1"),
+		bulkTestUser("2"),
+		bulkTestUser("3"),
+	}
+
+	gomock.InOrder(
+		// the results are mapped by bulkId, not by position
+		mockSCIM.EXPECT().Bulk(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, br *aws.BulkRequest) (*aws.BulkResponse, error) {
+			if len(br.Operations) != 2 {
+				t.Fatalf("Provider.CreateUsers() bulk operations = %d, want 2", len(br.Operations))
+			}
+			return &aws.BulkResponse{Operations: []*aws.BulkOperationResponse{
+				{Method: http.MethodPost, BulkID: "user-1", Status: http.StatusConflict},
+				{Method: http.MethodPost, BulkID: "user-0", Status: http.StatusCreated, Location: "https://scim/Users/s1"},
+			}}, nil
+		}),
+		mockSCIM.EXPECT().CreateOrGetUser(gomock.Any(), gomock.Any()).Return(&aws.CreateUserResponse{ID: "s2"}, nil),
+		mockSCIM.EXPECT().Bulk(gomock.Any(), gomock.Any()).Return(&aws.BulkResponse{Operations: []*aws.BulkOperationResponse{
+			{Method: http.MethodPost, BulkID: "user-2", Status: http.StatusCreated, Response: json.RawMessage(`{"id":"s3"}`)},
+		}}, nil),
+	)
+
+	p, _ := NewProvider(mockSCIM, WithBulk(2, 0))
+
+	got, err := p.CreateUsers(context.Background(), model.UsersResultBuilder().WithResources(users).Build())
+	if err != nil {
+		t.Fatalf("Provider.CreateUsers() error = %v", err)
+	}
+
+	ids := make([]string, len(got.Resources))
+	for i, u := range got.Resources {
+		ids[i] = u.SCIMID
+	}
+	if diff := cmp.Diff([]string{"s1", "s2", "s3"}, ids); diff != "" {
+		t.Errorf("Provider.CreateUsers() (-want +got):\n%s", diff)
+	}
+}
+
+func TestProvider_DeleteGroupsBulk(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockSCIM := mock_scim.NewMockAWSSCIMProvider(ctrl)
+
+	mockSCIM.EXPECT().Bulk(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, br *aws.BulkRequest) (*aws.BulkResponse, error) {
+		want := []*aws.BulkOperation{
+			{Method: http.MethodDelete, Path: "/Groups/g1"},
+			{Method: http.MethodDelete, Path: "/Groups/g2"},
+			{Method: http.MethodDelete, Path: "/Groups/g3"},
+		}
+		if diff := cmp.Diff(want, br.Operations); diff != "" {
+			t.Errorf("Provider.DeleteGroups() (-want +got):\n%s", diff)
+		}
+		return &aws.BulkResponse{Operations: []*aws.BulkOperationResponse{
+			{Method: http.MethodDelete, Status: http.StatusNoContent},
+			{Method: http.MethodDelete, Status: http.StatusNotFound, Response: json.RawMessage(`{"detail":"not found"}`)},
+			{Method: http.MethodDelete, Status: http.StatusNoContent},
+		}}, nil
+	})
+
+	p, _ := NewProvider(mockSCIM, WithBulk(10, 0))
+
+	err := p.DeleteGroups(context.Background(), &model.GroupsResult{Resources: []*model.Group{{SCIMID: "g1"}, {SCIMID: "g2"}, {SCIMID: "g3"}}})
+
+	// the group not found fails as without bulk requests
+	var httpErr *aws.HTTPResponseError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Provider.DeleteGroups() error = %v, want not found", err)
+	}
+}
+
+func TestProvider_DeleteGroupsBulk_FailedRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockSCIM := mock_scim.NewMockAWSSCIMProvider(ctrl)
+
+	errBulk := errors.New("connection reset")
+	deleted := &aws.BulkResponse{Operations: []*aws.BulkOperationResponse{{Method: http.MethodDelete, Status: http.StatusNoContent}}}
+
+	// the failed request does not stop the next ones
+	gomock.InOrder(
+		mockSCIM.EXPECT().Bulk(gomock.Any(), gomock.Any()).Return(deleted, nil),
+		mockSCIM.EXPECT().Bulk(gomock.Any(), gomock.Any()).Return(nil, errBulk),
+		mockSCIM.EXPECT().Bulk(gomock.Any(), gomock.Any()).Return(deleted, nil),
+	)
+
+	p, _ := NewProvider(mockSCIM, WithBulk(1, 0))
+
+	err := p.DeleteGroups(context.Background(), &model.GroupsResult{Resources: []*model.Group{{SCIMID: "g1"}, {SCIMID: "g2"}, {SCIMID: "g3"}}})
+	if !errors.Is(err, errBulk) {
+		t.Errorf("Provider.DeleteGroups() error = %v, want %v", err, errBulk)
+	}
+}
+
+func TestProvider_DeleteBulk_EmptyID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockSCIM := mock_scim.NewMockAWSSCIMProvider(ctrl)
+
+	// no bulk request is sent, an empty id would delete the whole collection
+	p, _ := NewProvider(mockSCIM, WithBulk(10, 0))
+
+	err := p.DeleteGroups(context.Background(), &model.GroupsResult{Resources: []*model.Group{{SCIMID: "g1"}, {Name: "group2"}}})
+	if !errors.Is(err, aws.ErrGroupIDEmpty) {
+		t.Errorf("Provider.DeleteGroups() error = %v, want %v", err, aws.ErrGroupIDEmpty)
+	}
+
+	err = p.DeleteUsers(context.Background(), &model.UsersResult{Resources: []*model.User{bulkTestUser("1")}})
+	if !errors.Is(err, aws.ErrUserIDEmpty) {
+		t.Errorf("Provider.DeleteUsers() error = %v, want %v", err, aws.ErrUserIDEmpty)
+	}
+}
+
+func TestProvider_CreateGroupsMembersBulk(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockSCIM := mock_scim.NewMockAWSSCIMProvider(ctrl)
+
+	gmr := &model.GroupsMembersResult{
+		Resources: []*model.GroupMembers{
+			{Group: &model.Group{SCIMID: "g1", Name: "group1"}, Resources: patchMembers("u1", "u2", "u3")},
+			{Group: &model.Group{SCIMID: "g2", Name: "group2"}, Resources: patchMembers("u4")},
+		},
+	}
+
+	// the membership patches of all the groups go in the same bulk request
+	mockSCIM.EXPECT().Bulk(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, br *aws.BulkRequest) (*aws.BulkResponse, error) {
+		paths := make([]string, len(br.Operations))
+		resp := &aws.BulkResponse{}
+		for i, op := range br.Operations {
+			paths[i] = op.Path
+			resp.Operations = append(resp.Operations, &aws.BulkOperationResponse{Method: op.Method, Status: http.StatusNoContent})
+		}
+		if diff := cmp.Diff([]string{"/Groups/g1", "/Groups/g1", "/Groups/g2"}, paths); diff != "" {
+			t.Errorf("Provider.CreateGroupsMembers() (-want +got):\n%s", diff)
+		}
+		return resp, nil
+	})
+
+	p, _ := NewProvider(mockSCIM, WithMaxMembersPerRequest(2), WithBulk(10, 0))
+
+	got, err := p.CreateGroupsMembers(context.Background(), gmr)
+	if err != nil {
+		t.Fatalf("Provider.CreateGroupsMembers() error = %v", err)
+	}
+	if len(got.Resources) != 2 {
+		t.Errorf("Provider.CreateGroupsMembers() groups = %d, want 2", len(got.Resources))
+	}
+}
+
+func TestProvider_bulkBatches(t *testing.T) {
+	ops := make([]*bulkOperation, 5)
+	for i := range ops {
+		ops[i] = &bulkOperation{op: &aws.BulkOperation{Method: http.MethodDelete, Path: "/Users/0123456789"}}
+	}
+
+	size, _ := json.Marshal(ops[0].op)
+
+	tests := []struct {
+		name           string
+		maxOperations  int
+		maxPayloadSize int
+		want           []int
+		wantErr        bool
+	}{
+		{name: "should split by operations", maxOperations: 2, want: []int{2, 2, 1}},
+		{name: "should split by payload size", maxOperations: 10, maxPayloadSize: bulkRequestOverhead + 3*(len(size)+1), want: []int{3, 2}},
+		{name: "should fail when a single operation is too big", maxOperations: 10, maxPayloadSize: bulkRequestOverhead, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Provider{bulkMaxOperations: tt.maxOperations, bulkMaxPayloadSize: tt.maxPayloadSize}
+
+			var got []int
+			var gotErr error
+			for batch, err := range p.bulkBatches(ops) {
+				if err != nil {
+					gotErr = err
+					break
+				}
+				got = append(got, len(batch))
+			}
+
+			if (gotErr != nil) != tt.wantErr {
+				t.Fatalf("Provider.bulkBatches() error = %v, wantErr %v", gotErr, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Provider.bulkBatches() (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// bulkTestUser helper function to build a valid user with the given id
+func bulkTestUser(id string) *model.User {
+	return model.UserBuilder().
+		WithIPID(id).
+		WithUserName("user." + id + "@mail.com").
+		WithDisplayName("user " + id).
+		WithName(&model.Name{GivenName: "user", FamilyName: id}).
+		WithEmail(model.Email{Value: "user." + id + "@mail.com", Type: "work", Primary: true}).
+		Build()
+}
+
+// patchMembers helper function to build members with the given SCIM ids
+func patchMembers(ids ...string) []*model.Member {
+	members := make([]*model.Member, len(ids))
+	for i, id := range ids {
+		members[i] = &model.Member{SCIMID: id, Email: id + "@mail.com"}
+	}
+	return members
+}
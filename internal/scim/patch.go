@@ -0,0 +1,170 @@
+package scim
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	"github.com/slashdevops/idp-scim-sync/pkg/aws"
+)
+
+// enterpriseUserSchema is the prefix of the paths of the enterprise user attributes
+const enterpriseUserSchema = "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"
+
+// userRequiredPaths are the attributes AWS requires in every user,
+// they can be replaced but not removed with a patch.
+var userRequiredPaths = map[string]bool{
+	"userName":        true,
+	"displayName":     true,
+	"name.givenName":  true,
+	"name.familyName": true,
+	"emails":          true,
+}
+
+// userStringAttributes are the single-valued string attributes of the user
+// compared to build the patch operations, the optional ones with the sync
+// field that includes them.
+var userStringAttributes = []struct {
+	path  string
+	field model.SyncUserField
+	value func(*aws.User) string
+}{
+	{"externalId", "", func(u *aws.User) string { return u.ExternalID }},
+	{"userName", "", func(u *aws.User) string { return u.UserName }},
+	{"displayName", "", func(u *aws.User) string { return u.DisplayName }},
+	{"nickName", model.SyncUserFieldNickName, func(u *aws.User) string { return u.NickName }},
+	{"profileUrl", model.SyncUserFieldProfileURL, func(u *aws.User) string { return u.ProfileURL }},
+	{"userType", model.SyncUserFieldUserType, func(u *aws.User) string { return u.UserType }},
+	{"title", model.SyncUserFieldTitle, func(u *aws.User) string { return u.Title }},
+	{"preferredLanguage", model.SyncUserFieldPreferredLanguage, func(u *aws.User) string { return u.PreferredLanguage }},
+	{"locale", model.SyncUserFieldLocale, func(u *aws.User) string { return u.Locale }},
+	{"timezone", model.SyncUserFieldTimezone, func(u *aws.User) string { return u.Timezone }},
+	{"name.formatted", "", func(u *aws.User) string { return nameOf(u).Formatted }},
+	{"name.familyName", "", func(u *aws.User) string { return nameOf(u).FamilyName }},
+	{"name.givenName", "", func(u *aws.User) string { return nameOf(u).GivenName }},
+	{"name.middleName", "", func(u *aws.User) string { return nameOf(u).MiddleName }},
+	{"name.honorificPrefix", "", func(u *aws.User) string { return nameOf(u).HonorificPrefix }},
+	{"name.honorificSuffix", "", func(u *aws.User) string { return nameOf(u).HonorificSuffix }},
+	{enterpriseUserSchema + ":employeeNumber", model.SyncUserFieldEnterpriseData, func(u *aws.User) string { return enterpriseOf(u).EmployeeNumber }},
+	{enterpriseUserSchema + ":costCenter", model.SyncUserFieldEnterpriseData, func(u *aws.User) string { return enterpriseOf(u).CostCenter }},
+	{enterpriseUserSchema + ":organization", model.SyncUserFieldEnterpriseData, func(u *aws.User) string { return enterpriseOf(u).Organization }},
+	{enterpriseUserSchema + ":division", model.SyncUserFieldEnterpriseData, func(u *aws.User) string { return enterpriseOf(u).Division }},
+	{enterpriseUserSchema + ":department", model.SyncUserFieldEnterpriseData, func(u *aws.User) string { return enterpriseOf(u).Department }},
+}
+
+// userPatchOperations returns the operations that turn the current user into
+// the desired one, the attributes equal in both are not sent, and the optional
+// attributes not included in the sync fields are not compared, so they are
+// kept as they are in the service provider.
+// It returns false when any of the changes cannot be expressed as a patch,
+// e.g. the removal of a required attribute, and the user must be replaced.
+func userPatchOperations(current, desired *aws.User, fields *model.SyncFieldSet) ([]*aws.Operation, bool) {
+	var ops []*aws.Operation
+
+	synced := func(field model.SyncUserField) bool {
+		return field == "" || fields.Includes(field)
+	}
+
+	for _, attr := range userStringAttributes {
+		if !synced(attr.field) {
+			continue
+		}
+		op := patchOperation(attr.path, attr.value(current) == "", attr.value(desired) == "", attr.value(current) == attr.value(desired), attr.value(desired))
+		if op == nil {
+			continue
+		}
+		if op.OP == "remove" && userRequiredPaths[attr.path] {
+			return nil, false
+		}
+		ops = append(ops, op)
+	}
+
+	if current.Active != desired.Active {
+		ops = append(ops, &aws.Operation{OP: "replace", Path: "active", Value: desired.Active})
+	}
+
+	curManager, desManager := managerOf(current), managerOf(desired)
+	if synced(model.SyncUserFieldEnterpriseData) {
+		if op := patchOperation(enterpriseUserSchema+":manager", curManager == "", desManager == "", curManager == desManager, map[string]string{"value": desManager}); op != nil {
+			ops = append(ops, op)
+		}
+	}
+
+	multiValued := []struct {
+		path     string
+		field    model.SyncUserField
+		cur, des int
+		equal    bool
+		value    any
+	}{
+		{"emails", "", len(current.Emails), len(desired.Emails), slices.Equal(current.Emails, desired.Emails), desired.Emails},
+		{"addresses", model.SyncUserFieldAddresses, len(current.Addresses), len(desired.Addresses), slices.Equal(current.Addresses, desired.Addresses), desired.Addresses},
+		{"phoneNumbers", model.SyncUserFieldPhoneNumbers, len(current.PhoneNumbers), len(desired.PhoneNumbers), slices.Equal(current.PhoneNumbers, desired.PhoneNumbers), desired.PhoneNumbers},
+	}
+	for _, attr := range multiValued {
+		if !synced(attr.field) {
+			continue
+		}
+		op := patchOperation(attr.path, attr.cur == 0, attr.des == 0, attr.equal, attr.value)
+		if op == nil {
+			continue
+		}
+		if op.OP == "remove" && userRequiredPaths[attr.path] {
+			return nil, false
+		}
+		ops = append(ops, op)
+	}
+
+	return ops, true
+}
+
+// patchOperation returns the operation for the attribute in path, nil when
+// the attribute did not change.
+func patchOperation(path string, curEmpty, desEmpty, equal bool, value any) *aws.Operation {
+	switch {
+	case equal:
+		return nil
+	case desEmpty:
+		return &aws.Operation{OP: "remove", Path: path}
+	case curEmpty:
+		return &aws.Operation{OP: "add", Path: path, Value: value}
+	default:
+		return &aws.Operation{OP: "replace", Path: path, Value: value}
+	}
+}
+
+// isPatchNotSupported returns true when the error means the service provider
+// rejected the patch request, e.g. for an unsupported path.
+func isPatchNotSupported(err error) bool {
+	httpErr, ok := errors.AsType[*aws.HTTPResponseError](err)
+	return ok && httpErr.StatusCode == http.StatusBadRequest
+}
+
+// isUserNotReadable returns true when the error means the user cannot be read
+// to compute the patch, e.g. it was not found, and the user must be replaced.
+func isUserNotReadable(err error) bool {
+	httpErr, ok := errors.AsType[*aws.HTTPResponseError](err)
+	return ok && (httpErr.StatusCode == http.StatusNotFound || httpErr.StatusCode == http.StatusBadRequest)
+}
+
+func nameOf(u *aws.User) aws.Name {
+	if u.Name == nil {
+		return aws.Name{}
+	}
+	return *u.Name
+}
+
+func enterpriseOf(u *aws.User) aws.SchemaEnterpriseUser {
+	if u.SchemaEnterpriseUser == nil {
+		return aws.SchemaEnterpriseUser{}
+	}
+	return *u.SchemaEnterpriseUser
+}
+
+func managerOf(u *aws.User) string {
+	if e := enterpriseOf(u); e.Manager != nil {
+		return e.Manager.Value
+	}
+	return ""
+}
@@ -0,0 +1,118 @@
+package scim
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	"github.com/slashdevops/idp-scim-sync/pkg/aws"
+)
+
+func Test_userPatchOperations(t *testing.T) {
+	user := func() *aws.User {
+		return &aws.User{
+			ID:          "1",
+			ExternalID:  "ip1",
+			UserName:    "user.1@mail.com",
+			DisplayName: "user 1",
+			Title:       "engineer",
+			Active:      true,
+			Name:        &aws.Name{GivenName: "user", FamilyName: "1"},
+			Emails:      []aws.Email{{Value: "user.1@mail.com", Type: "work", Primary: true}},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		desired func(u *aws.User)
+		fields  []string
+		want    []*aws.Operation
+		wantOK  bool
+	}{
+		{
+			name:    "should return no operations when the user is unchanged",
+			desired: func(u *aws.User) {},
+			wantOK:  true,
+		},
+		{
+			name: "should replace, add and remove the changed attributes",
+			desired: func(u *aws.User) {
+				u.DisplayName = "user one"
+				u.Title = ""
+				u.Locale = "en-US"
+				u.Active = false
+			},
+			want: []*aws.Operation{
+				{OP: "replace", Path: "displayName", Value: "user one"},
+				{OP: "remove", Path: "title"},
+				{OP: "add", Path: "locale", Value: "en-US"},
+				{OP: "replace", Path: "active", Value: false},
+			},
+			wantOK: true,
+		},
+		{
+			name: "should replace the multi-valued and enterprise attributes",
+			desired: func(u *aws.User) {
+				u.Emails = []aws.Email{{Value: "user.one@mail.com", Type: "work", Primary: true}}
+				u.PhoneNumbers = []aws.PhoneNumber{{Value: "555", Type: "work"}}
+				u.SchemaEnterpriseUser = &aws.SchemaEnterpriseUser{Department: "sales", Manager: &aws.Manager{Value: "m1"}}
+			},
+			want: []*aws.Operation{
+				{OP: "add", Path: enterpriseUserSchema + ":department", Value: "sales"},
+				{OP: "add", Path: enterpriseUserSchema + ":manager", Value: map[string]string{"value": "m1"}},
+				{OP: "replace", Path: "emails", Value: []aws.Email{{Value: "user.one@mail.com", Type: "work", Primary: true}}},
+				{OP: "add", Path: "phoneNumbers", Value: []aws.PhoneNumber{{Value: "555", Type: "work"}}},
+			},
+			wantOK: true,
+		},
+		{
+			name: "should not compare the attributes not included in the sync fields",
+			desired: func(u *aws.User) {
+				u.Title = ""
+				u.Locale = "en-US"
+				u.PhoneNumbers = []aws.PhoneNumber{{Value: "555", Type: "work"}}
+				u.SchemaEnterpriseUser = &aws.SchemaEnterpriseUser{Department: "sales", Manager: &aws.Manager{Value: "m1"}}
+			},
+			fields: []string{"locale"},
+			want: []*aws.Operation{
+				{OP: "add", Path: "locale", Value: "en-US"},
+			},
+			wantOK: true,
+		},
+		{
+			name: "should not patch the removal of a required attribute",
+			desired: func(u *aws.User) {
+				u.Name = nil
+			},
+			wantOK: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			desired := user()
+			tt.desired(desired)
+
+			got, ok := userPatchOperations(user(), desired, model.NewSyncFieldSet(tt.fields))
+			if ok != tt.wantOK {
+				t.Fatalf("userPatchOperations() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("userPatchOperations() (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_isPatchNotSupported(t *testing.T) {
+	if !isPatchNotSupported(&aws.HTTPResponseError{StatusCode: http.StatusBadRequest}) {
+		t.Errorf("isPatchNotSupported() = false, want true for bad request")
+	}
+	if isPatchNotSupported(&aws.HTTPResponseError{StatusCode: http.StatusInternalServerError}) {
+		t.Errorf("isPatchNotSupported() = true, want false for internal server error")
+	}
+	if isPatchNotSupported(errors.New("error")) {
+		t.Errorf("isPatchNotSupported() = true, want false for other errors")
+	}
+}
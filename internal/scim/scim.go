@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"slices"
 	"sync"
 
 	"github.com/slashdevops/idp-scim-sync/internal/model"
@@ -26,6 +27,9 @@ type AWSSCIMProvider interface {
 	// PutUser updates a user in SCIM Provider
 	PutUser(ctx context.Context, usr *aws.PutUserRequest) (*aws.PutUserResponse, error)
 
+	// PatchUser patches a user in SCIM Provider
+	PatchUser(ctx context.Context, pur *aws.PatchUserRequest) error
+
 	// DeleteUser deletes a user in SCIM Provider
 	DeleteUser(ctx context.Context, id string) error
 
@@ -50,6 +54,9 @@ type AWSSCIMProvider interface {
 
 	// PatchGroup patches a group in SCIM Provider
 	PatchGroup(ctx context.Context, pgr *aws.PatchGroupRequest) error
+
+	// Bulk sends several operations in a single request to the SCIM Provider
+	Bulk(ctx context.Context, br *aws.BulkRequest) (*aws.BulkResponse, error)
 }
 
 // MaxPatchGroupMembersPerRequest is the Maximum members in group members in a single request.
@@ -62,6 +69,16 @@ var ErrSCIMProviderNil = fmt.Errorf("scim: Provider is nil")
 type Provider struct {
 	scim                 AWSSCIMProvider
 	maxMembersPerRequest int
+	bulkMaxOperations    int
+	bulkMaxPayloadSize   int
+	concurrency          int
+	rateLimiter          *aws.AdaptiveRateLimiter
+	syncFieldSet         *model.SyncFieldSet
+
+	// scimUsers are the users read by GetUsers, by SCIM ID, the updates
+	// are patched with the attributes that differ from them.
+	scimUsersMu sync.Mutex
+	scimUsers   map[string]*aws.User
 }
 
 // ProviderOption is a function that modifies the Provider.
@@ -85,6 +102,46 @@ func NewProvider(scim AWSSCIMProvider, opts ...ProviderOption) (*Provider, error
 	return p, nil
 }
 
+// WithConcurrency sets the maximum number of concurrent requests to create, update
+// and delete groups, users and groups members, one by default.
+func WithConcurrency(concurrency int) ProviderOption {
+	return func(p *Provider) {
+		p.concurrency = concurrency
+	}
+}
+
+// WithRateLimiter sets the rate limiter of the requests to the AWS SCIM API, its metrics are
+// logged once the sync is done.
+func WithRateLimiter(limiter *aws.AdaptiveRateLimiter) ProviderOption {
+	return func(p *Provider) {
+		p.rateLimiter = limiter
+	}
+}
+
+// LogStats logs the metrics of the rate limiter of the requests, if any.
+func (s *Provider) LogStats() {
+	if s.rateLimiter == nil {
+		return
+	}
+
+	stats := s.rateLimiter.Stats()
+	slog.Info("scim: aws requests rate limiter",
+		"qps", stats.QPS,
+		"concurrency", stats.Concurrency,
+		"requests", stats.Requests,
+		"throttled", stats.Throttled,
+	)
+}
+
+// WithSyncFieldSet sets the optional user fields synced, the user patches only
+// change the attributes of these fields. When the field set is nil or empty,
+// all fields are synced (default behavior).
+func WithSyncFieldSet(fields *model.SyncFieldSet) ProviderOption {
+	return func(p *Provider) {
+		p.syncFieldSet = fields
+	}
+}
+
 // WithMaxMembersPerRequest sets the maximum number of members per request.
 func WithMaxMembersPerRequest(max int) ProviderOption {
 	return func(p *Provider) {
@@ -117,28 +174,54 @@ func (s *Provider) GetGroups(ctx context.Context) (*model.GroupsResult, error) {
 	return groupsResult, nil
 }
 
+// forEach calls fn for every index in [0, n) in up to concurrency goroutines,
+// the first error cancels the context of the rest of the calls and is returned.
+func (s *Provider) forEach(ctx context.Context, n int, fn func(ctx context.Context, i int) error) error {
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(max(s.concurrency, 1))
+
+	for i := range n {
+		g.Go(func() error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			return fn(ctx, i)
+		})
+	}
+
+	return g.Wait()
+}
+
 // processGroups processes a list of groups and applies a function to each group.
 func (s *Provider) processGroups(ctx context.Context, gr *model.GroupsResult, processFunc func(context.Context, *model.Group) (*model.Group, error)) (*model.GroupsResult, error) {
 	if gr == nil {
 		return nil, fmt.Errorf("scim: groups result is nil")
 	}
 
-	processedGroups := make([]*model.Group, 0, len(gr.Resources))
-	for _, group := range gr.Resources {
-		processedGroup, err := processFunc(ctx, group)
+	processedGroups := make([]*model.Group, len(gr.Resources))
+	err := s.forEach(ctx, len(gr.Resources), func(ctx context.Context, i int) error {
+		processedGroup, err := processFunc(ctx, gr.Resources[i])
 		if err != nil {
-			return nil, err
-		}
-		if processedGroup != nil {
-			processedGroups = append(processedGroups, processedGroup)
+			return err
 		}
+		processedGroups[i] = processedGroup
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	// keep the order of the groups, the ones without result are dropped
+	processedGroups = slices.DeleteFunc(processedGroups, func(g *model.Group) bool { return g == nil })
+
 	return model.GroupsResultBuilder().WithResources(processedGroups).Build(), nil
 }
 
 // CreateGroups creates groups in SCIM Provider
 func (s *Provider) CreateGroups(ctx context.Context, gr *model.GroupsResult) (*model.GroupsResult, error) {
+	if s.bulkEnabled() {
+		return s.createGroupsBulk(ctx, gr)
+	}
 	return s.processGroups(ctx, gr, s.createGroup)
 }
 
@@ -165,6 +248,9 @@ func (s *Provider) createGroup(ctx context.Context, group *model.Group) (*model.
 
 // UpdateGroups updates groups in SCIM Provider
 func (s *Provider) UpdateGroups(ctx context.Context, gr *model.GroupsResult) (*model.GroupsResult, error) {
+	if s.bulkEnabled() {
+		return s.updateGroupsBulk(ctx, gr)
+	}
 	return s.processGroups(ctx, gr, s.updateGroup)
 }
 
@@ -184,6 +270,11 @@ func (s *Provider) updateGroup(ctx context.Context, group *model.Group) (*model.
 						"externalId": group.IPID,
 					},
 				},
+				{
+					OP:    "replace",
+					Path:  "displayName",
+					Value: group.Name,
+				},
 			},
 		},
 	}
@@ -199,6 +290,19 @@ func (s *Provider) updateGroup(ctx context.Context, group *model.Group) (*model.
 
 // DeleteGroups deletes groups in SCIM Provider
 func (s *Provider) DeleteGroups(ctx context.Context, gr *model.GroupsResult) error {
+	if s.bulkEnabled() && gr != nil {
+		ids := make([]string, len(gr.Resources))
+		for i, group := range gr.Resources {
+			// an empty id would delete the whole collection
+			if group.SCIMID == "" {
+				return fmt.Errorf("scim: error deleting group: %s, %w", group.Name, aws.ErrGroupIDEmpty)
+			}
+			slog.Warn("deleting group", "group", group.Name, "email", group.Email)
+			ids[i] = group.SCIMID
+		}
+		return s.deleteBulk(ctx, aws.GroupsPath, ids)
+	}
+
 	_, err := s.processGroups(ctx, gr, s.deleteGroup)
 	return err
 }
@@ -214,13 +318,13 @@ func (s *Provider) deleteGroup(ctx context.Context, group *model.Group) (*model.
 
 // GetUsers returns users from SCIM Provider
 func (s *Provider) GetUsers(ctx context.Context) (*model.UsersResult, error) {
-	usersResponse, err := s.scim.ListUsers(ctx, "")
+	scimUsers, err := s.listUsers(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("scim: error listing users: %w", err)
+		return nil, err
 	}
 
-	users := make([]*model.User, len(usersResponse.Resources))
-	for i, user := range usersResponse.Resources {
+	users := make([]*model.User, len(scimUsers))
+	for i, user := range scimUsers {
 		u := buildUser(user)
 		users[i] = u
 	}
@@ -237,22 +341,30 @@ func (s *Provider) processUsers(ctx context.Context, ur *model.UsersResult, proc
 		return nil, fmt.Errorf("scim: users result is nil")
 	}
 
-	processedUsers := make([]*model.User, 0, len(ur.Resources))
-	for _, user := range ur.Resources {
-		processedUser, err := processFunc(ctx, user)
+	processedUsers := make([]*model.User, len(ur.Resources))
+	err := s.forEach(ctx, len(ur.Resources), func(ctx context.Context, i int) error {
+		processedUser, err := processFunc(ctx, ur.Resources[i])
 		if err != nil {
-			return nil, err
-		}
-		if processedUser != nil {
-			processedUsers = append(processedUsers, processedUser)
+			return err
 		}
+		processedUsers[i] = processedUser
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	// keep the order of the users, the ones without result are dropped
+	processedUsers = slices.DeleteFunc(processedUsers, func(u *model.User) bool { return u == nil })
+
 	return model.UsersResultBuilder().WithResources(processedUsers).Build(), nil
 }
 
 // CreateUsers creates users in SCIM Provider
 func (s *Provider) CreateUsers(ctx context.Context, ur *model.UsersResult) (*model.UsersResult, error) {
+	if s.bulkEnabled() {
+		return s.createUsersBulk(ctx, ur)
+	}
 	return s.processUsers(ctx, ur, s.createUser)
 }
 
@@ -274,6 +386,19 @@ func (s *Provider) createUser(ctx context.Context, user *model.User) (*model.Use
 
 // UpdateUsers updates users in SCIM Provider given a list of users
 func (s *Provider) UpdateUsers(ctx context.Context, ur *model.UsersResult) (*model.UsersResult, error) {
+	if ur == nil {
+		return nil, fmt.Errorf("scim: users result is nil")
+	}
+
+	// the users are patched against the ones in the SCIM Provider, read at once
+	// when GetUsers did not read them, e.g. when syncing from the state
+	if err := s.loadUsers(ctx); err != nil {
+		return nil, err
+	}
+
+	if s.bulkEnabled() {
+		return s.updateUsersBulk(ctx, ur)
+	}
 	return s.processUsers(ctx, ur, s.updateUser)
 }
 
@@ -286,11 +411,128 @@ func (s *Provider) updateUser(ctx context.Context, user *model.User) (*model.Use
 
 	slog.Warn("updating user", "user", user.DisplayName, "email", user.GetPrimaryEmailAddress())
 
+	current, err := s.currentUser(ctx, user.SCIMID)
+	if err != nil {
+		if !isUserNotReadable(err) {
+			return nil, fmt.Errorf("scim: error getting user: %w", err)
+		}
+
+		slog.Warn("cannot get the scim user, replacing it", "user", user.DisplayName, "error", err)
+		return s.replaceUser(ctx, user, userRequest)
+	}
+
+	ops, ok := userPatchOperations(current, (*aws.User)(userRequest), s.syncFieldSet)
+	if !ok {
+		return s.replaceUser(ctx, user, userRequest)
+	}
+
+	if len(ops) == 0 {
+		slog.Debug("scim: user attributes unchanged, nothing to patch", "user", user.DisplayName)
+		user.SetHashCode()
+		return user, nil
+	}
+
+	patchUserRequest := &aws.PatchUserRequest{
+		User: aws.User{ID: user.SCIMID},
+		Patch: aws.Patch{
+			Schemas:    []string{"urn:ietf:params:scim:api:messages:2.0:PatchOp"},
+			Operations: ops,
+		},
+	}
+
+	if err := s.scim.PatchUser(ctx, patchUserRequest); err != nil {
+		if !isPatchNotSupported(err) {
+			return nil, fmt.Errorf("scim: error patching user: %w", err)
+		}
+
+		slog.Warn("scim user patch rejected, replacing it", "user", user.DisplayName, "error", err)
+		return s.replaceUser(ctx, user, userRequest)
+	}
+
+	s.rememberUser((*aws.User)(userRequest))
+	user.SetHashCode()
+
+	return user, nil
+}
+
+// currentUser returns the user in the SCIM Provider, the one read by GetUsers
+// or loadUsers when available.
+func (s *Provider) currentUser(ctx context.Context, id string) (*aws.User, error) {
+	if u, ok := s.knownUser(id); ok {
+		return u, nil
+	}
+
+	u, err := s.scim.GetUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return (*aws.User)(u), nil
+}
+
+// listUsers reads the users of the SCIM Provider and keeps them to patch the
+// updates against them.
+func (s *Provider) listUsers(ctx context.Context) ([]*aws.User, error) {
+	usersResponse, err := s.scim.ListUsers(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("scim: error listing users: %w", err)
+	}
+
+	s.scimUsersMu.Lock()
+	defer s.scimUsersMu.Unlock()
+
+	s.scimUsers = make(map[string]*aws.User, len(usersResponse.Resources))
+	for _, user := range usersResponse.Resources {
+		s.scimUsers[user.ID] = user
+	}
+
+	return usersResponse.Resources, nil
+}
+
+// loadUsers reads the users of the SCIM Provider when they are not known yet,
+// so the updates do not read the users one by one.
+func (s *Provider) loadUsers(ctx context.Context) error {
+	s.scimUsersMu.Lock()
+	loaded := s.scimUsers != nil
+	s.scimUsersMu.Unlock()
+
+	if loaded {
+		return nil
+	}
+
+	_, err := s.listUsers(ctx)
+	return err
+}
+
+// rememberUser keeps the user as it is in the SCIM Provider.
+func (s *Provider) rememberUser(u *aws.User) {
+	s.scimUsersMu.Lock()
+	defer s.scimUsersMu.Unlock()
+
+	if s.scimUsers == nil {
+		s.scimUsers = make(map[string]*aws.User)
+	}
+	s.scimUsers[u.ID] = u
+}
+
+// knownUser returns the user as it is in the SCIM Provider, when known.
+func (s *Provider) knownUser(id string) (*aws.User, bool) {
+	s.scimUsersMu.Lock()
+	defer s.scimUsersMu.Unlock()
+
+	u, ok := s.scimUsers[id]
+	return u, ok
+}
+
+// replaceUser replaces the whole user in the SCIM Provider.
+func (s *Provider) replaceUser(ctx context.Context, user *model.User, userRequest *aws.PutUserRequest) (*model.User, error) {
 	pur, err := s.scim.PutUser(ctx, userRequest)
 	if err != nil {
 		return nil, fmt.Errorf("scim: error updating user: %w", err)
 	}
 
+	s.rememberUser((*aws.User)(pur))
+
 	// update the user SCIM ID from the put user response
 	user.SCIMID = pur.ID
 	user.SetHashCode()
@@ -300,6 +542,19 @@ func (s *Provider) updateUser(ctx context.Context, user *model.User) (*model.Use
 
 // DeleteUsers deletes users in SCIM Provider given a list of users
 func (s *Provider) DeleteUsers(ctx context.Context, ur *model.UsersResult) error {
+	if s.bulkEnabled() && ur != nil {
+		ids := make([]string, len(ur.Resources))
+		for i, user := range ur.Resources {
+			// an empty id would delete the whole collection
+			if user.SCIMID == "" {
+				return fmt.Errorf("scim: error deleting user: %s, %w", user.GetPrimaryEmailAddress(), aws.ErrUserIDEmpty)
+			}
+			slog.Warn("deleting user", "user", user.DisplayName, "email", user.GetPrimaryEmailAddress())
+			ids[i] = user.SCIMID
+		}
+		return s.deleteBulk(ctx, aws.UsersPath, ids)
+	}
+
 	_, err := s.processUsers(ctx, ur, s.deleteUser)
 	return err
 }
@@ -320,8 +575,11 @@ type patchValue struct {
 // CreateGroupsMembers creates groups members in SCIM Provider given a list of groups members
 func (s *Provider) CreateGroupsMembers(ctx context.Context, gmr *model.GroupsMembersResult) (*model.GroupsMembersResult, error) {
 	groupsMembers := make([]*model.GroupMembers, len(gmr.Resources))
+	bulkPatches := make([][]*aws.PatchGroupRequest, len(gmr.Resources))
 
-	for i, groupMembers := range gmr.Resources {
+	// the groups are patched concurrently, the requests of every group in order
+	err := s.forEach(ctx, len(gmr.Resources), func(ctx context.Context, i int) error {
+		groupMembers := gmr.Resources[i]
 		members := make([]*model.Member, len(groupMembers.Resources))
 		membersIDValue := make([]patchValue, len(groupMembers.Resources))
 
@@ -329,7 +587,7 @@ func (s *Provider) CreateGroupsMembers(ctx context.Context, gmr *model.GroupsMem
 			if member.SCIMID == "" {
 				u, err := s.scim.GetUserByUserName(ctx, member.Email)
 				if err != nil {
-					return nil, fmt.Errorf("scim: error getting user by email: %w", err)
+					return fmt.Errorf("scim: error getting user by email: %w", err)
 				}
 				member.SCIMID = u.ID
 			}
@@ -343,6 +601,7 @@ func (s *Provider) CreateGroupsMembers(ctx context.Context, gmr *model.GroupsMem
 				WithSCIMID(member.SCIMID).
 				WithEmail(member.Email).
 				WithStatus(member.Status).
+				WithVia(member.Via).
 				Build()
 
 			slog.Warn("adding member to group", "group", groupMembers.Group.Name, "email", member.Email)
@@ -367,11 +626,25 @@ func (s *Provider) CreateGroupsMembers(ctx context.Context, gmr *model.GroupsMem
 			)
 		}
 
+		if s.bulkEnabled() {
+			bulkPatches[i] = patchOperations
+			return nil
+		}
+
 		for _, patchGroupRequest := range patchOperations {
 			if err := s.scim.PatchGroup(ctx, patchGroupRequest); err != nil {
-				return nil, fmt.Errorf("scim: error patching group: %w", err)
+				return fmt.Errorf("scim: error patching group: %w", err)
 			}
 		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.patchGroupsBulk(ctx, slices.Concat(bulkPatches...)); err != nil {
+		return nil, err
 	}
 
 	groupsMembersResult := model.GroupsMembersResultBuilder().WithResources(groupsMembers).Build()
@@ -382,7 +655,11 @@ func (s *Provider) CreateGroupsMembers(ctx context.Context, gmr *model.GroupsMem
 
 // DeleteGroupsMembers deletes groups members in SCIM Provider given a list of groups members
 func (s *Provider) DeleteGroupsMembers(ctx context.Context, gmr *model.GroupsMembersResult) error {
-	for _, groupMembers := range gmr.Resources {
+	bulkPatches := make([][]*aws.PatchGroupRequest, len(gmr.Resources))
+
+	// the groups are patched concurrently, the requests of every group in order
+	err := s.forEach(ctx, len(gmr.Resources), func(ctx context.Context, i int) error {
+		groupMembers := gmr.Resources[i]
 		membersIDValue := []patchValue{}
 
 		for _, member := range groupMembers.Resources {
@@ -403,14 +680,24 @@ func (s *Provider) DeleteGroupsMembers(ctx context.Context, gmr *model.GroupsMem
 			)
 		}
 
+		if s.bulkEnabled() {
+			bulkPatches[i] = patchOperations
+			return nil
+		}
+
 		for _, patchGroupRequest := range patchOperations {
 			if err := s.scim.PatchGroup(ctx, patchGroupRequest); err != nil {
 				return fmt.Errorf("scim: error patching group: %w", err)
 			}
 		}
+
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	return nil
+	return s.patchGroupsBulk(ctx, slices.Concat(bulkPatches...))
 }
 
 // patchGroupOperations assembles the operations for patch groups
@@ -3,6 +3,7 @@ package scim
 import (
 	"context"
 	"errors"
+	"net/http"
 	"strconv"
 	"sync"
 	"testing"
@@ -483,7 +484,17 @@ func TestProvider_UpdateGroups(t *testing.T) {
 				},
 			},
 			prepare: func(m *mock_scim.MockAWSSCIMProvider) {
-				m.EXPECT().PatchGroup(gomock.Any(), gomock.Any()).Return(nil)
+				// the renamed groups keep their SCIM ID, the display name is replaced
+				m.EXPECT().PatchGroup(gomock.Any(), &aws.PatchGroupRequest{
+					Group: aws.Group{ID: "1", DisplayName: "group1"},
+					Patch: aws.Patch{
+						Schemas: []string{"urn:ietf:params:scim:api:messages:2.0:PatchOp"},
+						Operations: []*aws.Operation{
+							{OP: "replace", Value: map[string]string{"id": "1", "externalId": "1"}},
+							{OP: "replace", Path: "displayName", Value: "group1"},
+						},
+					},
+				}).Return(nil)
 			},
 			want: &model.GroupsResult{
 				Resources: []*model.Group{
@@ -873,9 +884,16 @@ func TestProvider_UpdateUsers(t *testing.T) {
 				},
 			},
 			prepare: func(m *mock_scim.MockAWSSCIMProvider) {
-				m.EXPECT().PutUser(gomock.Any(), gomock.Any()).Return(&aws.PutUserResponse{
-					ID: "1",
+				m.EXPECT().ListUsers(gomock.Any(), "").Return(&aws.ListUsersResponse{
+					Resources: []*aws.User{{ID: "1", UserName: "user0", ExternalID: "1"}},
 				}, nil)
+				m.EXPECT().PatchUser(gomock.Any(), &aws.PatchUserRequest{
+					User: aws.User{ID: "1"},
+					Patch: aws.Patch{
+						Schemas:    []string{"urn:ietf:params:scim:api:messages:2.0:PatchOp"},
+						Operations: []*aws.Operation{{OP: "replace", Path: "userName", Value: "user1"}},
+					},
+				}).Return(nil)
 			},
 			want: &model.UsersResult{
 				Resources: []*model.User{
@@ -906,11 +924,128 @@ func TestProvider_UpdateUsers(t *testing.T) {
 				},
 			},
 			prepare: func(m *mock_scim.MockAWSSCIMProvider) {
+				m.EXPECT().ListUsers(gomock.Any(), "").Return(&aws.ListUsersResponse{}, nil)
+				m.EXPECT().GetUser(gomock.Any(), "1").Return(nil, &aws.HTTPResponseError{StatusCode: http.StatusNotFound})
 				m.EXPECT().PutUser(gomock.Any(), gomock.Any()).Return(nil, errors.New("error"))
 			},
 			want:    nil,
 			wantErr: true,
 		},
+		{
+			name: "should return an error when the users cannot be listed",
+			fields: fields{
+				scim: mockScimProvider,
+			},
+			args: args{
+				ctx: context.Background(),
+				ur: &model.UsersResult{
+					Resources: []*model.User{
+						{
+							SCIMID:   "1",
+							UserName: "user1",
+							IPID:     "1",
+						},
+					},
+				},
+			},
+			prepare: func(m *mock_scim.MockAWSSCIMProvider) {
+				m.EXPECT().ListUsers(gomock.Any(), "").Return(nil, errors.New("error"))
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "should not replace the user when it cannot be read",
+			fields: fields{
+				scim: mockScimProvider,
+			},
+			args: args{
+				ctx: context.Background(),
+				ur: &model.UsersResult{
+					Resources: []*model.User{
+						{
+							SCIMID:   "1",
+							UserName: "user1",
+							IPID:     "1",
+						},
+					},
+				},
+			},
+			prepare: func(m *mock_scim.MockAWSSCIMProvider) {
+				m.EXPECT().ListUsers(gomock.Any(), "").Return(&aws.ListUsersResponse{}, nil)
+				m.EXPECT().GetUser(gomock.Any(), "1").Return(nil, &aws.HTTPResponseError{StatusCode: http.StatusInternalServerError})
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "should replace the user not found",
+			fields: fields{
+				scim: mockScimProvider,
+			},
+			args: args{
+				ctx: context.Background(),
+				ur: &model.UsersResult{
+					Resources: []*model.User{
+						{
+							SCIMID:   "1",
+							UserName: "user1",
+							IPID:     "1",
+						},
+					},
+				},
+			},
+			prepare: func(m *mock_scim.MockAWSSCIMProvider) {
+				m.EXPECT().ListUsers(gomock.Any(), "").Return(&aws.ListUsersResponse{}, nil)
+				m.EXPECT().GetUser(gomock.Any(), "1").Return(nil, &aws.HTTPResponseError{StatusCode: http.StatusNotFound})
+				m.EXPECT().PutUser(gomock.Any(), gomock.Any()).Return(&aws.PutUserResponse{ID: "1"}, nil)
+			},
+			want: &model.UsersResult{
+				Resources: []*model.User{
+					{
+						SCIMID:   "1",
+						UserName: "user1",
+						IPID:     "1",
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "should replace the user when the patch is rejected",
+			fields: fields{
+				scim: mockScimProvider,
+			},
+			args: args{
+				ctx: context.Background(),
+				ur: &model.UsersResult{
+					Resources: []*model.User{
+						{
+							SCIMID:   "1",
+							UserName: "user1",
+							IPID:     "1",
+						},
+					},
+				},
+			},
+			prepare: func(m *mock_scim.MockAWSSCIMProvider) {
+				m.EXPECT().ListUsers(gomock.Any(), "").Return(&aws.ListUsersResponse{
+					Resources: []*aws.User{{ID: "1", UserName: "user0"}},
+				}, nil)
+				m.EXPECT().PatchUser(gomock.Any(), gomock.Any()).Return(&aws.HTTPResponseError{StatusCode: http.StatusBadRequest})
+				m.EXPECT().PutUser(gomock.Any(), gomock.Any()).Return(&aws.PutUserResponse{ID: "1"}, nil)
+			},
+			want: &model.UsersResult{
+				Resources: []*model.User{
+					{
+						SCIMID:   "1",
+						UserName: "user1",
+						IPID:     "1",
+					},
+				},
+			},
+			wantErr: false,
+		},
 		{
 			name: "should return an error if users result is nil",
 			fields: fields{
@@ -940,7 +1075,9 @@ func TestProvider_UpdateUsers(t *testing.T) {
 					},
 				},
 			},
-			prepare: func(m *mock_scim.MockAWSSCIMProvider) {},
+			prepare: func(m *mock_scim.MockAWSSCIMProvider) {
+				m.EXPECT().ListUsers(gomock.Any(), "").Return(&aws.ListUsersResponse{}, nil)
+			},
 			want:    nil,
 			wantErr: true,
 		},
@@ -1704,3 +1841,103 @@ func TestProvider_GetGroupsMembers_ConcurrencyLimit(t *testing.T) {
 		}
 	})
 }
+
+// TestProvider_DeleteUsers_Concurrency checks the mutations are sent by up to
+// WithConcurrency workers, using testing/synctest as the test above.
+func TestProvider_DeleteUsers_Concurrency(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockScimProvider := mock_scim.NewMockAWSSCIMProvider(ctrl)
+
+		const userCount = 12
+		const concurrency = 3
+
+		users := make([]*model.User, 0, userCount)
+		for i := range userCount {
+			users = append(users, &model.User{SCIMID: "u-" + strconv.Itoa(i)})
+		}
+
+		var (
+			mu          sync.Mutex
+			inFlight    int
+			maxInFlight int
+		)
+
+		mockScimProvider.EXPECT().
+			DeleteUser(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ string) error {
+				mu.Lock()
+				inFlight++
+				maxInFlight = max(maxInFlight, inFlight)
+				mu.Unlock()
+
+				time.Sleep(50 * time.Millisecond)
+
+				mu.Lock()
+				inFlight--
+				mu.Unlock()
+				return nil
+			}).Times(userCount)
+
+		p, _ := NewProvider(mockScimProvider, WithConcurrency(concurrency))
+
+		if err := p.DeleteUsers(context.Background(), &model.UsersResult{Resources: users}); err != nil {
+			t.Fatalf("DeleteUsers() error = %v", err)
+		}
+		if maxInFlight != concurrency {
+			t.Errorf("max in-flight calls = %d, want %d", maxInFlight, concurrency)
+		}
+	})
+}
+
+func TestProvider_CreateGroups_Concurrency(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockScimProvider := mock_scim.NewMockAWSSCIMProvider(ctrl)
+
+		const groupCount = 6
+
+		groups := make([]*model.Group, 0, groupCount)
+		for i := range groupCount {
+			groups = append(groups, &model.Group{IPID: strconv.Itoa(i), Name: "group-" + strconv.Itoa(i)})
+		}
+
+		// the first groups are answered last, the results keep the order requested
+		mockScimProvider.EXPECT().
+			CreateOrGetGroup(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, g *aws.CreateGroupRequest) (*aws.CreateGroupResponse, error) {
+				i, _ := strconv.Atoi(g.ExternalID)
+				time.Sleep(time.Duration(groupCount-i) * time.Millisecond)
+				return &aws.CreateGroupResponse{ID: "g-" + g.ExternalID}, nil
+			}).Times(groupCount)
+
+		p, _ := NewProvider(mockScimProvider, WithConcurrency(groupCount))
+
+		got, err := p.CreateGroups(context.Background(), &model.GroupsResult{Resources: groups})
+		if err != nil {
+			t.Fatalf("CreateGroups() error = %v", err)
+		}
+		for i, g := range got.Resources {
+			if want := "g-" + strconv.Itoa(i); g.SCIMID != want {
+				t.Errorf("CreateGroups() group %d SCIMID = %s, want %s", i, g.SCIMID, want)
+			}
+		}
+	})
+
+	t.Run("should stop on the first error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockScimProvider := mock_scim.NewMockAWSSCIMProvider(ctrl)
+
+		mockScimProvider.EXPECT().CreateOrGetGroup(gomock.Any(), gomock.Any()).Return(nil, errors.New("error"))
+
+		p, _ := NewProvider(mockScimProvider, WithConcurrency(1))
+
+		_, err := p.CreateGroups(context.Background(), &model.GroupsResult{Resources: []*model.Group{
+			{IPID: "1", Name: "group-1"},
+			{IPID: "2", Name: "group-2"},
+		}})
+		if err == nil {
+			t.Errorf("CreateGroups() error = nil, want error")
+		}
+	})
+}
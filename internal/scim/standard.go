@@ -0,0 +1,708 @@
+package scim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	"github.com/slashdevops/idp-scim-sync/pkg/scimclient"
+	"golang.org/x/sync/errgroup"
+)
+
+// This implement core.SCIMService interface for any SCIM 2.0 service provider
+
+//go:generate go tool mockgen -package=mocks -destination=../../mocks/scim/standard_mocks.go -source=standard.go StandardSCIMProvider
+
+// StandardSCIMProvider interface to consume scimclient package methods
+type StandardSCIMProvider interface {
+	// ListUsers lists the users matching the filter expressions
+	ListUsers(ctx context.Context, filter []string) ([]*scimclient.User, error)
+
+	// GetUserByUserName gets a user by its user name
+	GetUserByUserName(ctx context.Context, userName string) (*scimclient.User, error)
+
+	// CreateUser creates a user
+	CreateUser(ctx context.Context, u *scimclient.User) (*scimclient.User, error)
+
+	// ReplaceUser replaces a user, conditional to its version when it is defined
+	ReplaceUser(ctx context.Context, u *scimclient.User) (*scimclient.User, error)
+
+	// DeleteUser deletes a user
+	DeleteUser(ctx context.Context, userID string) error
+
+	// ListGroups lists the groups, without members, matching the filter expressions
+	ListGroups(ctx context.Context, filter []string) ([]*scimclient.Group, error)
+
+	// GetGroup gets a group with its members
+	GetGroup(ctx context.Context, groupID string) (*scimclient.Group, error)
+
+	// GetGroupByDisplayName gets a group by its display name
+	GetGroupByDisplayName(ctx context.Context, displayName string) (*scimclient.Group, error)
+
+	// CreateGroup creates a group
+	CreateGroup(ctx context.Context, g *scimclient.Group) (*scimclient.Group, error)
+
+	// PatchGroup patches a group, conditional to the version when it is not empty
+	PatchGroup(ctx context.Context, groupID, version string, ops []*scimclient.PatchOperation) (string, error)
+
+	// DeleteGroup deletes a group
+	DeleteGroup(ctx context.Context, groupID string) error
+}
+
+// StandardProvider represents a standards compliant SCIM 2.0 service provider, e.g. GitHub Enterprise,
+// Atlassian or Slack.
+//
+// Unlike AWS IAM Identity Center, the group members are returned by the group GETs, the members
+// are removed with a filter path and the modifications are conditional to the ETags of the resources
+// read before, so the resources modified by someone else since then are not overwritten.
+type StandardProvider struct {
+	scim                 StandardSCIMProvider
+	maxMembersPerRequest int
+
+	userVersions  *versions
+	groupVersions *versions
+}
+
+// NewStandardProvider creates a new standards compliant SCIM provider.
+func NewStandardProvider(scim StandardSCIMProvider) (*StandardProvider, error) {
+	if scim == nil {
+		return nil, ErrSCIMProviderNil
+	}
+
+	return &StandardProvider{
+		scim:                 scim,
+		maxMembersPerRequest: MaxPatchGroupMembersPerRequest,
+		userVersions:         newVersions(),
+		groupVersions:        newVersions(),
+	}, nil
+}
+
+// versions keeps the versions, ETags, of the resources read from the SCIM service provider.
+type versions struct {
+	mu sync.Mutex
+	m  map[string]string
+}
+
+func newVersions() *versions {
+	return &versions{m: make(map[string]string)}
+}
+
+// get returns the version of the resource, empty when unknown.
+func (v *versions) get(id string) string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.m[id]
+}
+
+// set keeps the version of the resource, an empty version forgets it.
+func (v *versions) set(id string, meta *scimclient.Meta) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if meta == nil || meta.Version == "" {
+		delete(v.m, id)
+		return
+	}
+	v.m[id] = meta.Version
+}
+
+// isStatusCode returns true when err is a SCIM API error with the status code.
+func isStatusCode(err error, statusCode int) bool {
+	apiErr, ok := errors.AsType[*scimclient.APIError](err)
+	return ok && apiErr.StatusCode == statusCode
+}
+
+// GetGroups returns groups from SCIM Provider
+func (s *StandardProvider) GetGroups(ctx context.Context) (*model.GroupsResult, error) {
+	sGroups, err := s.scim.ListGroups(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("scim: error listing groups: %w", err)
+	}
+
+	groups := make([]*model.Group, len(sGroups))
+	for i, group := range sGroups {
+		s.groupVersions.set(group.ID, group.Meta)
+
+		groups[i] = model.GroupBuilder().
+			WithSCIMID(group.ID).
+			WithName(group.DisplayName).
+			WithIPID(group.ExternalID).
+			Build()
+	}
+
+	groupsResult := model.GroupsResultBuilder().WithResources(groups).Build()
+	slog.Debug("scim: standard GetGroups()", "groups", len(groups))
+
+	return groupsResult, nil
+}
+
+// CreateGroups creates groups in SCIM Provider
+func (s *StandardProvider) CreateGroups(ctx context.Context, gr *model.GroupsResult) (*model.GroupsResult, error) {
+	return processStandardGroups(ctx, gr, s.createGroup)
+}
+
+func (s *StandardProvider) createGroup(ctx context.Context, group *model.Group) (*model.Group, error) {
+	slog.Warn("creating group", "group", group.Name)
+
+	g, err := s.scim.CreateGroup(ctx, &scimclient.Group{
+		DisplayName: group.Name,
+		ExternalID:  group.IPID,
+	})
+	if err != nil {
+		if !isStatusCode(err, http.StatusConflict) {
+			return nil, fmt.Errorf("scim: error creating group: %w", err)
+		}
+
+		// the group already exists
+		g, err = s.scim.GetGroupByDisplayName(ctx, group.Name)
+		if err != nil {
+			return nil, fmt.Errorf("scim: error getting existing group: %w", err)
+		}
+	}
+	s.groupVersions.set(g.ID, g.Meta)
+
+	return model.GroupBuilder().
+		WithSCIMID(g.ID).
+		WithName(group.Name).
+		WithIPID(group.IPID).
+		WithEmail(group.Email).
+		Build(), nil
+}
+
+// UpdateGroups updates groups in SCIM Provider
+func (s *StandardProvider) UpdateGroups(ctx context.Context, gr *model.GroupsResult) (*model.GroupsResult, error) {
+	return processStandardGroups(ctx, gr, s.updateGroup)
+}
+
+func (s *StandardProvider) updateGroup(ctx context.Context, group *model.Group) (*model.Group, error) {
+	slog.Warn("updating group", "group", group.Name, "email", group.Email)
+
+	ops := []*scimclient.PatchOperation{
+		{
+			Op: scimclient.PatchOpReplace,
+			Value: map[string]string{
+				"displayName": group.Name,
+				"externalId":  group.IPID,
+			},
+		},
+	}
+
+	if err := s.patchGroup(ctx, group.SCIMID, ops); err != nil {
+		return nil, fmt.Errorf("scim: error updating groups: %w", err)
+	}
+
+	return group, nil
+}
+
+// patchGroup patches the group conditional to its version and keeps the new one.
+func (s *StandardProvider) patchGroup(ctx context.Context, groupID string, ops []*scimclient.PatchOperation) error {
+	version, err := s.scim.PatchGroup(ctx, groupID, s.groupVersions.get(groupID), ops)
+	if err != nil {
+		return err
+	}
+	s.groupVersions.set(groupID, &scimclient.Meta{Version: version})
+
+	return nil
+}
+
+// DeleteGroups deletes groups in SCIM Provider
+func (s *StandardProvider) DeleteGroups(ctx context.Context, gr *model.GroupsResult) error {
+	_, err := processStandardGroups(ctx, gr, s.deleteGroup)
+	return err
+}
+
+func (s *StandardProvider) deleteGroup(ctx context.Context, group *model.Group) (*model.Group, error) {
+	slog.Warn("deleting group", "group", group.Name, "email", group.Email)
+
+	// the group may be deleted already
+	if err := s.scim.DeleteGroup(ctx, group.SCIMID); err != nil && !isStatusCode(err, http.StatusNotFound) {
+		return nil, fmt.Errorf("scim: error deleting group: %s, %w", group.SCIMID, err)
+	}
+	return nil, nil
+}
+
+// processStandardGroups processes a list of groups and applies a function to each group.
+func processStandardGroups(ctx context.Context, gr *model.GroupsResult, processFunc func(context.Context, *model.Group) (*model.Group, error)) (*model.GroupsResult, error) {
+	if gr == nil {
+		return nil, fmt.Errorf("scim: groups result is nil")
+	}
+
+	processedGroups := make([]*model.Group, 0, len(gr.Resources))
+	for _, group := range gr.Resources {
+		processedGroup, err := processFunc(ctx, group)
+		if err != nil {
+			return nil, err
+		}
+		if processedGroup != nil {
+			processedGroups = append(processedGroups, processedGroup)
+		}
+	}
+
+	return model.GroupsResultBuilder().WithResources(processedGroups).Build(), nil
+}
+
+// GetUsers returns users from SCIM Provider
+func (s *StandardProvider) GetUsers(ctx context.Context) (*model.UsersResult, error) {
+	sUsers, err := s.scim.ListUsers(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("scim: error listing users: %w", err)
+	}
+
+	users := make([]*model.User, 0, len(sUsers))
+	for _, user := range sUsers {
+		s.userVersions.set(user.ID, user.Meta)
+
+		if u := buildStandardUser(user); u != nil {
+			users = append(users, u)
+		}
+	}
+
+	usersResult := model.UsersResultBuilder().WithResources(users).Build()
+	slog.Debug("scim: standard GetUsers()", "users", len(users))
+
+	return usersResult, nil
+}
+
+// CreateUsers creates users in SCIM Provider
+func (s *StandardProvider) CreateUsers(ctx context.Context, ur *model.UsersResult) (*model.UsersResult, error) {
+	return processStandardUsers(ctx, ur, s.createUser)
+}
+
+func (s *StandardProvider) createUser(ctx context.Context, user *model.User) (*model.User, error) {
+	slog.Warn("creating user", "user", user.DisplayName, "email", user.GetPrimaryEmailAddress())
+
+	u, err := s.scim.CreateUser(ctx, buildStandardUserRequest(user))
+	if err != nil {
+		if !isStatusCode(err, http.StatusConflict) {
+			return nil, fmt.Errorf("scim: error creating user: %w", err)
+		}
+
+		// the user already exists
+		u, err = s.scim.GetUserByUserName(ctx, user.UserName)
+		if err != nil {
+			return nil, fmt.Errorf("scim: error getting existing user: %w", err)
+		}
+	}
+	s.userVersions.set(u.ID, u.Meta)
+
+	user.SCIMID = u.ID
+	user.SetHashCode()
+
+	return user, nil
+}
+
+// UpdateUsers updates users in SCIM Provider given a list of users
+func (s *StandardProvider) UpdateUsers(ctx context.Context, ur *model.UsersResult) (*model.UsersResult, error) {
+	return processStandardUsers(ctx, ur, s.updateUser)
+}
+
+func (s *StandardProvider) updateUser(ctx context.Context, user *model.User) (*model.User, error) {
+	if user.SCIMID == "" {
+		return nil, fmt.Errorf("scim: error updating user, user ID is empty: %s", user.SCIMID)
+	}
+
+	slog.Warn("updating user", "user", user.DisplayName, "email", user.GetPrimaryEmailAddress())
+
+	userRequest := buildStandardUserRequest(user)
+	userRequest.ID = user.SCIMID
+	if version := s.userVersions.get(user.SCIMID); version != "" {
+		userRequest.Meta = &scimclient.Meta{Version: version}
+	}
+
+	u, err := s.scim.ReplaceUser(ctx, userRequest)
+	if err != nil {
+		return nil, fmt.Errorf("scim: error updating user: %w", err)
+	}
+	s.userVersions.set(u.ID, u.Meta)
+
+	// update the user SCIM ID from the replace user response
+	user.SCIMID = u.ID
+	user.SetHashCode()
+
+	return user, nil
+}
+
+// DeleteUsers deletes users in SCIM Provider given a list of users
+func (s *StandardProvider) DeleteUsers(ctx context.Context, ur *model.UsersResult) error {
+	_, err := processStandardUsers(ctx, ur, s.deleteUser)
+	return err
+}
+
+func (s *StandardProvider) deleteUser(ctx context.Context, user *model.User) (*model.User, error) {
+	slog.Warn("deleting user", "user", user.DisplayName, "email", user.GetPrimaryEmailAddress())
+
+	// the user may be deleted already
+	if err := s.scim.DeleteUser(ctx, user.SCIMID); err != nil && !isStatusCode(err, http.StatusNotFound) {
+		return nil, fmt.Errorf("scim: error deleting user: %s, %w", user.SCIMID, err)
+	}
+	return nil, nil
+}
+
+// processStandardUsers processes a list of users and applies a function to each user.
+func processStandardUsers(ctx context.Context, ur *model.UsersResult, processFunc func(context.Context, *model.User) (*model.User, error)) (*model.UsersResult, error) {
+	if ur == nil {
+		return nil, fmt.Errorf("scim: users result is nil")
+	}
+
+	processedUsers := make([]*model.User, 0, len(ur.Resources))
+	for _, user := range ur.Resources {
+		processedUser, err := processFunc(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+		if processedUser != nil {
+			processedUsers = append(processedUsers, processedUser)
+		}
+	}
+
+	return model.UsersResultBuilder().WithResources(processedUsers).Build(), nil
+}
+
+// CreateGroupsMembers creates groups members in SCIM Provider given a list of groups members
+func (s *StandardProvider) CreateGroupsMembers(ctx context.Context, gmr *model.GroupsMembersResult) (*model.GroupsMembersResult, error) {
+	groupsMembers := make([]*model.GroupMembers, len(gmr.Resources))
+
+	for i, groupMembers := range gmr.Resources {
+		members := make([]*model.Member, len(groupMembers.Resources))
+		membersIDValue := make([]patchValue, len(groupMembers.Resources))
+
+		for j, member := range groupMembers.Resources {
+			if member.SCIMID == "" {
+				u, err := s.scim.GetUserByUserName(ctx, member.Email)
+				if err != nil {
+					return nil, fmt.Errorf("scim: error getting user by email: %w", err)
+				}
+				member.SCIMID = u.ID
+			}
+
+			membersIDValue[j] = patchValue{
+				Value: member.SCIMID,
+			}
+
+			members[j] = model.MemberBuilder().
+				WithIPID(member.IPID).
+				WithSCIMID(member.SCIMID).
+				WithEmail(member.Email).
+				WithStatus(member.Status).
+				WithVia(member.Via).
+				Build()
+
+			slog.Warn("adding member to group", "group", groupMembers.Group.Name, "email", member.Email)
+		}
+
+		groupsMembers[i] = model.GroupMembersBuilder().
+			WithGroup(groupMembers.Group).
+			WithResources(members).
+			Build()
+
+		for chunk := range chunks(membersIDValue, s.maxMembersPerRequest) {
+			ops := []*scimclient.PatchOperation{
+				{
+					Op:    scimclient.PatchOpAdd,
+					Path:  "members",
+					Value: chunk,
+				},
+			}
+
+			if err := s.patchGroup(ctx, groupMembers.Group.SCIMID, ops); err != nil {
+				return nil, fmt.Errorf("scim: error patching group: %w", err)
+			}
+		}
+	}
+
+	groupsMembersResult := model.GroupsMembersResultBuilder().WithResources(groupsMembers).Build()
+	slog.Debug("scim: standard CreateGroupsMembers()", "groups_members", len(groupsMembers))
+
+	return groupsMembersResult, nil
+}
+
+// DeleteGroupsMembers deletes groups members in SCIM Provider given a list of groups members.
+// Every member is removed with a value filter path, the standard way to remove some values of
+// a multi-valued attribute.
+// reference: https://datatracker.ietf.org/doc/html/rfc7644#section-3.5.2.2
+func (s *StandardProvider) DeleteGroupsMembers(ctx context.Context, gmr *model.GroupsMembersResult) error {
+	for _, groupMembers := range gmr.Resources {
+		ops := make([]*scimclient.PatchOperation, 0, len(groupMembers.Resources))
+
+		for _, member := range groupMembers.Resources {
+			ops = append(ops, &scimclient.PatchOperation{
+				Op:   scimclient.PatchOpRemove,
+				Path: fmt.Sprintf("members[value eq %q]", member.SCIMID),
+			})
+			slog.Warn("removing member from group", "group", groupMembers.Group.Name, "email", member.Email)
+		}
+
+		for chunk := range chunks(ops, s.maxMembersPerRequest) {
+			if err := s.patchGroup(ctx, groupMembers.Group.SCIMID, chunk); err != nil {
+				return fmt.Errorf("scim: error patching group: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// chunks yields the values in chunks of size at most, all of them in a single chunk when size is not positive.
+func chunks[T any](values []T, size int) func(yield func([]T) bool) {
+	return func(yield func([]T) bool) {
+		if size <= 0 {
+			size = len(values)
+		}
+
+		for i := 0; i < len(values); i += size {
+			if !yield(values[i:min(i+size, len(values))]) {
+				return
+			}
+		}
+	}
+}
+
+// GetGroupsMembers returns the SCIM-side groups-to-members mapping for the groups in gr,
+// read from the members returned by the group GETs.
+//
+// Only the members present in ur are written into the result; the members of other
+// resource types, e.g. nested groups, or not in scope are discarded. The result
+// preserves the order of gr.Resources.
+func (s *StandardProvider) GetGroupsMembers(ctx context.Context, gr *model.GroupsResult, ur *model.UsersResult) (*model.GroupsMembersResult, error) {
+	userBySCIMID := make(map[string]*model.User, len(ur.Resources))
+	for _, user := range ur.Resources {
+		userBySCIMID[user.SCIMID] = user
+	}
+
+	groupMembers := make([]*model.GroupMembers, len(gr.Resources))
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(getGroupsMembersConcurrency)
+
+	for i, group := range gr.Resources {
+		g.Go(func() error {
+			sGroup, err := s.scim.GetGroup(ctx, group.SCIMID)
+			if err != nil {
+				return fmt.Errorf("scim: error getting group %q: %w", group.SCIMID, err)
+			}
+			s.groupVersions.set(sGroup.ID, sGroup.Meta)
+
+			members := make([]*model.Member, 0, len(sGroup.Members))
+			for _, sMember := range sGroup.Members {
+				if sMember.IsGroup() {
+					continue
+				}
+
+				user, inScope := userBySCIMID[sMember.Value]
+				if !inScope {
+					continue
+				}
+
+				m := model.MemberBuilder().
+					WithIPID(user.IPID).
+					WithSCIMID(user.SCIMID).
+					WithEmail(user.GetPrimaryEmailAddress()).
+					Build()
+
+				if user.Active {
+					m.Status = "ACTIVE"
+				}
+
+				members = append(members, m)
+			}
+
+			groupMembers[i] = model.GroupMembersBuilder().
+				WithGroup(group).
+				WithResources(members).
+				Build()
+
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	slog.Debug("scim: standard GetGroupsMembers()", "groups_members", len(groupMembers))
+	return model.GroupsMembersResultBuilder().WithResources(groupMembers).Build(), nil
+}
+
+// buildStandardUser creates a User model from a SCIM user
+func buildStandardUser(user *scimclient.User) *model.User {
+	if user == nil {
+		return nil
+	}
+
+	if user.ID == "" {
+		slog.Warn("scim: User ID is empty")
+		return nil
+	}
+
+	var emails []model.Email
+	for _, email := range user.Emails {
+		if email.Primary {
+			emails = append(emails,
+				model.EmailBuilder().
+					WithPrimary(email.Primary).
+					WithType(strings.TrimSpace(email.Type)).
+					WithValue(strings.TrimSpace(email.Value)).
+					Build(),
+			)
+		}
+	}
+
+	var addresses []model.Address
+	if len(user.Addresses) > 0 {
+		addresses = append(addresses,
+			model.AddressBuilder().
+				WithFormatted(strings.TrimSpace(user.Addresses[0].Formatted)).
+				WithStreetAddress(user.Addresses[0].StreetAddress).
+				WithLocality(user.Addresses[0].Locality).
+				WithRegion(user.Addresses[0].Region).
+				WithPostalCode(user.Addresses[0].PostalCode).
+				WithCountry(user.Addresses[0].Country).
+				Build(),
+		)
+	}
+
+	var phoneNumbers []model.PhoneNumber
+	if len(user.PhoneNumbers) > 0 {
+		phoneNumbers = append(phoneNumbers,
+			model.PhoneNumberBuilder().
+				WithValue(strings.TrimSpace(user.PhoneNumbers[0].Value)).
+				WithType(strings.TrimSpace(user.PhoneNumbers[0].Type)).
+				Build(),
+		)
+	}
+
+	var enterpriseData *model.EnterpriseData
+	if eu := user.EnterpriseUser; eu != nil {
+		var manager *model.Manager
+		if eu.Manager != nil {
+			manager = model.ManagerBuilder().
+				WithValue(strings.TrimSpace(eu.Manager.Value)).
+				WithRef(strings.TrimSpace(eu.Manager.Ref)).
+				Build()
+		}
+
+		enterpriseData = model.EnterpriseDataBuilder().
+			WithEmployeeNumber(strings.TrimSpace(eu.EmployeeNumber)).
+			WithCostCenter(strings.TrimSpace(eu.CostCenter)).
+			WithOrganization(strings.TrimSpace(eu.Organization)).
+			WithDivision(strings.TrimSpace(eu.Division)).
+			WithDepartment(strings.TrimSpace(eu.Department)).
+			WithManager(manager).
+			Build()
+	}
+
+	var name *model.Name
+	if user.Name != nil {
+		name = model.NameBuilder().
+			WithGivenName(strings.TrimSpace(user.Name.GivenName)).
+			WithFamilyName(strings.TrimSpace(user.Name.FamilyName)).
+			WithFormatted(strings.TrimSpace(user.Name.Formatted)).
+			WithMiddleName(user.Name.MiddleName).
+			WithHonorificPrefix(user.Name.HonorificPrefix).
+			WithHonorificSuffix(user.Name.HonorificSuffix).
+			Build()
+	}
+
+	return model.UserBuilder().
+		WithIPID(strings.TrimSpace(user.ExternalID)).
+		WithSCIMID(strings.TrimSpace(user.ID)).
+		WithUserName(strings.TrimSpace(user.UserName)).
+		WithDisplayName(strings.TrimSpace(user.DisplayName)).
+		WithTitle(strings.TrimSpace(user.Title)).
+		WithUserType(strings.TrimSpace(user.UserType)).
+		WithPreferredLanguage(strings.TrimSpace(user.PreferredLanguage)).
+		WithActive(user.IsActive()).
+		// Arrays
+		WithEmails(emails).
+		WithAddresses(addresses).
+		WithPhoneNumbers(phoneNumbers).
+		// Pointers
+		WithName(name).
+		WithEnterpriseData(enterpriseData).
+		Build()
+}
+
+// buildStandardUserRequest builds a SCIM user from a User model
+func buildStandardUserRequest(user *model.User) *scimclient.User {
+	active := user.Active
+
+	u := &scimclient.User{
+		ExternalID:        user.IPID,
+		UserName:          user.UserName,
+		DisplayName:       user.DisplayName,
+		UserType:          user.UserType,
+		Title:             user.Title,
+		PreferredLanguage: user.PreferredLanguage,
+		Locale:            user.Locale,
+		Timezone:          user.Timezone,
+		Active:            &active,
+	}
+
+	if user.Name != nil {
+		u.Name = &scimclient.Name{
+			FamilyName:      user.Name.FamilyName,
+			GivenName:       user.Name.GivenName,
+			Formatted:       user.Name.Formatted,
+			MiddleName:      user.Name.MiddleName,
+			HonorificPrefix: user.Name.HonorificPrefix,
+			HonorificSuffix: user.Name.HonorificSuffix,
+		}
+	}
+
+	for _, email := range user.Emails {
+		if email.Primary {
+			u.Emails = append(u.Emails, &scimclient.Email{
+				Value:   email.Value,
+				Type:    email.Type,
+				Primary: email.Primary,
+			})
+		}
+	}
+
+	if len(user.Addresses) > 0 {
+		u.Addresses = []*scimclient.Address{
+			{
+				Formatted:     user.Addresses[0].Formatted,
+				StreetAddress: user.Addresses[0].StreetAddress,
+				Locality:      user.Addresses[0].Locality,
+				Region:        user.Addresses[0].Region,
+				PostalCode:    user.Addresses[0].PostalCode,
+				Country:       user.Addresses[0].Country,
+			},
+		}
+	}
+
+	if len(user.PhoneNumbers) > 0 {
+		u.PhoneNumbers = []*scimclient.PhoneNumber{
+			{
+				Value: user.PhoneNumbers[0].Value,
+				Type:  user.PhoneNumbers[0].Type,
+			},
+		}
+	}
+
+	if user.EnterpriseData != nil {
+		u.EnterpriseUser = &scimclient.EnterpriseUser{
+			EmployeeNumber: user.EnterpriseData.EmployeeNumber,
+			CostCenter:     user.EnterpriseData.CostCenter,
+			Organization:   user.EnterpriseData.Organization,
+			Division:       user.EnterpriseData.Division,
+			Department:     user.EnterpriseData.Department,
+		}
+
+		if user.EnterpriseData.Manager != nil {
+			u.EnterpriseUser.Manager = &scimclient.Manager{
+				Value: user.EnterpriseData.Manager.Value,
+				Ref:   user.EnterpriseData.Manager.Ref,
+			}
+		}
+	}
+
+	return u
+}
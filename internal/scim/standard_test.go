@@ -0,0 +1,215 @@
+package scim
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	mock_scim "github.com/slashdevops/idp-scim-sync/mocks/scim"
+	"github.com/slashdevops/idp-scim-sync/pkg/scimclient"
+	"go.uber.org/mock/gomock"
+)
+
+func TestNewStandardProvider(t *testing.T) {
+	if _, err := NewStandardProvider(nil); !errors.Is(err, ErrSCIMProviderNil) {
+		t.Errorf("NewStandardProvider() error = %v, want %v", err, ErrSCIMProviderNil)
+	}
+
+	ctrl := gomock.NewController(t)
+	p, err := NewStandardProvider(mock_scim.NewMockStandardSCIMProvider(ctrl))
+	if err != nil {
+		t.Fatalf("NewStandardProvider() error = %v", err)
+	}
+	if p.maxMembersPerRequest != MaxPatchGroupMembersPerRequest {
+		t.Errorf("NewStandardProvider() maxMembersPerRequest = %d, want %d", p.maxMembersPerRequest, MaxPatchGroupMembersPerRequest)
+	}
+}
+
+func TestStandardProvider_CreateGroups(t *testing.T) {
+	conflict := &scimclient.APIError{StatusCode: http.StatusConflict, ScimType: "uniqueness"}
+
+	tests := []struct {
+		name    string
+		prepare func(m *mock_scim.MockStandardSCIMProvider)
+		want    *model.GroupsResult
+		wantErr bool
+	}{
+		{
+			name: "should create groups",
+			prepare: func(m *mock_scim.MockStandardSCIMProvider) {
+				m.EXPECT().CreateGroup(gomock.Any(), &scimclient.Group{DisplayName: "group1", ExternalID: "1"}).Return(&scimclient.Group{ID: "s1"}, nil)
+			},
+			want: &model.GroupsResult{Resources: []*model.Group{{SCIMID: "s1", Name: "group1", IPID: "1"}}},
+		},
+		{
+			name: "should get the existing group",
+			prepare: func(m *mock_scim.MockStandardSCIMProvider) {
+				m.EXPECT().CreateGroup(gomock.Any(), gomock.Any()).Return(nil, conflict)
+				m.EXPECT().GetGroupByDisplayName(gomock.Any(), "group1").Return(&scimclient.Group{ID: "s1"}, nil)
+			},
+			want: &model.GroupsResult{Resources: []*model.Group{{SCIMID: "s1", Name: "group1", IPID: "1"}}},
+		},
+		{
+			name: "should return an error",
+			prepare: func(m *mock_scim.MockStandardSCIMProvider) {
+				m.EXPECT().CreateGroup(gomock.Any(), gomock.Any()).Return(nil, errors.New("error"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockScimProvider := mock_scim.NewMockStandardSCIMProvider(ctrl)
+			tt.prepare(mockScimProvider)
+
+			p, _ := NewStandardProvider(mockScimProvider)
+			got, err := p.CreateGroups(context.Background(), &model.GroupsResult{Resources: []*model.Group{{Name: "group1", IPID: "1"}}})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("StandardProvider.CreateGroups() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if diff := cmp.Diff(tt.want, got, cmpopts.IgnoreFields(model.GroupsResult{}, "HashCode", "Items"), cmpopts.IgnoreFields(model.Group{}, "HashCode")); diff != "" {
+				t.Errorf("StandardProvider.CreateGroups() (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStandardProvider_UpdateUsers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockScimProvider := mock_scim.NewMockStandardSCIMProvider(ctrl)
+
+	mockScimProvider.EXPECT().ListUsers(gomock.Any(), nil).Return([]*scimclient.User{
+		{ID: "s1", UserName: "user.1@mail.com", Meta: &scimclient.Meta{Version: `W/"1"`}},
+	}, nil)
+
+	// the user is replaced conditional to the version read
+	mockScimProvider.EXPECT().ReplaceUser(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, u *scimclient.User) (*scimclient.User, error) {
+		if u.ID != "s1" || u.Meta == nil || u.Meta.Version != `W/"1"` {
+			t.Errorf("StandardProvider.UpdateUsers() replaced user = %+v", u)
+		}
+		if u.Active == nil || !*u.Active {
+			t.Errorf("StandardProvider.UpdateUsers() replaced user is not active")
+		}
+		return &scimclient.User{ID: "s1", Meta: &scimclient.Meta{Version: `W/"2"`}}, nil
+	})
+
+	p, _ := NewStandardProvider(mockScimProvider)
+
+	if _, err := p.GetUsers(context.Background()); err != nil {
+		t.Fatalf("StandardProvider.GetUsers() error = %v", err)
+	}
+
+	user := model.UserBuilder().WithSCIMID("s1").WithIPID("1").WithUserName("user.1@mail.com").WithActive(true).Build()
+	got, err := p.UpdateUsers(context.Background(), model.UsersResultBuilder().WithResources([]*model.User{user}).Build())
+	if err != nil {
+		t.Fatalf("StandardProvider.UpdateUsers() error = %v", err)
+	}
+	if got.Items != 1 || got.Resources[0].SCIMID != "s1" {
+		t.Errorf("StandardProvider.UpdateUsers() = %+v", got.Resources)
+	}
+	if v := p.userVersions.get("s1"); v != `W/"2"` {
+		t.Errorf("StandardProvider.UpdateUsers() version = %s, want %s", v, `W/"2"`)
+	}
+}
+
+func TestStandardProvider_DeleteUsers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockScimProvider := mock_scim.NewMockStandardSCIMProvider(ctrl)
+
+	// the users deleted already are skipped
+	mockScimProvider.EXPECT().DeleteUser(gomock.Any(), "s1").Return(&scimclient.APIError{StatusCode: http.StatusNotFound})
+	mockScimProvider.EXPECT().DeleteUser(gomock.Any(), "s2").Return(&scimclient.APIError{StatusCode: http.StatusForbidden})
+
+	p, _ := NewStandardProvider(mockScimProvider)
+
+	err := p.DeleteUsers(context.Background(), &model.UsersResult{Resources: []*model.User{{SCIMID: "s1"}, {SCIMID: "s2"}}})
+	if !isStatusCode(err, http.StatusForbidden) {
+		t.Errorf("StandardProvider.DeleteUsers() error = %v, want forbidden", err)
+	}
+}
+
+func TestStandardProvider_DeleteGroupsMembers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockScimProvider := mock_scim.NewMockStandardSCIMProvider(ctrl)
+
+	gmr := &model.GroupsMembersResult{
+		Resources: []*model.GroupMembers{
+			{
+				Group:     &model.Group{SCIMID: "g1", Name: "group1"},
+				Resources: []*model.Member{{SCIMID: "u1"}, {SCIMID: "u2"}, {SCIMID: "u3"}},
+			},
+			{
+				Group: &model.Group{SCIMID: "g2", Name: "group2"},
+			},
+		},
+	}
+
+	gomock.InOrder(
+		mockScimProvider.EXPECT().PatchGroup(gomock.Any(), "g1", "", []*scimclient.PatchOperation{
+			{Op: scimclient.PatchOpRemove, Path: `members[value eq "u1"]`},
+			{Op: scimclient.PatchOpRemove, Path: `members[value eq "u2"]`},
+		}).Return(`W/"2"`, nil),
+		// the next request is conditional to the version of the previous one
+		mockScimProvider.EXPECT().PatchGroup(gomock.Any(), "g1", `W/"2"`, []*scimclient.PatchOperation{
+			{Op: scimclient.PatchOpRemove, Path: `members[value eq "u3"]`},
+		}).Return("", nil),
+	)
+
+	p, _ := NewStandardProvider(mockScimProvider)
+	p.maxMembersPerRequest = 2
+
+	if err := p.DeleteGroupsMembers(context.Background(), gmr); err != nil {
+		t.Errorf("StandardProvider.DeleteGroupsMembers() error = %v", err)
+	}
+}
+
+func TestStandardProvider_GetGroupsMembers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockScimProvider := mock_scim.NewMockStandardSCIMProvider(ctrl)
+
+	mockScimProvider.EXPECT().GetGroup(gomock.Any(), "g1").Return(&scimclient.Group{ID: "g1", Members: []*scimclient.Member{
+		{Value: "u1", Type: scimclient.MemberTypeUser},
+		{Value: "u2"},
+		{Value: "g2", Type: scimclient.MemberTypeGroup},
+		{Value: "out-of-scope"},
+	}}, nil)
+	mockScimProvider.EXPECT().GetGroup(gomock.Any(), "g2").Return(&scimclient.Group{ID: "g2"}, nil)
+
+	group1 := &model.Group{SCIMID: "g1", Name: "group1"}
+	group2 := &model.Group{SCIMID: "g2", Name: "group2"}
+	gr := &model.GroupsResult{Resources: []*model.Group{group1, group2}}
+	ur := &model.UsersResult{Resources: []*model.User{
+		{SCIMID: "u1", IPID: "1", Active: true, Emails: []model.Email{{Value: "user.1@mail.com", Primary: true}}},
+		{SCIMID: "u2", IPID: "2", Emails: []model.Email{{Value: "user.2@mail.com", Primary: true}}},
+	}}
+
+	p, _ := NewStandardProvider(mockScimProvider)
+
+	got, err := p.GetGroupsMembers(context.Background(), gr, ur)
+	if err != nil {
+		t.Fatalf("StandardProvider.GetGroupsMembers() error = %v", err)
+	}
+
+	want := &model.GroupsMembersResult{Resources: []*model.GroupMembers{
+		{Group: group1, Resources: []*model.Member{
+			{IPID: "1", SCIMID: "u1", Email: "user.1@mail.com", Status: "ACTIVE"},
+			{IPID: "2", SCIMID: "u2", Email: "user.2@mail.com"},
+		}},
+		{Group: group2, Resources: []*model.Member{}},
+	}}
+
+	if diff := cmp.Diff(want, got,
+		cmpopts.IgnoreFields(model.GroupsMembersResult{}, "HashCode", "Items"),
+		cmpopts.IgnoreFields(model.GroupMembers{}, "HashCode", "Items"),
+		cmpopts.IgnoreFields(model.Member{}, "HashCode"),
+		cmpopts.IgnoreFields(model.Group{}, "HashCode"),
+	); diff != "" {
+		t.Errorf("StandardProvider.GetGroupsMembers() (-want +got):\n%s", diff)
+	}
+}
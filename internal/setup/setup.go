@@ -2,10 +2,13 @@ package setup
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
@@ -20,7 +23,12 @@ import (
 	"github.com/slashdevops/idp-scim-sync/internal/scim"
 	"github.com/slashdevops/idp-scim-sync/internal/version"
 	"github.com/slashdevops/idp-scim-sync/pkg/aws"
+	"github.com/slashdevops/idp-scim-sync/pkg/entra"
+	"github.com/slashdevops/idp-scim-sync/pkg/file"
 	"github.com/slashdevops/idp-scim-sync/pkg/google"
+	"github.com/slashdevops/idp-scim-sync/pkg/ldap"
+	"github.com/slashdevops/idp-scim-sync/pkg/okta"
+	"github.com/slashdevops/idp-scim-sync/pkg/scimclient"
 	"github.com/spf13/viper"
 )
 
@@ -72,13 +80,71 @@ func Configuration(cfg *config.Config) error {
 		"gws_user_email_secret_name",
 		"gws_service_account_file",
 		"gws_service_account_file_secret_name",
+		"gws_service_account_email",
 		"gws_groups_filter",
+		"gws_org_units",
+		"gws_nesting_policy",
+		"gws_suspended_users_policy",
+		"gws_member_roles",
+		"gws_external_members_policy",
+		"gws_external_domains",
+		"gws_duplicate_group_names",
+		"gws_custom_schema_attributes",
+		"gws_groups_source",
+		"gws_customer_id",
+		"gws_group_labels",
+		"gws_rate_limit_qps",
+		"gws_rate_limit_burst",
+		"gws_bulk_users_threshold",
+		"gws_cache",
 		"aws_scim_access_token",
 		"aws_scim_access_token_secret_name",
 		"aws_scim_endpoint",
 		"aws_scim_endpoint_secret_name",
+		"aws_scim_concurrency",
+		"aws_scim_rate_limit_qps",
+		"aws_scim_rate_limit_concurrency",
+		"target_type",
+		"scim_target_url",
+		"scim_target_token",
+		"scim_target_token_secret_name",
+		"scim_target_page_size",
 		"use_secrets_manager",
 		"sync_user_fields",
+		"profile",
+		"all_profiles",
+		"idp_type",
+		"entra_tenant_id",
+		"entra_client_id",
+		"entra_client_secret",
+		"entra_client_secret_secret_name",
+		"entra_groups_filter",
+		"entra_users_delta",
+		"okta_org_url",
+		"okta_api_token",
+		"okta_api_token_secret_name",
+		"okta_groups_filter",
+		"ldap_url",
+		"ldap_bind_dn",
+		"ldap_bind_password",
+		"ldap_bind_password_secret_name",
+		"ldap_base_dn",
+		"ldap_groups_filter",
+		"ldap_members_strategy",
+		"ldap_start_tls",
+		"ldap_insecure_skip_verify",
+		"ldap_page_size",
+		"file_path",
+		"file_format",
+		"file_groups_filter",
+		"scim_idp_url",
+		"scim_idp_token",
+		"scim_idp_token_secret_name",
+		"scim_idp_username",
+		"scim_idp_password",
+		"scim_idp_password_secret_name",
+		"scim_idp_groups_filter",
+		"scim_idp_page_size",
 	}
 	for _, e := range envVars {
 		if err := viper.BindEnv(e); err != nil {
@@ -124,6 +190,9 @@ func Configuration(cfg *config.Config) error {
 		return fmt.Errorf("cannot unmarshal config: %w", err)
 	}
 
+	// the profiles replace the top-level settings they define, even with zero values
+	cfg.DefineProfileSettings(viper.Get("profiles"))
+
 	if cfg.Debug {
 		cfg.LogLevel = "debug"
 	}
@@ -131,7 +200,13 @@ func Configuration(cfg *config.Config) error {
 	return nil
 }
 
-// Secrets sets up the secrets
+// secret is an AWS Secrets Manager secret name and the configuration value where its content is stored.
+type secret struct {
+	name  string
+	value *string
+}
+
+// Secrets sets up the secrets, only the secrets of the configured identity provider and target are read
 func Secrets(cfg *config.Config) error {
 	slog.Info("reading secrets from AWS Secrets Manager")
 
@@ -147,55 +222,74 @@ func Secrets(cfg *config.Config) error {
 		return fmt.Errorf("cannot create aws secrets manager service: %w", err)
 	}
 
-	// create a channel to receive the results
-	results := make(chan error, 4)
+	var toRead []secret
 
-	go func() {
-		slog.Debug("reading secret", "name", cfg.GWSUserEmailSecretName)
-		unwrap, err := secrets.GetSecretValue(context.Background(), cfg.GWSUserEmailSecretName)
-		if err != nil {
-			results <- fmt.Errorf("cannot get secretmanager value: %w", err)
-			return
-		}
-		cfg.GWSUserEmail = unwrap
-		results <- nil
-	}()
+	switch cfg.TargetType {
+	case config.TargetTypeSCIM:
+		toRead = append(toRead,
+			secret{name: cfg.SCIMTargetTokenSecretName, value: &cfg.SCIMTargetToken},
+		)
+	default:
+		toRead = append(toRead,
+			secret{name: cfg.AWSSCIMAccessTokenSecretName, value: &cfg.AWSSCIMAccessToken},
+			secret{name: cfg.AWSSCIMEndpointSecretName, value: &cfg.AWSSCIMEndpoint},
+		)
+	}
 
-	go func() {
-		slog.Debug("reading secret", "name", cfg.GWSServiceAccountFileSecretName)
-		unwrap, err := secrets.GetSecretValue(context.Background(), cfg.GWSServiceAccountFileSecretName)
-		if err != nil {
-			results <- fmt.Errorf("cannot get secretmanager value: %w", err)
-			return
+	switch cfg.IDPType {
+	case config.IDPTypeEntra:
+		toRead = append(toRead,
+			secret{name: cfg.EntraClientSecretSecretName, value: &cfg.EntraClientSecret},
+		)
+	case config.IDPTypeOkta:
+		toRead = append(toRead,
+			secret{name: cfg.OktaAPITokenSecretName, value: &cfg.OktaAPIToken},
+		)
+	case config.IDPTypeLDAP:
+		// the connection is not bound without bind dn
+		if cfg.LDAPBindDN != "" {
+			toRead = append(toRead,
+				secret{name: cfg.LDAPBindPasswordSecretName, value: &cfg.LDAPBindPassword},
+			)
 		}
-		cfg.GWSServiceAccountFile = unwrap
-		results <- nil
-	}()
-
-	go func() {
-		slog.Debug("reading secret", "name", cfg.AWSSCIMAccessTokenSecretName)
-		unwrap, err := secrets.GetSecretValue(context.Background(), cfg.AWSSCIMAccessTokenSecretName)
-		if err != nil {
-			results <- fmt.Errorf("cannot get secretmanager value: %w", err)
-			return
+	case config.IDPTypeFile:
+		// the directory file doesn't need credentials
+	case config.IDPTypeSCIM:
+		// basic authentication is used when the username is defined
+		if cfg.SCIMIdPUsername != "" {
+			toRead = append(toRead,
+				secret{name: cfg.SCIMIdPPasswordSecretName, value: &cfg.SCIMIdPPassword},
+			)
+		} else {
+			toRead = append(toRead,
+				secret{name: cfg.SCIMIdPTokenSecretName, value: &cfg.SCIMIdPToken},
+			)
 		}
-		cfg.AWSSCIMAccessToken = unwrap
-		results <- nil
-	}()
+	default:
+		toRead = append(toRead,
+			secret{name: cfg.GWSUserEmailSecretName, value: &cfg.GWSUserEmail},
+			secret{name: cfg.GWSServiceAccountFileSecretName, value: &cfg.GWSServiceAccountFile},
+		)
+	}
 
-	go func() {
-		slog.Debug("reading secret", "name", cfg.AWSSCIMEndpointSecretName)
-		unwrap, err := secrets.GetSecretValue(context.Background(), cfg.AWSSCIMEndpointSecretName)
-		if err != nil {
-			results <- fmt.Errorf("cannot get secretmanager value: %w", err)
-			return
-		}
-		cfg.AWSSCIMEndpoint = unwrap
-		results <- nil
-	}()
+	// create a channel to receive the results
+	results := make(chan error, len(toRead))
+
+	for _, sec := range toRead {
+		go func() {
+			slog.Debug("reading secret", "name", sec.name)
+			unwrap, err := secrets.GetSecretValue(context.Background(), sec.name)
+			if err != nil {
+				results <- fmt.Errorf("cannot get secretmanager value: %w", err)
+				return
+			}
+			*sec.value = unwrap
+			results <- nil
+		}()
+	}
 
 	// wait for all the goroutines to finish
-	for range 4 {
+	for range toRead {
 		if err := <-results; err != nil {
 			return err
 		}
@@ -206,17 +300,119 @@ func Secrets(cfg *config.Config) error {
 
 // SyncService sets up the sync service
 func SyncService(ctx context.Context, cfg *config.Config) (*core.SyncService, error) {
-	// cfg.GWSServiceAccountFile could be a file path or a content of the file
-	gwsServiceAccountContent := []byte(cfg.GWSServiceAccountFile)
+	userAgent := fmt.Sprintf("idp-scim-sync/%s", version.Version)
 
-	if !cfg.IsLambda {
-		gwsServiceAccount, err := os.ReadFile(cfg.GWSServiceAccountFile)
+	idpService, err := identityProvider(ctx, cfg, userAgent)
+	if err != nil {
+		return nil, err
+	}
+
+	scimService, err := scimTarget(ctx, cfg, userAgent)
+	if err != nil {
+		return nil, err
+	}
+
+	awsConf, err := aws.NewDefaultConf(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("cannot load aws config: %w", err)
+	}
+
+	s3Client := s3.NewFromConfig(awsConf)
+	repo, err := repository.NewS3Repository(s3Client, repository.WithBucket(cfg.AWSS3BucketName), repository.WithKey(cfg.AWSS3BucketKey))
+	if err != nil {
+		return nil, fmt.Errorf("cannot create s3 repository: %w", err)
+	}
+
+	// the suspended Google Workspace users are removed from the groups by the sync service
+	removeInactiveMembers := (cfg.IDPType == "" || cfg.IDPType == config.IDPTypeGoogle) &&
+		cfg.GWSSuspendedUsersPolicy == string(idp.SuspendedUsersPolicyDeactivateUngroup)
+
+	ss, err := core.NewSyncService(
+		idpService,
+		scimService,
+		repo,
+		core.WithIdentityProviderGroupsFilter(cfg.GroupsFilter()),
+		core.WithInactiveMembersRemoved(removeInactiveMembers),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create sync service: %w", err)
+	}
+
+	return ss, nil
+}
+
+// scimTarget sets up the SCIM service of the configured target type
+func scimTarget(ctx context.Context, cfg *config.Config, userAgent string) (core.SCIMService, error) {
+	if cfg.TargetType == config.TargetTypeSCIM {
+		// httpClient with jitter backoff to avoid thundering herd on 429 rate limits
+		scimClient := httpx.NewClientBuilder().
+			WithMaxRetries(10).
+			WithRetryStrategy(httpx.JitterBackoffStrategy).
+			WithRetryBaseDelay(500 * time.Millisecond).
+			WithRetryMaxDelay(10 * time.Second).
+			Build()
+
+		// SCIM 2.0 Service
+		targetSCIM, err := scimclient.NewClient(scimClient, cfg.SCIMTargetURL,
+			scimclient.WithBearerToken(cfg.SCIMTargetToken),
+			scimclient.WithPageSize(cfg.SCIMTargetPageSize),
+		)
 		if err != nil {
-			return nil, fmt.Errorf("cannot read google workspace service account file: %w", err)
+			return nil, fmt.Errorf("cannot create target scim client: %w", err)
 		}
-		gwsServiceAccountContent = gwsServiceAccount
+		targetSCIM.UserAgent = userAgent
+
+		scimService, err := scim.NewStandardProvider(targetSCIM)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create scim provider: %w", err)
+		}
+
+		return scimService, nil
+	}
+
+	// the adaptive rate limiter is the base transport of the retries, so every retry waits for it
+	// and the throttled retries reduce the rate of all the concurrent requests
+	limiter := aws.NewAdaptiveRateLimiter(cfg.AWSSCIMRateLimitQPS, cfg.AWSSCIMRateLimitConcurrency)
+	awsSCIMClient := httpx.NewHTTPRetryClient(
+		httpx.WithMaxRetriesRetry(10),
+		httpx.WithRetryStrategyRetry(httpx.JitterBackoff(500*time.Millisecond, 10*time.Second)),
+		httpx.WithBaseTransport(limiter.Transport(nil)),
+	)
+	// the timeout covers the retries and the waits for the rate limiter
+	awsSCIMClient.Timeout = time.Minute
+
+	// AWS SCIM Service
+	awsSCIM, err := aws.NewSCIMService(awsSCIMClient, cfg.AWSSCIMEndpoint, cfg.AWSSCIMAccessToken)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create aws scim service: %w", err)
+	}
+	awsSCIM.UserAgent = userAgent
+
+	opts := []scim.ProviderOption{
+		scim.WithConcurrency(cfg.AWSSCIMConcurrency),
+		scim.WithRateLimiter(limiter),
+		scim.WithSyncFieldSet(model.NewSyncFieldSet(cfg.SyncUserFields)),
+	}
+
+	// batch the changes in bulk requests when the service provider supports them
+	spc, err := awsSCIM.ServiceProviderConfig(ctx)
+	if err != nil {
+		slog.Warn("cannot get the scim service provider config, bulk requests disabled", "error", err)
+	} else if spc.Bulk.Supported && spc.Bulk.MaxOperations > 0 {
+		slog.Info("scim service provider supports bulk requests", "max_operations", spc.Bulk.MaxOperations, "max_payload_size", spc.Bulk.MaxPayloadSize)
+		opts = append(opts, scim.WithBulk(spc.Bulk.MaxOperations, spc.Bulk.MaxPayloadSize))
+	}
+
+	scimService, err := scim.NewProvider(awsSCIM, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create scim provider: %w", err)
 	}
 
+	return scimService, nil
+}
+
+// identityProvider sets up the identity provider service of the configured identity provider type
+func identityProvider(ctx context.Context, cfg *config.Config, userAgent string) (core.IdentityProviderService, error) {
 	idpClient := httpx.NewClientBuilder().
 		WithMaxRetries(10).
 		WithRetryStrategy(httpx.ExponentialBackoffStrategy).
@@ -224,14 +420,64 @@ func SyncService(ctx context.Context, cfg *config.Config) (*core.SyncService, er
 		WithRetryMaxDelay(10 * time.Second).
 		Build()
 
-	userAgent := fmt.Sprintf("idp-scim-sync/%s", version.Version)
+	// Build the sync field set from configuration
+	syncFieldSet := model.NewSyncFieldSet(cfg.SyncUserFields)
+
+	switch cfg.IDPType {
+	case "", config.IDPTypeGoogle:
+		return googleIdentityProvider(ctx, cfg, userAgent, syncFieldSet)
+	case config.IDPTypeEntra:
+		return entraIdentityProvider(ctx, cfg, idpClient, userAgent, syncFieldSet)
+	case config.IDPTypeOkta:
+		return oktaIdentityProvider(cfg, idpClient, userAgent, syncFieldSet)
+	case config.IDPTypeLDAP:
+		return ldapIdentityProvider(cfg, syncFieldSet)
+	case config.IDPTypeFile:
+		return fileIdentityProvider(ctx, cfg, syncFieldSet)
+	case config.IDPTypeSCIM:
+		return scimIdentityProvider(cfg, idpClient, userAgent, syncFieldSet)
+	default:
+		return nil, fmt.Errorf("%w: %q", config.ErrInvalidIDPType, cfg.IDPType)
+	}
+}
+
+// googleIdentityProvider sets up the Google Workspace identity provider service
+func googleIdentityProvider(ctx context.Context, cfg *config.Config, userAgent string, syncFieldSet *model.SyncFieldSet) (core.IdentityProviderService, error) {
+	// cfg.GWSServiceAccountFile could be a file path or a content of the file
+	gwsServiceAccountContent := []byte(cfg.GWSServiceAccountFile)
+
+	if !cfg.IsLambda {
+		gwsServiceAccount, err := os.ReadFile(cfg.GWSServiceAccountFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read google workspace service account file: %w", err)
+		}
+		gwsServiceAccountContent = gwsServiceAccount
+	}
+
+	scopes := cfg.GWSServiceAccountScopes
+	if cfg.GWSGroupsSource == "cloud-identity" && !slices.Contains(scopes, google.CloudIdentityGroupsScope) {
+		scopes = append(slices.Clone(scopes), google.CloudIdentityGroupsScope)
+	}
+
+	// the rate limiter is the base transport of the retries, so every retry waits for it and the
+	// requests rejected by rate limit pause all the requests for their Retry-After
+	limiter := google.NewRateLimiter(cfg.GWSRateLimitQPS, cfg.GWSRateLimitBurst)
+	gwsClient := httpx.NewHTTPRetryClient(
+		httpx.WithMaxRetriesRetry(10),
+		httpx.WithRetryStrategyRetry(httpx.ExponentialBackoff(500*time.Millisecond, 10*time.Second)),
+		httpx.WithBaseTransport(limiter.Transport(nil)),
+	)
+	// the timeout covers the retries and the pauses of the rate limiter
+	gwsClient.Timeout = 2 * time.Minute
 
 	gServiceConfig := google.DirectoryServiceConfig{
 		UserEmail:      cfg.GWSUserEmail,
 		ServiceAccount: gwsServiceAccountContent,
-		Scopes:         cfg.GWSServiceAccountScopes,
+		Scopes:         scopes,
 		UserAgent:      userAgent,
-		Client:         idpClient,
+		Client:         gwsClient,
+
+		ServiceAccountEmail: cfg.GWSServiceAccountEmail,
 	}
 
 	// Google Client Service
@@ -240,57 +486,231 @@ func SyncService(ctx context.Context, cfg *config.Config) (*core.SyncService, er
 		return nil, fmt.Errorf("cannot create google service: %w", err)
 	}
 
-	// Build the sync field set from configuration
-	syncFieldSet := model.NewSyncFieldSet(cfg.SyncUserFields)
+	customAttributes, err := idp.ParseCustomAttributes(cfg.GWSCustomSchemaAttributes)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse google workspace custom schema attributes: %w", err)
+	}
+
+	// the cache is stored next to the state by the sync service
+	var gwsCache *google.Cache
+	if cfg.GWSCache {
+		gwsCache = google.NewCache()
+	}
 
 	// Google Directory Service
-	gwsDS, err := google.NewDirectoryService(gwsService, google.WithSyncFieldSet(syncFieldSet))
+	gwsDS, err := google.NewDirectoryService(gwsService,
+		google.WithSyncFieldSet(syncFieldSet),
+		google.WithCustomSchemas(idp.CustomSchemas(customAttributes)),
+		google.WithCache(gwsCache),
+		google.WithConcurrency(limiter.Concurrency()),
+	)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create google directory service: %w", err)
 	}
 
+	var gwsGroups idp.GoogleProviderService = gwsDS
+	if cfg.GWSGroupsSource == "cloud-identity" {
+		// the client transport is authorized by google.NewService
+		ciService, err := google.NewCloudIdentityAPIService(ctx, gwsClient, userAgent)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create google cloud identity api service: %w", err)
+		}
+
+		gwsCIS, err := google.NewCloudIdentityService(gwsDS, ciService, cfg.GWSCustomerID,
+			google.WithGroupLabels(cfg.GWSGroupLabels),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create google cloud identity service: %w", err)
+		}
+		gwsGroups = gwsCIS
+	}
+
+	orgUnitGroups, err := idp.ParseOrgUnitGroups(cfg.GWSOrgUnits)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse google workspace org units: %w", err)
+	}
+
+	nestingPolicy, err := idp.ParseNestingPolicy(cfg.GWSNestingPolicy)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse google workspace nesting policy: %w", err)
+	}
+
+	suspendedUsersPolicy, err := idp.ParseSuspendedUsersPolicy(cfg.GWSSuspendedUsersPolicy)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse google workspace suspended users policy: %w", err)
+	}
+
+	roleProjections, err := idp.ParseRoleProjections(cfg.GWSMemberRoles)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse google workspace member roles: %w", err)
+	}
+
+	externalMembersPolicy, err := idp.ParseExternalMembersPolicy(cfg.GWSExternalMembersPolicy)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse google workspace external members policy: %w", err)
+	}
+
+	duplicateGroupNames, err := idp.ParseDuplicateGroupNamesStrategy(cfg.GWSDuplicateGroupNames)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse google workspace duplicate group names strategy: %w", err)
+	}
+
 	// Identity Provider Service
-	idpService, err := idp.NewIdentityProvider(gwsDS, idp.WithSyncFieldSet(syncFieldSet))
+	idpService, err := idp.NewIdentityProvider(
+		gwsGroups,
+		idp.WithSyncFieldSet(syncFieldSet),
+		idp.WithOrgUnitGroups(orgUnitGroups),
+		idp.WithNestingPolicy(nestingPolicy),
+		idp.WithSuspendedUsersPolicy(suspendedUsersPolicy),
+		idp.WithRoleProjections(roleProjections),
+		idp.WithExternalMembersPolicy(externalMembersPolicy, cfg.GWSExternalDomains),
+		idp.WithDuplicateGroupNamesStrategy(duplicateGroupNames),
+		idp.WithCustomAttributes(customAttributes),
+		idp.WithBulkUsersThreshold(cfg.GWSBulkUsersThreshold),
+		idp.WithCache(gwsCache),
+		idp.WithConcurrency(limiter.Concurrency()),
+	)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create identity provider service: %w", err)
 	}
 
-	// AWS SCIM Service
+	return idpService, nil
+}
 
-	// httpClient with jitter backoff to avoid thundering herd on 429 rate limits
-	scimClient := httpx.NewClientBuilder().
-		WithMaxRetries(10).
-		WithRetryStrategy(httpx.JitterBackoffStrategy).
-		WithRetryBaseDelay(500 * time.Millisecond).
-		WithRetryMaxDelay(10 * time.Second).
-		Build()
+// entraIdentityProvider sets up the Microsoft Entra ID identity provider service
+func entraIdentityProvider(ctx context.Context, cfg *config.Config, idpClient *http.Client, userAgent string, syncFieldSet *model.SyncFieldSet) (core.IdentityProviderService, error) {
+	graphClient, err := entra.NewHTTPClient(ctx, entra.ClientCredentialsConfig{
+		Client:       idpClient,
+		TenantID:     cfg.EntraTenantID,
+		ClientID:     cfg.EntraClientID,
+		ClientSecret: cfg.EntraClientSecret,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot create entra http client: %w", err)
+	}
 
-	awsSCIM, err := aws.NewSCIMService(scimClient, cfg.AWSSCIMEndpoint, cfg.AWSSCIMAccessToken)
+	// Microsoft Graph Service
+	entraService, err := entra.NewClient(graphClient, entra.WithSyncFieldSet(syncFieldSet))
 	if err != nil {
-		return nil, fmt.Errorf("cannot create aws scim service: %w", err)
+		return nil, fmt.Errorf("cannot create entra service: %w", err)
 	}
-	awsSCIM.UserAgent = userAgent
+	entraService.UserAgent = userAgent
 
-	scimService, err := scim.NewProvider(awsSCIM)
+	// Identity Provider Service
+	idpService, err := idp.NewEntraIdentityProvider(entraService,
+		idp.WithSyncFieldSet(syncFieldSet),
+		idp.WithUsersDelta(cfg.EntraUsersDelta),
+	)
 	if err != nil {
-		return nil, fmt.Errorf("cannot create scim provider: %w", err)
+		return nil, fmt.Errorf("cannot create identity provider service: %w", err)
 	}
 
-	awsConf, err := aws.NewDefaultConf(context.Background())
+	return idpService, nil
+}
+
+// oktaIdentityProvider sets up the Okta identity provider service
+func oktaIdentityProvider(cfg *config.Config, idpClient *http.Client, userAgent string, syncFieldSet *model.SyncFieldSet) (core.IdentityProviderService, error) {
+	// Okta Management API Service
+	oktaService, err := okta.NewClient(idpClient, cfg.OktaOrgURL, cfg.OktaAPIToken)
 	if err != nil {
-		return nil, fmt.Errorf("cannot load aws config: %w", err)
+		return nil, fmt.Errorf("cannot create okta service: %w", err)
 	}
+	oktaService.UserAgent = userAgent
 
-	s3Client := s3.NewFromConfig(awsConf)
-	repo, err := repository.NewS3Repository(s3Client, repository.WithBucket(cfg.AWSS3BucketName), repository.WithKey(cfg.AWSS3BucketKey))
+	// Identity Provider Service
+	idpService, err := idp.NewOktaIdentityProvider(oktaService, idp.WithSyncFieldSet(syncFieldSet))
 	if err != nil {
-		return nil, fmt.Errorf("cannot create s3 repository: %w", err)
+		return nil, fmt.Errorf("cannot create identity provider service: %w", err)
+	}
+
+	return idpService, nil
+}
+
+// ldapIdentityProvider sets up the LDAP identity provider service
+func ldapIdentityProvider(cfg *config.Config, syncFieldSet *model.SyncFieldSet) (core.IdentityProviderService, error) {
+	dialConfig := ldap.DialConfig{
+		URL:          cfg.LDAPURL,
+		BindDN:       cfg.LDAPBindDN,
+		BindPassword: cfg.LDAPBindPassword,
+		StartTLS:     cfg.LDAPStartTLS,
+	}
+
+	if cfg.LDAPInsecureSkipVerify {
+		slog.Warn("the LDAP server certificate is not verified")
+		dialConfig.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: true} // #nosec G402 -- opt-in for self-signed certificates
 	}
 
-	ss, err := core.NewSyncService(idpService, scimService, repo, core.WithIdentityProviderGroupsFilter(cfg.GWSGroupsFilter))
+	dial, err := ldap.NewDialFunc(dialConfig)
 	if err != nil {
-		return nil, fmt.Errorf("cannot create sync service: %w", err)
+		return nil, fmt.Errorf("cannot create ldap dial function: %w", err)
 	}
 
-	return ss, nil
+	// LDAP Service
+	ldapService, err := ldap.NewClient(dial, cfg.LDAPBaseDN,
+		ldap.WithPageSize(uint32(max(cfg.LDAPPageSize, 0))),
+		ldap.WithMembersStrategy(cfg.LDAPMembersStrategy),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create ldap service: %w", err)
+	}
+
+	// Identity Provider Service
+	idpService, err := idp.NewLDAPIdentityProvider(ldapService, idp.WithSyncFieldSet(syncFieldSet))
+	if err != nil {
+		return nil, fmt.Errorf("cannot create identity provider service: %w", err)
+	}
+
+	return idpService, nil
+}
+
+// fileIdentityProvider sets up the directory file identity provider service
+func fileIdentityProvider(ctx context.Context, cfg *config.Config, syncFieldSet *model.SyncFieldSet) (core.IdentityProviderService, error) {
+	opts := []file.SourceOption{file.WithFormat(cfg.FileFormat)}
+
+	// the s3 client is only needed when the directory file is an s3 object
+	if strings.HasPrefix(cfg.FilePath, "s3://") {
+		awsConf, err := aws.NewDefaultConf(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load aws config: %w", err)
+		}
+
+		opts = append(opts, file.WithS3Client(s3.NewFromConfig(awsConf)))
+	}
+
+	// File Service
+	fileService, err := file.NewSource(cfg.FilePath, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create file service: %w", err)
+	}
+
+	// Identity Provider Service
+	idpService, err := idp.NewFileIdentityProvider(fileService, idp.WithSyncFieldSet(syncFieldSet))
+	if err != nil {
+		return nil, fmt.Errorf("cannot create identity provider service: %w", err)
+	}
+
+	return idpService, nil
+}
+
+// scimIdentityProvider sets up the SCIM 2.0 service provider identity provider service
+func scimIdentityProvider(cfg *config.Config, idpClient *http.Client, userAgent string, syncFieldSet *model.SyncFieldSet) (core.IdentityProviderService, error) {
+	auth := scimclient.WithBearerToken(cfg.SCIMIdPToken)
+	if cfg.SCIMIdPUsername != "" {
+		auth = scimclient.WithBasicAuth(cfg.SCIMIdPUsername, cfg.SCIMIdPPassword)
+	}
+
+	// SCIM Service
+	scimService, err := scimclient.NewClient(idpClient, cfg.SCIMIdPURL, auth, scimclient.WithPageSize(cfg.SCIMIdPPageSize))
+	if err != nil {
+		return nil, fmt.Errorf("cannot create scim client: %w", err)
+	}
+	scimService.UserAgent = userAgent
+
+	// Identity Provider Service
+	idpService, err := idp.NewSCIMIdentityProvider(scimService, idp.WithSyncFieldSet(syncFieldSet))
+	if err != nil {
+		return nil, fmt.Errorf("cannot create identity provider service: %w", err)
+	}
+
+	return idpService, nil
 }
@@ -115,3 +115,92 @@ func (mr *MockIdentityProviderServiceMockRecorder) GetUsersByGroupsMembers(ctx,
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsersByGroupsMembers", reflect.TypeOf((*MockIdentityProviderService)(nil).GetUsersByGroupsMembers), ctx, gmr)
 }
+
+// MockGroupsNamesKeeper is a mock of GroupsNamesKeeper interface.
+type MockGroupsNamesKeeper struct {
+	ctrl     *gomock.Controller
+	recorder *MockGroupsNamesKeeperMockRecorder
+	isgomock struct{}
+}
+
+// MockGroupsNamesKeeperMockRecorder is the mock recorder for MockGroupsNamesKeeper.
+type MockGroupsNamesKeeperMockRecorder struct {
+	mock *MockGroupsNamesKeeper
+}
+
+// NewMockGroupsNamesKeeper creates a new mock instance.
+func NewMockGroupsNamesKeeper(ctrl *gomock.Controller) *MockGroupsNamesKeeper {
+	mock := &MockGroupsNamesKeeper{ctrl: ctrl}
+	mock.recorder = &MockGroupsNamesKeeperMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockGroupsNamesKeeper) EXPECT() *MockGroupsNamesKeeperMockRecorder {
+	return m.recorder
+}
+
+// KeepGroupsNames mocks base method.
+func (m *MockGroupsNamesKeeper) KeepGroupsNames(gr *model.GroupsResult) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "KeepGroupsNames", gr)
+}
+
+// KeepGroupsNames indicates an expected call of KeepGroupsNames.
+func (mr *MockGroupsNamesKeeperMockRecorder) KeepGroupsNames(gr any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "KeepGroupsNames", reflect.TypeOf((*MockGroupsNamesKeeper)(nil).KeepGroupsNames), gr)
+}
+
+// MockCacheKeeper is a mock of CacheKeeper interface.
+type MockCacheKeeper struct {
+	ctrl     *gomock.Controller
+	recorder *MockCacheKeeperMockRecorder
+	isgomock struct{}
+}
+
+// MockCacheKeeperMockRecorder is the mock recorder for MockCacheKeeper.
+type MockCacheKeeperMockRecorder struct {
+	mock *MockCacheKeeper
+}
+
+// NewMockCacheKeeper creates a new mock instance.
+func NewMockCacheKeeper(ctrl *gomock.Controller) *MockCacheKeeper {
+	mock := &MockCacheKeeper{ctrl: ctrl}
+	mock.recorder = &MockCacheKeeperMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCacheKeeper) EXPECT() *MockCacheKeeperMockRecorder {
+	return m.recorder
+}
+
+// LoadCache mocks base method.
+func (m *MockCacheKeeper) LoadCache(data []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadCache", data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LoadCache indicates an expected call of LoadCache.
+func (mr *MockCacheKeeperMockRecorder) LoadCache(data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadCache", reflect.TypeOf((*MockCacheKeeper)(nil).LoadCache), data)
+}
+
+// SaveCache mocks base method.
+func (m *MockCacheKeeper) SaveCache() ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveCache")
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveCache indicates an expected call of SaveCache.
+func (mr *MockCacheKeeperMockRecorder) SaveCache() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveCache", reflect.TypeOf((*MockCacheKeeper)(nil).SaveCache))
+}
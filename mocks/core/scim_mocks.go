@@ -202,3 +202,39 @@ func (mr *MockSCIMServiceMockRecorder) UpdateUsers(ctx, ur any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUsers", reflect.TypeOf((*MockSCIMService)(nil).UpdateUsers), ctx, ur)
 }
+
+// MockStatsLogger is a mock of StatsLogger interface.
+type MockStatsLogger struct {
+	ctrl     *gomock.Controller
+	recorder *MockStatsLoggerMockRecorder
+	isgomock struct{}
+}
+
+// MockStatsLoggerMockRecorder is the mock recorder for MockStatsLogger.
+type MockStatsLoggerMockRecorder struct {
+	mock *MockStatsLogger
+}
+
+// NewMockStatsLogger creates a new mock instance.
+func NewMockStatsLogger(ctrl *gomock.Controller) *MockStatsLogger {
+	mock := &MockStatsLogger{ctrl: ctrl}
+	mock.recorder = &MockStatsLoggerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStatsLogger) EXPECT() *MockStatsLoggerMockRecorder {
+	return m.recorder
+}
+
+// LogStats mocks base method.
+func (m *MockStatsLogger) LogStats() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "LogStats")
+}
+
+// LogStats indicates an expected call of LogStats.
+func (mr *MockStatsLoggerMockRecorder) LogStats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogStats", reflect.TypeOf((*MockStatsLogger)(nil).LogStats))
+}
@@ -0,0 +1,117 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: entra.go
+//
+// Generated by this command:
+//
+//	mockgen -package=mocks -destination=../../mocks/idp/entra_mocks.go -source=entra.go EntraProviderService
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	entra "github.com/slashdevops/idp-scim-sync/pkg/entra"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockEntraProviderService is a mock of EntraProviderService interface.
+type MockEntraProviderService struct {
+	ctrl     *gomock.Controller
+	recorder *MockEntraProviderServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockEntraProviderServiceMockRecorder is the mock recorder for MockEntraProviderService.
+type MockEntraProviderServiceMockRecorder struct {
+	mock *MockEntraProviderService
+}
+
+// NewMockEntraProviderService creates a new mock instance.
+func NewMockEntraProviderService(ctrl *gomock.Controller) *MockEntraProviderService {
+	mock := &MockEntraProviderService{ctrl: ctrl}
+	mock.recorder = &MockEntraProviderServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEntraProviderService) EXPECT() *MockEntraProviderServiceMockRecorder {
+	return m.recorder
+}
+
+// GetUser mocks base method.
+func (m *MockEntraProviderService) GetUser(ctx context.Context, userID string) (*entra.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", ctx, userID)
+	ret0, _ := ret[0].(*entra.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockEntraProviderServiceMockRecorder) GetUser(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockEntraProviderService)(nil).GetUser), ctx, userID)
+}
+
+// ListGroupMembers mocks base method.
+func (m *MockEntraProviderService) ListGroupMembers(ctx context.Context, groupID string) ([]*entra.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListGroupMembers", ctx, groupID)
+	ret0, _ := ret[0].([]*entra.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListGroupMembers indicates an expected call of ListGroupMembers.
+func (mr *MockEntraProviderServiceMockRecorder) ListGroupMembers(ctx, groupID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListGroupMembers", reflect.TypeOf((*MockEntraProviderService)(nil).ListGroupMembers), ctx, groupID)
+}
+
+// ListGroups mocks base method.
+func (m *MockEntraProviderService) ListGroups(ctx context.Context, filter []string) ([]*entra.Group, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListGroups", ctx, filter)
+	ret0, _ := ret[0].([]*entra.Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListGroups indicates an expected call of ListGroups.
+func (mr *MockEntraProviderServiceMockRecorder) ListGroups(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListGroups", reflect.TypeOf((*MockEntraProviderService)(nil).ListGroups), ctx, filter)
+}
+
+// ListUsers mocks base method.
+func (m *MockEntraProviderService) ListUsers(ctx context.Context, filter []string) ([]*entra.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsers", ctx, filter)
+	ret0, _ := ret[0].([]*entra.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUsers indicates an expected call of ListUsers.
+func (mr *MockEntraProviderServiceMockRecorder) ListUsers(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockEntraProviderService)(nil).ListUsers), ctx, filter)
+}
+
+// ListUsersDelta mocks base method.
+func (m *MockEntraProviderService) ListUsersDelta(ctx context.Context, deltaLink string) (*entra.UsersDelta, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsersDelta", ctx, deltaLink)
+	ret0, _ := ret[0].(*entra.UsersDelta)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUsersDelta indicates an expected call of ListUsersDelta.
+func (mr *MockEntraProviderServiceMockRecorder) ListUsersDelta(ctx, deltaLink any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsersDelta", reflect.TypeOf((*MockEntraProviderService)(nil).ListUsersDelta), ctx, deltaLink)
+}
@@ -0,0 +1,57 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: file.go
+//
+// Generated by this command:
+//
+//	mockgen -package=mocks -destination=../../mocks/idp/file_mocks.go -source=file.go FileProviderService
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	file "github.com/slashdevops/idp-scim-sync/pkg/file"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockFileProviderService is a mock of FileProviderService interface.
+type MockFileProviderService struct {
+	ctrl     *gomock.Controller
+	recorder *MockFileProviderServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockFileProviderServiceMockRecorder is the mock recorder for MockFileProviderService.
+type MockFileProviderServiceMockRecorder struct {
+	mock *MockFileProviderService
+}
+
+// NewMockFileProviderService creates a new mock instance.
+func NewMockFileProviderService(ctrl *gomock.Controller) *MockFileProviderService {
+	mock := &MockFileProviderService{ctrl: ctrl}
+	mock.recorder = &MockFileProviderServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFileProviderService) EXPECT() *MockFileProviderServiceMockRecorder {
+	return m.recorder
+}
+
+// Load mocks base method.
+func (m *MockFileProviderService) Load(ctx context.Context) (*file.Directory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Load", ctx)
+	ret0, _ := ret[0].(*file.Directory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Load indicates an expected call of Load.
+func (mr *MockFileProviderServiceMockRecorder) Load(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Load", reflect.TypeOf((*MockFileProviderService)(nil).Load), ctx)
+}
@@ -42,6 +42,21 @@ func (m *MockGoogleProviderService) EXPECT() *MockGoogleProviderServiceMockRecor
 	return m.recorder
 }
 
+// GetGroup mocks base method.
+func (m *MockGoogleProviderService) GetGroup(ctx context.Context, groupID string) (*admin.Group, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGroup", ctx, groupID)
+	ret0, _ := ret[0].(*admin.Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGroup indicates an expected call of GetGroup.
+func (mr *MockGoogleProviderServiceMockRecorder) GetGroup(ctx, groupID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroup", reflect.TypeOf((*MockGoogleProviderService)(nil).GetGroup), ctx, groupID)
+}
+
 // GetUser mocks base method.
 func (m *MockGoogleProviderService) GetUser(ctx context.Context, userID string) (*admin.User, error) {
 	m.ctrl.T.Helper()
@@ -112,6 +127,21 @@ func (mr *MockGoogleProviderServiceMockRecorder) ListGroups(ctx, query any) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListGroups", reflect.TypeOf((*MockGoogleProviderService)(nil).ListGroups), ctx, query)
 }
 
+// ListOrgUnitUsers mocks base method.
+func (m *MockGoogleProviderService) ListOrgUnitUsers(ctx context.Context, orgUnitPath string) ([]*admin.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOrgUnitUsers", ctx, orgUnitPath)
+	ret0, _ := ret[0].([]*admin.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOrgUnitUsers indicates an expected call of ListOrgUnitUsers.
+func (mr *MockGoogleProviderServiceMockRecorder) ListOrgUnitUsers(ctx, orgUnitPath any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOrgUnitUsers", reflect.TypeOf((*MockGoogleProviderService)(nil).ListOrgUnitUsers), ctx, orgUnitPath)
+}
+
 // ListUsers mocks base method.
 func (m *MockGoogleProviderService) ListUsers(ctx context.Context, query []string) ([]*admin.User, error) {
 	m.ctrl.T.Helper()
@@ -0,0 +1,102 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ldap.go
+//
+// Generated by this command:
+//
+//	mockgen -package=mocks -destination=../../mocks/idp/ldap_mocks.go -source=ldap.go LDAPProviderService
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	ldap "github.com/slashdevops/idp-scim-sync/pkg/ldap"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockLDAPProviderService is a mock of LDAPProviderService interface.
+type MockLDAPProviderService struct {
+	ctrl     *gomock.Controller
+	recorder *MockLDAPProviderServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockLDAPProviderServiceMockRecorder is the mock recorder for MockLDAPProviderService.
+type MockLDAPProviderServiceMockRecorder struct {
+	mock *MockLDAPProviderService
+}
+
+// NewMockLDAPProviderService creates a new mock instance.
+func NewMockLDAPProviderService(ctrl *gomock.Controller) *MockLDAPProviderService {
+	mock := &MockLDAPProviderService{ctrl: ctrl}
+	mock.recorder = &MockLDAPProviderServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLDAPProviderService) EXPECT() *MockLDAPProviderServiceMockRecorder {
+	return m.recorder
+}
+
+// GetUser mocks base method.
+func (m *MockLDAPProviderService) GetUser(ctx context.Context, userDN string) (*ldap.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", ctx, userDN)
+	ret0, _ := ret[0].(*ldap.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockLDAPProviderServiceMockRecorder) GetUser(ctx, userDN any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockLDAPProviderService)(nil).GetUser), ctx, userDN)
+}
+
+// ListGroupMembers mocks base method.
+func (m *MockLDAPProviderService) ListGroupMembers(ctx context.Context, groupDN string) ([]*ldap.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListGroupMembers", ctx, groupDN)
+	ret0, _ := ret[0].([]*ldap.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListGroupMembers indicates an expected call of ListGroupMembers.
+func (mr *MockLDAPProviderServiceMockRecorder) ListGroupMembers(ctx, groupDN any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListGroupMembers", reflect.TypeOf((*MockLDAPProviderService)(nil).ListGroupMembers), ctx, groupDN)
+}
+
+// ListGroups mocks base method.
+func (m *MockLDAPProviderService) ListGroups(ctx context.Context, filter []string) ([]*ldap.Group, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListGroups", ctx, filter)
+	ret0, _ := ret[0].([]*ldap.Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListGroups indicates an expected call of ListGroups.
+func (mr *MockLDAPProviderServiceMockRecorder) ListGroups(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListGroups", reflect.TypeOf((*MockLDAPProviderService)(nil).ListGroups), ctx, filter)
+}
+
+// ListUsers mocks base method.
+func (m *MockLDAPProviderService) ListUsers(ctx context.Context, filter []string) ([]*ldap.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsers", ctx, filter)
+	ret0, _ := ret[0].([]*ldap.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUsers indicates an expected call of ListUsers.
+func (mr *MockLDAPProviderServiceMockRecorder) ListUsers(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockLDAPProviderService)(nil).ListUsers), ctx, filter)
+}
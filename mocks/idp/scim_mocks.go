@@ -0,0 +1,102 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: scim.go
+//
+// Generated by this command:
+//
+//	mockgen -package=mocks -destination=../../mocks/idp/scim_mocks.go -source=scim.go SCIMProviderService
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	scimclient "github.com/slashdevops/idp-scim-sync/pkg/scimclient"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockSCIMProviderService is a mock of SCIMProviderService interface.
+type MockSCIMProviderService struct {
+	ctrl     *gomock.Controller
+	recorder *MockSCIMProviderServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockSCIMProviderServiceMockRecorder is the mock recorder for MockSCIMProviderService.
+type MockSCIMProviderServiceMockRecorder struct {
+	mock *MockSCIMProviderService
+}
+
+// NewMockSCIMProviderService creates a new mock instance.
+func NewMockSCIMProviderService(ctrl *gomock.Controller) *MockSCIMProviderService {
+	mock := &MockSCIMProviderService{ctrl: ctrl}
+	mock.recorder = &MockSCIMProviderServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSCIMProviderService) EXPECT() *MockSCIMProviderServiceMockRecorder {
+	return m.recorder
+}
+
+// GetUser mocks base method.
+func (m *MockSCIMProviderService) GetUser(ctx context.Context, userID string) (*scimclient.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", ctx, userID)
+	ret0, _ := ret[0].(*scimclient.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockSCIMProviderServiceMockRecorder) GetUser(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockSCIMProviderService)(nil).GetUser), ctx, userID)
+}
+
+// ListGroupMembers mocks base method.
+func (m *MockSCIMProviderService) ListGroupMembers(ctx context.Context, groupID string) ([]*scimclient.Member, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListGroupMembers", ctx, groupID)
+	ret0, _ := ret[0].([]*scimclient.Member)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListGroupMembers indicates an expected call of ListGroupMembers.
+func (mr *MockSCIMProviderServiceMockRecorder) ListGroupMembers(ctx, groupID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListGroupMembers", reflect.TypeOf((*MockSCIMProviderService)(nil).ListGroupMembers), ctx, groupID)
+}
+
+// ListGroups mocks base method.
+func (m *MockSCIMProviderService) ListGroups(ctx context.Context, filter []string) ([]*scimclient.Group, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListGroups", ctx, filter)
+	ret0, _ := ret[0].([]*scimclient.Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListGroups indicates an expected call of ListGroups.
+func (mr *MockSCIMProviderServiceMockRecorder) ListGroups(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListGroups", reflect.TypeOf((*MockSCIMProviderService)(nil).ListGroups), ctx, filter)
+}
+
+// ListUsers mocks base method.
+func (m *MockSCIMProviderService) ListUsers(ctx context.Context, filter []string) ([]*scimclient.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsers", ctx, filter)
+	ret0, _ := ret[0].([]*scimclient.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUsers indicates an expected call of ListUsers.
+func (mr *MockSCIMProviderServiceMockRecorder) ListUsers(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockSCIMProviderService)(nil).ListUsers), ctx, filter)
+}
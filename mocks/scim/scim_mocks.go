@@ -41,6 +41,21 @@ func (m *MockAWSSCIMProvider) EXPECT() *MockAWSSCIMProviderMockRecorder {
 	return m.recorder
 }
 
+// Bulk mocks base method.
+func (m *MockAWSSCIMProvider) Bulk(ctx context.Context, br *aws.BulkRequest) (*aws.BulkResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Bulk", ctx, br)
+	ret0, _ := ret[0].(*aws.BulkResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Bulk indicates an expected call of Bulk.
+func (mr *MockAWSSCIMProviderMockRecorder) Bulk(ctx, br any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Bulk", reflect.TypeOf((*MockAWSSCIMProvider)(nil).Bulk), ctx, br)
+}
+
 // CreateOrGetGroup mocks base method.
 func (m *MockAWSSCIMProvider) CreateOrGetGroup(ctx context.Context, g *aws.CreateGroupRequest) (*aws.CreateGroupResponse, error) {
 	m.ctrl.T.Helper()
@@ -188,6 +203,20 @@ func (mr *MockAWSSCIMProviderMockRecorder) PatchGroup(ctx, pgr any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchGroup", reflect.TypeOf((*MockAWSSCIMProvider)(nil).PatchGroup), ctx, pgr)
 }
 
+// PatchUser mocks base method.
+func (m *MockAWSSCIMProvider) PatchUser(ctx context.Context, pur *aws.PatchUserRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchUser", ctx, pur)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PatchUser indicates an expected call of PatchUser.
+func (mr *MockAWSSCIMProviderMockRecorder) PatchUser(ctx, pur any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchUser", reflect.TypeOf((*MockAWSSCIMProvider)(nil).PatchUser), ctx, pur)
+}
+
 // PutUser mocks base method.
 func (m *MockAWSSCIMProvider) PutUser(ctx context.Context, usr *aws.PutUserRequest) (*aws.PutUserResponse, error) {
 	m.ctrl.T.Helper()
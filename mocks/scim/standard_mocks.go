@@ -0,0 +1,205 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: standard.go
+//
+// Generated by this command:
+//
+//	mockgen -package=mocks -destination=../../mocks/scim/standard_mocks.go -source=standard.go StandardSCIMProvider
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	scimclient "github.com/slashdevops/idp-scim-sync/pkg/scimclient"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockStandardSCIMProvider is a mock of StandardSCIMProvider interface.
+type MockStandardSCIMProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockStandardSCIMProviderMockRecorder
+	isgomock struct{}
+}
+
+// MockStandardSCIMProviderMockRecorder is the mock recorder for MockStandardSCIMProvider.
+type MockStandardSCIMProviderMockRecorder struct {
+	mock *MockStandardSCIMProvider
+}
+
+// NewMockStandardSCIMProvider creates a new mock instance.
+func NewMockStandardSCIMProvider(ctrl *gomock.Controller) *MockStandardSCIMProvider {
+	mock := &MockStandardSCIMProvider{ctrl: ctrl}
+	mock.recorder = &MockStandardSCIMProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStandardSCIMProvider) EXPECT() *MockStandardSCIMProviderMockRecorder {
+	return m.recorder
+}
+
+// CreateGroup mocks base method.
+func (m *MockStandardSCIMProvider) CreateGroup(ctx context.Context, g *scimclient.Group) (*scimclient.Group, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateGroup", ctx, g)
+	ret0, _ := ret[0].(*scimclient.Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateGroup indicates an expected call of CreateGroup.
+func (mr *MockStandardSCIMProviderMockRecorder) CreateGroup(ctx, g any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateGroup", reflect.TypeOf((*MockStandardSCIMProvider)(nil).CreateGroup), ctx, g)
+}
+
+// CreateUser mocks base method.
+func (m *MockStandardSCIMProvider) CreateUser(ctx context.Context, u *scimclient.User) (*scimclient.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUser", ctx, u)
+	ret0, _ := ret[0].(*scimclient.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUser indicates an expected call of CreateUser.
+func (mr *MockStandardSCIMProviderMockRecorder) CreateUser(ctx, u any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockStandardSCIMProvider)(nil).CreateUser), ctx, u)
+}
+
+// DeleteGroup mocks base method.
+func (m *MockStandardSCIMProvider) DeleteGroup(ctx context.Context, groupID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteGroup", ctx, groupID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteGroup indicates an expected call of DeleteGroup.
+func (mr *MockStandardSCIMProviderMockRecorder) DeleteGroup(ctx, groupID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteGroup", reflect.TypeOf((*MockStandardSCIMProvider)(nil).DeleteGroup), ctx, groupID)
+}
+
+// DeleteUser mocks base method.
+func (m *MockStandardSCIMProvider) DeleteUser(ctx context.Context, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUser", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUser indicates an expected call of DeleteUser.
+func (mr *MockStandardSCIMProviderMockRecorder) DeleteUser(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockStandardSCIMProvider)(nil).DeleteUser), ctx, userID)
+}
+
+// GetGroup mocks base method.
+func (m *MockStandardSCIMProvider) GetGroup(ctx context.Context, groupID string) (*scimclient.Group, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGroup", ctx, groupID)
+	ret0, _ := ret[0].(*scimclient.Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGroup indicates an expected call of GetGroup.
+func (mr *MockStandardSCIMProviderMockRecorder) GetGroup(ctx, groupID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroup", reflect.TypeOf((*MockStandardSCIMProvider)(nil).GetGroup), ctx, groupID)
+}
+
+// GetGroupByDisplayName mocks base method.
+func (m *MockStandardSCIMProvider) GetGroupByDisplayName(ctx context.Context, displayName string) (*scimclient.Group, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGroupByDisplayName", ctx, displayName)
+	ret0, _ := ret[0].(*scimclient.Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGroupByDisplayName indicates an expected call of GetGroupByDisplayName.
+func (mr *MockStandardSCIMProviderMockRecorder) GetGroupByDisplayName(ctx, displayName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroupByDisplayName", reflect.TypeOf((*MockStandardSCIMProvider)(nil).GetGroupByDisplayName), ctx, displayName)
+}
+
+// GetUserByUserName mocks base method.
+func (m *MockStandardSCIMProvider) GetUserByUserName(ctx context.Context, userName string) (*scimclient.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByUserName", ctx, userName)
+	ret0, _ := ret[0].(*scimclient.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByUserName indicates an expected call of GetUserByUserName.
+func (mr *MockStandardSCIMProviderMockRecorder) GetUserByUserName(ctx, userName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByUserName", reflect.TypeOf((*MockStandardSCIMProvider)(nil).GetUserByUserName), ctx, userName)
+}
+
+// ListGroups mocks base method.
+func (m *MockStandardSCIMProvider) ListGroups(ctx context.Context, filter []string) ([]*scimclient.Group, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListGroups", ctx, filter)
+	ret0, _ := ret[0].([]*scimclient.Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListGroups indicates an expected call of ListGroups.
+func (mr *MockStandardSCIMProviderMockRecorder) ListGroups(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListGroups", reflect.TypeOf((*MockStandardSCIMProvider)(nil).ListGroups), ctx, filter)
+}
+
+// ListUsers mocks base method.
+func (m *MockStandardSCIMProvider) ListUsers(ctx context.Context, filter []string) ([]*scimclient.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsers", ctx, filter)
+	ret0, _ := ret[0].([]*scimclient.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUsers indicates an expected call of ListUsers.
+func (mr *MockStandardSCIMProviderMockRecorder) ListUsers(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockStandardSCIMProvider)(nil).ListUsers), ctx, filter)
+}
+
+// PatchGroup mocks base method.
+func (m *MockStandardSCIMProvider) PatchGroup(ctx context.Context, groupID, version string, ops []*scimclient.PatchOperation) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchGroup", ctx, groupID, version, ops)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PatchGroup indicates an expected call of PatchGroup.
+func (mr *MockStandardSCIMProviderMockRecorder) PatchGroup(ctx, groupID, version, ops any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchGroup", reflect.TypeOf((*MockStandardSCIMProvider)(nil).PatchGroup), ctx, groupID, version, ops)
+}
+
+// ReplaceUser mocks base method.
+func (m *MockStandardSCIMProvider) ReplaceUser(ctx context.Context, u *scimclient.User) (*scimclient.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceUser", ctx, u)
+	ret0, _ := ret[0].(*scimclient.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReplaceUser indicates an expected call of ReplaceUser.
+func (mr *MockStandardSCIMProviderMockRecorder) ReplaceUser(ctx, u any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceUser", reflect.TypeOf((*MockStandardSCIMProvider)(nil).ReplaceUser), ctx, u)
+}
@@ -0,0 +1,299 @@
+package aws
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// rateLimitMinQPS is the lowest rate the requests are reduced to when AWS throttles them.
+	rateLimitMinQPS = 0.5
+
+	// rateLimitDecreaseInterval is the minimum time between two reductions of the limits,
+	// so the concurrent requests throttled at the same time reduce them once.
+	rateLimitDecreaseInterval = time.Second
+
+	// rateLimitMaxRetryAfter is the maximum pause of the requests honored from a Retry-After header.
+	rateLimitMaxRetryAfter = 30 * time.Second
+)
+
+// AdaptiveRateLimiter limits the rate and the concurrency of the requests to the AWS SCIM API,
+// shared by all the concurrent calls of the SCIM service.
+// Both limits are adapted with AIMD: they grow additively while the requests succeed, and they are
+// halved when AWS throttles a request with 429, pausing all the requests for its Retry-After.
+// A nil AdaptiveRateLimiter doesn't limit the requests.
+type AdaptiveRateLimiter struct {
+	mu             sync.Mutex
+	maxQPS         float64
+	maxConcurrency float64
+	qps            float64
+	concurrency    float64
+	tokens         float64
+	last           time.Time
+	pausedUntil    time.Time
+	lastDecrease   time.Time
+	reduced        bool
+	inFlight       int
+	released       chan struct{}
+	requests       int64
+	throttled      int64
+}
+
+// RateLimiterStats are the metrics of the adaptive rate limiter.
+type RateLimiterStats struct {
+	// QPS is the current rate of requests per second.
+	QPS float64
+
+	// Concurrency is the current number of requests allowed in flight.
+	Concurrency int
+
+	// Requests is the number of requests sent.
+	Requests int64
+
+	// Throttled is the number of requests throttled by AWS.
+	Throttled int64
+}
+
+// NewAdaptiveRateLimiter returns an adaptive rate limiter of up to qps requests per second and up to
+// concurrency requests in flight, nil when qps is not positive, a concurrency lower than 1 is 1.
+func NewAdaptiveRateLimiter(qps float64, concurrency int) *AdaptiveRateLimiter {
+	if qps <= 0 {
+		return nil
+	}
+
+	c := float64(max(concurrency, 1))
+
+	return &AdaptiveRateLimiter{
+		maxQPS:         qps,
+		maxConcurrency: c,
+		qps:            qps,
+		concurrency:    c,
+		tokens:         1,
+		last:           time.Now(),
+		released:       make(chan struct{}),
+	}
+}
+
+// Wait blocks until a request is allowed or the context is done.
+// The returned function must be called once the request is done, after its response body is closed.
+func (l *AdaptiveRateLimiter) Wait(ctx context.Context) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	for {
+		d, released, ok := l.reserve(time.Now())
+		if ok {
+			return l.release, nil
+		}
+
+		var timer *time.Timer
+		var expired <-chan time.Time
+		if d > 0 {
+			timer = time.NewTimer(d)
+			expired = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return nil, ctx.Err()
+		case <-expired:
+		case <-released:
+		}
+	}
+}
+
+// reserve takes a token and a slot in flight returning true, or returns how long to wait for the
+// next token, or the channel closed when a request in flight is released.
+func (l *AdaptiveRateLimiter) reserve(now time.Time) (time.Duration, <-chan struct{}, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Before(l.pausedUntil) {
+		return l.pausedUntil.Sub(now), nil, false
+	}
+
+	if l.inFlight >= int(l.concurrency) {
+		return 0, l.released, false
+	}
+
+	if elapsed := now.Sub(l.last); elapsed > 0 {
+		l.tokens = min(1, l.tokens+elapsed.Seconds()*l.qps)
+		l.last = now
+	}
+
+	if l.tokens < 1 {
+		return time.Duration((1 - l.tokens) / l.qps * float64(time.Second)), nil, false
+	}
+
+	l.tokens--
+	l.inFlight++
+	l.requests++
+
+	return 0, nil, true
+}
+
+// release frees the slot in flight of a request and wakes up the requests waiting for it.
+func (l *AdaptiveRateLimiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inFlight--
+	close(l.released)
+	l.released = make(chan struct{})
+}
+
+// observe adapts the limits to the response of a request.
+func (l *AdaptiveRateLimiter) observe(resp *http.Response) {
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		l.decrease(time.Now(), retryAfter(resp.Header.Get("Retry-After")))
+	case resp.StatusCode < http.StatusInternalServerError:
+		l.increase()
+	}
+}
+
+// decrease halves the limits and pauses all the requests for d.
+func (l *AdaptiveRateLimiter) decrease(now time.Time, d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.throttled++
+
+	if until := now.Add(d); until.After(l.pausedUntil) {
+		l.pausedUntil = until
+		l.last = until
+		l.tokens = 0
+	}
+
+	if now.Sub(l.lastDecrease) < rateLimitDecreaseInterval {
+		return
+	}
+
+	l.lastDecrease = now
+	l.reduced = true
+	l.qps = max(l.qps/2, min(rateLimitMinQPS, l.maxQPS))
+	l.concurrency = max(l.concurrency/2, 1)
+
+	slog.Warn("aws: scim requests throttled, reducing the rate",
+		"qps", l.qps,
+		"concurrency", int(l.concurrency),
+		"retry_after", d,
+	)
+}
+
+// increase grows the limits about one request per second and one request in flight per round of
+// successful requests, up to their maximum.
+func (l *AdaptiveRateLimiter) increase() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.reduced {
+		return
+	}
+
+	l.qps = min(l.qps+1/l.qps, l.maxQPS)
+	l.concurrency = min(l.concurrency+1/l.concurrency, l.maxConcurrency)
+
+	if l.qps == l.maxQPS && l.concurrency == l.maxConcurrency {
+		l.reduced = false
+		slog.Info("aws: scim requests rate recovered", "qps", l.qps, "concurrency", int(l.concurrency))
+	}
+}
+
+// Stats returns the metrics of the rate limiter.
+func (l *AdaptiveRateLimiter) Stats() RateLimiterStats {
+	if l == nil {
+		return RateLimiterStats{}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return RateLimiterStats{
+		QPS:         l.qps,
+		Concurrency: int(l.concurrency),
+		Requests:    l.requests,
+		Throttled:   l.throttled,
+	}
+}
+
+// Transport returns a http.RoundTripper sending the requests to base once the rate limiter allows
+// them, and adapting the limits to their responses.
+// A request is in flight until its response body is closed.
+// It must be the base transport of the retries, so the retried requests are limited too.
+func (l *AdaptiveRateLimiter) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	if l == nil {
+		return base
+	}
+
+	return &rateLimitTransport{limiter: l, base: base}
+}
+
+// rateLimitTransport is a http.RoundTripper waiting for the adaptive rate limiter before every request.
+type rateLimitTransport struct {
+	limiter *AdaptiveRateLimiter
+	base    http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	release, err := t.limiter.Wait(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	t.limiter.observe(resp)
+
+	if resp.Body == nil {
+		release()
+		return resp, nil
+	}
+
+	resp.Body = &releaseBody{ReadCloser: resp.Body, release: sync.OnceFunc(release)}
+
+	return resp, nil
+}
+
+// releaseBody is a response body releasing the slot in flight of its request when it is closed.
+type releaseBody struct {
+	io.ReadCloser
+	release func()
+}
+
+// Close implements io.Closer.
+func (b *releaseBody) Close() error {
+	defer b.release()
+	return b.ReadCloser.Close()
+}
+
+// retryAfter returns the delay of the Retry-After header, in seconds or as a date, 0 when it is missing.
+func retryAfter(value string) time.Duration {
+	var d time.Duration
+
+	if secs, err := strconv.Atoi(value); err == nil {
+		d = time.Duration(secs) * time.Second
+	} else if date, err := http.ParseTime(value); err == nil {
+		d = time.Until(date)
+	}
+
+	return min(max(d, 0), rateLimitMaxRetryAfter)
+}
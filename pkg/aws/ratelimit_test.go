@@ -0,0 +1,191 @@
+package aws
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAdaptiveRateLimiter(t *testing.T) {
+	assert.Nil(t, NewAdaptiveRateLimiter(0, 10))
+	assert.Equal(t, http.DefaultTransport, NewAdaptiveRateLimiter(0, 10).Transport(nil), "a nil rate limiter doesn't limit")
+
+	release, err := NewAdaptiveRateLimiter(0, 10).Wait(context.TODO())
+	assert.NoError(t, err)
+	release()
+
+	l := NewAdaptiveRateLimiter(5, 0)
+	assert.Equal(t, RateLimiterStats{QPS: 5, Concurrency: 1}, l.Stats())
+}
+
+func TestAdaptiveRateLimiter_reserve(t *testing.T) {
+	l := NewAdaptiveRateLimiter(10, 2)
+	now := l.last
+
+	_, _, ok := l.reserve(now)
+	assert.True(t, ok)
+
+	d, _, ok := l.reserve(now)
+	assert.False(t, ok)
+	assert.Equal(t, 100*time.Millisecond, d, "the token is spent")
+
+	_, _, ok = l.reserve(now.Add(100 * time.Millisecond))
+	assert.True(t, ok)
+
+	_, released, ok := l.reserve(now.Add(time.Second))
+	assert.False(t, ok)
+	assert.NotNil(t, released, "the requests in flight are at the concurrency")
+
+	l.release()
+	select {
+	case <-released:
+	default:
+		t.Error("the waiting requests are not woken up")
+	}
+
+	_, _, ok = l.reserve(now.Add(time.Second))
+	assert.True(t, ok)
+}
+
+func TestAdaptiveRateLimiter_Wait(t *testing.T) {
+	l := NewAdaptiveRateLimiter(1, 1)
+
+	release, err := l.Wait(context.TODO())
+	assert.NoError(t, err)
+	release()
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err = l.Wait(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestAdaptiveRateLimiter_AIMD(t *testing.T) {
+	l := NewAdaptiveRateLimiter(10, 4)
+	now := time.Now()
+
+	// the requests throttled at the same time reduce the limits once
+	l.decrease(now, 2*time.Second)
+	l.decrease(now, time.Second)
+	assert.Equal(t, RateLimiterStats{QPS: 5, Concurrency: 2, Throttled: 2}, l.Stats())
+
+	d, _, ok := l.reserve(now)
+	assert.False(t, ok)
+	assert.Equal(t, 2*time.Second, d, "the requests are paused for the Retry-After")
+
+	l.decrease(now.Add(rateLimitDecreaseInterval), 0)
+	assert.Equal(t, RateLimiterStats{QPS: 2.5, Concurrency: 1, Throttled: 3}, l.Stats())
+
+	// the limits grow back while the requests succeed
+	for range 100 {
+		l.increase()
+	}
+	assert.Equal(t, RateLimiterStats{QPS: 10, Concurrency: 4, Throttled: 3}, l.Stats())
+	assert.False(t, l.reduced)
+}
+
+func Test_rateLimitTransport(t *testing.T) {
+	t.Run("should reduce the limits of the throttled requests", func(t *testing.T) {
+		var calls atomic.Int32
+
+		svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) == 1 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			_, _ = w.Write([]byte(`{}`))
+		}))
+		defer svr.Close()
+
+		l := NewAdaptiveRateLimiter(100, 4)
+		client := &http.Client{Transport: l.Transport(svr.Client().Transport)}
+
+		resp, err := client.Get(svr.URL)
+		assert.NoError(t, err)
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode, "the retries are not done by the rate limiter")
+		assert.Equal(t, RateLimiterStats{QPS: 50, Concurrency: 2, Requests: 1, Throttled: 1}, l.Stats())
+
+		resp, err = client.Get(svr.URL)
+		assert.NoError(t, err)
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Greater(t, l.Stats().QPS, float64(50), "the rate grows with the successful requests")
+	})
+
+	t.Run("should limit the requests in flight", func(t *testing.T) {
+		var (
+			mu          sync.Mutex
+			inFlight    int
+			maxInFlight int
+		)
+
+		svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			inFlight++
+			maxInFlight = max(maxInFlight, inFlight)
+			mu.Unlock()
+
+			time.Sleep(20 * time.Millisecond)
+
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+		}))
+		defer svr.Close()
+
+		client := &http.Client{Transport: NewAdaptiveRateLimiter(1000, 2).Transport(svr.Client().Transport)}
+
+		var wg sync.WaitGroup
+		for range 6 {
+			wg.Go(func() {
+				resp, err := client.Get(svr.URL)
+				assert.NoError(t, err)
+				resp.Body.Close()
+			})
+		}
+		wg.Wait()
+
+		assert.Equal(t, 2, maxInFlight)
+	})
+
+	t.Run("should hold the request in flight until the response body is closed", func(t *testing.T) {
+		svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{}`))
+		}))
+		defer svr.Close()
+
+		l := NewAdaptiveRateLimiter(1000, 1)
+		client := &http.Client{Transport: l.Transport(svr.Client().Transport)}
+
+		resp, err := client.Get(svr.URL)
+		assert.NoError(t, err)
+
+		_, _, ok := l.reserve(time.Now().Add(time.Second))
+		assert.False(t, ok, "the response body is not closed")
+
+		assert.NoError(t, resp.Body.Close())
+		assert.NoError(t, resp.Body.Close(), "the request is released once")
+
+		_, _, ok = l.reserve(time.Now().Add(time.Second))
+		assert.True(t, ok)
+		l.release()
+	})
+}
+
+func Test_retryAfter(t *testing.T) {
+	assert.Equal(t, 3*time.Second, retryAfter("3"))
+	assert.Equal(t, rateLimitMaxRetryAfter, retryAfter("3600"))
+	assert.Equal(t, time.Duration(0), retryAfter(time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)))
+	assert.Equal(t, time.Duration(0), retryAfter(""))
+}
@@ -22,6 +22,7 @@ const (
 	UsersPath                 = "/Users"
 	GroupsPath                = "/Groups"
 	ServiceProviderConfigPath = "/ServiceProviderConfig"
+	BulkPath                  = "/Bulk"
 
 	// Content types
 	ContentTypeSCIMJSON = "application/scim+json"
@@ -64,6 +65,9 @@ var (
 
 	// ErrServiceProviderConfigEmpty is returned when the service provider config is empty.
 	ErrServiceProviderConfigEmpty = errors.New("aws: service provider config may not be empty")
+
+	// ErrBulkRequestEmpty is returned when the bulk request is empty.
+	ErrBulkRequestEmpty = errors.New("aws: bulk request may not be empty")
 )
 
 //go:generate go tool mockgen -package=mocks -destination=../../mocks/aws/scim_mocks.go -source=scim.go HTTPClient
@@ -285,23 +289,28 @@ func (s *SCIMService) CreateOrGetUser(ctx context.Context, cur *CreateUserReques
 				"displayName", response.DisplayName,
 			)
 
-			// Check if the user already exists, same externalId but different email, email change in the identity provider
-			// when response.ID is empty, the user does not exists, so this is the case when the new user is a existing user
-			// with a different email same externalId.
+			// the user does not exist with this userName, so the conflict is the externalId:
+			// the user was renamed in the identity provider, e.g. its email changed, and the
+			// existing user is updated in place to keep its id and its account assignments.
 			if response.ID == "" {
-				slog.Warn("aws CreateOrGetUser: user already exists, but with a different name, same id",
-					"user", cur.UserName,
-					"name", cur.DisplayName,
-				)
+				if cur.ExternalID == "" {
+					return nil, e
+				}
+
+				response, err = s.GetUserByExternalID(ctx, cur.ExternalID)
+				if err != nil {
+					return nil, fmt.Errorf("aws CreateOrGetUser: error getting user information: %w", err)
+				}
+				if response.ID == "" {
+					return nil, e
+				}
 
-				// remove the ExternalID from the user request, and call itself again to create the new user
-				slog.Warn("aws CreateOrGetUser: removing ExternalID from the user request, calling itself again to create the new user",
+				slog.Warn("aws CreateOrGetUser: user already exists with the same externalId but a different userName, renaming the user",
 					"user", cur.UserName,
-					"name", cur.DisplayName,
+					"previous_user", response.UserName,
+					"id", response.ID,
+					"externalId", response.ExternalID,
 				)
-
-				cur.ExternalID = ""
-				return s.CreateOrGetUser(ctx, cur)
 			}
 
 			curesp := CreateUserResponse(*(*User)(response))
@@ -459,6 +468,53 @@ func (s *SCIMService) GetUserByUserName(ctx context.Context, userName string) (*
 	return &GetUserResponse{}, nil
 }
 
+// GetUserByExternalID returns an user from the AWS SSO Using the API,
+// an empty user when it does not exist.
+func (s *SCIMService) GetUserByExternalID(ctx context.Context, externalID string) (*GetUserResponse, error) {
+	if externalID == "" {
+		return nil, ErrUserExternalIDEmpty
+	}
+
+	reqURL, err := url.Parse(s.url.String())
+	if err != nil {
+		return nil, fmt.Errorf("aws GetUserByExternalID: error parsing url: %w", err)
+	}
+
+	reqURL.Path = path.Join(reqURL.Path, "/Users")
+
+	filter := fmt.Sprintf("externalId eq %q", externalID)
+	q := reqURL.Query()
+	q.Add("filter", filter)
+	reqURL.RawQuery = q.Encode()
+
+	req, err := s.newRequest(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("aws GetUserByExternalID: error creating request, externalId: %s, http method: %s, url: %v, error: %w", externalID, http.MethodGet, reqURL.String(), err)
+	}
+
+	resp, err := s.do(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("aws GetUserByExternalID: error sending request, externalId: %s, http method: %s, url: %v, error: %w", externalID, http.MethodGet, reqURL.String(), err)
+	}
+	defer resp.Body.Close()
+
+	if e := s.checkHTTPResponse(resp); e != nil {
+		return nil, e
+	}
+
+	var lur ListUsersResponse
+	if err = json.NewDecoder(resp.Body).Decode(&lur); err != nil {
+		return nil, fmt.Errorf("aws GetUserByExternalID: externalId: %s, error decoding response body: %w", externalID, err)
+	}
+
+	if len(lur.Resources) > 0 {
+		response := GetUserResponse(*lur.Resources[0])
+		return &response, nil
+	}
+
+	return &GetUserResponse{}, nil
+}
+
 // GetUser returns an user from the AWS SSO Using the API
 func (s *SCIMService) GetUser(ctx context.Context, userID string) (*GetUserResponse, error) {
 	if userID == "" {
@@ -985,3 +1041,47 @@ func (s *SCIMService) ServiceProviderConfig(ctx context.Context) (*ServiceProvid
 
 	return &response, nil
 }
+
+// Bulk sends several operations in a single request, the service provider must advertise the
+// bulk support in its ServiceProviderConfig. The result of every operation is returned in the
+// response, a response without error only means the request was processed.
+// references:
+// + https://datatracker.ietf.org/doc/html/rfc7644#section-3.7
+func (s *SCIMService) Bulk(ctx context.Context, br *BulkRequest) (*BulkResponse, error) {
+	if br == nil || len(br.Operations) == 0 {
+		return nil, ErrBulkRequestEmpty
+	}
+
+	if len(br.Schemas) == 0 {
+		br.Schemas = []string{BulkRequestSchema}
+	}
+
+	reqURL, err := url.Parse(s.url.String())
+	if err != nil {
+		return nil, fmt.Errorf("aws Bulk: error parsing url: %w", err)
+	}
+
+	reqURL.Path = path.Join(reqURL.Path, BulkPath)
+
+	req, err := s.newRequest(ctx, http.MethodPost, reqURL, br)
+	if err != nil {
+		return nil, fmt.Errorf("aws Bulk: error creating request, operations: %d, http method: %s, url: %v, error: %w", len(br.Operations), http.MethodPost, reqURL.String(), err)
+	}
+
+	resp, err := s.do(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("aws Bulk: error sending request, operations: %d, http method: %s, url: %v, error: %w", len(br.Operations), http.MethodPost, reqURL.String(), err)
+	}
+	defer resp.Body.Close()
+
+	if e := s.checkHTTPResponse(resp); e != nil {
+		return nil, e
+	}
+
+	var response BulkResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("aws Bulk: error decoding response body: %w", err)
+	}
+
+	return &response, nil
+}
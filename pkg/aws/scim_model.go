@@ -4,6 +4,15 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"path"
+	"strconv"
+)
+
+// SCIM bulk message schemas
+// reference: https://datatracker.ietf.org/doc/html/rfc7644#section-3.7
+const (
+	BulkRequestSchema  = "urn:ietf:params:scim:api:messages:2.0:BulkRequest"
+	BulkResponseSchema = "urn:ietf:params:scim:api:messages:2.0:BulkResponse"
 )
 
 var (
@@ -153,7 +162,7 @@ type User struct {
 	Addresses            []Address             `json:"addresses,omitempty"`
 	Emails               []Email               `json:"emails,omitempty"`
 	PhoneNumbers         []PhoneNumber         `json:"phoneNumbers,omitempty"`
-	Active               bool                  `json:"active,omitempty"`
+	Active               bool                  `json:"active"`
 }
 
 // Validate check if the user entity is valid according to the SCIM spec constraints
@@ -385,3 +394,99 @@ type ServiceProviderConfig struct {
 		Supported bool `json:"supported"`
 	} `json:"etag"`
 }
+
+// BulkRequest represent a bulk request entity, the operations are processed in order.
+// reference: https://datatracker.ietf.org/doc/html/rfc7644#section-3.7
+type BulkRequest struct {
+	Schemas      []string         `json:"schemas"`
+	FailOnErrors int              `json:"failOnErrors,omitempty"`
+	Operations   []*BulkOperation `json:"Operations"`
+}
+
+// BulkOperation represent an operation of a bulk request.
+// The BulkID is required by the POST operations, it maps the result of the operation to the
+// resource created. The bulkId references between operations are not supported.
+type BulkOperation struct {
+	Method  string `json:"method"`
+	BulkID  string `json:"bulkId,omitempty"`
+	Version string `json:"version,omitempty"`
+	Path    string `json:"path"`
+	Data    any    `json:"data,omitempty"`
+}
+
+// BulkResponse represent a bulk response entity
+type BulkResponse struct {
+	Schemas    []string                 `json:"schemas"`
+	Operations []*BulkOperationResponse `json:"Operations"`
+}
+
+// BulkOperationResponse represent the result of an operation of a bulk request
+type BulkOperationResponse struct {
+	Method   string          `json:"method"`
+	BulkID   string          `json:"bulkId,omitempty"`
+	Version  string          `json:"version,omitempty"`
+	Location string          `json:"location,omitempty"`
+	Status   BulkStatus      `json:"status"`
+	Response json.RawMessage `json:"response,omitempty"`
+}
+
+// ResourceID returns the id of the resource of the operation, taken from its location or,
+// when the location is missing, from the response.
+func (r *BulkOperationResponse) ResourceID() string {
+	if r.Location != "" {
+		return path.Base(r.Location)
+	}
+
+	var resource struct {
+		ID string `json:"id"`
+	}
+	if json.Unmarshal(r.Response, &resource) == nil {
+		return resource.ID
+	}
+
+	return ""
+}
+
+// Err returns the error of the operation, nil when the operation succeeded.
+func (r *BulkOperationResponse) Err() error {
+	if r.Status >= 200 && r.Status < 400 {
+		return nil
+	}
+
+	var errorResp struct {
+		ScimType string `json:"scimType"`
+		Detail   string `json:"detail"`
+	}
+	if json.Unmarshal(r.Response, &errorResp) == nil && errorResp.Detail != "" {
+		return &HTTPResponseError{StatusCode: int(r.Status), Code: errorResp.ScimType, Message: errorResp.Detail}
+	}
+
+	return &HTTPResponseError{StatusCode: int(r.Status), Code: strconv.Itoa(int(r.Status)), Message: string(r.Response)}
+}
+
+// BulkStatus is the HTTP status code of a bulk operation, sent as a string by the RFC and as a
+// number by some service providers.
+type BulkStatus int
+
+// UnmarshalJSON implements the json.Unmarshaler interface accepting strings and numbers.
+func (s *BulkStatus) UnmarshalJSON(data []byte) error {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	switch v := v.(type) {
+	case float64:
+		*s = BulkStatus(v)
+	case string:
+		code, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("aws: invalid bulk operation status %q: %w", v, err)
+		}
+		*s = BulkStatus(code)
+	default:
+		return fmt.Errorf("aws: invalid bulk operation status %s", data)
+	}
+
+	return nil
+}
@@ -1,6 +1,8 @@
 package aws
 
 import (
+	"encoding/json"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -981,3 +983,55 @@ func TestGroup_String(t *testing.T) {
 		assert.Contains(t, str, "user-1")
 	})
 }
+
+func TestUser_MarshalJSON(t *testing.T) {
+	t.Run("should serialize the active attribute of the suspended users", func(t *testing.T) {
+		user := &User{UserName: "user.1@mail.com", Active: false}
+
+		got, err := json.Marshal(user)
+		assert.NoError(t, err)
+		assert.Contains(t, string(got), `"active":false`)
+
+		got, err = json.Marshal((*CreateUserRequest)(user))
+		assert.NoError(t, err)
+		assert.Contains(t, string(got), `"active":false`)
+
+		got, err = json.Marshal((*PutUserRequest)(user))
+		assert.NoError(t, err)
+		assert.Contains(t, string(got), `"active":false`)
+	})
+
+	t.Run("should serialize the active attribute of the active users", func(t *testing.T) {
+		got, err := json.Marshal(&User{UserName: "user.1@mail.com", Active: true})
+		assert.NoError(t, err)
+		assert.Contains(t, string(got), `"active":true`)
+	})
+}
+
+func TestBulkOperationResponse(t *testing.T) {
+	t.Run("should unmarshal string and number status", func(t *testing.T) {
+		var ops []*BulkOperationResponse
+		err := json.Unmarshal([]byte(`[{"status":"201"},{"status":200}]`), &ops)
+		assert.NoError(t, err)
+		assert.Equal(t, BulkStatus(201), ops[0].Status)
+		assert.Equal(t, BulkStatus(200), ops[1].Status)
+
+		err = json.Unmarshal([]byte(`[{"status":"created"}]`), &ops)
+		assert.Error(t, err)
+	})
+
+	t.Run("should return the resource id from the response", func(t *testing.T) {
+		op := &BulkOperationResponse{Status: 201, Response: json.RawMessage(`{"id":"a1b2"}`)}
+		assert.Equal(t, "a1b2", op.ResourceID())
+		assert.Empty(t, (&BulkOperationResponse{}).ResourceID())
+	})
+
+	t.Run("should return the error of the operation", func(t *testing.T) {
+		op := &BulkOperationResponse{Status: 409, Response: json.RawMessage(`{"scimType":"uniqueness","detail":"duplicated"}`)}
+
+		var httpErr *HTTPResponseError
+		assert.True(t, errors.As(op.Err(), &httpErr))
+		assert.Equal(t, 409, httpErr.StatusCode)
+		assert.Equal(t, "uniqueness", httpErr.Code)
+	})
+}
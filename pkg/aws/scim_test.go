@@ -2,6 +2,7 @@ package aws
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -1126,6 +1127,100 @@ func TestCreateOrGetUser(t *testing.T) {
 	})
 }
 
+func TestCreateOrGetUser_Renamed(t *testing.T) {
+	newUser := &CreateUserRequest{
+		ExternalID:  "702135",
+		UserName:    "mark.jackson@example.com",
+		DisplayName: "Mark Jackson",
+		Name:        &Name{GivenName: "Mark", FamilyName: "Jackson"},
+		Emails:      []Email{{Value: "mark.jackson@example.com", Type: "work", Primary: true}},
+		Active:      true,
+	}
+
+	t.Run("should update the user with the same externalId in place", func(t *testing.T) {
+		var putPath string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			switch {
+			case r.Method == http.MethodPost:
+				w.WriteHeader(http.StatusConflict)
+				_, _ = fmt.Fprint(w, `{"detail":"Duplicate externalId","status":"409"}`)
+			case r.URL.Query().Get("filter") == `userName eq "mark.jackson@example.com"`:
+				_, _ = fmt.Fprint(w, `{"Resources":[]}`)
+			case r.URL.Query().Get("filter") == `externalId eq "702135"`:
+				_, _ = fmt.Fprint(w, `{"Resources":[{"id":"u1","externalId":"702135","userName":"mjack@example.com","active":true}]}`)
+			case r.Method == http.MethodPut:
+				putPath = r.URL.Path
+				_, _ = fmt.Fprint(w, `{"id":"u1","externalId":"702135","userName":"mark.jackson@example.com","active":true}`)
+			default:
+				w.WriteHeader(http.StatusBadRequest)
+			}
+		}))
+		defer server.Close()
+
+		service, err := NewSCIMService(http.DefaultClient, server.URL, "MyToken")
+		assert.NoError(t, err)
+
+		got, err := service.CreateOrGetUser(context.Background(), newUser)
+		assert.NoError(t, err)
+		assert.Equal(t, "u1", got.ID)
+		assert.Equal(t, "mark.jackson@example.com", got.UserName)
+		assert.Equal(t, "/Users/u1", putPath)
+	})
+
+	t.Run("should return the conflict when no user has the externalId", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			if r.Method == http.MethodPost {
+				w.WriteHeader(http.StatusConflict)
+				_, _ = fmt.Fprint(w, `{"detail":"Duplicate","status":"409"}`)
+				return
+			}
+			_, _ = fmt.Fprint(w, `{"Resources":[]}`)
+		}))
+		defer server.Close()
+
+		service, err := NewSCIMService(http.DefaultClient, server.URL, "MyToken")
+		assert.NoError(t, err)
+
+		got, err := service.CreateOrGetUser(context.Background(), newUser)
+		assert.Nil(t, got)
+
+		var httpErr *HTTPResponseError
+		assert.True(t, errors.As(err, &httpErr))
+		assert.Equal(t, http.StatusConflict, httpErr.StatusCode)
+	})
+}
+
+func TestGetUserByExternalID(t *testing.T) {
+	t.Run("should return error when the externalId is empty", func(t *testing.T) {
+		service, err := NewSCIMService(http.DefaultClient, "https://testing.com", "MyToken")
+		assert.NoError(t, err)
+
+		got, err := service.GetUserByExternalID(context.Background(), "")
+		assert.ErrorIs(t, err, ErrUserExternalIDEmpty)
+		assert.Nil(t, got)
+	})
+
+	t.Run("should filter the users by externalId", func(t *testing.T) {
+		var filter string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			filter = r.URL.Query().Get("filter")
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = fmt.Fprint(w, ReadJSONFileAsString(t, "testdata/ListUserResponse.json"))
+		}))
+		defer server.Close()
+
+		service, err := NewSCIMService(http.DefaultClient, server.URL, "MyToken")
+		assert.NoError(t, err)
+
+		got, err := service.GetUserByExternalID(context.Background(), "702135")
+		assert.NoError(t, err)
+		assert.Equal(t, `externalId eq "702135"`, filter)
+		assert.Equal(t, "702135", got.ExternalID)
+	})
+}
+
 func TestDeleteUser(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -2834,3 +2929,92 @@ func TestCreateOrGetGroup_AdditionalCases(t *testing.T) {
 		assert.Contains(t, err.Error(), "network failure")
 	})
 }
+
+func TestBulk(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	endpoint := "https://testing.com"
+
+	t.Run("should return error when the request is empty", func(t *testing.T) {
+		service, err := NewSCIMService(mocks.NewMockHTTPClient(mockCtrl), endpoint, "MyToken")
+		assert.NoError(t, err)
+
+		got, err := service.Bulk(context.Background(), &BulkRequest{})
+		assert.ErrorIs(t, err, ErrBulkRequestEmpty)
+		assert.Nil(t, got)
+	})
+
+	t.Run("should send the operations and return the results", func(t *testing.T) {
+		mockHTTPClient := mocks.NewMockHTTPClient(mockCtrl)
+
+		jsonResp := `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:BulkResponse"],
+			"Operations": [
+				{"method": "POST", "bulkId": "u1", "location": "https://testing.com/Users/a1b2", "status": "201"},
+				{"method": "PATCH", "location": "https://testing.com/Groups/g1", "status": 204},
+				{"method": "DELETE", "location": "https://testing.com/Users/c3", "status": "404", "response": {"detail": "user not found", "status": "404"}}
+			]
+		}`
+
+		mockHTTPClient.EXPECT().Do(gomock.Any()).DoAndReturn(func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, http.MethodPost, req.Method)
+			assert.Equal(t, "/Bulk", req.URL.Path)
+
+			var br BulkRequest
+			assert.NoError(t, json.NewDecoder(req.Body).Decode(&br))
+			assert.Equal(t, []string{BulkRequestSchema}, br.Schemas)
+			assert.Len(t, br.Operations, 3)
+			assert.Equal(t, "u1", br.Operations[0].BulkID)
+
+			return &http.Response{
+				Status:     "200 OK",
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(jsonResp)),
+			}, nil
+		})
+
+		service, err := NewSCIMService(mockHTTPClient, endpoint, "MyToken")
+		assert.NoError(t, err)
+
+		got, err := service.Bulk(context.Background(), &BulkRequest{
+			Operations: []*BulkOperation{
+				{Method: http.MethodPost, BulkID: "u1", Path: UsersPath, Data: &User{UserName: "user.1@mail.com"}},
+				{Method: http.MethodPatch, Path: GroupsPath + "/g1", Data: &Patch{}},
+				{Method: http.MethodDelete, Path: UsersPath + "/c3"},
+			},
+		})
+		assert.NoError(t, err)
+		assert.Len(t, got.Operations, 3)
+
+		assert.Equal(t, "a1b2", got.Operations[0].ResourceID())
+		assert.NoError(t, got.Operations[0].Err())
+		assert.Equal(t, BulkStatus(http.StatusNoContent), got.Operations[1].Status)
+		assert.NoError(t, got.Operations[1].Err())
+
+		var httpErr *HTTPResponseError
+		assert.True(t, errors.As(got.Operations[2].Err(), &httpErr))
+		assert.Equal(t, http.StatusNotFound, httpErr.StatusCode)
+		assert.Equal(t, "user not found", httpErr.Message)
+	})
+
+	t.Run("should return error on HTTP error response", func(t *testing.T) {
+		mockHTTPClient := mocks.NewMockHTTPClient(mockCtrl)
+
+		mockHTTPClient.EXPECT().Do(gomock.Any()).Return(&http.Response{
+			Status:     "413 Request Entity Too Large",
+			StatusCode: http.StatusRequestEntityTooLarge,
+			Body:       io.NopCloser(strings.NewReader(`{"detail":"too many operations","status":"413"}`)),
+		}, nil)
+
+		service, err := NewSCIMService(mockHTTPClient, endpoint, "MyToken")
+		assert.NoError(t, err)
+
+		got, err := service.Bulk(context.Background(), &BulkRequest{Operations: []*BulkOperation{{Method: http.MethodDelete, Path: UsersPath + "/c3"}}})
+		assert.Error(t, err)
+		assert.Nil(t, got)
+
+		var httpErr *HTTPResponseError
+		assert.True(t, errors.As(err, &httpErr))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, httpErr.StatusCode)
+	})
+}
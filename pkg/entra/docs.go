@@ -0,0 +1,22 @@
+// Package entra provides a Microsoft Graph API client to read groups, users and
+// group memberships from Microsoft Entra ID (formerly Azure Active Directory).
+//
+// Basic usage:
+//
+//	httpClient, err := entra.NewHTTPClient(ctx, entra.ClientCredentialsConfig{
+//	    Client:       http.DefaultClient,
+//	    TenantID:     tenantID,
+//	    ClientID:     clientID,
+//	    ClientSecret: clientSecret,
+//	})
+//	if err != nil {
+//	    return err
+//	}
+//
+//	client, err := entra.NewClient(httpClient)
+//	if err != nil {
+//	    return err
+//	}
+//
+//	groups, err := client.ListGroups(ctx, []string{"startswith(displayName,'AWS')"})
+package entra
@@ -0,0 +1,520 @@
+package entra
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// Microsoft Graph API
+// reference: https://learn.microsoft.com/en-us/graph/overview
+
+const (
+	// DefaultBaseURL is the Microsoft Graph API v1.0 endpoint.
+	DefaultBaseURL = "https://graph.microsoft.com/v1.0"
+
+	// DefaultAuthorityURL is the Microsoft identity platform endpoint used to request tokens.
+	DefaultAuthorityURL = "https://login.microsoftonline.com"
+
+	// DefaultScope is the scope requested with the client credentials flow,
+	// the granted application permissions are the ones consented in the app registration.
+	DefaultScope = "https://graph.microsoft.com/.default"
+
+	// maxPageSize is the maximum page size allowed by the groups and users collections.
+	maxPageSize = "999"
+
+	// Fields requested for each resource type
+	groupFields  = "id,displayName,mail,mailNickname,description,securityEnabled"
+	memberFields = "id,userPrincipalName,mail,accountEnabled"
+	userFields   = "id,userPrincipalName,mail,displayName,givenName,surname,accountEnabled"
+)
+
+var (
+	// ErrHTTPClientNil is returned when the http client is nil.
+	ErrHTTPClientNil = errors.New("entra: http client is required")
+
+	// ErrTenantIDEmpty is returned when the tenant id is empty.
+	ErrTenantIDEmpty = errors.New("entra: tenant id is required")
+
+	// ErrClientIDEmpty is returned when the client id is empty.
+	ErrClientIDEmpty = errors.New("entra: client id is required")
+
+	// ErrClientSecretEmpty is returned when the client secret is empty.
+	ErrClientSecretEmpty = errors.New("entra: client secret is required")
+
+	// ErrGroupIDEmpty is returned when the group id is empty.
+	ErrGroupIDEmpty = errors.New("entra: group id is required")
+
+	// ErrUserIDEmpty is returned when the user id is empty.
+	ErrUserIDEmpty = errors.New("entra: user id is required")
+)
+
+// HTTPClient is an interface for sending HTTP requests.
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// ClientCredentialsConfig is the configuration used to authenticate against Microsoft Entra ID
+// using the OAuth 2.0 client credentials flow of an app registration.
+type ClientCredentialsConfig struct {
+	// Client is the base http client used to request tokens and to call the Graph API.
+	Client       *http.Client
+	TenantID     string
+	ClientID     string
+	ClientSecret string
+
+	// AuthorityURL allows to use national clouds or test servers, default: DefaultAuthorityURL.
+	AuthorityURL string
+
+	// Scopes requested, default: DefaultScope.
+	Scopes []string
+}
+
+// NewHTTPClient returns an http client authenticated with the client credentials flow.
+// References:
+// - https://learn.microsoft.com/en-us/entra/identity-platform/v2-oauth2-client-creds-grant-flow
+// Required application permissions:
+// - GroupMember.Read.All
+// - User.Read.All
+func NewHTTPClient(ctx context.Context, config ClientCredentialsConfig) (*http.Client, error) {
+	if config.Client == nil {
+		return nil, ErrHTTPClientNil
+	}
+
+	if config.TenantID == "" {
+		return nil, ErrTenantIDEmpty
+	}
+
+	if config.ClientID == "" {
+		return nil, ErrClientIDEmpty
+	}
+
+	if config.ClientSecret == "" {
+		return nil, ErrClientSecretEmpty
+	}
+
+	authority := config.AuthorityURL
+	if authority == "" {
+		authority = DefaultAuthorityURL
+	}
+
+	scopes := config.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{DefaultScope}
+	}
+
+	tokenURL, err := url.JoinPath(authority, config.TenantID, "oauth2", "v2.0", "token")
+	if err != nil {
+		return nil, fmt.Errorf("entra: error building token url: %w", err)
+	}
+
+	cc := clientcredentials.Config{
+		ClientID:     config.ClientID,
+		ClientSecret: config.ClientSecret,
+		TokenURL:     tokenURL,
+		Scopes:       scopes,
+		AuthStyle:    oauth2.AuthStyleInParams,
+	}
+
+	// the token requests are sent using a copy of the base client, so they are not authenticated
+	tokenClient := *config.Client
+	tokenCtx := context.WithValue(ctx, oauth2.HTTPClient, &tokenClient)
+
+	return &http.Client{
+		Timeout: config.Client.Timeout,
+		Transport: &oauth2.Transport{
+			Source: cc.TokenSource(tokenCtx),
+			Base:   config.Client.Transport,
+		},
+	}, nil
+}
+
+// Client is a Microsoft Graph API client.
+type Client struct {
+	httpClient HTTPClient
+	baseURL    string
+	userFields string
+	expand     string
+	UserAgent  string
+}
+
+// ClientOption is a function that configures a Client.
+type ClientOption func(*Client)
+
+// WithBaseURL configures the Microsoft Graph endpoint, useful for national clouds.
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *Client) {
+		c.baseURL = baseURL
+	}
+}
+
+// WithSyncFieldSet configures the Client to only request the user properties
+// needed for the configured sync field set.
+// When fields is nil or empty, all user properties are requested (default behavior).
+func WithSyncFieldSet(fields *model.SyncFieldSet) ClientOption {
+	return func(c *Client) {
+		c.userFields, c.expand = buildUserFields(fields)
+	}
+}
+
+// buildUserFields constructs the $select and $expand parameters based on the configured field set.
+func buildUserFields(fields *model.SyncFieldSet) (string, string) {
+	parts := []string{userFields}
+
+	if fields.Includes(model.SyncUserFieldAddresses) {
+		parts = append(parts, "streetAddress,city,state,postalCode,country")
+	}
+	if fields.Includes(model.SyncUserFieldPhoneNumbers) {
+		parts = append(parts, "mobilePhone,businessPhones")
+	}
+	if fields.Includes(model.SyncUserFieldPreferredLanguage) {
+		parts = append(parts, "preferredLanguage")
+	}
+	if fields.Includes(model.SyncUserFieldTitle) {
+		parts = append(parts, "jobTitle")
+	}
+	if fields.Includes(model.SyncUserFieldUserType) {
+		parts = append(parts, "userType")
+	}
+
+	var expand string
+	if fields.Includes(model.SyncUserFieldEnterpriseData) {
+		parts = append(parts, "department,companyName,employeeId,officeLocation")
+		expand = "manager($select=id,displayName)"
+	}
+
+	return strings.Join(parts, ","), expand
+}
+
+// NewClient returns a new Microsoft Graph API client.
+// The http client must be authenticated, see NewHTTPClient.
+func NewClient(httpClient HTTPClient, opts ...ClientOption) (*Client, error) {
+	if httpClient == nil {
+		return nil, ErrHTTPClientNil
+	}
+
+	c := &Client{
+		httpClient: httpClient,
+		baseURL:    DefaultBaseURL,
+	}
+	c.userFields, c.expand = buildUserFields(nil)
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if _, err := url.Parse(c.baseURL); err != nil {
+		return nil, fmt.Errorf("entra: error parsing base url: %w", err)
+	}
+
+	return c, nil
+}
+
+// buildURL returns the url of the given resource path with the given query parameters.
+func (c *Client) buildURL(resource string, query url.Values) (string, error) {
+	u, err := url.Parse(c.baseURL)
+	if err != nil {
+		return "", fmt.Errorf("entra: error parsing base url: %w", err)
+	}
+
+	u.Path = path.Join(u.Path, resource)
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+// get sends a GET request to the given url and decodes the JSON response into v.
+func (c *Client) get(ctx context.Context, reqURL string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return fmt.Errorf("entra: error creating request, url: %s, error: %w", reqURL, err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	// required by the advanced queries, e.g. endsWith or $count, harmless for the rest
+	req.Header.Set("ConsistencyLevel", "eventual")
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("entra: error sending request, url: %s, error: %w", reqURL, err)
+	}
+	defer resp.Body.Close()
+
+	if err := checkHTTPResponse(resp); err != nil {
+		return err
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("entra: error decoding response body, url: %s, error: %w", reqURL, err)
+	}
+
+	return nil
+}
+
+// checkHTTPResponse returns a GraphError when the status code of the response is not successful.
+func checkHTTPResponse(resp *http.Response) error {
+	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusBadRequest {
+		return nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("entra: error reading response body: %w", err)
+	}
+
+	var errorResp struct {
+		Error GraphError `json:"error"`
+	}
+
+	gErr := &GraphError{Code: resp.Status, Message: string(body)}
+	if json.Unmarshal(body, &errorResp) == nil && errorResp.Error.Code != "" {
+		gErr = &errorResp.Error
+	}
+	gErr.StatusCode = resp.StatusCode
+	gErr.RetryAfter = resp.Header.Get("Retry-After")
+
+	slog.Debug("entra checkHTTPResponse()", "statusCode", resp.StatusCode, "code", gErr.Code, "retryAfter", gErr.RetryAfter)
+
+	return gErr
+}
+
+// list follows the @odata.nextLink of a collection starting at the given url and
+// returns all its items and the @odata.deltaLink, if any.
+func list[T any](ctx context.Context, c *Client, reqURL string) ([]T, string, error) {
+	items := make([]T, 0, 50)
+
+	for reqURL != "" {
+		if err := ctx.Err(); err != nil {
+			return nil, "", err
+		}
+
+		var p page[T]
+		if err := c.get(ctx, reqURL, &p); err != nil {
+			return nil, "", err
+		}
+
+		items = append(items, p.Value...)
+
+		if p.DeltaLink != "" {
+			return items, p.DeltaLink, nil
+		}
+		reqURL = p.NextLink
+	}
+
+	return items, "", nil
+}
+
+// ListGroups returns the groups matching the given OData filters, all the groups when filter is empty.
+// Groups matching more than one filter are returned once.
+// References:
+// - https://learn.microsoft.com/en-us/graph/api/group-list
+// - https://learn.microsoft.com/en-us/graph/filter-query-parameter
+func (c *Client) ListGroups(ctx context.Context, filter []string) ([]*Group, error) {
+	filters := filter
+	if len(filters) == 0 {
+		filters = []string{""}
+	}
+
+	uniq := make(map[string]struct{})
+	groups := make([]*Group, 0, 50)
+
+	for _, f := range filters {
+		q := url.Values{}
+		q.Set("$select", groupFields)
+		q.Set("$top", maxPageSize)
+		if f != "" {
+			q.Set("$filter", f)
+			q.Set("$count", "true")
+		}
+
+		reqURL, err := c.buildURL("/groups", q)
+		if err != nil {
+			return nil, err
+		}
+
+		slog.Debug("entra: listing groups", "filter", f)
+
+		gs, _, err := list[*Group](ctx, c, reqURL)
+		if err != nil {
+			return nil, fmt.Errorf("entra: failed to list groups with filter %q: %w", f, err)
+		}
+
+		for _, g := range gs {
+			if _, ok := uniq[g.ID]; ok {
+				continue
+			}
+			uniq[g.ID] = struct{}{}
+			groups = append(groups, g)
+		}
+	}
+
+	return groups, nil
+}
+
+// ListUsers returns the users matching the given OData filters, all the users when filter is empty.
+// Users matching more than one filter are returned once.
+// reference: https://learn.microsoft.com/en-us/graph/api/user-list
+func (c *Client) ListUsers(ctx context.Context, filter []string) ([]*User, error) {
+	filters := filter
+	if len(filters) == 0 {
+		filters = []string{""}
+	}
+
+	uniq := make(map[string]struct{})
+	users := make([]*User, 0, 50)
+
+	for _, f := range filters {
+		q := c.userQuery()
+		q.Set("$top", maxPageSize)
+		if f != "" {
+			q.Set("$filter", f)
+			q.Set("$count", "true")
+		}
+
+		reqURL, err := c.buildURL("/users", q)
+		if err != nil {
+			return nil, err
+		}
+
+		slog.Debug("entra: listing users", "filter", f)
+
+		us, _, err := list[*User](ctx, c, reqURL)
+		if err != nil {
+			return nil, fmt.Errorf("entra: failed to list users with filter %q: %w", f, err)
+		}
+
+		for _, u := range us {
+			if _, ok := uniq[u.ID]; ok {
+				continue
+			}
+			uniq[u.ID] = struct{}{}
+			users = append(users, u)
+		}
+	}
+
+	return users, nil
+}
+
+// ListGroupMembers returns the users that are members of the given group, including the members
+// of the nested groups. Only enabled accounts are returned.
+// reference: https://learn.microsoft.com/en-us/graph/api/group-list-transitivemembers
+func (c *Client) ListGroupMembers(ctx context.Context, groupID string) ([]*User, error) {
+	if groupID == "" {
+		return nil, ErrGroupIDEmpty
+	}
+
+	q := url.Values{}
+	q.Set("$select", memberFields)
+	q.Set("$top", maxPageSize)
+
+	// the cast segment returns only the users, the nested groups are already expanded
+	reqURL, err := c.buildURL("/groups/"+url.PathEscape(groupID)+"/transitiveMembers/microsoft.graph.user", q)
+	if err != nil {
+		return nil, err
+	}
+
+	us, _, err := list[*User](ctx, c, reqURL)
+	if err != nil {
+		return nil, fmt.Errorf("entra: failed to list members of group %s: %w", groupID, err)
+	}
+
+	members := make([]*User, 0, len(us))
+	for _, u := range us {
+		if !u.AccountEnabled {
+			slog.Warn("entra: member not included in group because the account is disabled", "id", u.ID, "userPrincipalName", u.UserPrincipalName, "groupID", groupID)
+			continue
+		}
+		members = append(members, u)
+	}
+
+	return members, nil
+}
+
+// GetUser returns a user given its id or user principal name.
+// reference: https://learn.microsoft.com/en-us/graph/api/user-get
+func (c *Client) GetUser(ctx context.Context, userID string) (*User, error) {
+	if userID == "" {
+		return nil, ErrUserIDEmpty
+	}
+
+	reqURL, err := c.buildURL("/users/"+url.PathEscape(userID), c.userQuery())
+	if err != nil {
+		return nil, err
+	}
+
+	var u User
+	if err := c.get(ctx, reqURL, &u); err != nil {
+		return nil, fmt.Errorf("entra: error getting user %s: %w", userID, err)
+	}
+
+	return &u, nil
+}
+
+// ListUsersDelta returns the users changed since the given delta link was issued and the delta link
+// to use in the next call. When deltaLink is empty, all the users are returned.
+// Deleted users are returned with the Removed property set.
+// reference: https://learn.microsoft.com/en-us/graph/api/user-delta
+func (c *Client) ListUsersDelta(ctx context.Context, deltaLink string) (*UsersDelta, error) {
+	reqURL := deltaLink
+	if reqURL == "" {
+		var err error
+		if reqURL, err = c.buildURL("/users/delta", c.userQuery()); err != nil {
+			return nil, err
+		}
+	}
+
+	us, link, err := list[*User](ctx, c, reqURL)
+	if err != nil {
+		return nil, fmt.Errorf("entra: failed to list users delta: %w", err)
+	}
+
+	return &UsersDelta{Users: us, DeltaLink: link}, nil
+}
+
+// ListGroupsDelta returns the groups changed since the given delta link was issued and the delta link
+// to use in the next call. When deltaLink is empty, all the groups are returned.
+// Deleted groups are returned with the Removed property set.
+// reference: https://learn.microsoft.com/en-us/graph/api/group-delta
+func (c *Client) ListGroupsDelta(ctx context.Context, deltaLink string) (*GroupsDelta, error) {
+	reqURL := deltaLink
+	if reqURL == "" {
+		q := url.Values{}
+		q.Set("$select", groupFields)
+
+		var err error
+		if reqURL, err = c.buildURL("/groups/delta", q); err != nil {
+			return nil, err
+		}
+	}
+
+	gs, link, err := list[*Group](ctx, c, reqURL)
+	if err != nil {
+		return nil, fmt.Errorf("entra: failed to list groups delta: %w", err)
+	}
+
+	return &GroupsDelta{Groups: gs, DeltaLink: link}, nil
+}
+
+// userQuery returns the $select and $expand query parameters of the user requests.
+func (c *Client) userQuery() url.Values {
+	q := url.Values{}
+	q.Set("$select", c.userFields)
+	if c.expand != "" {
+		q.Set("$expand", c.expand)
+	}
+	return q
+}
@@ -0,0 +1,98 @@
+package entra
+
+import "fmt"
+
+// Group represents a Microsoft Graph group resource.
+// reference: https://learn.microsoft.com/en-us/graph/api/resources/group
+type Group struct {
+	ID              string   `json:"id"`
+	DisplayName     string   `json:"displayName"`
+	Mail            string   `json:"mail,omitempty"`
+	MailNickname    string   `json:"mailNickname,omitempty"`
+	Description     string   `json:"description,omitempty"`
+	SecurityEnabled bool     `json:"securityEnabled,omitempty"`
+	Removed         *Removed `json:"@removed,omitempty"`
+}
+
+// User represents a Microsoft Graph user resource.
+// reference: https://learn.microsoft.com/en-us/graph/api/resources/user
+type User struct {
+	ID                string   `json:"id"`
+	UserPrincipalName string   `json:"userPrincipalName"`
+	Mail              string   `json:"mail,omitempty"`
+	DisplayName       string   `json:"displayName,omitempty"`
+	GivenName         string   `json:"givenName,omitempty"`
+	Surname           string   `json:"surname,omitempty"`
+	AccountEnabled    bool     `json:"accountEnabled"`
+	UserType          string   `json:"userType,omitempty"`
+	JobTitle          string   `json:"jobTitle,omitempty"`
+	Department        string   `json:"department,omitempty"`
+	CompanyName       string   `json:"companyName,omitempty"`
+	EmployeeID        string   `json:"employeeId,omitempty"`
+	PreferredLanguage string   `json:"preferredLanguage,omitempty"`
+	MobilePhone       string   `json:"mobilePhone,omitempty"`
+	BusinessPhones    []string `json:"businessPhones,omitempty"`
+	StreetAddress     string   `json:"streetAddress,omitempty"`
+	City              string   `json:"city,omitempty"`
+	State             string   `json:"state,omitempty"`
+	PostalCode        string   `json:"postalCode,omitempty"`
+	Country           string   `json:"country,omitempty"`
+	OfficeLocation    string   `json:"officeLocation,omitempty"`
+	Manager           *Manager `json:"manager,omitempty"`
+	Removed           *Removed `json:"@removed,omitempty"`
+}
+
+// Email returns the email address of the user, the mail attribute when it is defined
+// or the user principal name otherwise.
+func (u *User) Email() string {
+	if u.Mail != "" {
+		return u.Mail
+	}
+	return u.UserPrincipalName
+}
+
+// Manager represents the manager of a user, expanded in the user resource.
+type Manager struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"displayName,omitempty"`
+}
+
+// Removed is present in the delta query responses when a resource was deleted.
+// reference: https://learn.microsoft.com/en-us/graph/delta-query-overview
+type Removed struct {
+	Reason string `json:"reason"`
+}
+
+// UsersDelta is the result of a users delta query.
+type UsersDelta struct {
+	Users     []*User
+	DeltaLink string
+}
+
+// GroupsDelta is the result of a groups delta query.
+type GroupsDelta struct {
+	Groups    []*Group
+	DeltaLink string
+}
+
+// page is a page of a Microsoft Graph collection response.
+type page[T any] struct {
+	Value     []T    `json:"value"`
+	NextLink  string `json:"@odata.nextLink,omitempty"`
+	DeltaLink string `json:"@odata.deltaLink,omitempty"`
+}
+
+// GraphError is returned when the Microsoft Graph API responds with an error.
+// reference: https://learn.microsoft.com/en-us/graph/errors
+type GraphError struct {
+	StatusCode int    `json:"-"`
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+
+	// RetryAfter is the value of the Retry-After header returned with throttled responses.
+	RetryAfter string `json:"-"`
+}
+
+func (e *GraphError) Error() string {
+	return fmt.Sprintf("statusCode: %d, errCode: %s, errMsg: %s", e.StatusCode, e.Code, e.Message)
+}
@@ -0,0 +1,306 @@
+package entra
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/slashdevops/idp-scim-sync/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// newGraphServer returns an httptest Microsoft Graph stand-in serving the given handlers under /v1.0.
+func newGraphServer(t *testing.T, handlers map[string]http.HandlerFunc) (*httptest.Server, *Client) {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	for p, h := range handlers {
+		mux.HandleFunc(p, h)
+	}
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	c, err := NewClient(srv.Client(), WithBaseURL(srv.URL+"/v1.0"))
+	assert.NoError(t, err)
+
+	return srv, c
+}
+
+func writeJSON(t *testing.T, w http.ResponseWriter, v any) {
+	t.Helper()
+
+	w.Header().Set("Content-Type", "application/json")
+	assert.NoError(t, json.NewEncoder(w).Encode(v))
+}
+
+func TestNewClient(t *testing.T) {
+	t.Run("should return error when http client is nil", func(t *testing.T) {
+		got, err := NewClient(nil)
+		assert.ErrorIs(t, err, ErrHTTPClientNil)
+		assert.Nil(t, got)
+	})
+
+	t.Run("should return error when base url is bad formed", func(t *testing.T) {
+		got, err := NewClient(http.DefaultClient, WithBaseURL("https://%%graph"))
+		assert.Error(t, err)
+		assert.Nil(t, got)
+	})
+
+	t.Run("should return client with defaults", func(t *testing.T) {
+		got, err := NewClient(http.DefaultClient)
+		assert.NoError(t, err)
+		assert.Equal(t, DefaultBaseURL, got.baseURL)
+		assert.Contains(t, got.userFields, "businessPhones")
+		assert.Equal(t, "manager($select=id,displayName)", got.expand)
+	})
+
+	t.Run("should reduce the user fields with sync field set", func(t *testing.T) {
+		got, err := NewClient(http.DefaultClient, WithSyncFieldSet(model.NewSyncFieldSet([]string{"title"})))
+		assert.NoError(t, err)
+		assert.Equal(t, userFields+",jobTitle", got.userFields)
+		assert.Empty(t, got.expand)
+	})
+}
+
+func TestNewHTTPClient(t *testing.T) {
+	t.Run("should validate the configuration", func(t *testing.T) {
+		_, err := NewHTTPClient(context.Background(), ClientCredentialsConfig{})
+		assert.ErrorIs(t, err, ErrHTTPClientNil)
+
+		_, err = NewHTTPClient(context.Background(), ClientCredentialsConfig{Client: http.DefaultClient})
+		assert.ErrorIs(t, err, ErrTenantIDEmpty)
+
+		_, err = NewHTTPClient(context.Background(), ClientCredentialsConfig{Client: http.DefaultClient, TenantID: "t"})
+		assert.ErrorIs(t, err, ErrClientIDEmpty)
+
+		_, err = NewHTTPClient(context.Background(), ClientCredentialsConfig{Client: http.DefaultClient, TenantID: "t", ClientID: "c"})
+		assert.ErrorIs(t, err, ErrClientSecretEmpty)
+	})
+
+	t.Run("should authenticate the requests with the client credentials token", func(t *testing.T) {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/tenant-id/oauth2/v2.0/token", func(w http.ResponseWriter, r *http.Request) {
+			assert.NoError(t, r.ParseForm())
+			assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+			assert.Equal(t, "client-id", r.PostForm.Get("client_id"))
+			assert.Equal(t, "client-secret", r.PostForm.Get("client_secret"))
+			assert.Equal(t, DefaultScope, r.PostForm.Get("scope"))
+			writeJSON(t, w, map[string]any{"access_token": "token", "token_type": "Bearer", "expires_in": 3600})
+		})
+		mux.HandleFunc("/v1.0/users/u1", func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+			writeJSON(t, w, User{ID: "u1"})
+		})
+		srv := httptest.NewServer(mux)
+		defer srv.Close()
+
+		hc, err := NewHTTPClient(context.Background(), ClientCredentialsConfig{
+			Client:       srv.Client(),
+			TenantID:     "tenant-id",
+			ClientID:     "client-id",
+			ClientSecret: "client-secret",
+			AuthorityURL: srv.URL,
+		})
+		assert.NoError(t, err)
+
+		c, err := NewClient(hc, WithBaseURL(srv.URL+"/v1.0"))
+		assert.NoError(t, err)
+
+		u, err := c.GetUser(context.Background(), "u1")
+		assert.NoError(t, err)
+		assert.Equal(t, "u1", u.ID)
+	})
+}
+
+func TestListGroups(t *testing.T) {
+	t.Run("should follow the next link and dedupe groups across filters", func(t *testing.T) {
+		var srv *httptest.Server
+		srv, c := newGraphServer(t, map[string]http.HandlerFunc{
+			"/v1.0/groups": func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "eventual", r.Header.Get("ConsistencyLevel"))
+				assert.Equal(t, groupFields, r.URL.Query().Get("$select"))
+				assert.Equal(t, "true", r.URL.Query().Get("$count"))
+
+				switch {
+				case r.URL.Query().Get("$filter") == "startswith(displayName,'AWS')" && r.URL.Query().Get("$skiptoken") == "":
+					writeJSON(t, w, page[*Group]{
+						Value:    []*Group{{ID: "g1", DisplayName: "AWS-1"}},
+						NextLink: srv.URL + "/v1.0/groups?$filter=startswith(displayName,'AWS')&$count=true&$select=" + groupFields + "&$skiptoken=next",
+					})
+				case r.URL.Query().Get("$skiptoken") == "next":
+					writeJSON(t, w, page[*Group]{Value: []*Group{{ID: "g2", DisplayName: "AWS-2"}}})
+				default:
+					writeJSON(t, w, page[*Group]{Value: []*Group{{ID: "g2", DisplayName: "AWS-2"}, {ID: "g3", DisplayName: "Admins"}}})
+				}
+			},
+		})
+
+		got, err := c.ListGroups(context.Background(), []string{"startswith(displayName,'AWS')", "displayName eq 'Admins'"})
+		assert.NoError(t, err)
+		assert.Equal(t, []*Group{{ID: "g1", DisplayName: "AWS-1"}, {ID: "g2", DisplayName: "AWS-2"}, {ID: "g3", DisplayName: "Admins"}}, got)
+	})
+
+	t.Run("should return all the groups without filter", func(t *testing.T) {
+		_, c := newGraphServer(t, map[string]http.HandlerFunc{
+			"/v1.0/groups": func(w http.ResponseWriter, r *http.Request) {
+				assert.False(t, r.URL.Query().Has("$filter"))
+				assert.False(t, r.URL.Query().Has("$count"))
+				writeJSON(t, w, page[*Group]{Value: []*Group{{ID: "g1"}}})
+			},
+		})
+
+		got, err := c.ListGroups(context.Background(), nil)
+		assert.NoError(t, err)
+		assert.Len(t, got, 1)
+	})
+
+	t.Run("should return the graph error", func(t *testing.T) {
+		_, c := newGraphServer(t, map[string]http.HandlerFunc{
+			"/v1.0/groups": func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Retry-After", "10")
+				w.WriteHeader(http.StatusTooManyRequests)
+				writeJSON(t, w, map[string]any{"error": map[string]string{"code": "TooManyRequests", "message": "throttled"}})
+			},
+		})
+
+		got, err := c.ListGroups(context.Background(), nil)
+		assert.Nil(t, got)
+
+		var gErr *GraphError
+		assert.ErrorAs(t, err, &gErr)
+		assert.Equal(t, http.StatusTooManyRequests, gErr.StatusCode)
+		assert.Equal(t, "TooManyRequests", gErr.Code)
+		assert.Equal(t, "throttled", gErr.Message)
+		assert.Equal(t, "10", gErr.RetryAfter)
+	})
+}
+
+func TestListUsers(t *testing.T) {
+	_, c := newGraphServer(t, map[string]http.HandlerFunc{
+		"/v1.0/users": func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "accountEnabled eq true", r.URL.Query().Get("$filter"))
+			assert.Equal(t, "manager($select=id,displayName)", r.URL.Query().Get("$expand"))
+			writeJSON(t, w, page[*User]{Value: []*User{{ID: "u1"}, {ID: "u1"}, {ID: "u2"}}})
+		},
+	})
+
+	got, err := c.ListUsers(context.Background(), []string{"accountEnabled eq true"})
+	assert.NoError(t, err)
+	assert.Equal(t, []*User{{ID: "u1"}, {ID: "u2"}}, got)
+}
+
+func TestListGroupMembers(t *testing.T) {
+	t.Run("should return error when group id is empty", func(t *testing.T) {
+		c, err := NewClient(http.DefaultClient)
+		assert.NoError(t, err)
+
+		got, err := c.ListGroupMembers(context.Background(), "")
+		assert.ErrorIs(t, err, ErrGroupIDEmpty)
+		assert.Nil(t, got)
+	})
+
+	t.Run("should return only the enabled transitive members", func(t *testing.T) {
+		_, c := newGraphServer(t, map[string]http.HandlerFunc{
+			"/v1.0/groups/g1/transitiveMembers/microsoft.graph.user": func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, memberFields, r.URL.Query().Get("$select"))
+				writeJSON(t, w, page[*User]{Value: []*User{
+					{ID: "u1", UserPrincipalName: "u1@example.com", AccountEnabled: true},
+					{ID: "u2", UserPrincipalName: "u2@example.com", AccountEnabled: false},
+				}})
+			},
+		})
+
+		got, err := c.ListGroupMembers(context.Background(), "g1")
+		assert.NoError(t, err)
+		assert.Equal(t, []*User{{ID: "u1", UserPrincipalName: "u1@example.com", AccountEnabled: true}}, got)
+	})
+}
+
+func TestGetUser(t *testing.T) {
+	t.Run("should return error when user id is empty", func(t *testing.T) {
+		c, err := NewClient(http.DefaultClient)
+		assert.NoError(t, err)
+
+		got, err := c.GetUser(context.Background(), "")
+		assert.ErrorIs(t, err, ErrUserIDEmpty)
+		assert.Nil(t, got)
+	})
+
+	t.Run("should return the user with the manager", func(t *testing.T) {
+		_, c := newGraphServer(t, map[string]http.HandlerFunc{
+			"/v1.0/users/u1": func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, err := w.Write([]byte(`{"id":"u1","userPrincipalName":"u1@example.com","accountEnabled":true,"manager":{"id":"m1","displayName":"Manager"}}`))
+				assert.NoError(t, err)
+			},
+		})
+
+		got, err := c.GetUser(context.Background(), "u1")
+		assert.NoError(t, err)
+		assert.Equal(t, &User{ID: "u1", UserPrincipalName: "u1@example.com", AccountEnabled: true, Manager: &Manager{ID: "m1", DisplayName: "Manager"}}, got)
+		assert.Equal(t, "u1@example.com", got.Email())
+	})
+
+	t.Run("should return error when the user does not exist", func(t *testing.T) {
+		_, c := newGraphServer(t, map[string]http.HandlerFunc{
+			"/v1.0/users/u1": func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "not found", http.StatusNotFound)
+			},
+		})
+
+		got, err := c.GetUser(context.Background(), "u1")
+		assert.Nil(t, got)
+
+		var gErr *GraphError
+		assert.ErrorAs(t, err, &gErr)
+		assert.Equal(t, http.StatusNotFound, gErr.StatusCode)
+	})
+}
+
+func TestListUsersDelta(t *testing.T) {
+	var srv *httptest.Server
+	srv, c := newGraphServer(t, map[string]http.HandlerFunc{
+		"/v1.0/users/delta": func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.URL.Query().Get("$deltatoken") == "d1":
+				w.Header().Set("Content-Type", "application/json")
+				_, err := w.Write([]byte(`{"value":[{"id":"u2","@removed":{"reason":"changed"}}],"@odata.deltaLink":"` + srv.URL + `/v1.0/users/delta?$deltatoken=d2"}`))
+				assert.NoError(t, err)
+			case r.URL.Query().Get("$skiptoken") == "s1":
+				writeJSON(t, w, page[*User]{Value: []*User{{ID: "u2"}}, DeltaLink: srv.URL + "/v1.0/users/delta?$deltatoken=d1"})
+			default:
+				assert.True(t, strings.HasPrefix(r.URL.Query().Get("$select"), userFields))
+				writeJSON(t, w, page[*User]{Value: []*User{{ID: "u1"}}, NextLink: srv.URL + "/v1.0/users/delta?$skiptoken=s1"})
+			}
+		},
+	})
+
+	got, err := c.ListUsersDelta(context.Background(), "")
+	assert.NoError(t, err)
+	assert.Equal(t, []*User{{ID: "u1"}, {ID: "u2"}}, got.Users)
+	assert.Equal(t, srv.URL+"/v1.0/users/delta?$deltatoken=d1", got.DeltaLink)
+
+	got, err = c.ListUsersDelta(context.Background(), got.DeltaLink)
+	assert.NoError(t, err)
+	assert.Equal(t, []*User{{ID: "u2", Removed: &Removed{Reason: "changed"}}}, got.Users)
+	assert.Equal(t, srv.URL+"/v1.0/users/delta?$deltatoken=d2", got.DeltaLink)
+}
+
+func TestListGroupsDelta(t *testing.T) {
+	var srv *httptest.Server
+	srv, c := newGraphServer(t, map[string]http.HandlerFunc{
+		"/v1.0/groups/delta": func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, groupFields, r.URL.Query().Get("$select"))
+			writeJSON(t, w, page[*Group]{Value: []*Group{{ID: "g1"}}, DeltaLink: srv.URL + "/v1.0/groups/delta?$deltatoken=d1"})
+		},
+	})
+
+	got, err := c.ListGroupsDelta(context.Background(), "")
+	assert.NoError(t, err)
+	assert.Equal(t, []*Group{{ID: "g1"}}, got.Groups)
+	assert.Equal(t, srv.URL+"/v1.0/groups/delta?$deltatoken=d1", got.DeltaLink)
+}
@@ -0,0 +1,28 @@
+// Package file reads a declarative directory of groups, users and memberships from a
+// YAML, JSON or CSV document stored in a local file or in an S3 object.
+//
+// YAML document:
+//
+//	groups:
+//	  - name: AWS-Vendors
+//	    members:
+//	      - bob@vendor.example
+//	users:
+//	  - email: bob@vendor.example
+//	    givenName: Bob
+//	    familyName: Jones
+//
+// CSV document, a row per user and the group names separated by ";":
+//
+//	email,givenName,familyName,groups
+//	bob@vendor.example,Bob,Jones,AWS-Vendors;AWS-Readers
+//
+// Basic usage:
+//
+//	src, err := file.NewSource("s3://bucket/access/directory.yaml", file.WithS3Client(s3Client))
+//	if err != nil {
+//	    return err
+//	}
+//
+//	dir, err := src.Load(ctx)
+package file
@@ -0,0 +1,396 @@
+package file
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"gopkg.in/yaml.v3"
+)
+
+// Supported document formats.
+const (
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+)
+
+// s3Scheme is the prefix of the S3 object locations, s3://bucket/key.
+const s3Scheme = "s3://"
+
+var (
+	// ErrLocationEmpty is returned when the source location is empty.
+	ErrLocationEmpty = errors.New("file: location may not be empty")
+
+	// ErrS3ClientNil is returned when the source is an S3 object and the S3 client is nil.
+	ErrS3ClientNil = errors.New("file: s3 client may not be nil")
+
+	// ErrInvalidS3Location is returned when the S3 location is not s3://bucket/key.
+	ErrInvalidS3Location = errors.New("file: invalid s3 location")
+
+	// ErrUnsupportedFormat is returned when the document format is not supported.
+	ErrUnsupportedFormat = errors.New("file: unsupported format")
+
+	// ErrInvalidDirectory is returned when the document is not a valid directory.
+	ErrInvalidDirectory = errors.New("file: invalid directory")
+)
+
+// S3ClientAPI is the subset of the S3 client methods used to read the S3 objects.
+type S3ClientAPI interface {
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+}
+
+// Source reads the directory document from a local file or an S3 object.
+type Source struct {
+	location string
+	format   string
+	s3Client S3ClientAPI
+}
+
+// SourceOption is a function that configures a Source.
+type SourceOption func(*Source)
+
+// WithFormat configures the document format, by default it is inferred from the location extension.
+func WithFormat(format string) SourceOption {
+	return func(s *Source) {
+		if format != "" {
+			s.format = format
+		}
+	}
+}
+
+// WithS3Client configures the S3 client used to read the s3://bucket/key locations.
+func WithS3Client(client S3ClientAPI) SourceOption {
+	return func(s *Source) {
+		s.s3Client = client
+	}
+}
+
+// NewSource returns a new Source of the given location, a local path or s3://bucket/key.
+func NewSource(location string, opts ...SourceOption) (*Source, error) {
+	if location == "" {
+		return nil, ErrLocationEmpty
+	}
+
+	s := &Source{location: location}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.format == "" {
+		s.format = FormatFromPath(location)
+	}
+
+	if _, err := normalizeFormat(s.format); err != nil {
+		return nil, err
+	}
+
+	if strings.HasPrefix(location, s3Scheme) {
+		if s.s3Client == nil {
+			return nil, ErrS3ClientNil
+		}
+		if _, _, err := parseS3Location(location); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// Load reads and parses the directory document.
+func (s *Source) Load(ctx context.Context) (*Directory, error) {
+	var data []byte
+	var err error
+
+	if strings.HasPrefix(s.location, s3Scheme) {
+		data, err = s.readS3(ctx)
+	} else {
+		data, err = os.ReadFile(s.location)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("file: error reading %s: %w", s.location, err)
+	}
+
+	return Parse(bytes.NewReader(data), s.format)
+}
+
+// readS3 returns the content of the S3 object.
+func (s *Source) readS3(ctx context.Context) ([]byte, error) {
+	bucket, key, err := parseS3Location(s.location)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.s3Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return io.ReadAll(resp.Body)
+}
+
+// parseS3Location returns the bucket and key of a s3://bucket/key location.
+func parseS3Location(location string) (string, string, error) {
+	bucket, key, ok := strings.Cut(strings.TrimPrefix(location, s3Scheme), "/")
+	if !ok || bucket == "" || key == "" {
+		return "", "", fmt.Errorf("%w: %q", ErrInvalidS3Location, location)
+	}
+	return bucket, key, nil
+}
+
+// FormatFromPath returns the document format inferred from the path extension.
+func FormatFromPath(path string) string {
+	return strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+}
+
+// normalizeFormat returns the format constant of the given format name.
+func normalizeFormat(format string) (string, error) {
+	switch strings.ToLower(format) {
+	case "yaml", "yml":
+		return FormatYAML, nil
+	case "json":
+		return FormatJSON, nil
+	case "csv":
+		return FormatCSV, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+	}
+}
+
+// Parse decodes a directory document in the given format, yaml, json or csv, and validates it.
+//
+// The csv documents have a header row with the user attributes names, e.g. email,givenName,familyName,groups,
+// and a row per user. The groups column lists the names of the user's groups separated by ";".
+func Parse(r io.Reader, format string) (*Directory, error) {
+	f, err := normalizeFormat(format)
+	if err != nil {
+		return nil, err
+	}
+
+	var dir Directory
+
+	switch f {
+	case FormatYAML:
+		dec := yaml.NewDecoder(r)
+		dec.KnownFields(true)
+		if err := dec.Decode(&dir); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("file: error decoding yaml: %w", err)
+		}
+	case FormatJSON:
+		dec := json.NewDecoder(r)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&dir); err != nil {
+			return nil, fmt.Errorf("file: error decoding json: %w", err)
+		}
+	case FormatCSV:
+		users, err := parseCSV(r)
+		if err != nil {
+			return nil, err
+		}
+		dir.Users = users
+	}
+
+	if err := dir.normalize(); err != nil {
+		return nil, err
+	}
+
+	return &dir, nil
+}
+
+// csvColumns are the setters of the csv columns, the column names are the json attributes names.
+var csvColumns = map[string]func(u *User, v string) error{
+	"id":                func(u *User, v string) error { u.ID = v; return nil },
+	"username":          func(u *User, v string) error { u.UserName = v; return nil },
+	"email":             func(u *User, v string) error { u.Email = v; return nil },
+	"givenname":         func(u *User, v string) error { u.GivenName = v; return nil },
+	"familyname":        func(u *User, v string) error { u.FamilyName = v; return nil },
+	"middlename":        func(u *User, v string) error { u.MiddleName = v; return nil },
+	"displayname":       func(u *User, v string) error { u.DisplayName = v; return nil },
+	"nickname":          func(u *User, v string) error { u.NickName = v; return nil },
+	"profileurl":        func(u *User, v string) error { u.ProfileURL = v; return nil },
+	"title":             func(u *User, v string) error { u.Title = v; return nil },
+	"usertype":          func(u *User, v string) error { u.UserType = v; return nil },
+	"preferredlanguage": func(u *User, v string) error { u.PreferredLanguage = v; return nil },
+	"locale":            func(u *User, v string) error { u.Locale = v; return nil },
+	"timezone":          func(u *User, v string) error { u.Timezone = v; return nil },
+	"phonenumber":       func(u *User, v string) error { u.PhoneNumber = v; return nil },
+	"streetaddress":     func(u *User, v string) error { u.StreetAddress = v; return nil },
+	"locality":          func(u *User, v string) error { u.Locality = v; return nil },
+	"region":            func(u *User, v string) error { u.Region = v; return nil },
+	"postalcode":        func(u *User, v string) error { u.PostalCode = v; return nil },
+	"country":           func(u *User, v string) error { u.Country = v; return nil },
+	"employeenumber":    func(u *User, v string) error { u.EmployeeNumber = v; return nil },
+	"costcenter":        func(u *User, v string) error { u.CostCenter = v; return nil },
+	"organization":      func(u *User, v string) error { u.Organization = v; return nil },
+	"division":          func(u *User, v string) error { u.Division = v; return nil },
+	"department":        func(u *User, v string) error { u.Department = v; return nil },
+	"manager":           func(u *User, v string) error { u.Manager = v; return nil },
+	"active": func(u *User, v string) error {
+		if v == "" {
+			return nil
+		}
+		active, err := strconv.ParseBool(v)
+		if err != nil {
+			return err
+		}
+		u.Active = &active
+		return nil
+	},
+	"groups": func(u *User, v string) error {
+		for g := range strings.SplitSeq(v, ";") {
+			if g = strings.TrimSpace(g); g != "" {
+				u.Groups = append(u.Groups, g)
+			}
+		}
+		return nil
+	},
+}
+
+// parseCSV returns the users of a csv document.
+func parseCSV(r io.Reader) ([]*User, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("file: error decoding csv: %w", err)
+	}
+
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	header := records[0]
+	for _, col := range header {
+		if _, ok := csvColumns[strings.ToLower(strings.TrimSpace(col))]; !ok {
+			return nil, fmt.Errorf("%w: unknown csv column %q", ErrInvalidDirectory, col)
+		}
+	}
+
+	users := make([]*User, 0, len(records)-1)
+	for i, record := range records[1:] {
+		u := &User{}
+		for j, v := range record {
+			col := strings.ToLower(strings.TrimSpace(header[j]))
+			if err := csvColumns[col](u, strings.TrimSpace(v)); err != nil {
+				return nil, fmt.Errorf("%w: line %d, column %q: %w", ErrInvalidDirectory, i+2, header[j], err)
+			}
+		}
+		users = append(users, u)
+	}
+
+	return users, nil
+}
+
+// normalize fills the default ids and user names, merges the users groups into the groups members
+// and validates the directory.
+func (d *Directory) normalize() error {
+	problems := make([]string, 0)
+
+	groupsByName := make(map[string]*Group, len(d.Groups))
+	for i, g := range d.Groups {
+		g.Name = strings.TrimSpace(g.Name)
+		if g.Name == "" {
+			problems = append(problems, fmt.Sprintf("group %d: name is empty", i+1))
+			continue
+		}
+		if g.ID == "" {
+			g.ID = g.Name
+		}
+		if _, ok := groupsByName[g.Name]; ok {
+			problems = append(problems, fmt.Sprintf("group %q: duplicated name", g.Name))
+			continue
+		}
+		groupsByName[g.Name] = g
+	}
+
+	usersByRef := make(map[string]*User, len(d.Users)*2)
+	for i, u := range d.Users {
+		u.Email = strings.TrimSpace(u.Email)
+		if u.Email == "" {
+			problems = append(problems, fmt.Sprintf("user %d: email is empty", i+1))
+			continue
+		}
+		if u.GivenName == "" || u.FamilyName == "" {
+			problems = append(problems, fmt.Sprintf("user %q: givenName and familyName are required", u.Email))
+		}
+		if u.ID == "" {
+			u.ID = u.Email
+		}
+		if u.UserName == "" {
+			u.UserName = u.Email
+		}
+		if _, ok := usersByRef[strings.ToLower(u.Email)]; ok {
+			problems = append(problems, fmt.Sprintf("user %q: duplicated email", u.Email))
+			continue
+		}
+		usersByRef[strings.ToLower(u.Email)] = u
+		usersByRef[strings.ToLower(u.ID)] = u
+
+		for _, name := range u.Groups {
+			g, ok := groupsByName[name]
+			if !ok {
+				g = &Group{ID: name, Name: name}
+				groupsByName[name] = g
+				d.Groups = append(d.Groups, g)
+			}
+			if !containsFold(g.Members, u.ID) && !containsFold(g.Members, u.Email) {
+				g.Members = append(g.Members, u.ID)
+			}
+		}
+	}
+
+	for _, g := range d.Groups {
+		for _, m := range g.Members {
+			if _, ok := usersByRef[strings.ToLower(m)]; !ok {
+				problems = append(problems, fmt.Sprintf("group %q: member %q is not a user", g.Name, m))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidDirectory, strings.Join(problems, "; "))
+	}
+	d.users = usersByRef
+
+	return nil
+}
+
+// User returns the user with the given id or email.
+func (d *Directory) User(ref string) *User {
+	if d.users != nil {
+		return d.users[strings.ToLower(ref)]
+	}
+
+	for _, u := range d.Users {
+		if strings.EqualFold(u.ID, ref) || strings.EqualFold(u.Email, ref) {
+			return u
+		}
+	}
+	return nil
+}
+
+// containsFold returns true when the list contains the value, case insensitive.
+func containsFold(list []string, v string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, v) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package file
+
+// Directory is the declarative document of the groups, users and memberships.
+//
+// The memberships can be declared in the groups members, as user ids or emails,
+// in the users groups, as group names, or in both.
+type Directory struct {
+	Groups []*Group `json:"groups" yaml:"groups"`
+	Users  []*User  `json:"users" yaml:"users"`
+
+	// users indexes the users by lower case id and email
+	users map[string]*User
+}
+
+// Group is a group of the directory.
+type Group struct {
+	// ID is the identity provider id of the group, the name when empty.
+	ID    string `json:"id,omitempty" yaml:"id,omitempty"`
+	Name  string `json:"name" yaml:"name"`
+	Email string `json:"email,omitempty" yaml:"email,omitempty"`
+
+	// Members are the ids or emails of the users that are members of the group.
+	Members []string `json:"members,omitempty" yaml:"members,omitempty"`
+}
+
+// User is a user of the directory.
+type User struct {
+	// ID is the identity provider id of the user, the email when empty.
+	ID string `json:"id,omitempty" yaml:"id,omitempty"`
+
+	// UserName is the email when empty.
+	UserName          string `json:"userName,omitempty" yaml:"userName,omitempty"`
+	Email             string `json:"email" yaml:"email"`
+	GivenName         string `json:"givenName" yaml:"givenName"`
+	FamilyName        string `json:"familyName" yaml:"familyName"`
+	MiddleName        string `json:"middleName,omitempty" yaml:"middleName,omitempty"`
+	DisplayName       string `json:"displayName,omitempty" yaml:"displayName,omitempty"`
+	NickName          string `json:"nickName,omitempty" yaml:"nickName,omitempty"`
+	ProfileURL        string `json:"profileUrl,omitempty" yaml:"profileUrl,omitempty"`
+	Title             string `json:"title,omitempty" yaml:"title,omitempty"`
+	UserType          string `json:"userType,omitempty" yaml:"userType,omitempty"`
+	PreferredLanguage string `json:"preferredLanguage,omitempty" yaml:"preferredLanguage,omitempty"`
+	Locale            string `json:"locale,omitempty" yaml:"locale,omitempty"`
+	Timezone          string `json:"timezone,omitempty" yaml:"timezone,omitempty"`
+	PhoneNumber       string `json:"phoneNumber,omitempty" yaml:"phoneNumber,omitempty"`
+	StreetAddress     string `json:"streetAddress,omitempty" yaml:"streetAddress,omitempty"`
+	Locality          string `json:"locality,omitempty" yaml:"locality,omitempty"`
+	Region            string `json:"region,omitempty" yaml:"region,omitempty"`
+	PostalCode        string `json:"postalCode,omitempty" yaml:"postalCode,omitempty"`
+	Country           string `json:"country,omitempty" yaml:"country,omitempty"`
+	EmployeeNumber    string `json:"employeeNumber,omitempty" yaml:"employeeNumber,omitempty"`
+	CostCenter        string `json:"costCenter,omitempty" yaml:"costCenter,omitempty"`
+	Organization      string `json:"organization,omitempty" yaml:"organization,omitempty"`
+	Division          string `json:"division,omitempty" yaml:"division,omitempty"`
+	Department        string `json:"department,omitempty" yaml:"department,omitempty"`
+
+	// Manager is the id of the user's manager.
+	Manager string `json:"manager,omitempty" yaml:"manager,omitempty"`
+
+	// Active is true when not defined.
+	Active *bool `json:"active,omitempty" yaml:"active,omitempty"`
+
+	// Groups are the names of the groups the user is member of.
+	Groups []string `json:"groups,omitempty" yaml:"groups,omitempty"`
+}
+
+// IsActive returns true when the user is active, users are active unless defined otherwise.
+func (u *User) IsActive() bool {
+	return u.Active == nil || *u.Active
+}
@@ -0,0 +1,171 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeS3Client struct {
+	objects map[string]string
+}
+
+func (f *fakeS3Client) GetObject(_ context.Context, params *s3.GetObjectInput, _ ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	data, ok := f.objects[aws.ToString(params.Bucket)+"/"+aws.ToString(params.Key)]
+	if !ok {
+		return nil, errors.New("NoSuchKey")
+	}
+	return &s3.GetObjectOutput{Body: io.NopCloser(strings.NewReader(data))}, nil
+}
+
+func TestNewSource(t *testing.T) {
+	t.Run("should return error when location is empty", func(t *testing.T) {
+		got, err := NewSource("")
+		assert.ErrorIs(t, err, ErrLocationEmpty)
+		assert.Nil(t, got)
+	})
+
+	t.Run("should return error when format is not supported", func(t *testing.T) {
+		got, err := NewSource("directory.txt")
+		assert.ErrorIs(t, err, ErrUnsupportedFormat)
+		assert.Nil(t, got)
+	})
+
+	t.Run("should return error when s3 client is nil", func(t *testing.T) {
+		got, err := NewSource("s3://bucket/directory.yaml")
+		assert.ErrorIs(t, err, ErrS3ClientNil)
+		assert.Nil(t, got)
+	})
+
+	t.Run("should return error when s3 location is invalid", func(t *testing.T) {
+		got, err := NewSource("s3://bucket", WithS3Client(&fakeS3Client{}), WithFormat("yaml"))
+		assert.ErrorIs(t, err, ErrInvalidS3Location)
+		assert.Nil(t, got)
+	})
+
+	t.Run("should use the given format", func(t *testing.T) {
+		got, err := NewSource("directory", WithFormat("yml"))
+		assert.NoError(t, err)
+		assert.Equal(t, "yml", got.format)
+	})
+}
+
+func TestSourceLoad(t *testing.T) {
+	for _, name := range []string{"directory.yaml", "directory.json", "directory.csv"} {
+		t.Run("should load "+name, func(t *testing.T) {
+			src, err := NewSource(filepath.Join("testdata", name))
+			assert.NoError(t, err)
+
+			got, err := src.Load(context.Background())
+			assert.NoError(t, err)
+
+			assert.Len(t, got.Users, 3)
+			assert.Len(t, got.Groups, 2)
+
+			admins, vendors := got.Groups[0], got.Groups[1]
+			if admins.Name != "AWS-Admins" {
+				admins, vendors = vendors, admins
+			}
+			assert.Equal(t, "AWS-Admins", admins.Name)
+			assert.Equal(t, "AWS-Vendors", vendors.Name)
+			assert.Len(t, admins.Members, 1)
+			assert.Equal(t, []string{"bob@vendor.example", "carol@vendor.example"}, vendors.Members)
+
+			alice := got.User("ALICE@example.com")
+			assert.Equal(t, "alice", alice.ID)
+			assert.Equal(t, "alice@example.com", alice.UserName)
+			assert.Equal(t, "Engineer", alice.Title)
+			assert.Equal(t, "42", alice.EmployeeNumber)
+			assert.True(t, alice.IsActive())
+			assert.Same(t, alice, got.User(admins.Members[0]))
+
+			assert.False(t, got.User("carol@vendor.example").IsActive())
+		})
+	}
+
+	t.Run("should load the s3 object", func(t *testing.T) {
+		data, err := os.ReadFile(filepath.Join("testdata", "directory.yaml"))
+		assert.NoError(t, err)
+
+		src, err := NewSource("s3://bucket/access/directory.yaml", WithS3Client(&fakeS3Client{
+			objects: map[string]string{"bucket/access/directory.yaml": string(data)},
+		}))
+		assert.NoError(t, err)
+
+		got, err := src.Load(context.Background())
+		assert.NoError(t, err)
+		assert.Len(t, got.Users, 3)
+	})
+
+	t.Run("should return error when the s3 object doesn't exist", func(t *testing.T) {
+		src, err := NewSource("s3://bucket/missing.yaml", WithS3Client(&fakeS3Client{}))
+		assert.NoError(t, err)
+
+		got, err := src.Load(context.Background())
+		assert.Error(t, err)
+		assert.Nil(t, got)
+	})
+
+	t.Run("should return error when the file doesn't exist", func(t *testing.T) {
+		src, err := NewSource(filepath.Join(t.TempDir(), "missing.json"))
+		assert.NoError(t, err)
+
+		got, err := src.Load(context.Background())
+		assert.ErrorIs(t, err, os.ErrNotExist)
+		assert.Nil(t, got)
+	})
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		doc     string
+		wantErr error
+	}{
+		{name: "empty yaml document", format: "yaml", doc: ""},
+		{name: "unsupported format", format: "xml", doc: "", wantErr: ErrUnsupportedFormat},
+		{name: "unknown yaml field", format: "yaml", doc: "users:\n  - mail: a@example.com\n"},
+		{name: "unknown json field", format: "json", doc: `{"groups": [{"title": "AWS"}]}`},
+		{name: "unknown csv column", format: "csv", doc: "mail\na@example.com\n", wantErr: ErrInvalidDirectory},
+		{name: "invalid csv active", format: "csv", doc: "email,givenName,familyName,active\na@example.com,A,A,maybe\n", wantErr: ErrInvalidDirectory},
+		{name: "group without name", format: "yaml", doc: "groups:\n  - email: g@example.com\n", wantErr: ErrInvalidDirectory},
+		{name: "duplicated group", format: "yaml", doc: "groups:\n  - name: G\n  - name: G\n", wantErr: ErrInvalidDirectory},
+		{name: "user without email", format: "yaml", doc: "users:\n  - givenName: A\n    familyName: A\n", wantErr: ErrInvalidDirectory},
+		{name: "user without names", format: "yaml", doc: "users:\n  - email: a@example.com\n", wantErr: ErrInvalidDirectory},
+		{name: "duplicated user", format: "csv", doc: "email,givenName,familyName\na@example.com,A,A\nA@example.com,A,A\n", wantErr: ErrInvalidDirectory},
+		{name: "unknown member", format: "yaml", doc: "groups:\n  - name: G\n    members: [nobody@example.com]\n", wantErr: ErrInvalidDirectory},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(strings.NewReader(tt.doc), tt.format)
+
+			switch {
+			case tt.wantErr != nil:
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, got)
+			case strings.HasPrefix(tt.name, "unknown"):
+				assert.Error(t, err)
+				assert.Nil(t, got)
+			default:
+				assert.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+
+	t.Run("should report all the problems", func(t *testing.T) {
+		_, err := Parse(strings.NewReader("groups:\n  - name: G\n    members: [x]\nusers:\n  - email: a@example.com\n"), "yaml")
+		assert.ErrorContains(t, err, `user "a@example.com": givenName and familyName are required`)
+		assert.ErrorContains(t, err, `group "G": member "x" is not a user`)
+	})
+}
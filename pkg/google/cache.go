@@ -0,0 +1,227 @@
+package google
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"google.golang.org/api/googleapi"
+)
+
+// cacheVersion is the version of the cache format, the caches of other versions are discarded.
+const cacheVersion = 2
+
+// Cache keeps the Google resources with their ETags between syncs, the resources are requested with
+// If-None-Match and the kept ones are used when Google answers they are not modified.
+// Only the resources requested since the cache was loaded are saved, so the deleted ones are dropped.
+// A nil Cache doesn't keep anything.
+type Cache struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	used    map[string]cacheEntry
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// cacheEntry is a resource kept in the cache.
+type cacheEntry struct {
+	ETag string          `json:"etag"`
+	Data json.RawMessage `json:"data"`
+}
+
+// cacheFile is the persisted cache.
+type cacheFile struct {
+	Version int                   `json:"version"`
+	Entries map[string]cacheEntry `json:"entries"`
+}
+
+// cachedPage is a page of a list kept in the cache.
+type cachedPage[T any] struct {
+	Items         []T    `json:"items"`
+	NextPageToken string `json:"nextPageToken,omitempty"`
+}
+
+// CacheStats are the metrics of the cache.
+type CacheStats struct {
+	// Hits is the number of resources not modified, read from the cache.
+	Hits int64
+
+	// Misses is the number of resources downloaded.
+	Misses int64
+
+	// Entries is the number of resources kept.
+	Entries int
+}
+
+// NewCache returns an empty Cache.
+func NewCache() *Cache {
+	return &Cache{
+		entries: make(map[string]cacheEntry),
+		used:    make(map[string]cacheEntry),
+	}
+}
+
+// Load replaces the resources of the cache with the saved ones, a cache of another version is discarded.
+func (c *Cache) Load(data []byte) error {
+	if c == nil || len(data) == 0 {
+		return nil
+	}
+
+	var f cacheFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("google: error decoding cache: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[string]cacheEntry, len(f.Entries))
+	c.used = make(map[string]cacheEntry)
+	if f.Version == cacheVersion {
+		for k, e := range f.Entries {
+			c.entries[k] = e
+		}
+	}
+
+	return nil
+}
+
+// Save returns the resources requested since the cache was loaded to be loaded in the next sync.
+func (c *Cache) Save() ([]byte, error) {
+	if c == nil {
+		return nil, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, err := json.Marshal(cacheFile{Version: cacheVersion, Entries: c.used})
+	if err != nil {
+		return nil, fmt.Errorf("google: error encoding cache: %w", err)
+	}
+
+	return data, nil
+}
+
+// Stats returns the metrics of the cache.
+func (c *Cache) Stats() CacheStats {
+	if c == nil {
+		return CacheStats{}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return CacheStats{
+		Hits:    c.hits.Load(),
+		Misses:  c.misses.Load(),
+		Entries: len(c.used),
+	}
+}
+
+// etag returns the ETag of the resource kept with the key, empty when there is none.
+func (c *Cache) etag(key string) string {
+	if c == nil {
+		return ""
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.entries[key].ETag
+}
+
+// hit decodes the resource kept with the key into v and keeps it for the next sync.
+func (c *Cache) hit(key string, v any) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return fmt.Errorf("google: resource %s not cached", key)
+	}
+
+	if err := json.Unmarshal(e.Data, v); err != nil {
+		return fmt.Errorf("google: error decoding cached resource %s: %w", key, err)
+	}
+
+	c.used[key] = e
+	c.hits.Add(1)
+
+	return nil
+}
+
+// miss keeps the resource downloaded with its ETag, the resources without ETag are not kept.
+func (c *Cache) miss(key, etag string, v any) {
+	if c == nil {
+		return
+	}
+
+	c.misses.Add(1)
+
+	if etag == "" {
+		return
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e := cacheEntry{ETag: etag, Data: data}
+	c.entries[key] = e
+	c.used[key] = e
+}
+
+// cachedGet returns the resource of the key calling get with the ETag of the kept resource, if any,
+// the kept resource is returned when Google answers it is not modified. get returns the resource
+// and its ETag, empty when the resource can't be kept.
+func cachedGet[T any](c *Cache, key string, get func(etag string) (T, string, error)) (T, error) {
+	etag := c.etag(key)
+
+	v, newETag, err := get(etag)
+	if err != nil {
+		var apiErr *googleapi.Error
+		if etag != "" && errors.As(err, &apiErr) && googleapi.IsNotModified(apiErr) {
+			var cached T
+			if err := c.hit(key, &cached); err != nil {
+				return cached, err
+			}
+			return cached, nil
+		}
+		return v, err
+	}
+
+	c.miss(key, newETag, v)
+
+	return v, nil
+}
+
+// cachedPages returns the items of all the pages of a list from pageToken, every page is requested
+// calling get with its page token and the ETag of the kept page, and the kept page is used when
+// Google answers it is not modified. The pages are kept with the key and their page token.
+func cachedPages[T any](c *Cache, key, pageToken string, get func(pageToken, etag string) (cachedPage[T], string, error)) ([]T, error) {
+	var items []T
+
+	for {
+		page, err := cachedGet(c, key+"&pageToken="+pageToken, func(etag string) (cachedPage[T], string, error) {
+			return get(pageToken, etag)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, page.Items...)
+
+		if page.NextPageToken == "" {
+			return items, nil
+		}
+		pageToken = page.NextPageToken
+	}
+}
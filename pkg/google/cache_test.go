@@ -0,0 +1,201 @@
+package google
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	admin "google.golang.org/api/admin/directory/v1"
+	"google.golang.org/api/option"
+)
+
+func TestCache_Load(t *testing.T) {
+	t.Run("should keep the entries of the cache version", func(t *testing.T) {
+		c := NewCache()
+		assert.NoError(t, c.Load([]byte(`{"version": 2, "entries": {"users/a": {"etag": "e1", "data": {}}}}`)))
+		assert.Equal(t, "e1", c.etag("users/a"))
+	})
+
+	t.Run("should discard the entries of other versions", func(t *testing.T) {
+		c := NewCache()
+		assert.NoError(t, c.Load([]byte(`{"version": 1, "entries": {"users/a": {"etag": "e1", "data": {}}}}`)))
+		assert.Empty(t, c.etag("users/a"))
+	})
+
+	t.Run("should return error when the cache is not valid", func(t *testing.T) {
+		assert.Error(t, NewCache().Load([]byte(`not json`)))
+	})
+
+	t.Run("should do nothing when the cache is nil", func(t *testing.T) {
+		var c *Cache
+		assert.NoError(t, c.Load([]byte(`{}`)))
+
+		data, err := c.Save()
+		assert.NoError(t, err)
+		assert.Nil(t, data)
+		assert.Equal(t, CacheStats{}, c.Stats())
+	})
+}
+
+func newTestCachedDirectoryService(t *testing.T, cache *Cache, handler http.HandlerFunc) *DirectoryService {
+	t.Helper()
+
+	svr := httptest.NewServer(handler)
+	t.Cleanup(svr.Close)
+
+	svc, err := admin.NewService(context.TODO(), option.WithHTTPClient(svr.Client()), option.WithEndpoint(svr.URL), option.WithUserAgent("test"))
+	assert.NoError(t, err)
+
+	ds, err := NewDirectoryService(svc, WithCache(cache))
+	assert.NoError(t, err)
+
+	return ds
+}
+
+func TestDirectoryService_cache(t *testing.T) {
+	handler := func(t *testing.T, etag string, body any) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			writeJSON(t, w, body)
+		}
+	}
+
+	t.Run("should return the cached user when it is not modified", func(t *testing.T) {
+		user := &admin.User{Id: "u1", Etag: `"e1"`, PrimaryEmail: "user.1@example.com"}
+
+		cache := NewCache()
+		ds := newTestCachedDirectoryService(t, cache, handler(t, `"e1"`, user))
+
+		got, err := ds.GetUser(context.TODO(), "user.1@example.com")
+		assert.NoError(t, err)
+		assert.Equal(t, "u1", got.Id)
+
+		got, err = ds.GetUser(context.TODO(), "user.1@example.com")
+		assert.NoError(t, err)
+		assert.Equal(t, "u1", got.Id)
+		assert.Equal(t, "user.1@example.com", got.PrimaryEmail)
+		assert.Equal(t, CacheStats{Hits: 1, Misses: 1, Entries: 1}, cache.Stats())
+
+		// the next sync requests the user with the saved ETag
+		data, err := cache.Save()
+		assert.NoError(t, err)
+
+		next := NewCache()
+		assert.NoError(t, next.Load(data))
+		ds = newTestCachedDirectoryService(t, next, handler(t, `"e1"`, user))
+
+		got, err = ds.GetUser(context.TODO(), "user.1@example.com")
+		assert.NoError(t, err)
+		assert.Equal(t, "u1", got.Id)
+		assert.Equal(t, CacheStats{Hits: 1, Entries: 1}, next.Stats())
+	})
+
+	t.Run("should return the cached members when they are not modified", func(t *testing.T) {
+		members := &admin.Members{
+			Etag: `"m1"`,
+			Members: []*admin.Member{
+				{Id: "u1", Email: "user.1@example.com", Status: "ACTIVE", Type: "USER"},
+				{Id: "u2", Email: "user.2@example.com", Status: "SUSPENDED", Type: "USER"},
+			},
+		}
+
+		cache := NewCache()
+		ds := newTestCachedDirectoryService(t, cache, handler(t, `"m1"`, members))
+
+		want := []*admin.Member{{Id: "u1", Email: "user.1@example.com", Status: "ACTIVE", Type: "USER"}}
+
+		got, err := ds.ListGroupMembers(context.TODO(), "g1")
+		assert.NoError(t, err)
+		assert.Equal(t, want, got)
+
+		got, err = ds.ListGroupMembers(context.TODO(), "g1")
+		assert.NoError(t, err)
+		assert.Equal(t, want, got)
+		assert.Equal(t, CacheStats{Hits: 1, Misses: 1, Entries: 1}, cache.Stats())
+
+		// other options are cached apart
+		got, err = ds.ListGroupMembers(context.TODO(), "g1", WithIncludeInactiveMembers(true), WithRoles("MEMBER"))
+		assert.NoError(t, err)
+		assert.Len(t, got, 2)
+		assert.Equal(t, CacheStats{Hits: 1, Misses: 2, Entries: 2}, cache.Stats())
+	})
+
+	t.Run("should cache every page of the members", func(t *testing.T) {
+		pages := func(t *testing.T) http.HandlerFunc {
+			return func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Query().Get("pageToken") == "" {
+					if r.Header.Get("If-None-Match") == `"m1"` {
+						w.WriteHeader(http.StatusNotModified)
+						return
+					}
+					writeJSON(t, w, &admin.Members{Etag: `"m1"`, NextPageToken: "next", Members: []*admin.Member{{Id: "u1", Status: "ACTIVE"}}})
+					return
+				}
+				assert.Equal(t, "next", r.URL.Query().Get("pageToken"))
+				if r.Header.Get("If-None-Match") == `"m2"` {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				writeJSON(t, w, &admin.Members{Etag: `"m2"`, Members: []*admin.Member{{Id: "u2", Status: "ACTIVE"}}})
+			}
+		}
+
+		cache := NewCache()
+		ds := newTestCachedDirectoryService(t, cache, pages(t))
+
+		got, err := ds.ListGroupMembers(context.TODO(), "g1")
+		assert.NoError(t, err)
+		assert.Len(t, got, 2)
+		assert.Equal(t, CacheStats{Misses: 2, Entries: 2}, cache.Stats())
+
+		got, err = ds.ListGroupMembers(context.TODO(), "g1")
+		assert.NoError(t, err)
+		assert.Equal(t, []*admin.Member{{Id: "u1", Status: "ACTIVE"}, {Id: "u2", Status: "ACTIVE"}}, got)
+		assert.Equal(t, CacheStats{Hits: 2, Misses: 2, Entries: 2}, cache.Stats())
+	})
+
+	t.Run("should return the cached pages of all the users when they are not modified", func(t *testing.T) {
+		calls := 0
+		cache := NewCache()
+		ds := newTestCachedDirectoryService(t, cache, func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			assert.Equal(t, "/admin/directory/v1/users", r.URL.Path)
+			if r.URL.Query().Get("pageToken") == "" {
+				if r.Header.Get("If-None-Match") == `"p1"` {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				writeJSON(t, w, &admin.Users{Etag: `"p1"`, NextPageToken: "next", Users: []*admin.User{{Id: "u1", PrimaryEmail: "user.1@example.com"}}})
+				return
+			}
+			if r.Header.Get("If-None-Match") == `"p2"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			writeJSON(t, w, &admin.Users{Etag: `"p2"`, Users: []*admin.User{{Id: "u2", PrimaryEmail: "user.2@example.com"}}})
+		})
+
+		got, err := ds.ListUsers(context.TODO(), nil)
+		assert.NoError(t, err)
+		assert.Len(t, got, 2)
+
+		// the next sync requests the pages with the saved ETags
+		data, err := cache.Save()
+		assert.NoError(t, err)
+
+		next := NewCache()
+		assert.NoError(t, next.Load(data))
+		ds.cache = next
+
+		got, err = ds.ListUsers(context.TODO(), nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []*admin.User{{Id: "u1", PrimaryEmail: "user.1@example.com"}, {Id: "u2", PrimaryEmail: "user.2@example.com"}}, got)
+		assert.Equal(t, CacheStats{Hits: 2, Entries: 2}, next.Stats())
+		assert.Equal(t, 4, calls)
+	})
+}
@@ -0,0 +1,312 @@
+package google
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	admin "google.golang.org/api/admin/directory/v1"
+	"google.golang.org/api/cloudidentity/v1"
+	"google.golang.org/api/option"
+)
+
+const (
+	// CloudIdentityGroupsScope is the scope required to read the groups and their members from the Cloud Identity Groups API.
+	CloudIdentityGroupsScope = "https://www.googleapis.com/auth/cloud-identity.groups.readonly"
+
+	// DiscussionForumLabel is the label of the Google groups, every Google group has it.
+	DiscussionForumLabel = "cloudidentity.googleapis.com/groups.discussion_forum"
+
+	// SecurityLabel is the label of the security groups.
+	SecurityLabel = "cloudidentity.googleapis.com/groups.security"
+
+	// DynamicLabel is the label of the dynamic groups, groups which membership is defined by a query.
+	DynamicLabel = "cloudidentity.googleapis.com/groups.dynamic"
+
+	// Cloud Identity resource names prefixes
+	groupNamePrefix = "groups/"
+	userNamePrefix  = "users/"
+)
+
+// ErrCustomerIDNil is returned when the customer ID is nil.
+var ErrCustomerIDNil = fmt.Errorf("google: customer id is required")
+
+// CloudIdentityService reads the groups and their members from the Cloud Identity Groups API,
+// supporting the dynamic groups, the security groups and the transitive memberships,
+// the users are read from the Directory API.
+// The groups and the members are returned as the Directory API ones.
+// References:
+// - https://cloud.google.com/identity/docs/reference/rest/v1/groups
+type CloudIdentityService struct {
+	*DirectoryService
+
+	svc        *cloudidentity.Service
+	customerID string
+	labels     []string
+}
+
+// CloudIdentityServiceOption is a function that configures a CloudIdentityService.
+type CloudIdentityServiceOption func(*CloudIdentityService)
+
+// WithGroupLabels configures the CloudIdentityService to list only the groups with all the given labels,
+// e.g. SecurityLabel. The Google groups, with the DiscussionForumLabel, are listed when no label is given.
+func WithGroupLabels(labels []string) CloudIdentityServiceOption {
+	return func(cis *CloudIdentityService) {
+		for _, l := range labels {
+			if l = strings.TrimSpace(l); l != "" && !slices.Contains(cis.labels, l) {
+				cis.labels = append(cis.labels, l)
+			}
+		}
+	}
+}
+
+// NewCloudIdentityAPIService create a Cloud Identity API Service with an authorized client,
+// e.g. the client of NewService with the CloudIdentityGroupsScope.
+// References:
+// - https://pkg.go.dev/google.golang.org/api/cloudidentity/v1
+func NewCloudIdentityAPIService(ctx context.Context, client *http.Client, userAgent string) (*cloudidentity.Service, error) {
+	if client == nil {
+		return nil, ErrGoogleClientNil
+	}
+
+	if userAgent == "" {
+		return nil, ErrUserAgentNil
+	}
+
+	svc, err := cloudidentity.NewService(
+		ctx,
+		option.WithUserAgent(userAgent),
+		option.WithHTTPClient(client),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("google: %v", err)
+	}
+
+	return svc, nil
+}
+
+// NewCloudIdentityService create a Cloud Identity Groups API client reading the users with the given
+// Directory API client. The customerID is the Google Workspace customer id, e.g. C046psxkn.
+func NewCloudIdentityService(ds *DirectoryService, svc *cloudidentity.Service, customerID string, opts ...CloudIdentityServiceOption) (*CloudIdentityService, error) {
+	if ds == nil || svc == nil {
+		return nil, ErrGoogleClientNil
+	}
+
+	if customerID == "" {
+		return nil, ErrCustomerIDNil
+	}
+
+	cis := &CloudIdentityService{
+		DirectoryService: ds,
+		svc:              svc,
+		customerID:       customerID,
+	}
+
+	for _, opt := range opts {
+		opt(cis)
+	}
+
+	if len(cis.labels) == 0 {
+		cis.labels = []string{DiscussionForumLabel}
+	}
+
+	return cis, nil
+}
+
+// searchQuery returns the groups search query of the customer groups with the labels, and the given query when not empty,
+// e.g. "display_name.startsWith('AWS')".
+func (cis *CloudIdentityService) searchQuery(query string) string {
+	conditions := []string{fmt.Sprintf("parent == 'customers/%s'", cis.customerID)}
+	for _, l := range cis.labels {
+		conditions = append(conditions, fmt.Sprintf("'%s' in labels", l))
+	}
+
+	if query != "" {
+		conditions = append(conditions, "("+query+")")
+	}
+
+	return strings.Join(conditions, " && ")
+}
+
+// ListGroups list all the groups with the labels filtered by query, the queries are Cloud Identity
+// groups search query conditions, e.g. "display_name.startsWith('AWS')".
+// References:
+// - https://cloud.google.com/identity/docs/reference/rest/v1/groups/search
+func (cis *CloudIdentityService) ListGroups(ctx context.Context, query []string) ([]*admin.Group, error) {
+	queries := make([]string, 0, len(query))
+	for _, q := range query {
+		if q = strings.TrimSpace(q); q != "" {
+			queries = append(queries, q)
+		}
+	}
+	if len(queries) == 0 {
+		queries = append(queries, "")
+	}
+
+	g := make([]*admin.Group, 0, 50)
+	for _, q := range queries {
+		sq := cis.searchQuery(q)
+		slog.Debug("google: Searching cloud identity groups", "query", sq)
+
+		err := cis.svc.Groups.Search().Query(sq).Pages(ctx, func(groups *cloudidentity.SearchGroupsResponse) error {
+			for _, grp := range groups.Groups {
+				ag := toGroup(grp)
+				if !slices.ContainsFunc(g, func(e *admin.Group) bool { return e.Id == ag.Id }) {
+					g = append(g, ag)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("google: failed to search groups with query %q: %w", sq, err)
+		}
+	}
+
+	return g, nil
+}
+
+// GetGroup return a group given a group ID.
+func (cis *CloudIdentityService) GetGroup(ctx context.Context, groupID string) (*admin.Group, error) {
+	if groupID == "" {
+		return nil, ErrGroupIDNil
+	}
+
+	grp, err := cis.svc.Groups.Get(groupNamePrefix + groupID).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("google: error getting group %s: %v", groupID, err)
+	}
+
+	return toGroup(grp), nil
+}
+
+// ListGroupMembers return a list of members of the group, the transitive members when the derived
+// membership is included. The Cloud Identity Groups API doesn't return the status of the members,
+// they are returned as ACTIVE.
+// References:
+// - https://cloud.google.com/identity/docs/reference/rest/v1/groups.memberships/list
+// - https://cloud.google.com/identity/docs/reference/rest/v1/groups.memberships/searchTransitiveMemberships
+func (cis *CloudIdentityService) ListGroupMembers(ctx context.Context, groupID string, queries ...GetGroupMembersOption) ([]*admin.Member, error) {
+	if groupID == "" {
+		return nil, ErrGroupIDNil
+	}
+
+	qs := getGroupMembersOptions{}
+	for _, q := range queries {
+		q(&qs)
+	}
+
+	m := make([]*admin.Member, 0, 20)
+	add := func(name, email, memberType string, roles []string) {
+		if !hasRole(roles, qs.roles) {
+			return
+		}
+
+		switch memberType {
+		case "USER", "GROUP":
+			m = append(m, &admin.Member{Id: name, Email: email, Type: memberType, Status: "ACTIVE"})
+		default:
+			slog.Debug("google: member not included in group because its type is not supported", "email", email, "type", memberType, "groupID", groupID)
+		}
+	}
+
+	if qs.includeDerivedMembership {
+		err := cis.svc.Groups.Memberships.SearchTransitiveMemberships(groupNamePrefix+groupID).Pages(ctx, func(res *cloudidentity.SearchTransitiveMembershipsResponse) error {
+			for _, rel := range res.Memberships {
+				roles := make([]string, 0, len(rel.Roles))
+				for _, r := range rel.Roles {
+					roles = append(roles, r.Role)
+				}
+
+				name, memberType := memberName(rel.Member)
+				add(name, entityKeyID(rel.PreferredMemberKey), memberType, roles)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return m, nil
+	}
+
+	err := cis.svc.Groups.Memberships.List(groupNamePrefix+groupID).View("FULL").Pages(ctx, func(res *cloudidentity.ListMembershipsResponse) error {
+		for _, ms := range res.Memberships {
+			roles := make([]string, 0, len(ms.Roles))
+			for _, r := range ms.Roles {
+				roles = append(roles, r.Name)
+			}
+
+			// the membership name is groups/{group}/memberships/{member}
+			name := ms.Name[strings.LastIndex(ms.Name, "/")+1:]
+
+			var email string
+			if ms.PreferredMemberKey != nil {
+				email = ms.PreferredMemberKey.Id
+			}
+			add(name, email, ms.Type, roles)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// ListGroupMembersBatch retrieves members for multiple groups concurrently.
+// Returns a map where keys are group IDs and values are slices of members.
+func (cis *CloudIdentityService) ListGroupMembersBatch(ctx context.Context, groupIDs []string, queries ...GetGroupMembersOption) (map[string][]*admin.Member, error) {
+	return listGroupMembersBatch(ctx, groupIDs, cis.concurrency, func(ctx context.Context, groupID string) ([]*admin.Member, error) {
+		return cis.ListGroupMembers(ctx, groupID, queries...)
+	})
+}
+
+// toGroup returns the Cloud Identity group as a Directory API group.
+func toGroup(grp *cloudidentity.Group) *admin.Group {
+	ag := &admin.Group{
+		Id:   strings.TrimPrefix(grp.Name, groupNamePrefix),
+		Name: grp.DisplayName,
+	}
+	if grp.GroupKey != nil {
+		ag.Email = grp.GroupKey.Id
+	}
+
+	return ag
+}
+
+// memberName returns the id and the type of the member given its resource name, e.g. users/123.
+func memberName(name string) (string, string) {
+	switch {
+	case strings.HasPrefix(name, userNamePrefix):
+		return strings.TrimPrefix(name, userNamePrefix), "USER"
+	case strings.HasPrefix(name, groupNamePrefix):
+		return strings.TrimPrefix(name, groupNamePrefix), "GROUP"
+	default:
+		return name, "OTHER"
+	}
+}
+
+// entityKeyID returns the id, the email, of the first entity key.
+func entityKeyID(keys []*cloudidentity.EntityKey) string {
+	if len(keys) == 0 {
+		return ""
+	}
+
+	return keys[0].Id
+}
+
+// hasRole returns true when any of the roles is one of the wanted roles separated by comma,
+// or when no role is wanted.
+func hasRole(roles []string, wanted string) bool {
+	if wanted == "" {
+		return true
+	}
+
+	return slices.ContainsFunc(roles, func(r string) bool {
+		return slices.Contains(strings.Split(wanted, ","), r)
+	})
+}
@@ -0,0 +1,268 @@
+package google
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	admin "google.golang.org/api/admin/directory/v1"
+	"google.golang.org/api/cloudidentity/v1"
+	"google.golang.org/api/option"
+)
+
+func newTestCloudIdentityService(t *testing.T, handler http.HandlerFunc, opts ...CloudIdentityServiceOption) *CloudIdentityService {
+	t.Helper()
+
+	ctx := context.TODO()
+
+	svr := httptest.NewServer(handler)
+	t.Cleanup(svr.Close)
+
+	adminSvc, err := admin.NewService(ctx, option.WithHTTPClient(svr.Client()), option.WithEndpoint(svr.URL), option.WithUserAgent("test"))
+	assert.NoError(t, err)
+
+	ds, err := NewDirectoryService(adminSvc)
+	assert.NoError(t, err)
+
+	svc, err := cloudidentity.NewService(ctx, option.WithHTTPClient(svr.Client()), option.WithEndpoint(svr.URL), option.WithUserAgent("test"))
+	assert.NoError(t, err)
+
+	cis, err := NewCloudIdentityService(ds, svc, "C01234567", opts...)
+	assert.NoError(t, err)
+
+	return cis
+}
+
+func writeJSON(t *testing.T, w http.ResponseWriter, v any) {
+	t.Helper()
+
+	w.Header().Set("Content-Type", "application/json")
+	assert.NoError(t, json.NewEncoder(w).Encode(v))
+}
+
+func TestNewCloudIdentityAPIService(t *testing.T) {
+	got, err := NewCloudIdentityAPIService(context.TODO(), nil, "test")
+	assert.ErrorIs(t, err, ErrGoogleClientNil)
+	assert.Nil(t, got)
+
+	got, err = NewCloudIdentityAPIService(context.TODO(), http.DefaultClient, "")
+	assert.ErrorIs(t, err, ErrUserAgentNil)
+	assert.Nil(t, got)
+
+	got, err = NewCloudIdentityAPIService(context.TODO(), http.DefaultClient, "test")
+	assert.NoError(t, err)
+	assert.NotNil(t, got)
+}
+
+func TestNewCloudIdentityService(t *testing.T) {
+	ctx := context.TODO()
+
+	svc, err := cloudidentity.NewService(ctx, option.WithHTTPClient(http.DefaultClient), option.WithUserAgent("test"))
+	assert.NoError(t, err)
+
+	adminSvc, err := admin.NewService(ctx, option.WithHTTPClient(http.DefaultClient), option.WithUserAgent("test"))
+	assert.NoError(t, err)
+
+	ds, err := NewDirectoryService(adminSvc)
+	assert.NoError(t, err)
+
+	t.Run("should return error when the services are nil", func(t *testing.T) {
+		got, err := NewCloudIdentityService(nil, svc, "C01234567")
+		assert.ErrorIs(t, err, ErrGoogleClientNil)
+		assert.Nil(t, got)
+
+		got, err = NewCloudIdentityService(ds, nil, "C01234567")
+		assert.ErrorIs(t, err, ErrGoogleClientNil)
+		assert.Nil(t, got)
+	})
+
+	t.Run("should return error when the customer id is empty", func(t *testing.T) {
+		got, err := NewCloudIdentityService(ds, svc, "")
+		assert.ErrorIs(t, err, ErrCustomerIDNil)
+		assert.Nil(t, got)
+	})
+
+	t.Run("should list the Google groups by default", func(t *testing.T) {
+		got, err := NewCloudIdentityService(ds, svc, "C01234567")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{DiscussionForumLabel}, got.labels)
+	})
+
+	t.Run("should list the groups with the labels", func(t *testing.T) {
+		got, err := NewCloudIdentityService(ds, svc, "C01234567", WithGroupLabels([]string{DiscussionForumLabel, " " + SecurityLabel, DiscussionForumLabel, ""}))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{DiscussionForumLabel, SecurityLabel}, got.labels)
+	})
+}
+
+func TestCloudIdentityService_ListGroups(t *testing.T) {
+	t.Run("should search the groups of the customer with the labels", func(t *testing.T) {
+		var queries []string
+
+		cis := newTestCloudIdentityService(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/v1/groups:search", r.URL.Path)
+			queries = append(queries, r.URL.Query().Get("query"))
+
+			writeJSON(t, w, &cloudidentity.SearchGroupsResponse{
+				Groups: []*cloudidentity.Group{
+					{Name: "groups/g1", DisplayName: "AWS Admins", GroupKey: &cloudidentity.EntityKey{Id: "aws-admins@example.com"}},
+					{Name: "groups/g2", DisplayName: "AWS Developers", GroupKey: &cloudidentity.EntityKey{Id: "aws-developers@example.com"}},
+				},
+			})
+		}, WithGroupLabels([]string{SecurityLabel}))
+
+		got, err := cis.ListGroups(context.TODO(), []string{"display_name.startsWith('AWS')", "group_key.startsWith('aws')", " "})
+		assert.NoError(t, err)
+		assert.Equal(t, []*admin.Group{
+			{Id: "g1", Name: "AWS Admins", Email: "aws-admins@example.com"},
+			{Id: "g2", Name: "AWS Developers", Email: "aws-developers@example.com"},
+		}, got)
+		assert.Equal(t, []string{
+			"parent == 'customers/C01234567' && '" + SecurityLabel + "' in labels && (display_name.startsWith('AWS'))",
+			"parent == 'customers/C01234567' && '" + SecurityLabel + "' in labels && (group_key.startsWith('aws'))",
+		}, queries)
+	})
+
+	t.Run("should search all the groups without query", func(t *testing.T) {
+		var queries []string
+
+		cis := newTestCloudIdentityService(t, func(w http.ResponseWriter, r *http.Request) {
+			queries = append(queries, r.URL.Query().Get("query"))
+			writeJSON(t, w, &cloudidentity.SearchGroupsResponse{})
+		})
+
+		got, err := cis.ListGroups(context.TODO(), nil)
+		assert.NoError(t, err)
+		assert.Empty(t, got)
+		assert.Equal(t, []string{"parent == 'customers/C01234567' && '" + DiscussionForumLabel + "' in labels"}, queries)
+	})
+
+	t.Run("should return error when the search fails", func(t *testing.T) {
+		cis := newTestCloudIdentityService(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+		})
+
+		got, err := cis.ListGroups(context.TODO(), nil)
+		assert.Error(t, err)
+		assert.Nil(t, got)
+	})
+}
+
+func TestCloudIdentityService_GetGroup(t *testing.T) {
+	cis := newTestCloudIdentityService(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/groups/g1", r.URL.Path)
+		writeJSON(t, w, &cloudidentity.Group{Name: "groups/g1", DisplayName: "AWS Admins", GroupKey: &cloudidentity.EntityKey{Id: "aws-admins@example.com"}})
+	})
+
+	got, err := cis.GetGroup(context.TODO(), "g1")
+	assert.NoError(t, err)
+	assert.Equal(t, &admin.Group{Id: "g1", Name: "AWS Admins", Email: "aws-admins@example.com"}, got)
+
+	got, err = cis.GetGroup(context.TODO(), "")
+	assert.ErrorIs(t, err, ErrGroupIDNil)
+	assert.Nil(t, got)
+}
+
+func TestCloudIdentityService_ListGroupMembers(t *testing.T) {
+	t.Run("should list the direct members", func(t *testing.T) {
+		cis := newTestCloudIdentityService(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/v1/groups/g1/memberships", r.URL.Path)
+			assert.Equal(t, "FULL", r.URL.Query().Get("view"))
+
+			writeJSON(t, w, &cloudidentity.ListMembershipsResponse{
+				Memberships: []*cloudidentity.Membership{
+					{
+						Name:               "groups/g1/memberships/u1",
+						PreferredMemberKey: &cloudidentity.EntityKey{Id: "user.1@example.com"},
+						Roles:              []*cloudidentity.MembershipRole{{Name: "MEMBER"}, {Name: "OWNER"}},
+						Type:               "USER",
+					},
+					{
+						Name:               "groups/g1/memberships/g2",
+						PreferredMemberKey: &cloudidentity.EntityKey{Id: "group.2@example.com"},
+						Roles:              []*cloudidentity.MembershipRole{{Name: "MEMBER"}},
+						Type:               "GROUP",
+					},
+					{
+						Name:               "groups/g1/memberships/sa1",
+						PreferredMemberKey: &cloudidentity.EntityKey{Id: "sa@project.iam.gserviceaccount.com"},
+						Roles:              []*cloudidentity.MembershipRole{{Name: "MEMBER"}},
+						Type:               "SERVICE_ACCOUNT",
+					},
+				},
+			})
+		})
+
+		got, err := cis.ListGroupMembers(context.TODO(), "g1")
+		assert.NoError(t, err)
+		assert.Equal(t, []*admin.Member{
+			{Id: "u1", Email: "user.1@example.com", Type: "USER", Status: "ACTIVE"},
+			{Id: "g2", Email: "group.2@example.com", Type: "GROUP", Status: "ACTIVE"},
+		}, got)
+
+		got, err = cis.ListGroupMembers(context.TODO(), "g1", WithRoles("OWNER"))
+		assert.NoError(t, err)
+		assert.Equal(t, []*admin.Member{
+			{Id: "u1", Email: "user.1@example.com", Type: "USER", Status: "ACTIVE"},
+		}, got)
+	})
+
+	t.Run("should list the transitive members", func(t *testing.T) {
+		cis := newTestCloudIdentityService(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/v1/groups/g1/memberships:searchTransitiveMemberships", r.URL.Path)
+
+			writeJSON(t, w, &cloudidentity.SearchTransitiveMembershipsResponse{
+				Memberships: []*cloudidentity.MemberRelation{
+					{
+						Member:             "users/u1",
+						PreferredMemberKey: []*cloudidentity.EntityKey{{Id: "user.1@example.com"}},
+						Roles:              []*cloudidentity.TransitiveMembershipRole{{Role: "MEMBER"}},
+					},
+					{
+						Member:             "users/u3",
+						PreferredMemberKey: []*cloudidentity.EntityKey{{Id: "user.3@example.com"}},
+						Roles:              []*cloudidentity.TransitiveMembershipRole{{Role: "MEMBER"}},
+					},
+				},
+			})
+		})
+
+		got, err := cis.ListGroupMembers(context.TODO(), "g1", WithIncludeDerivedMembership(true))
+		assert.NoError(t, err)
+		assert.Equal(t, []*admin.Member{
+			{Id: "u1", Email: "user.1@example.com", Type: "USER", Status: "ACTIVE"},
+			{Id: "u3", Email: "user.3@example.com", Type: "USER", Status: "ACTIVE"},
+		}, got)
+	})
+
+	t.Run("should return error when the group id is empty", func(t *testing.T) {
+		cis := newTestCloudIdentityService(t, func(w http.ResponseWriter, r *http.Request) {})
+
+		got, err := cis.ListGroupMembers(context.TODO(), "")
+		assert.ErrorIs(t, err, ErrGroupIDNil)
+		assert.Nil(t, got)
+	})
+}
+
+func Test_memberName(t *testing.T) {
+	tests := []struct {
+		name     string
+		wantID   string
+		wantType string
+	}{
+		{name: "users/u1", wantID: "u1", wantType: "USER"},
+		{name: "groups/g1", wantID: "g1", wantType: "GROUP"},
+		{name: "devices/d1", wantID: "devices/d1", wantType: "OTHER"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, memberType := memberName(tt.name)
+			assert.Equal(t, tt.wantID, id)
+			assert.Equal(t, tt.wantType, memberType)
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package google
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/iamcredentials/v1"
+	"google.golang.org/api/option"
+)
+
+const (
+	// externalAccountType is the type of the workload identity federation credential configuration files.
+	externalAccountType = "external_account"
+
+	// cloudPlatformScope is the scope of the federated credentials, required to sign the delegation JWT.
+	cloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+
+	// googleTokenURL is the Google OAuth 2.0 token endpoint the signed delegation JWT is exchanged at.
+	googleTokenURL = "https://oauth2.googleapis.com/token"
+
+	// jwtBearerGrantType is the grant type of the JWT bearer token request, RFC 7523.
+	jwtBearerGrantType = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+
+	// delegationJWTLifetime is the lifetime of the signed delegation JWT, the maximum allowed by Google.
+	delegationJWTLifetime = time.Hour
+)
+
+// ErrServiceAccountEmailNil is returned when the service account of the external account credentials is unknown.
+var ErrServiceAccountEmailNil = fmt.Errorf("google: service account email is required with external account credentials")
+
+// credentialsFile is the part of the credentials files needed to select how to authenticate.
+type credentialsFile struct {
+	Type                           string `json:"type"`
+	ServiceAccountImpersonationURL string `json:"service_account_impersonation_url"`
+}
+
+// isExternalAccount returns true when the credentials are a workload identity federation
+// credential configuration, e.g. for AWS, instead of a service account key.
+func isExternalAccount(credentials []byte) bool {
+	var f credentialsFile
+	if err := json.Unmarshal(credentials, &f); err != nil {
+		return false
+	}
+
+	return f.Type == externalAccountType
+}
+
+// impersonatedServiceAccount returns the email of the service account impersonated by the
+// external account credentials, empty when they don't impersonate any.
+// e.g. https://iamcredentials.googleapis.com/v1/projects/-/serviceAccounts/sa@project.iam.gserviceaccount.com:generateAccessToken
+func impersonatedServiceAccount(credentials []byte) string {
+	var f credentialsFile
+	if err := json.Unmarshal(credentials, &f); err != nil {
+		return ""
+	}
+
+	_, sa, ok := strings.Cut(f.ServiceAccountImpersonationURL, "/serviceAccounts/")
+	if !ok {
+		return ""
+	}
+
+	sa, _, _ = strings.Cut(sa, ":")
+
+	return sa
+}
+
+// delegatedTokenSource is a token source of the domain-wide delegation of a service account
+// without key. The delegation JWT is signed by the IAM Credentials API with the federated
+// credentials and exchanged for an access token of the subject.
+// References:
+// - https://cloud.google.com/iam/docs/workload-identity-federation-with-other-clouds
+// - https://cloud.google.com/iam/docs/reference/credentials/rest/v1/projects.serviceAccounts/signJwt
+// - https://developers.google.com/identity/protocols/oauth2/service-account#httprest
+type delegatedTokenSource struct {
+	ctx            context.Context
+	iam            *iamcredentials.Service
+	client         *http.Client
+	tokenURL       string
+	serviceAccount string
+	subject        string
+	scopes         []string
+}
+
+// newDelegatedTokenSource returns a token source of the domain-wide delegation of the service account
+// to the subject authenticated with the external account credentials.
+// The requests are sent with the base client.
+func newDelegatedTokenSource(ctx context.Context, base *http.Client, credentials []byte, serviceAccount, subject string, scopes []string) (oauth2.TokenSource, error) {
+	if serviceAccount == "" {
+		serviceAccount = impersonatedServiceAccount(credentials)
+	}
+
+	if serviceAccount == "" {
+		return nil, ErrServiceAccountEmailNil
+	}
+
+	creds, err := google.CredentialsFromJSON(ctx, credentials, cloudPlatformScope)
+	if err != nil {
+		return nil, fmt.Errorf("google: %v", err)
+	}
+
+	iamClient := &http.Client{
+		Transport: &oauth2.Transport{
+			Source: creds.TokenSource,
+			Base:   base.Transport,
+		},
+		Timeout: base.Timeout,
+	}
+
+	iam, err := iamcredentials.NewService(ctx, option.WithHTTPClient(iamClient))
+	if err != nil {
+		return nil, fmt.Errorf("google: %v", err)
+	}
+
+	ts := &delegatedTokenSource{
+		ctx:            ctx,
+		iam:            iam,
+		client:         &http.Client{Transport: base.Transport, Timeout: base.Timeout},
+		tokenURL:       googleTokenURL,
+		serviceAccount: serviceAccount,
+		subject:        subject,
+		scopes:         scopes,
+	}
+
+	return oauth2.ReuseTokenSource(nil, ts), nil
+}
+
+// Token returns an access token of the subject.
+func (ts *delegatedTokenSource) Token() (*oauth2.Token, error) {
+	now := time.Now()
+
+	claims, err := json.Marshal(map[string]any{
+		"iss":   ts.serviceAccount,
+		"sub":   ts.subject,
+		"scope": strings.Join(ts.scopes, " "),
+		"aud":   ts.tokenURL,
+		"iat":   now.Unix(),
+		"exp":   now.Add(delegationJWTLifetime).Unix(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("google: error marshalling delegation claims: %w", err)
+	}
+
+	name := "projects/-/serviceAccounts/" + ts.serviceAccount
+	signed, err := ts.iam.Projects.ServiceAccounts.SignJwt(name, &iamcredentials.SignJwtRequest{Payload: string(claims)}).Context(ts.ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("google: error signing delegation jwt with service account %s: %w", ts.serviceAccount, err)
+	}
+
+	form := url.Values{
+		"grant_type": {jwtBearerGrantType},
+		"assertion":  {signed.SignedJwt},
+	}
+
+	req, err := http.NewRequestWithContext(ts.ctx, http.MethodPost, ts.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("google: error creating token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := ts.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("google: error requesting delegated token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var tr struct {
+		AccessToken      string `json:"access_token"`
+		TokenType        string `json:"token_type"`
+		ExpiresIn        int64  `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tr); err != nil {
+		return nil, fmt.Errorf("google: error decoding delegated token response, status %d: %w", resp.StatusCode, err)
+	}
+
+	if resp.StatusCode != http.StatusOK || tr.AccessToken == "" {
+		return nil, fmt.Errorf("google: error requesting delegated token for %s, status %d: %s %s", ts.subject, resp.StatusCode, tr.Error, tr.ErrorDescription)
+	}
+
+	return &oauth2.Token{
+		AccessToken: tr.AccessToken,
+		TokenType:   tr.TokenType,
+		Expiry:      now.Add(time.Duration(tr.ExpiresIn) * time.Second),
+	}, nil
+}
@@ -0,0 +1,143 @@
+package google
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/iamcredentials/v1"
+	"google.golang.org/api/option"
+)
+
+func TestNewService_externalAccount(t *testing.T) {
+	ctx := context.TODO()
+
+	externalAccount, err := os.ReadFile("testdata/external_account.json")
+	if err != nil {
+		t.Fatalf("Error loading golden file: %s", err)
+	}
+
+	t.Run("should return a new Service with the impersonated service account", func(t *testing.T) {
+		svc, err := NewService(ctx, DirectoryServiceConfig{
+			Client:         &http.Client{},
+			UserEmail:      "admin@example.com",
+			ServiceAccount: externalAccount,
+			Scopes:         []string{"https://www.googleapis.com/auth/admin.directory.user.readonly"},
+			UserAgent:      "test-agent",
+		})
+		assert.NoError(t, err)
+		assert.NotNil(t, svc)
+	})
+
+	t.Run("should return error when the service account is unknown", func(t *testing.T) {
+		var creds map[string]any
+		assert.NoError(t, json.Unmarshal(externalAccount, &creds))
+		delete(creds, "service_account_impersonation_url")
+
+		withoutImpersonation, err := json.Marshal(creds)
+		assert.NoError(t, err)
+
+		svc, err := NewService(ctx, DirectoryServiceConfig{
+			Client:         &http.Client{},
+			UserEmail:      "admin@example.com",
+			ServiceAccount: withoutImpersonation,
+			Scopes:         []string{"https://www.googleapis.com/auth/admin.directory.user.readonly"},
+			UserAgent:      "test-agent",
+		})
+		assert.ErrorIs(t, err, ErrServiceAccountEmailNil)
+		assert.Nil(t, svc)
+
+		svc, err = NewService(ctx, DirectoryServiceConfig{
+			Client:              &http.Client{},
+			UserEmail:           "admin@example.com",
+			ServiceAccount:      withoutImpersonation,
+			ServiceAccountEmail: "mock-sa@mock-project.iam.gserviceaccount.com",
+			Scopes:              []string{"https://www.googleapis.com/auth/admin.directory.user.readonly"},
+			UserAgent:           "test-agent",
+		})
+		assert.NoError(t, err)
+		assert.NotNil(t, svc)
+	})
+}
+
+func Test_isExternalAccount(t *testing.T) {
+	serviceAccount, err := os.ReadFile("testdata/service_account.json")
+	assert.NoError(t, err)
+	assert.False(t, isExternalAccount(serviceAccount))
+
+	externalAccount, err := os.ReadFile("testdata/external_account.json")
+	assert.NoError(t, err)
+	assert.True(t, isExternalAccount(externalAccount))
+	assert.Equal(t, "mock-sa@mock-project.iam.gserviceaccount.com", impersonatedServiceAccount(externalAccount))
+
+	assert.False(t, isExternalAccount([]byte("not json")))
+	assert.Empty(t, impersonatedServiceAccount([]byte(`{"type": "external_account"}`)))
+}
+
+func TestDelegatedTokenSource_Token(t *testing.T) {
+	ctx := context.TODO()
+
+	newTokenSource := func(t *testing.T, tokenStatus int, tokenResponse string) *delegatedTokenSource {
+		t.Helper()
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("/v1/projects/-/serviceAccounts/mock-sa@mock-project.iam.gserviceaccount.com:signJwt", func(w http.ResponseWriter, r *http.Request) {
+			var req iamcredentials.SignJwtRequest
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+			var claims map[string]any
+			assert.NoError(t, json.Unmarshal([]byte(req.Payload), &claims))
+			assert.Equal(t, "mock-sa@mock-project.iam.gserviceaccount.com", claims["iss"])
+			assert.Equal(t, "admin@example.com", claims["sub"])
+			assert.Equal(t, "scope.1 scope.2", claims["scope"])
+
+			writeJSON(t, w, &iamcredentials.SignJwtResponse{SignedJwt: "signed.jwt"})
+		})
+		mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+			assert.NoError(t, r.ParseForm())
+			assert.Equal(t, jwtBearerGrantType, r.PostForm.Get("grant_type"))
+			assert.Equal(t, "signed.jwt", r.PostForm.Get("assertion"))
+
+			w.WriteHeader(tokenStatus)
+			_, _ = w.Write([]byte(tokenResponse))
+		})
+
+		svr := httptest.NewServer(mux)
+		t.Cleanup(svr.Close)
+
+		iam, err := iamcredentials.NewService(ctx, option.WithHTTPClient(svr.Client()), option.WithEndpoint(svr.URL))
+		assert.NoError(t, err)
+
+		return &delegatedTokenSource{
+			ctx:            ctx,
+			iam:            iam,
+			client:         svr.Client(),
+			tokenURL:       svr.URL + "/token",
+			serviceAccount: "mock-sa@mock-project.iam.gserviceaccount.com",
+			subject:        "admin@example.com",
+			scopes:         []string{"scope.1", "scope.2"},
+		}
+	}
+
+	t.Run("should return the token of the subject", func(t *testing.T) {
+		ts := newTokenSource(t, http.StatusOK, `{"access_token": "access-token", "token_type": "Bearer", "expires_in": 3599}`)
+
+		got, err := ts.Token()
+		assert.NoError(t, err)
+		assert.Equal(t, "access-token", got.AccessToken)
+		assert.Equal(t, "Bearer", got.TokenType)
+		assert.True(t, got.Valid())
+	})
+
+	t.Run("should return error when the delegation is not allowed", func(t *testing.T) {
+		ts := newTokenSource(t, http.StatusUnauthorized, `{"error": "unauthorized_client", "error_description": "Client is unauthorized to retrieve access tokens using this method"}`)
+
+		got, err := ts.Token()
+		assert.ErrorContains(t, err, "unauthorized_client")
+		assert.Nil(t, got)
+	})
+}
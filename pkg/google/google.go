@@ -2,15 +2,18 @@ package google
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"slices"
 	"strings"
 	"sync"
 
 	"github.com/slashdevops/idp-scim-sync/internal/model"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
+	"golang.org/x/sync/errgroup"
 	admin "google.golang.org/api/admin/directory/v1"
 	"google.golang.org/api/googleapi"
 	"google.golang.org/api/option"
@@ -21,13 +24,14 @@ const (
 	baseFields = "id,etag"
 
 	// Field definitions for specific object types
-	userFields   = baseFields + ",primaryEmail,name,suspended,kind,emails,addresses,organizations,phones,languages,locations"
+	userFields   = baseFields + ",primaryEmail,name,suspended,archived,orgUnitPath,kind,emails,addresses,organizations,phones,languages,locations"
 	groupFields  = baseFields + ",name,email"
 	memberFields = baseFields + ",email,status,type"
 
 	// Complete field specifications for API calls
 	groupsRequiredFields    googleapi.Field = "nextPageToken, groups(" + groupFields + ")"
 	membersRequiredFields   googleapi.Field = "nextPageToken, members(" + memberFields + ")"
+	membersCachedFields     googleapi.Field = "etag, " + membersRequiredFields
 	listUsersRequiredFields googleapi.Field = "nextPageToken, users(" + userFields + ")"
 	getUsersRequiredFields  googleapi.Field = userFields
 )
@@ -39,12 +43,18 @@ var (
 	// ErrUserIDNil is returned when the user ID is nil.
 	ErrUserIDNil = fmt.Errorf("google: user id is required")
 
+	// ErrUserNotFound is returned when the user doesn't exist in the directory, e.g. an external user.
+	ErrUserNotFound = fmt.Errorf("google: user not found")
+
 	// ErrUserEmailNil is returned when the user email is nil.
 	ErrUserEmailNil = fmt.Errorf("google: user email is required")
 
 	// ErrGroupIDNil is returned when the group ID is nil.
 	ErrGroupIDNil = fmt.Errorf("google: group id is required")
 
+	// ErrOrgUnitPathNil is returned when the organizational unit path is nil.
+	ErrOrgUnitPathNil = fmt.Errorf("google: org unit path is required")
+
 	// ErrServiceAccountNil is returned when the service account credentials are nil.
 	ErrServiceAccountNil = fmt.Errorf("google: service account credentials are required")
 
@@ -60,6 +70,15 @@ type DirectoryService struct {
 	svc                     *admin.Service
 	listUsersRequiredFields googleapi.Field
 	getUsersRequiredFields  googleapi.Field
+
+	// customFieldMask are the custom schemas of the users requested, separated by comma
+	customFieldMask string
+
+	// cache keeps the users and the group members with their ETags between syncs
+	cache *Cache
+
+	// concurrency is the maximum number of concurrent requests of the calls reading several resources
+	concurrency int
 }
 
 type DirectoryServiceConfig struct {
@@ -68,9 +87,15 @@ type DirectoryServiceConfig struct {
 	UserAgent      string
 	ServiceAccount []byte
 	Scopes         []string
+
+	// ServiceAccountEmail is the service account delegated with external account credentials, optional
+	// when the credentials impersonate it.
+	ServiceAccountEmail string
 }
 
 // NewService create a Google Directory Service.
+// The credentials are a service account key or a workload identity federation credential configuration,
+// e.g. for AWS, whose service account signs the domain-wide delegation with the IAM Credentials API.
 // References:
 // - https://pkg.go.dev/google.golang.org/api/admin/directory/v1
 // Examples of scope:
@@ -98,16 +123,27 @@ func NewService(ctx context.Context, config DirectoryServiceConfig) (*admin.Serv
 		return nil, ErrUserAgentNil
 	}
 
-	creds, err := google.CredentialsFromJSONWithParams(ctx, config.ServiceAccount, google.CredentialsParams{
-		Scopes:  config.Scopes,
-		Subject: config.UserEmail,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("google: %v", err)
+	var ts oauth2.TokenSource
+	if isExternalAccount(config.ServiceAccount) {
+		// keyless domain-wide delegation, the delegation is signed by the IAM Credentials API
+		dts, err := newDelegatedTokenSource(ctx, config.Client, config.ServiceAccount, config.ServiceAccountEmail, config.UserEmail, config.Scopes)
+		if err != nil {
+			return nil, err
+		}
+		ts = dts
+	} else {
+		creds, err := google.CredentialsFromJSONWithParams(ctx, config.ServiceAccount, google.CredentialsParams{
+			Scopes:  config.Scopes,
+			Subject: config.UserEmail,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("google: %v", err)
+		}
+		ts = creds.TokenSource
 	}
 
 	config.Client.Transport = &oauth2.Transport{
-		Source: creds.TokenSource,
+		Source: ts,
 		Base:   config.Client.Transport,
 	}
 
@@ -126,6 +162,23 @@ func NewService(ctx context.Context, config DirectoryServiceConfig) (*admin.Serv
 // DirectoryServiceOption is a function that configures a DirectoryService.
 type DirectoryServiceOption func(*DirectoryService)
 
+// WithCache configures the DirectoryService to request the users and the group members with the
+// ETags of the cache, the cached ones are used when they are not modified.
+func WithCache(cache *Cache) DirectoryServiceOption {
+	return func(ds *DirectoryService) {
+		ds.cache = cache
+	}
+}
+
+// WithConcurrency sets the maximum number of concurrent requests of the calls reading several resources,
+// e.g. the members of several groups, DefaultConcurrency by default. It should be the Concurrency of
+// the rate limiter of the client.
+func WithConcurrency(concurrency int) DirectoryServiceOption {
+	return func(ds *DirectoryService) {
+		ds.concurrency = concurrency
+	}
+}
+
 // WithSyncFieldSet configures the DirectoryService to only request fields
 // needed for the configured sync field set from the Google API.
 // When fields is nil or empty, all user fields are requested (default behavior).
@@ -137,10 +190,26 @@ func WithSyncFieldSet(fields *model.SyncFieldSet) DirectoryServiceOption {
 	}
 }
 
+// WithCustomSchemas configures the DirectoryService to request the given custom schemas of the users,
+// e.g. HR, using the custom projection. The custom schemas are returned in the customSchemas field of the users.
+// References:
+// - https://developers.google.com/admin-sdk/directory/v1/guides/manage-schemas
+func WithCustomSchemas(schemas []string) DirectoryServiceOption {
+	return func(ds *DirectoryService) {
+		mask := make([]string, 0, len(schemas))
+		for _, s := range schemas {
+			if s = strings.TrimSpace(s); s != "" && !slices.Contains(mask, s) {
+				mask = append(mask, s)
+			}
+		}
+		ds.customFieldMask = strings.Join(mask, ",")
+	}
+}
+
 // buildUserFields constructs the Google API fields parameter based on the configured field set.
 func buildUserFields(fields *model.SyncFieldSet) string {
 	// Always include required fields
-	parts := []string{baseFields, "primaryEmail", "name", "suspended", "kind", "emails"}
+	parts := []string{baseFields, "primaryEmail", "name", "suspended", "archived", "orgUnitPath", "kind", "emails"}
 
 	if fields.Includes(model.SyncUserFieldAddresses) {
 		parts = append(parts, "addresses")
@@ -180,9 +249,24 @@ func NewDirectoryService(svc *admin.Service, opts ...DirectoryServiceOption) (*D
 		opt(ds)
 	}
 
+	if ds.customFieldMask != "" {
+		ds.getUsersRequiredFields += ",customSchemas"
+		ds.listUsersRequiredFields = "nextPageToken, users(" + ds.getUsersRequiredFields + ")"
+	}
+
 	return ds, nil
 }
 
+// listUsers returns the call to list the users of the customer with the required fields and custom schemas.
+func (ds *DirectoryService) listUsers() *admin.UsersListCall {
+	call := ds.svc.Users.List().Customer("my_customer").Fields(ds.listUsersRequiredFields)
+	if ds.customFieldMask != "" {
+		call = call.Projection("custom").CustomFieldMask(ds.customFieldMask)
+	}
+
+	return call
+}
+
 // ListUsers list all users in a Google Directory filtered by query.
 func (ds *DirectoryService) ListUsers(ctx context.Context, query []string) ([]*admin.User, error) {
 	select {
@@ -198,37 +282,100 @@ func (ds *DirectoryService) ListUsers(ctx context.Context, query []string) ([]*a
 		for _, q := range query {
 			if q != "" {
 				slog.Debug("google: Listing users with query", "query", q)
-				err := ds.svc.Users.List().Query(q).Customer("my_customer").Fields(ds.listUsersRequiredFields).Pages(ctx, func(users *admin.Users) error {
-					slog.Debug("google: Retrieved users page", "page_size", len(users.Users))
-					u = append(u, users.Users...)
-					return nil
-				})
+				users, err := ds.listUserPages(ctx, q)
 				if err != nil {
 					return nil, fmt.Errorf("google: failed to list users with query %q: %w", q, err)
 				}
+				u = append(u, users...)
 			} else {
-				err := ds.svc.Users.List().Customer("my_customer").Fields(ds.listUsersRequiredFields).Pages(ctx, func(users *admin.Users) error {
-					u = append(u, users.Users...)
-					return nil
-				})
+				users, err := ds.listUserPages(ctx, "")
 				if err != nil {
 					return nil, fmt.Errorf("google: failed to list users: %w", err)
 				}
+				u = append(u, users...)
 			}
 		}
 	} else {
-		err := ds.svc.Users.List().Customer("my_customer").Fields(ds.listUsersRequiredFields).Pages(ctx, func(users *admin.Users) error {
+		users, err := ds.listUserPages(ctx, "")
+		if err != nil {
+			return nil, fmt.Errorf("google: failed to list users: %w", err)
+		}
+		u = append(u, users...)
+	}
+
+	return u, nil
+}
+
+// listUserPages returns all the users of the query, all the users of the customer when it is empty,
+// the pages of users not modified are read from the cache.
+func (ds *DirectoryService) listUserPages(ctx context.Context, query string) ([]*admin.User, error) {
+	call := ds.listUsers()
+	if query != "" {
+		call = call.Query(query)
+	}
+
+	if ds.cache == nil {
+		var u []*admin.User
+		err := call.Pages(ctx, func(users *admin.Users) error {
+			slog.Debug("google: Retrieved users page", "page_size", len(users.Users))
 			u = append(u, users.Users...)
 			return nil
 		})
+		return u, err
+	}
+
+	key := fmt.Sprintf("users?query=%s&fields=%s&custom=%s", query, ds.listUsersRequiredFields, ds.customFieldMask)
+	return cachedPages(ds.cache, key, "", func(pageToken, etag string) (cachedPage[*admin.User], string, error) {
+		users, err := call.Fields("etag, " + ds.listUsersRequiredFields).PageToken(pageToken).IfNoneMatch(etag).Context(ctx).Do()
 		if err != nil {
-			return nil, fmt.Errorf("google: failed to list users: %w", err)
+			return cachedPage[*admin.User]{}, "", err
 		}
+
+		slog.Debug("google: Retrieved users page", "page_size", len(users.Users))
+		return cachedPage[*admin.User]{Items: users.Users, NextPageToken: users.NextPageToken}, users.Etag, nil
+	})
+}
+
+// ListOrgUnitUsers list all users in the given organizational unit, e.g. /Contractors/AWS,
+// and in its children organizational units.
+// References:
+// - https://developers.google.com/admin-sdk/directory/v1/guides/search-users
+func (ds *DirectoryService) ListOrgUnitUsers(ctx context.Context, orgUnitPath string) ([]*admin.User, error) {
+	if orgUnitPath == "" {
+		return nil, ErrOrgUnitPathNil
+	}
+
+	q := fmt.Sprintf("orgUnitPath='%s'", strings.ReplaceAll(orgUnitPath, "'", `\'`))
+	slog.Debug("google: Listing org unit users", "query", q)
+
+	u := make([]*admin.User, 0, 50)
+	err := ds.listUsers().Query(q).Pages(ctx, func(users *admin.Users) error {
+		for _, usr := range users.Users {
+			// the query already matches the children org units, this protects the include-children
+			// semantics from the users of sibling org units sharing the same path prefix, e.g. /Eng and /Engineering
+			if inOrgUnit(usr.OrgUnitPath, orgUnitPath) {
+				u = append(u, usr)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("google: failed to list users of org unit %q: %w", orgUnitPath, err)
 	}
 
 	return u, nil
 }
 
+// inOrgUnit returns true when the user org unit path is the given org unit or one of its children.
+func inOrgUnit(userOrgUnitPath, orgUnitPath string) bool {
+	if orgUnitPath == "/" {
+		return true
+	}
+
+	parent := strings.TrimSuffix(orgUnitPath, "/")
+	return strings.EqualFold(userOrgUnitPath, parent) || strings.HasPrefix(strings.ToLower(userOrgUnitPath), strings.ToLower(parent)+"/")
+}
+
 // ListGroups list all groups in a Google Directory filtered by query.
 // References:
 // - https://developers.google.com/admin-sdk/directory/reference/rest/v1/groups
@@ -313,24 +460,44 @@ func (ds *DirectoryService) ListGroupMembers(ctx context.Context, groupID string
 		mlc = mlc.Roles(qs.roles)
 	}
 
-	err := mlc.Fields(membersRequiredFields).Pages(ctx, func(members *admin.Members) error {
-		for _, member := range members.Members {
-			// Add only active members to list
-			if member.Status == "ACTIVE" {
-				m = append(m, member)
-			} else {
-				slog.Warn("google: member not included in group because status is not ACTIVE", "email", member.Email, "status", member.Status, "groupID", groupID)
-			}
-		}
-		return nil
-	})
+	members, err := ds.listMembers(ctx, mlc, fmt.Sprintf("members/%s?derived=%t&max=%d&roles=%s", groupID, qs.includeDerivedMembership, qs.maxResults, qs.roles), qs.pageToken)
 	if err != nil {
 		return nil, err
 	}
 
+	for _, member := range members {
+		// Add only active members to list, unless the inactive members are requested
+		if member.Status == "ACTIVE" || qs.includeInactiveMembers {
+			m = append(m, member)
+		} else {
+			slog.Warn("google: member not included in group because status is not ACTIVE", "email", member.Email, "status", member.Status, "groupID", groupID)
+		}
+	}
+
 	return m, nil
 }
 
+// listMembers returns all the members of the list call from pageToken, the pages of members not
+// modified are read from the cache.
+func (ds *DirectoryService) listMembers(ctx context.Context, mlc *admin.MembersListCall, key, pageToken string) ([]*admin.Member, error) {
+	if ds.cache == nil {
+		m := make([]*admin.Member, 0, 20)
+		err := mlc.Fields(membersRequiredFields).Pages(ctx, func(members *admin.Members) error {
+			m = append(m, members.Members...)
+			return nil
+		})
+		return m, err
+	}
+
+	return cachedPages(ds.cache, key, pageToken, func(pageToken, etag string) (cachedPage[*admin.Member], string, error) {
+		members, err := mlc.Fields(membersCachedFields).PageToken(pageToken).IfNoneMatch(etag).Context(ctx).Do()
+		if err != nil {
+			return cachedPage[*admin.Member]{}, "", err
+		}
+		return cachedPage[*admin.Member]{Items: members.Members, NextPageToken: members.NextPageToken}, members.Etag, nil
+	})
+}
+
 // GetUser return a user given a user ID.
 // userID: the user's primary email address, alias email address, or unique user ID.
 func (ds *DirectoryService) GetUser(ctx context.Context, userID string) (*admin.User, error) {
@@ -338,8 +505,28 @@ func (ds *DirectoryService) GetUser(ctx context.Context, userID string) (*admin.
 		return nil, ErrUserIDNil
 	}
 
-	u, err := ds.svc.Users.Get(userID).Fields(ds.getUsersRequiredFields).Context(ctx).Do()
+	call := ds.svc.Users.Get(userID).Fields(ds.getUsersRequiredFields)
+	if ds.customFieldMask != "" {
+		call = call.Projection("custom").CustomFieldMask(ds.customFieldMask)
+	}
+
+	key := fmt.Sprintf("users/%s?fields=%s&custom=%s", strings.ToLower(userID), ds.getUsersRequiredFields, ds.customFieldMask)
+	u, err := cachedGet(ds.cache, key, func(etag string) (*admin.User, string, error) {
+		if etag != "" {
+			call = call.IfNoneMatch(etag)
+		}
+
+		u, err := call.Context(ctx).Do()
+		if err != nil {
+			return nil, "", err
+		}
+		return u, u.Etag, nil
+	})
 	if err != nil {
+		var apiErr *googleapi.Error
+		if errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound {
+			return nil, fmt.Errorf("%w: %s", ErrUserNotFound, userID)
+		}
 		return nil, fmt.Errorf("google: error getting user %s: %v", userID, err)
 	}
 
@@ -363,45 +550,47 @@ func (ds *DirectoryService) GetGroup(ctx context.Context, groupID string) (*admi
 // ListGroupMembersBatch retrieves members for multiple groups concurrently.
 // Returns a map where keys are group IDs and values are slices of members.
 func (ds *DirectoryService) ListGroupMembersBatch(ctx context.Context, groupIDs []string, queries ...GetGroupMembersOption) (map[string][]*admin.Member, error) {
+	return listGroupMembersBatch(ctx, groupIDs, ds.concurrency, func(ctx context.Context, groupID string) ([]*admin.Member, error) {
+		return ds.ListGroupMembers(ctx, groupID, queries...)
+	})
+}
+
+// listGroupMembersBatch returns the members of the groups by group id calling listMembers concurrently
+// with up to concurrency goroutines, DefaultConcurrency when it is not positive.
+func listGroupMembersBatch(ctx context.Context, groupIDs []string, concurrency int, listMembers func(ctx context.Context, groupID string) ([]*admin.Member, error)) (map[string][]*admin.Member, error) {
 	if len(groupIDs) == 0 {
 		return make(map[string][]*admin.Member), nil
 	}
 
 	result := make(map[string][]*admin.Member, len(groupIDs))
 
-	// Process groups concurrently with a reasonable limit
-	const maxConcurrent = 10
-	sem := make(chan struct{}, maxConcurrent)
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+
+	// Process groups concurrently, the requests are rate limited by the client,
+	// the first error cancels the other requests
 	var mu sync.Mutex
-	var wg sync.WaitGroup
 
-	errChan := make(chan error, len(groupIDs))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
 
 	for _, groupID := range groupIDs {
-		wg.Add(1)
-		go func(gid string) {
-			defer wg.Done()
-
-			sem <- struct{}{}        // Acquire semaphore
-			defer func() { <-sem }() // Release semaphore
-
-			members, err := ds.ListGroupMembers(ctx, gid, queries...)
+		g.Go(func() error {
+			members, err := listMembers(gctx, groupID)
 			if err != nil {
-				errChan <- fmt.Errorf("google: error getting members for group %s: %w", gid, err)
-				return
+				return fmt.Errorf("google: error getting members for group %s: %w", groupID, err)
 			}
 
 			mu.Lock()
-			result[gid] = members
+			result[groupID] = members
 			mu.Unlock()
-		}(groupID)
-	}
 
-	wg.Wait()
-	close(errChan)
+			return nil
+		})
+	}
 
-	// Check for errors
-	if err := <-errChan; err != nil {
+	if err := g.Wait(); err != nil {
 		return nil, err
 	}
 
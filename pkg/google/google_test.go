@@ -6,11 +6,14 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/slashdevops/idp-scim-sync/internal/model"
 	"github.com/stretchr/testify/assert"
 	admin "google.golang.org/api/admin/directory/v1"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/option"
 )
 
@@ -429,6 +432,59 @@ func TestNewDirectoryService_ListUsers(t *testing.T) {
 	})
 }
 
+func TestNewDirectoryService_ListOrgUnitUsers(t *testing.T) {
+	t.Run("should return the users of the org unit and its children", func(t *testing.T) {
+		ctx := context.TODO()
+
+		userList := &admin.Users{
+			Users: []*admin.User{
+				{Id: "1", PrimaryEmail: "user.1@mail.com", OrgUnitPath: "/Contractors"},
+				{Id: "2", PrimaryEmail: "user.2@mail.com", OrgUnitPath: "/Contractors/AWS"},
+				{Id: "3", PrimaryEmail: "user.3@mail.com", OrgUnitPath: "/ContractorsOld"},
+			},
+		}
+		jsonBytes, err := userList.MarshalJSON()
+		assert.NoError(t, err)
+
+		svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/admin/directory/v1/users", r.URL.Path)
+			assert.Equal(t, "orgUnitPath='/Contractors'", r.URL.Query().Get("query"))
+			assert.Contains(t, r.URL.Query().Get("fields"), "orgUnitPath")
+			_, _ = w.Write(jsonBytes)
+		}))
+		defer svr.Close()
+
+		svc, err := admin.NewService(ctx, option.WithHTTPClient(svr.Client()), option.WithEndpoint(svr.URL), option.WithUserAgent("test"))
+		assert.NoError(t, err)
+
+		client, err := NewDirectoryService(svc)
+		assert.NoError(t, err)
+
+		got, err := client.ListOrgUnitUsers(ctx, "/Contractors")
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(got))
+		assert.Equal(t, "1", got[0].Id)
+		assert.Equal(t, "2", got[1].Id)
+	})
+
+	t.Run("should return error when org unit path is empty", func(t *testing.T) {
+		client, err := NewDirectoryService(&admin.Service{})
+		assert.NoError(t, err)
+
+		got, err := client.ListOrgUnitUsers(context.TODO(), "")
+		assert.ErrorIs(t, err, ErrOrgUnitPathNil)
+		assert.Nil(t, got)
+	})
+}
+
+func Test_inOrgUnit(t *testing.T) {
+	assert.True(t, inOrgUnit("/Engineering", "/"))
+	assert.True(t, inOrgUnit("/Engineering", "/Engineering"))
+	assert.True(t, inOrgUnit("/Engineering/Platform", "/engineering/"))
+	assert.False(t, inOrgUnit("/EngineeringOld", "/Engineering"))
+	assert.False(t, inOrgUnit("/Sales", "/Engineering"))
+}
+
 func TestNewDirectoryService_ListGroups(t *testing.T) {
 	t.Run("should return a valid list of two groups with nil argument", func(t *testing.T) {
 		ctx := context.TODO()
@@ -841,6 +897,26 @@ func TestNewDirectoryService_GetUser(t *testing.T) {
 		assert.Equal(t, "user", got.Name.GivenName)
 		assert.False(t, got.Suspended)
 	})
+
+	t.Run("should return ErrUserNotFound when the user doesn't exist", func(t *testing.T) {
+		ctx := context.TODO()
+
+		svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error": {"code": 404, "message": "Resource Not Found: userKey"}}`))
+		}))
+		defer svr.Close()
+
+		svc, err := admin.NewService(ctx, option.WithHTTPClient(svr.Client()), option.WithEndpoint(svr.URL), option.WithUserAgent("test"))
+		assert.NoError(t, err)
+
+		client, err := NewDirectoryService(svc)
+		assert.NoError(t, err)
+
+		got, err := client.GetUser(ctx, "contractor@gmail.com")
+		assert.ErrorIs(t, err, ErrUserNotFound)
+		assert.Nil(t, got)
+	})
 }
 
 func TestNewDirectoryService_GetGroup(t *testing.T) {
@@ -1006,6 +1082,44 @@ func TestNewDirectoryService_ListGroupMembersBatch(t *testing.T) {
 		assert.Error(t, err)
 		assert.Nil(t, got)
 	})
+
+	t.Run("Should limit the concurrent requests to the concurrency", func(t *testing.T) {
+		ctx := context.TODO()
+
+		var (
+			mu          sync.Mutex
+			inFlight    int
+			maxInFlight int
+		)
+
+		svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			inFlight++
+			maxInFlight = max(maxInFlight, inFlight)
+			mu.Unlock()
+
+			time.Sleep(20 * time.Millisecond)
+
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"members": []}`))
+		}))
+		defer svr.Close()
+
+		svc, err := admin.NewService(ctx, option.WithHTTPClient(svr.Client()), option.WithEndpoint(svr.URL), option.WithUserAgent("test"))
+		assert.NoError(t, err)
+
+		client, err := NewDirectoryService(svc, WithConcurrency(2))
+		assert.NoError(t, err)
+
+		got, err := client.ListGroupMembersBatch(ctx, []string{"g1", "g2", "g3", "g4", "g5", "g6"})
+		assert.NoError(t, err)
+		assert.Len(t, got, 6)
+		assert.Equal(t, 2, maxInFlight)
+	})
 }
 
 func TestWithSyncFieldSet(t *testing.T) {
@@ -1040,3 +1154,50 @@ func TestWithSyncFieldSet(t *testing.T) {
 		assert.Contains(t, listFields, "organizations")
 	})
 }
+
+func TestWithCustomSchemas(t *testing.T) {
+	t.Run("should request the custom schemas with the custom projection", func(t *testing.T) {
+		ctx := context.TODO()
+
+		user := &admin.User{
+			Id:           "123456789",
+			PrimaryEmail: "user.1@mail.com",
+			Name:         &admin.UserName{FamilyName: "1", GivenName: "user"},
+			CustomSchemas: map[string]googleapi.RawMessage{
+				"HR": googleapi.RawMessage(`{"CostCenter": "1234"}`),
+			},
+		}
+
+		jsonBytes, err := user.MarshalJSON()
+		assert.NoError(t, err)
+
+		svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "custom", r.URL.Query().Get("projection"))
+			assert.Equal(t, "HR,AWS", r.URL.Query().Get("customFieldMask"))
+			assert.Contains(t, r.URL.Query().Get("fields"), "customSchemas")
+			_, _ = w.Write(jsonBytes)
+		}))
+		defer svr.Close()
+
+		svc, err := admin.NewService(ctx, option.WithHTTPClient(svr.Client()), option.WithEndpoint(svr.URL), option.WithUserAgent("test"))
+		assert.NoError(t, err)
+
+		client, err := NewDirectoryService(svc,
+			WithSyncFieldSet(model.NewSyncFieldSet([]string{"title"})),
+			WithCustomSchemas([]string{"HR", " AWS ", "HR", ""}),
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, "nextPageToken, users("+string(client.getUsersRequiredFields)+")", string(client.listUsersRequiredFields))
+
+		got, err := client.GetUser(ctx, "user.1@mail.com")
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"CostCenter": "1234"}`, string(got.CustomSchemas["HR"]))
+	})
+
+	t.Run("should not request custom schemas when no schema is given", func(t *testing.T) {
+		client, err := NewDirectoryService(&admin.Service{}, WithCustomSchemas(nil))
+		assert.NoError(t, err)
+		assert.NotContains(t, string(client.getUsersRequiredFields), "customSchemas")
+		assert.Empty(t, client.customFieldMask)
+	})
+}
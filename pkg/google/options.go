@@ -5,6 +5,7 @@ type getGroupMembersOptions struct {
 	roles                    string
 	maxResults               int64
 	includeDerivedMembership bool
+	includeInactiveMembers   bool
 }
 
 // GetGroupMembersOption is a function that can be used to configure the Google provider
@@ -19,6 +20,14 @@ func WithIncludeDerivedMembership(include bool) GetGroupMembersOption {
 	}
 }
 
+// WithIncludeInactiveMembers is a GetGroupMembersOption that can be used to include the suspended and archived members.
+// includeInactiveMembers Whether to list the members which status is not ACTIVE. Default: false.
+func WithIncludeInactiveMembers(include bool) GetGroupMembersOption {
+	return func(ggmo *getGroupMembersOptions) {
+		ggmo.includeInactiveMembers = include
+	}
+}
+
 // WithMaxResults is a GetGroupMembersOption that can be used to provide a filter for members by max results.
 // maxResults Maximum number of results to return. Max allowed value is 200.
 func WithMaxResults(maxResults int64) GetGroupMembersOption {
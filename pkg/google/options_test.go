@@ -105,3 +105,28 @@ func TestWithRoles(t *testing.T) {
 		}
 	})
 }
+
+func TestWithIncludeInactiveMembers(t *testing.T) {
+	t.Run("validate the return type", func(t *testing.T) {
+		var ggmo GetGroupMembersOption
+		got := WithIncludeInactiveMembers(true)
+
+		if reflect.TypeFor[GetGroupMembersOption]() != reflect.TypeFor[GetGroupMembersOption]() {
+			t.Errorf("WithIncludeInactiveMembers() return %T, different type than %T", got, ggmo)
+		}
+	})
+
+	t.Run("validate the return values", func(t *testing.T) {
+		opt := WithIncludeInactiveMembers(true)
+		got := getGroupMembersOptions{}
+		opt(&got)
+
+		want := getGroupMembersOptions{
+			includeInactiveMembers: true,
+		}
+
+		if !reflect.DeepEqual(got.includeInactiveMembers, want.includeInactiveMembers) {
+			t.Errorf("got = %v, want %v", got.includeInactiveMembers, want.includeInactiveMembers)
+		}
+	})
+}
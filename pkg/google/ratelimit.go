@@ -0,0 +1,257 @@
+package google
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// maxRateLimitRetries is the number of times a request is retried when Google rejects it by rate limit.
+	maxRateLimitRetries = 5
+
+	// rateLimitBaseDelay is the delay before the first retry of a request rejected by rate limit
+	// without Retry-After header, doubled on every retry.
+	rateLimitBaseDelay = time.Second
+
+	// rateLimitMaxDelay is the maximum delay before retrying a request rejected by rate limit.
+	rateLimitMaxDelay = time.Minute
+
+	// DefaultConcurrency is the maximum number of concurrent requests of the calls reading several
+	// resources without rate limit.
+	DefaultConcurrency = 10
+)
+
+// RateLimiter is a token bucket rate limiter shared by the requests to the Google APIs,
+// so the concurrent calls stay below the quota instead of spending time in backoff.
+// When Google rejects a request by rate limit all the requests are paused.
+// A nil RateLimiter doesn't limit the requests.
+type RateLimiter struct {
+	mu          sync.Mutex
+	qps         float64
+	burst       float64
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+}
+
+// NewRateLimiter returns a token bucket rate limiter of qps requests per second with bursts of
+// up to burst requests, nil when qps is not positive, a burst lower than 1 is 1.
+func NewRateLimiter(qps float64, burst int) *RateLimiter {
+	if qps <= 0 {
+		return nil
+	}
+
+	b := math.Max(float64(burst), 1)
+
+	return &RateLimiter{
+		qps:    qps,
+		burst:  b,
+		tokens: b,
+		last:   time.Now(),
+	}
+}
+
+// Concurrency returns the number of requests worth sending at the same time, the burst of the rate
+// limiter, as the requests beyond it only wait for a token. DefaultConcurrency without rate limit.
+func (l *RateLimiter) Concurrency() int {
+	if l == nil {
+		return DefaultConcurrency
+	}
+
+	return int(l.burst)
+}
+
+// Wait blocks until a request is allowed or the context is done.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	for {
+		d := l.reserve(time.Now())
+		if d == 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// reserve takes a token returning 0, or returns how long to wait for the next one.
+func (l *RateLimiter) reserve(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Before(l.pausedUntil) {
+		return l.pausedUntil.Sub(now)
+	}
+
+	if elapsed := now.Sub(l.last); elapsed > 0 {
+		l.tokens = math.Min(l.burst, l.tokens+elapsed.Seconds()*l.qps)
+		l.last = now
+	}
+
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+
+	return time.Duration((1 - l.tokens) / l.qps * float64(time.Second))
+}
+
+// Pause holds all the requests for d, e.g. the Retry-After of a request rejected by rate limit.
+func (l *RateLimiter) Pause(d time.Duration) {
+	if l == nil || d <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if until := time.Now().Add(d); until.After(l.pausedUntil) {
+		l.pausedUntil = until
+		l.last = until
+		l.tokens = 0
+	}
+}
+
+// Transport returns a http.RoundTripper sending the requests to base once the rate limiter allows
+// them, pausing the rate limiter and retrying the requests rejected by rate limit.
+// It must be the base transport of the retries, so the retried requests are limited too.
+func (l *RateLimiter) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	if l == nil {
+		return base
+	}
+
+	return &rateLimitTransport{limiter: l, base: base}
+}
+
+// rateLimitTransport is a http.RoundTripper waiting for the rate limiter before every request,
+// and pausing the rate limiter and retrying the requests rejected by rate limit.
+type rateLimitTransport struct {
+	limiter *RateLimiter
+	base    http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := t.limiter.Wait(req.Context()); err != nil {
+			return nil, err
+		}
+
+		// the retries send a clone with a new body, a RoundTripper must not modify the request
+		r := req
+		if attempt > 0 {
+			r = req.Clone(req.Context())
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				r.Body = body
+			}
+		}
+
+		resp, err := base.RoundTrip(r)
+		if err != nil || attempt == maxRateLimitRetries || !isRateLimited(resp) {
+			return resp, err
+		}
+
+		// requests with a body that can't be sent again aren't retried
+		if req.Body != nil && req.GetBody == nil {
+			return resp, nil
+		}
+
+		delay := retryAfter(resp.Header.Get("Retry-After"), attempt)
+		slog.Warn("google: request rejected by rate limit, retrying",
+			"status", resp.StatusCode,
+			"attempt", attempt+1,
+			"delay", delay,
+			"url", req.URL.Redacted(),
+		)
+
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		t.limiter.Pause(delay)
+	}
+}
+
+// isRateLimited returns true when the response is a rejection by rate limit, a 429 or a 403 with
+// a rate limit reason. The body of the 403 responses is restored to be read again.
+func isRateLimited(resp *http.Response) bool {
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusForbidden:
+	default:
+		return false
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+
+	var e struct {
+		Error struct {
+			Errors []struct {
+				Reason string `json:"reason"`
+			} `json:"errors"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &e); err != nil {
+		return false
+	}
+
+	for _, ee := range e.Error.Errors {
+		switch ee.Reason {
+		case "rateLimitExceeded", "userRateLimitExceeded":
+			return true
+		}
+	}
+
+	return false
+}
+
+// retryAfter returns the delay of the Retry-After header, in seconds or as a date, or an exponential
+// delay of the attempt when it is missing.
+func retryAfter(value string, attempt int) time.Duration {
+	var d time.Duration
+
+	if secs, err := strconv.Atoi(value); err == nil {
+		d = time.Duration(secs) * time.Second
+	} else if date, err := http.ParseTime(value); err == nil {
+		d = time.Until(date)
+	} else {
+		d = rateLimitBaseDelay << attempt
+	}
+
+	return min(max(d, 0), rateLimitMaxDelay)
+}
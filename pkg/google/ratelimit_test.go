@@ -0,0 +1,165 @@
+package google
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/slashdevops/httpx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRateLimiter(t *testing.T) {
+	assert.Nil(t, NewRateLimiter(0, 10))
+	assert.NoError(t, NewRateLimiter(0, 10).Wait(context.TODO()), "a nil rate limiter doesn't limit")
+	assert.Equal(t, http.DefaultTransport, NewRateLimiter(0, 10).Transport(nil), "a nil rate limiter doesn't limit")
+
+	l := NewRateLimiter(5, 0)
+	assert.Equal(t, float64(1), l.burst)
+}
+
+func TestRateLimiter_Concurrency(t *testing.T) {
+	assert.Equal(t, DefaultConcurrency, NewRateLimiter(0, 3).Concurrency(), "without rate limit")
+	assert.Equal(t, 3, NewRateLimiter(5, 3).Concurrency(), "the burst of the rate limiter")
+}
+
+func TestRateLimiter_reserve(t *testing.T) {
+	l := NewRateLimiter(10, 2)
+	now := l.last
+
+	assert.Zero(t, l.reserve(now))
+	assert.Zero(t, l.reserve(now))
+	assert.Equal(t, 100*time.Millisecond, l.reserve(now), "the burst is spent")
+	assert.Zero(t, l.reserve(now.Add(100*time.Millisecond)))
+
+	l.Pause(time.Minute)
+	assert.Greater(t, l.reserve(time.Now()), 59*time.Second, "the requests are paused")
+}
+
+func TestRateLimiter_Wait(t *testing.T) {
+	l := NewRateLimiter(1, 1)
+	assert.NoError(t, l.Wait(context.TODO()))
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, l.Wait(ctx), context.DeadlineExceeded)
+}
+
+func Test_rateLimitTransport(t *testing.T) {
+	t.Run("should retry the requests rejected by rate limit", func(t *testing.T) {
+		var calls atomic.Int32
+
+		svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) == 1 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusForbidden)
+				_, _ = w.Write([]byte(`{"error": {"code": 403, "errors": [{"reason": "userRateLimitExceeded"}]}}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{}`))
+		}))
+		defer svr.Close()
+
+		client := &http.Client{Transport: &rateLimitTransport{limiter: NewRateLimiter(100, 1), base: svr.Client().Transport}}
+
+		resp, err := client.Get(svr.URL)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("should limit the requests rejected by rate limit under the retries", func(t *testing.T) {
+		var calls atomic.Int32
+
+		svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) == 1 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			_, _ = w.Write([]byte(`{}`))
+		}))
+		defer svr.Close()
+
+		l := NewRateLimiter(1, 2)
+		client := httpx.NewHTTPRetryClient(
+			httpx.WithMaxRetriesRetry(3),
+			httpx.WithBaseTransport(l.Transport(svr.Client().Transport)),
+		)
+
+		resp, err := client.Get(svr.URL)
+		assert.NoError(t, err)
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, int32(2), calls.Load())
+		assert.Greater(t, l.reserve(l.last), time.Duration(0), "the retry waits for the rate limiter")
+	})
+
+	t.Run("should retry the requests with a new body without modifying them", func(t *testing.T) {
+		var bodies []string
+
+		svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			bodies = append(bodies, string(body))
+			if len(bodies) == 1 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			_, _ = w.Write([]byte(`{}`))
+		}))
+		defer svr.Close()
+
+		req, err := http.NewRequest(http.MethodPost, svr.URL, strings.NewReader(`{"name":"group"}`))
+		assert.NoError(t, err)
+		body := req.Body
+
+		transport := &rateLimitTransport{limiter: NewRateLimiter(100, 1), base: svr.Client().Transport}
+		resp, err := transport.RoundTrip(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, []string{`{"name":"group"}`, `{"name":"group"}`}, bodies)
+		assert.True(t, body == req.Body, "the request is not modified")
+	})
+
+	t.Run("should not retry the forbidden requests", func(t *testing.T) {
+		var calls atomic.Int32
+
+		svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"error": {"code": 403, "errors": [{"reason": "forbidden"}]}}`))
+		}))
+		defer svr.Close()
+
+		client := &http.Client{Transport: &rateLimitTransport{limiter: NewRateLimiter(100, 1), base: svr.Client().Transport}}
+
+		resp, err := client.Get(svr.URL)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		assert.True(t, strings.Contains(string(body), "forbidden"), "the body is restored")
+		assert.Equal(t, int32(1), calls.Load())
+	})
+}
+
+func Test_retryAfter(t *testing.T) {
+	assert.Equal(t, 3*time.Second, retryAfter("3", 0))
+	assert.Equal(t, rateLimitMaxDelay, retryAfter("3600", 0))
+	assert.Equal(t, time.Duration(0), retryAfter(time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0))
+	assert.Equal(t, 4*time.Second, retryAfter("", 2))
+}
@@ -0,0 +1,26 @@
+// Package ldap provides an LDAP client to read groups, users and nested group
+// memberships from an LDAP directory or Microsoft Active Directory.
+//
+// Both inetOrgPerson/groupOfNames directories (OpenLDAP, 389 DS, ...) and Active
+// Directory user/group objects are supported. Searches use the paged results
+// control, and connections can use LDAPS (ldaps://) or StartTLS.
+//
+// Basic usage:
+//
+//	dial, err := ldap.NewDialFunc(ldap.DialConfig{
+//	    URL:          "ldaps://ldap.example.com:636",
+//	    BindDN:       "cn=sync,ou=services,dc=example,dc=com",
+//	    BindPassword: password,
+//	})
+//	if err != nil {
+//	    return err
+//	}
+//
+//	client, err := ldap.NewClient(dial, "dc=example,dc=com", ldap.WithMembersStrategy(ldap.MembersStrategyInChain))
+//	if err != nil {
+//	    return err
+//	}
+//	defer client.Close()
+//
+//	groups, err := client.ListGroups(ctx, []string{"(cn=AWS-*)"})
+package ldap
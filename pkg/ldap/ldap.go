@@ -0,0 +1,541 @@
+package ldap
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	goldap "github.com/go-ldap/ldap/v3"
+)
+
+const (
+	// DefaultPageSize is the default number of entries requested per page with the paged results control.
+	DefaultPageSize = 500
+
+	// DefaultTimeout is the default timeout to establish the connection.
+	DefaultTimeout = 30 * time.Second
+
+	// matchingRuleInChain is the Active Directory LDAP_MATCHING_RULE_IN_CHAIN, it walks the
+	// chain of ancestry of the memberOf attribute.
+	// reference: https://learn.microsoft.com/en-us/windows/win32/adsi/search-filter-syntax
+	matchingRuleInChain = "1.2.840.113556.1.4.1941"
+)
+
+// Members strategies, they define how the nested group memberships are expanded.
+const (
+	// MembersStrategyMember reads the member/uniqueMember attributes of the groups and
+	// expands the nested groups recursively.
+	MembersStrategyMember = "member"
+
+	// MembersStrategyMemberOf searches the entries whose memberOf attribute references the groups
+	// and expands the nested groups recursively.
+	MembersStrategyMemberOf = "memberof"
+
+	// MembersStrategyInChain resolves all the nested members of a group with a single search
+	// using the Active Directory LDAP_MATCHING_RULE_IN_CHAIN.
+	MembersStrategyInChain = "in_chain"
+)
+
+var (
+	// ErrURLEmpty is returned when the url is empty.
+	ErrURLEmpty = errors.New("ldap: url may not be empty")
+
+	// ErrDialFuncNil is returned when the dial function is nil.
+	ErrDialFuncNil = errors.New("ldap: dial function may not be nil")
+
+	// ErrBaseDNEmpty is returned when the base dn is empty.
+	ErrBaseDNEmpty = errors.New("ldap: base dn may not be empty")
+
+	// ErrGroupDNEmpty is returned when the group dn is empty.
+	ErrGroupDNEmpty = errors.New("ldap: group dn may not be empty")
+
+	// ErrUserDNEmpty is returned when the user dn is empty.
+	ErrUserDNEmpty = errors.New("ldap: user dn may not be empty")
+
+	// ErrUserNotFound is returned when the user entry doesn't exist.
+	ErrUserNotFound = errors.New("ldap: user not found")
+
+	// ErrInvalidMembersStrategy is returned when the members strategy is not supported.
+	ErrInvalidMembersStrategy = errors.New("ldap: invalid members strategy")
+)
+
+// Conn is the subset of the LDAP connection methods used by the client.
+type Conn interface {
+	SearchWithPaging(searchRequest *goldap.SearchRequest, pagingSize uint32) (*goldap.SearchResult, error)
+	IsClosing() bool
+	Close() error
+}
+
+// DialFunc returns a new bound connection to the LDAP server.
+type DialFunc func(ctx context.Context) (Conn, error)
+
+// DialConfig is the configuration of the connections to the LDAP server.
+type DialConfig struct {
+	// URL of the server, ldap://host:389 or ldaps://host:636.
+	URL string
+
+	// BindDN and BindPassword are the credentials of the simple bind,
+	// the connection is not bound when BindDN is empty.
+	BindDN       string
+	BindPassword string
+
+	// StartTLS upgrades the ldap:// connections to TLS.
+	StartTLS bool
+
+	// TLSConfig is used by the ldaps:// and StartTLS connections.
+	TLSConfig *tls.Config
+
+	// Timeout to establish the connection, DefaultTimeout when zero.
+	Timeout time.Duration
+}
+
+// NewDialFunc returns a DialFunc that connects and binds to the LDAP server with the given configuration.
+func NewDialFunc(cfg DialConfig) (DialFunc, error) {
+	if cfg.URL == "" {
+		return nil, ErrURLEmpty
+	}
+
+	if cfg.Timeout == 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+
+	return func(ctx context.Context) (Conn, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		opts := []goldap.DialOpt{goldap.DialWithDialer(&net.Dialer{Timeout: cfg.Timeout})}
+		if cfg.TLSConfig != nil {
+			opts = append(opts, goldap.DialWithTLSConfig(cfg.TLSConfig))
+		}
+
+		conn, err := goldap.DialURL(cfg.URL, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("ldap: error connecting to %s: %w", cfg.URL, err)
+		}
+
+		if cfg.StartTLS {
+			tlsConfig := cfg.TLSConfig
+			if tlsConfig == nil {
+				tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+			}
+
+			if tlsConfig.ServerName == "" {
+				tlsConfig = tlsConfig.Clone()
+				if u, err := url.Parse(cfg.URL); err == nil {
+					tlsConfig.ServerName = u.Hostname()
+				}
+			}
+
+			if err := conn.StartTLS(tlsConfig); err != nil {
+				conn.Close()
+				return nil, fmt.Errorf("ldap: error starting tls: %w", err)
+			}
+		}
+
+		if cfg.BindDN != "" {
+			if err := conn.Bind(cfg.BindDN, cfg.BindPassword); err != nil {
+				conn.Close()
+				return nil, fmt.Errorf("ldap: error binding as %s: %w", cfg.BindDN, err)
+			}
+		}
+
+		return conn, nil
+	}, nil
+}
+
+// Client is an LDAP client to read groups and users.
+type Client struct {
+	dial            DialFunc
+	baseDN          string
+	groupsBaseDN    string
+	usersBaseDN     string
+	pageSize        uint32
+	membersStrategy string
+
+	// the connection is shared by the concurrent searches and dialed again when it is closed
+	mu   sync.Mutex
+	conn Conn
+}
+
+// ClientOption is a function that configures a Client.
+type ClientOption func(*Client)
+
+// WithGroupsBaseDN configures the base dn of the groups searches, the client base dn by default.
+func WithGroupsBaseDN(dn string) ClientOption {
+	return func(c *Client) {
+		if dn != "" {
+			c.groupsBaseDN = dn
+		}
+	}
+}
+
+// WithUsersBaseDN configures the base dn of the users searches, the client base dn by default.
+func WithUsersBaseDN(dn string) ClientOption {
+	return func(c *Client) {
+		if dn != "" {
+			c.usersBaseDN = dn
+		}
+	}
+}
+
+// WithPageSize configures the number of entries requested per page.
+func WithPageSize(size uint32) ClientOption {
+	return func(c *Client) {
+		if size > 0 {
+			c.pageSize = size
+		}
+	}
+}
+
+// WithMembersStrategy configures how the nested group memberships are expanded,
+// MembersStrategyMember by default.
+func WithMembersStrategy(strategy string) ClientOption {
+	return func(c *Client) {
+		if strategy != "" {
+			c.membersStrategy = strategy
+		}
+	}
+}
+
+// NewClient returns a new LDAP client that searches under the given base dn.
+func NewClient(dial DialFunc, baseDN string, opts ...ClientOption) (*Client, error) {
+	if dial == nil {
+		return nil, ErrDialFuncNil
+	}
+
+	if baseDN == "" {
+		return nil, ErrBaseDNEmpty
+	}
+
+	c := &Client{
+		dial:            dial,
+		baseDN:          baseDN,
+		groupsBaseDN:    baseDN,
+		usersBaseDN:     baseDN,
+		pageSize:        DefaultPageSize,
+		membersStrategy: MembersStrategyMember,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	switch c.membersStrategy {
+	case MembersStrategyMember, MembersStrategyMemberOf, MembersStrategyInChain:
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrInvalidMembersStrategy, c.membersStrategy)
+	}
+
+	return c, nil
+}
+
+// Close closes the connection to the LDAP server.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil {
+		return nil
+	}
+
+	err := c.conn.Close()
+	c.conn = nil
+
+	return err
+}
+
+// connection returns the current connection or dials a new one when it is closed.
+func (c *Client) connection(ctx context.Context) (Conn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn != nil && !c.conn.IsClosing() {
+		return c.conn, nil
+	}
+
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.conn = conn
+
+	return conn, nil
+}
+
+// search returns the entries matching the filter, a base dn that doesn't exist returns no entries.
+func (c *Client) search(ctx context.Context, baseDN string, scope int, filter string, attributes []string) ([]*goldap.Entry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	conn, err := c.connection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req := goldap.NewSearchRequest(baseDN, scope, goldap.NeverDerefAliases, 0, 0, false, filter, attributes, nil)
+
+	res, err := conn.SearchWithPaging(req, c.pageSize)
+	if err != nil {
+		if goldap.IsErrorWithCode(err, goldap.LDAPResultNoSuchObject) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("ldap: error searching %s with filter %s: %w", baseDN, filter, err)
+	}
+
+	return res.Entries, nil
+}
+
+// searchByFilters returns the entries matching any of the given filters, the entries are unique by dn.
+//
+// A filter is either an LDAP filter, e.g. (cn=AWS-*), searched under the base dn, or the dn of an entry.
+// All the entries matching the object filter are returned when no filter is given.
+func (c *Client) searchByFilters(ctx context.Context, baseDN, objectFilter string, attributes, filter []string) ([]*goldap.Entry, error) {
+	if len(filter) == 0 {
+		filter = []string{""}
+	}
+
+	seen := make(map[string]struct{})
+	entries := make([]*goldap.Entry, 0)
+
+	for _, f := range filter {
+		f = strings.TrimSpace(f)
+
+		var found []*goldap.Entry
+		var err error
+
+		switch {
+		case f == "":
+			found, err = c.search(ctx, baseDN, goldap.ScopeWholeSubtree, objectFilter, attributes)
+		case strings.HasPrefix(f, "("):
+			found, err = c.search(ctx, baseDN, goldap.ScopeWholeSubtree, fmt.Sprintf("(&%s%s)", objectFilter, f), attributes)
+		default:
+			if _, perr := goldap.ParseDN(f); perr != nil {
+				return nil, fmt.Errorf("ldap: filter %q is neither an LDAP filter nor a dn: %w", f, perr)
+			}
+			found, err = c.search(ctx, f, goldap.ScopeBaseObject, objectFilter, attributes)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range found {
+			key := strings.ToLower(e.DN)
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			entries = append(entries, e)
+		}
+	}
+
+	return entries, nil
+}
+
+// ListGroups returns the groups matching any of the given filters.
+//
+// The filter parameter is a list of LDAP filters, e.g. (cn=AWS-*), or group dns.
+func (c *Client) ListGroups(ctx context.Context, filter []string) ([]*Group, error) {
+	entries, err := c.searchByFilters(ctx, c.groupsBaseDN, groupObjectFilter(), groupAttributes, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]*Group, 0, len(entries))
+	for _, e := range entries {
+		groups = append(groups, newGroup(e))
+	}
+
+	return groups, nil
+}
+
+// ListUsers returns the users matching any of the given filters.
+//
+// The filter parameter is a list of LDAP filters, e.g. (department=Engineering), or user dns.
+func (c *Client) ListUsers(ctx context.Context, filter []string) ([]*User, error) {
+	entries, err := c.searchByFilters(ctx, c.usersBaseDN, userObjectFilter(), userAttributes, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]*User, 0, len(entries))
+	for _, e := range entries {
+		users = append(users, newUser(e))
+	}
+
+	return users, nil
+}
+
+// GetUser returns the user with the given dn.
+func (c *Client) GetUser(ctx context.Context, userDN string) (*User, error) {
+	if userDN == "" {
+		return nil, ErrUserDNEmpty
+	}
+
+	entries, err := c.search(ctx, userDN, goldap.ScopeBaseObject, userObjectFilter(), userAttributes)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrUserNotFound, userDN)
+	}
+
+	return newUser(entries[0]), nil
+}
+
+// ListGroupMembers returns the enabled users that are members of the group, directly or through
+// nested groups, using the configured members strategy.
+func (c *Client) ListGroupMembers(ctx context.Context, groupDN string) ([]*User, error) {
+	if groupDN == "" {
+		return nil, ErrGroupDNEmpty
+	}
+
+	var entries []*goldap.Entry
+	var err error
+
+	switch c.membersStrategy {
+	case MembersStrategyInChain:
+		filter := fmt.Sprintf("(&%s(memberOf:%s:=%s))", userObjectFilter(), matchingRuleInChain, goldap.EscapeFilter(groupDN))
+		entries, err = c.search(ctx, c.usersBaseDN, goldap.ScopeWholeSubtree, filter, userAttributes)
+	case MembersStrategyMemberOf:
+		entries, err = c.expandMembers(ctx, groupDN, c.memberOfEntries)
+	default:
+		entries, err = c.expandMembers(ctx, groupDN, c.memberEntries)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]struct{}, len(entries))
+	users := make([]*User, 0, len(entries))
+	for _, e := range entries {
+		key := strings.ToLower(e.DN)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+
+		u := newUser(e)
+		if u.Disabled {
+			continue
+		}
+		users = append(users, u)
+	}
+
+	return users, nil
+}
+
+// expandMembers walks the nested groups of the group, breadth first and visiting each group once,
+// and returns the user entries found. The members function returns the direct members of a group.
+func (c *Client) expandMembers(ctx context.Context, groupDN string, members func(ctx context.Context, groupDN string) ([]*goldap.Entry, error)) ([]*goldap.Entry, error) {
+	visited := map[string]struct{}{strings.ToLower(groupDN): {}}
+	queue := []string{groupDN}
+	users := make([]*goldap.Entry, 0)
+
+	for len(queue) > 0 {
+		dn := queue[0]
+		queue = queue[1:]
+
+		entries, err := members(ctx, dn)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range entries {
+			if !isGroup(e) {
+				users = append(users, e)
+				continue
+			}
+
+			key := strings.ToLower(e.DN)
+			if _, ok := visited[key]; ok {
+				continue
+			}
+			visited[key] = struct{}{}
+			queue = append(queue, e.DN)
+		}
+	}
+
+	return users, nil
+}
+
+// memberEntries returns the direct members of the group reading its member/uniqueMember attributes.
+func (c *Client) memberEntries(ctx context.Context, groupDN string) ([]*goldap.Entry, error) {
+	groups, err := c.search(ctx, groupDN, goldap.ScopeBaseObject, groupObjectFilter(), groupAttributes)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(groups) == 0 {
+		slog.Warn("ldap: group not found", "dn", groupDN)
+		return nil, nil
+	}
+
+	entries := make([]*goldap.Entry, 0)
+	for _, dn := range newGroup(groups[0]).Members {
+		found, err := c.search(ctx, dn, goldap.ScopeBaseObject, memberObjectFilter(), memberAttributes())
+		if err != nil {
+			return nil, err
+		}
+
+		if len(found) == 0 {
+			slog.Debug("ldap: member is neither a user nor a group", "group", groupDN, "member", dn)
+			continue
+		}
+		entries = append(entries, found[0])
+	}
+
+	return entries, nil
+}
+
+// memberOfEntries returns the direct members of the group searching the entries whose memberOf
+// attribute references it.
+func (c *Client) memberOfEntries(ctx context.Context, groupDN string) ([]*goldap.Entry, error) {
+	filter := fmt.Sprintf("(&%s(memberOf=%s))", memberObjectFilter(), goldap.EscapeFilter(groupDN))
+	return c.search(ctx, c.baseDN, goldap.ScopeWholeSubtree, filter, memberAttributes())
+}
+
+// groupObjectFilter returns the filter matching the group entries.
+func groupObjectFilter() string {
+	var sb strings.Builder
+	sb.WriteString("(|")
+	for _, oc := range groupObjectClasses {
+		sb.WriteString("(objectClass=" + oc + ")")
+	}
+	sb.WriteString(")")
+
+	return sb.String()
+}
+
+// userObjectFilter returns the filter matching the user entries, inetOrgPerson entries and
+// Active Directory person users (computer accounts are users too).
+func userObjectFilter() string {
+	return "(|(objectClass=inetOrgPerson)(&(objectClass=user)(objectCategory=person)))"
+}
+
+// memberObjectFilter returns the filter matching the entries that can be group members.
+func memberObjectFilter() string {
+	return "(|" + userObjectFilter() + groupObjectFilter() + ")"
+}
+
+// memberAttributes returns the attributes read from the group members.
+func memberAttributes() []string {
+	attrs := slices.Clone(userAttributes)
+	for _, attr := range groupAttributes {
+		if !slices.Contains(attrs, attr) {
+			attrs = append(attrs, attr)
+		}
+	}
+
+	return attrs
+}
@@ -0,0 +1,147 @@
+package ldap
+
+import (
+	"strconv"
+	"strings"
+
+	goldap "github.com/go-ldap/ldap/v3"
+)
+
+// adAccountDisabled is the ACCOUNTDISABLE flag of the Active Directory userAccountControl attribute.
+// reference: https://learn.microsoft.com/en-us/troubleshoot/windows-server/active-directory/useraccountcontrol-manipulate-account-properties
+const adAccountDisabled = 0x2
+
+var (
+	// groupObjectClasses are the object classes of the groups, groupOfNames and groupOfUniqueNames
+	// for LDAP directories and group for Active Directory.
+	groupObjectClasses = []string{"group", "groupOfNames", "groupOfUniqueNames"}
+
+	// groupAttributes are the attributes read from the group entries.
+	groupAttributes = []string{"objectClass", "cn", "description", "mail", "member", "uniqueMember"}
+
+	// userAttributes are the attributes read from the user entries, inetOrgPerson attributes
+	// and their Active Directory counterparts.
+	userAttributes = []string{
+		"objectClass", "uid", "sAMAccountName", "userPrincipalName", "mail",
+		"cn", "givenName", "sn", "middleName", "initials", "displayName",
+		"title", "employeeType", "preferredLanguage", "telephoneNumber", "mobile",
+		"street", "streetAddress", "l", "st", "postalCode", "c", "postalAddress",
+		"employeeNumber", "employeeID", "departmentNumber", "department", "o", "company",
+		"manager", "userAccountControl",
+	}
+)
+
+// Group represents an LDAP group.
+type Group struct {
+	DN          string
+	Name        string
+	Description string
+	Email       string
+
+	// Members are the DNs of the direct members, users or groups.
+	Members []string
+}
+
+// User represents an LDAP user, an inetOrgPerson or an Active Directory user.
+type User struct {
+	DN                string
+	UserName          string
+	Email             string
+	GivenName         string
+	FamilyName        string
+	MiddleName        string
+	DisplayName       string
+	Title             string
+	UserType          string
+	PreferredLanguage string
+	TelephoneNumber   string
+	Mobile            string
+	StreetAddress     string
+	Locality          string
+	Region            string
+	PostalCode        string
+	Country           string
+	PostalAddress     string
+	EmployeeNumber    string
+	Department        string
+	Organization      string
+
+	// ManagerDN is the DN of the user's manager.
+	ManagerDN string
+
+	// Disabled is true when the Active Directory account is disabled.
+	Disabled bool
+}
+
+// isGroup returns true when the entry is a group.
+func isGroup(e *goldap.Entry) bool {
+	for _, oc := range e.GetEqualFoldAttributeValues("objectClass") {
+		for _, goc := range groupObjectClasses {
+			if strings.EqualFold(oc, goc) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// firstValue returns the first non empty value of the given attributes.
+func firstValue(e *goldap.Entry, attributes ...string) string {
+	for _, attr := range attributes {
+		if v := strings.TrimSpace(e.GetEqualFoldAttributeValue(attr)); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// newGroup returns a Group from a group entry.
+func newGroup(e *goldap.Entry) *Group {
+	members := make([]string, 0)
+	members = append(members, e.GetEqualFoldAttributeValues("member")...)
+	members = append(members, e.GetEqualFoldAttributeValues("uniqueMember")...)
+
+	return &Group{
+		DN:          e.DN,
+		Name:        firstValue(e, "cn"),
+		Description: firstValue(e, "description"),
+		Email:       firstValue(e, "mail"),
+		Members:     members,
+	}
+}
+
+// newUser returns a User from a user entry, the Active Directory attributes are used
+// when the inetOrgPerson ones are not defined.
+func newUser(e *goldap.Entry) *User {
+	u := &User{
+		DN:                e.DN,
+		UserName:          firstValue(e, "userPrincipalName", "uid", "sAMAccountName", "mail"),
+		Email:             firstValue(e, "mail", "userPrincipalName"),
+		GivenName:         firstValue(e, "givenName"),
+		FamilyName:        firstValue(e, "sn"),
+		MiddleName:        firstValue(e, "middleName", "initials"),
+		DisplayName:       firstValue(e, "displayName", "cn"),
+		Title:             firstValue(e, "title"),
+		UserType:          firstValue(e, "employeeType"),
+		PreferredLanguage: firstValue(e, "preferredLanguage"),
+		TelephoneNumber:   firstValue(e, "telephoneNumber"),
+		Mobile:            firstValue(e, "mobile"),
+		StreetAddress:     firstValue(e, "streetAddress", "street"),
+		Locality:          firstValue(e, "l"),
+		Region:            firstValue(e, "st"),
+		PostalCode:        firstValue(e, "postalCode"),
+		Country:           firstValue(e, "c"),
+		// postalAddress lines are separated by "$"
+		PostalAddress:  strings.ReplaceAll(firstValue(e, "postalAddress"), "$", ", "),
+		EmployeeNumber: firstValue(e, "employeeNumber", "employeeID"),
+		Department:     firstValue(e, "department", "departmentNumber"),
+		Organization:   firstValue(e, "company", "o"),
+		ManagerDN:      firstValue(e, "manager"),
+	}
+
+	if uac, err := strconv.ParseInt(firstValue(e, "userAccountControl"), 10, 64); err == nil {
+		u.Disabled = uac&adAccountDisabled != 0
+	}
+
+	return u
+}
@@ -0,0 +1,366 @@
+package ldap
+
+import (
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	goldap "github.com/go-ldap/ldap/v3"
+)
+
+// testEntry is an entry of the test directory.
+type testEntry struct {
+	dn    string
+	attrs map[string][]string
+}
+
+// get returns the values of the attribute, attribute names are case insensitive.
+func (e *testEntry) get(attr string) []string {
+	for k, v := range e.attrs {
+		if strings.EqualFold(k, attr) {
+			return v
+		}
+	}
+	return nil
+}
+
+// testServer is an in-process LDAP server for the tests. It supports simple binds and
+// paged searches with and/or/not, equality, presence, substrings and the Active Directory
+// in chain extensible match filters over an in-memory directory.
+type testServer struct {
+	t        *testing.T
+	ln       net.Listener
+	entries  []*testEntry
+	bindDN   string
+	password string
+
+	mu       sync.Mutex
+	searches []string
+}
+
+// newTestServer starts a test server with the given entries, the binds are accepted for
+// the given dn and password.
+func newTestServer(t *testing.T, bindDN, password string, entries []*testEntry) *testServer {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("cannot listen: %v", err)
+	}
+
+	s := &testServer{t: t, ln: ln, entries: entries, bindDN: bindDN, password: password}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+
+	return s
+}
+
+// url returns the ldap url of the server.
+func (s *testServer) url() string {
+	return "ldap://" + s.ln.Addr().String()
+}
+
+// filters returns the filters of the searches received by the server.
+func (s *testServer) filters() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.searches...)
+}
+
+func (s *testServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				s.t.Logf("test server: error reading packet: %v", err)
+			}
+			return
+		}
+
+		msgID := packet.Children[0].Value.(int64)
+		op := packet.Children[1]
+
+		switch op.Tag {
+		case goldap.ApplicationBindRequest:
+			code := uint16(goldap.LDAPResultSuccess)
+			if str(op.Children[1]) != s.bindDN || str(op.Children[2]) != s.password {
+				code = goldap.LDAPResultInvalidCredentials
+			}
+			s.write(conn, msgID, result(goldap.ApplicationBindResponse, code), nil)
+		case goldap.ApplicationSearchRequest:
+			s.search(conn, msgID, packet)
+		case goldap.ApplicationUnbindRequest:
+			return
+		default:
+			s.t.Errorf("test server: unexpected operation %d", op.Tag)
+			return
+		}
+	}
+}
+
+func (s *testServer) search(conn net.Conn, msgID int64, packet *ber.Packet) {
+	op := packet.Children[1]
+	base := str(op.Children[0])
+	scope := op.Children[1].Value.(int64)
+
+	filter, err := goldap.DecompileFilter(op.Children[6])
+	if err != nil {
+		s.t.Errorf("test server: cannot decompile filter: %v", err)
+	}
+
+	var paging *goldap.ControlPaging
+	if len(packet.Children) > 2 {
+		for _, c := range packet.Children[2].Children {
+			if ctrl, err := goldap.DecodeControl(c); err == nil {
+				if p, ok := ctrl.(*goldap.ControlPaging); ok {
+					paging = p
+				}
+			}
+		}
+	}
+
+	// abandoned paged search
+	if paging != nil && paging.PagingSize == 0 {
+		s.write(conn, msgID, result(goldap.ApplicationSearchResultDone, goldap.LDAPResultSuccess), nil)
+		return
+	}
+
+	s.mu.Lock()
+	s.searches = append(s.searches, filter)
+	s.mu.Unlock()
+
+	if !s.exists(base) {
+		s.write(conn, msgID, result(goldap.ApplicationSearchResultDone, goldap.LDAPResultNoSuchObject), nil)
+		return
+	}
+
+	found := make([]*testEntry, 0)
+	for _, e := range s.entries {
+		if inScope(e.dn, base, scope) && s.match(e, op.Children[6]) {
+			found = append(found, e)
+		}
+	}
+
+	start, end := 0, len(found)
+	var done *goldap.ControlPaging
+	if paging != nil {
+		if len(paging.Cookie) > 0 {
+			start, _ = strconv.Atoi(string(paging.Cookie))
+		}
+		end = min(start+int(paging.PagingSize), len(found))
+
+		done = goldap.NewControlPaging(paging.PagingSize)
+		if end < len(found) {
+			done.SetCookie([]byte(strconv.Itoa(end)))
+		}
+	}
+
+	for _, e := range found[start:end] {
+		entry := ber.Encode(ber.ClassApplication, ber.TypeConstructed, goldap.ApplicationSearchResultEntry, nil, "")
+		entry.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, e.dn, ""))
+
+		attrs := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+		for name, values := range e.attrs {
+			attr := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+			attr.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, ""))
+
+			vals := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "")
+			for _, v := range values {
+				vals.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, v, ""))
+			}
+			attr.AppendChild(vals)
+			attrs.AppendChild(attr)
+		}
+		entry.AppendChild(attrs)
+
+		s.write(conn, msgID, entry, nil)
+	}
+
+	s.write(conn, msgID, result(goldap.ApplicationSearchResultDone, goldap.LDAPResultSuccess), done)
+}
+
+func (s *testServer) write(conn net.Conn, msgID int64, op *ber.Packet, control goldap.Control) {
+	packet := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+	packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, msgID, ""))
+	packet.AppendChild(op)
+
+	if control != nil {
+		controls := ber.Encode(ber.ClassContext, ber.TypeConstructed, 0, nil, "")
+		controls.AppendChild(control.Encode())
+		packet.AppendChild(controls)
+	}
+
+	if _, err := conn.Write(packet.Bytes()); err != nil {
+		s.t.Logf("test server: error writing packet: %v", err)
+	}
+}
+
+// exists returns true when the dn is an entry of the directory or a suffix of one.
+func (s *testServer) exists(dn string) bool {
+	for _, e := range s.entries {
+		if inScope(e.dn, dn, goldap.ScopeWholeSubtree) {
+			return true
+		}
+	}
+	return false
+}
+
+// entry returns the entry with the given dn.
+func (s *testServer) entry(dn string) *testEntry {
+	for _, e := range s.entries {
+		if strings.EqualFold(e.dn, dn) {
+			return e
+		}
+	}
+	return nil
+}
+
+// match evaluates the filter packet against the entry.
+func (s *testServer) match(e *testEntry, f *ber.Packet) bool {
+	switch f.Tag {
+	case goldap.FilterAnd:
+		for _, c := range f.Children {
+			if !s.match(e, c) {
+				return false
+			}
+		}
+		return true
+	case goldap.FilterOr:
+		for _, c := range f.Children {
+			if s.match(e, c) {
+				return true
+			}
+		}
+		return false
+	case goldap.FilterNot:
+		return !s.match(e, f.Children[0])
+	case goldap.FilterPresent:
+		return len(e.get(f.Data.String())) > 0
+	case goldap.FilterEqualityMatch:
+		for _, v := range e.get(str(f.Children[0])) {
+			if strings.EqualFold(v, str(f.Children[1])) {
+				return true
+			}
+		}
+		return false
+	case goldap.FilterSubstrings:
+		for _, v := range e.get(str(f.Children[0])) {
+			if matchSubstrings(strings.ToLower(v), f.Children[1].Children) {
+				return true
+			}
+		}
+		return false
+	case goldap.FilterExtensibleMatch:
+		var rule, attr, value string
+		for _, c := range f.Children {
+			switch c.Tag {
+			case 1:
+				rule = str(c)
+			case 2:
+				attr = str(c)
+			case 3:
+				value = str(c)
+			}
+		}
+		if rule != matchingRuleInChain || !strings.EqualFold(attr, "memberOf") {
+			s.t.Errorf("test server: unsupported extensible match %s:%s", attr, rule)
+			return false
+		}
+		return s.memberOfInChain(e, value, map[string]bool{})
+	default:
+		s.t.Errorf("test server: unsupported filter %d", f.Tag)
+		return false
+	}
+}
+
+// memberOfInChain returns true when the entry is a member of the group, directly or through nested groups.
+func (s *testServer) memberOfInChain(e *testEntry, groupDN string, visited map[string]bool) bool {
+	for _, dn := range e.get("memberOf") {
+		if strings.EqualFold(dn, groupDN) {
+			return true
+		}
+		if visited[strings.ToLower(dn)] {
+			continue
+		}
+		visited[strings.ToLower(dn)] = true
+
+		if parent := s.entry(dn); parent != nil && s.memberOfInChain(parent, groupDN, visited) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSubstrings matches the lower case value against the initial, any and final substrings.
+func matchSubstrings(v string, subs []*ber.Packet) bool {
+	for _, sub := range subs {
+		part := strings.ToLower(str(sub))
+		switch sub.Tag {
+		case goldap.FilterSubstringsInitial:
+			if !strings.HasPrefix(v, part) {
+				return false
+			}
+			v = v[len(part):]
+		case goldap.FilterSubstringsAny:
+			i := strings.Index(v, part)
+			if i < 0 {
+				return false
+			}
+			v = v[i+len(part):]
+		case goldap.FilterSubstringsFinal:
+			if !strings.HasSuffix(v, part) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// inScope returns true when the dn is in the scope of the search base.
+func inScope(dn, base string, scope int64) bool {
+	dn, base = strings.ToLower(dn), strings.ToLower(base)
+
+	switch scope {
+	case goldap.ScopeBaseObject:
+		return dn == base
+	case goldap.ScopeSingleLevel:
+		parent, ok := strings.CutSuffix(dn, ","+base)
+		return ok && !strings.Contains(parent, ",")
+	default:
+		return dn == base || strings.HasSuffix(dn, ","+base)
+	}
+}
+
+// result returns an LDAPResult operation packet with the given tag and result code.
+func result(tag ber.Tag, code uint16) *ber.Packet {
+	p := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "")
+	p.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(code), ""))
+	p.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+	p.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+	return p
+}
+
+// str returns the string value of a packet.
+func str(p *ber.Packet) string {
+	if v, ok := p.Value.(string); ok {
+		return v
+	}
+	return p.Data.String()
+}
@@ -0,0 +1,315 @@
+package ldap
+
+import (
+	"context"
+	"testing"
+
+	goldap "github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testBaseDN   = "dc=example,dc=com"
+	testBindDN   = "cn=sync,ou=services,dc=example,dc=com"
+	testPassword = "secret"
+
+	adminsDN = "cn=AWS-Admins,ou=groups,dc=example,dc=com"
+	nestedDN = "cn=AWS-Nested,ou=groups,dc=example,dc=com"
+	otherDN  = "cn=Other,ou=groups,dc=example,dc=com"
+	aliceDN  = "uid=alice,ou=users,dc=example,dc=com"
+	bobDN    = "uid=bob,ou=users,dc=example,dc=com"
+	carolDN  = "uid=carol,ou=users,dc=example,dc=com"
+	daveDN   = "cn=Dave,ou=users,dc=example,dc=com"
+)
+
+// testDirectory returns a directory with nested groups, including a membership cycle,
+// inetOrgPerson users and a disabled Active Directory user.
+func testDirectory() []*testEntry {
+	return []*testEntry{
+		{dn: testBaseDN, attrs: map[string][]string{"objectClass": {"domain"}}},
+		{dn: adminsDN, attrs: map[string][]string{
+			"objectClass": {"top", "groupOfNames"},
+			"cn":          {"AWS-Admins"},
+			"description": {"AWS administrators"},
+			"mail":        {"aws-admins@example.com"},
+			"member":      {aliceDN, nestedDN, daveDN},
+			"memberOf":    {nestedDN},
+		}},
+		{dn: nestedDN, attrs: map[string][]string{
+			"objectClass": {"top", "groupOfNames"},
+			"cn":          {"AWS-Nested"},
+			"member":      {bobDN, adminsDN},
+			"memberOf":    {adminsDN},
+		}},
+		{dn: otherDN, attrs: map[string][]string{
+			"objectClass":  {"top", "groupOfUniqueNames"},
+			"cn":           {"Other"},
+			"uniqueMember": {carolDN},
+		}},
+		{dn: aliceDN, attrs: map[string][]string{
+			"objectClass":    {"top", "person", "inetOrgPerson"},
+			"uid":            {"alice"},
+			"mail":           {"alice@example.com"},
+			"cn":             {"Alice Smith"},
+			"givenName":      {"Alice"},
+			"sn":             {"Smith"},
+			"title":          {"Engineer"},
+			"postalAddress":  {"1 Main St$Springfield"},
+			"employeeNumber": {"42"},
+			"manager":        {bobDN},
+			"memberOf":       {adminsDN},
+		}},
+		{dn: bobDN, attrs: map[string][]string{
+			"objectClass": {"top", "person", "inetOrgPerson"},
+			"uid":         {"bob"},
+			"mail":        {"bob@example.com"},
+			"givenName":   {"Bob"},
+			"sn":          {"Jones"},
+			"memberOf":    {nestedDN},
+		}},
+		{dn: carolDN, attrs: map[string][]string{
+			"objectClass": {"top", "person", "inetOrgPerson"},
+			"uid":         {"carol"},
+			"mail":        {"carol@example.com"},
+			"givenName":   {"Carol"},
+			"sn":          {"White"},
+			"memberOf":    {otherDN},
+		}},
+		{dn: daveDN, attrs: map[string][]string{
+			"objectClass":        {"top", "person", "organizationalPerson", "user"},
+			"objectCategory":     {"person"},
+			"sAMAccountName":     {"dave"},
+			"userPrincipalName":  {"dave@example.com"},
+			"givenName":          {"Dave"},
+			"sn":                 {"Brown"},
+			"userAccountControl": {"514"},
+			"memberOf":           {adminsDN},
+		}},
+	}
+}
+
+func newTestClient(t *testing.T, opts ...ClientOption) (*testServer, *Client) {
+	t.Helper()
+
+	srv := newTestServer(t, testBindDN, testPassword, testDirectory())
+
+	dial, err := NewDialFunc(DialConfig{URL: srv.url(), BindDN: testBindDN, BindPassword: testPassword})
+	assert.NoError(t, err)
+
+	c, err := NewClient(dial, testBaseDN, opts...)
+	assert.NoError(t, err)
+	t.Cleanup(func() { c.Close() })
+
+	return srv, c
+}
+
+func userNames(users []*User) []string {
+	names := make([]string, 0, len(users))
+	for _, u := range users {
+		names = append(names, u.UserName)
+	}
+	return names
+}
+
+func TestNewDialFunc(t *testing.T) {
+	t.Run("should return error when url is empty", func(t *testing.T) {
+		got, err := NewDialFunc(DialConfig{})
+		assert.ErrorIs(t, err, ErrURLEmpty)
+		assert.Nil(t, got)
+	})
+
+	t.Run("should bind with the credentials", func(t *testing.T) {
+		srv := newTestServer(t, testBindDN, testPassword, testDirectory())
+
+		dial, err := NewDialFunc(DialConfig{URL: srv.url(), BindDN: testBindDN, BindPassword: testPassword})
+		assert.NoError(t, err)
+
+		conn, err := dial(context.Background())
+		assert.NoError(t, err)
+		assert.NoError(t, conn.Close())
+	})
+
+	t.Run("should return error when the credentials are invalid", func(t *testing.T) {
+		srv := newTestServer(t, testBindDN, testPassword, testDirectory())
+
+		dial, err := NewDialFunc(DialConfig{URL: srv.url(), BindDN: testBindDN, BindPassword: "invalid"})
+		assert.NoError(t, err)
+
+		conn, err := dial(context.Background())
+		assert.True(t, goldap.IsErrorWithCode(err, goldap.LDAPResultInvalidCredentials))
+		assert.Nil(t, conn)
+	})
+}
+
+func TestNewClient(t *testing.T) {
+	dial := func(context.Context) (Conn, error) { return nil, nil }
+
+	t.Run("should return error when dial function is nil", func(t *testing.T) {
+		got, err := NewClient(nil, testBaseDN)
+		assert.ErrorIs(t, err, ErrDialFuncNil)
+		assert.Nil(t, got)
+	})
+
+	t.Run("should return error when base dn is empty", func(t *testing.T) {
+		got, err := NewClient(dial, "")
+		assert.ErrorIs(t, err, ErrBaseDNEmpty)
+		assert.Nil(t, got)
+	})
+
+	t.Run("should return error when members strategy is invalid", func(t *testing.T) {
+		got, err := NewClient(dial, testBaseDN, WithMembersStrategy("invalid"))
+		assert.ErrorIs(t, err, ErrInvalidMembersStrategy)
+		assert.Nil(t, got)
+	})
+
+	t.Run("should return client with the options", func(t *testing.T) {
+		got, err := NewClient(dial, testBaseDN,
+			WithGroupsBaseDN("ou=groups,"+testBaseDN),
+			WithUsersBaseDN("ou=users,"+testBaseDN),
+			WithPageSize(100),
+			WithMembersStrategy(MembersStrategyInChain),
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, "ou=groups,"+testBaseDN, got.groupsBaseDN)
+		assert.Equal(t, "ou=users,"+testBaseDN, got.usersBaseDN)
+		assert.Equal(t, uint32(100), got.pageSize)
+		assert.Equal(t, MembersStrategyInChain, got.membersStrategy)
+	})
+}
+
+func TestListGroups(t *testing.T) {
+	t.Run("should return all the groups when there is no filter", func(t *testing.T) {
+		_, c := newTestClient(t)
+
+		got, err := c.ListGroups(context.Background(), nil)
+		assert.NoError(t, err)
+		assert.Len(t, got, 3)
+	})
+
+	t.Run("should return the groups by filter and dn without duplicates", func(t *testing.T) {
+		_, c := newTestClient(t)
+
+		got, err := c.ListGroups(context.Background(), []string{"(cn=AWS-*)", adminsDN, otherDN})
+		assert.NoError(t, err)
+		assert.Equal(t, []*Group{
+			{DN: adminsDN, Name: "AWS-Admins", Description: "AWS administrators", Email: "aws-admins@example.com", Members: []string{aliceDN, nestedDN, daveDN}},
+			{DN: nestedDN, Name: "AWS-Nested", Members: []string{bobDN, adminsDN}},
+			{DN: otherDN, Name: "Other", Members: []string{carolDN}},
+		}, got)
+	})
+
+	t.Run("should return no groups when the dn doesn't exist", func(t *testing.T) {
+		_, c := newTestClient(t)
+
+		got, err := c.ListGroups(context.Background(), []string{"cn=Missing,ou=groups,dc=example,dc=org"})
+		assert.NoError(t, err)
+		assert.Empty(t, got)
+	})
+
+	t.Run("should return error when the filter is invalid", func(t *testing.T) {
+		_, c := newTestClient(t)
+
+		got, err := c.ListGroups(context.Background(), []string{"not a dn"})
+		assert.Error(t, err)
+		assert.Nil(t, got)
+	})
+}
+
+func TestListUsers(t *testing.T) {
+	t.Run("should return all the users across pages", func(t *testing.T) {
+		_, c := newTestClient(t, WithPageSize(1))
+
+		got, err := c.ListUsers(context.Background(), nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"alice", "bob", "carol", "dave@example.com"}, userNames(got))
+	})
+
+	t.Run("should return the users matching the filter", func(t *testing.T) {
+		_, c := newTestClient(t)
+
+		got, err := c.ListUsers(context.Background(), []string{"(sn=Jones)"})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"bob"}, userNames(got))
+	})
+}
+
+func TestGetUser(t *testing.T) {
+	t.Run("should return error when user dn is empty", func(t *testing.T) {
+		_, c := newTestClient(t)
+
+		got, err := c.GetUser(context.Background(), "")
+		assert.ErrorIs(t, err, ErrUserDNEmpty)
+		assert.Nil(t, got)
+	})
+
+	t.Run("should return error when the user doesn't exist", func(t *testing.T) {
+		_, c := newTestClient(t)
+
+		got, err := c.GetUser(context.Background(), adminsDN)
+		assert.ErrorIs(t, err, ErrUserNotFound)
+		assert.Nil(t, got)
+	})
+
+	t.Run("should return the inetOrgPerson user", func(t *testing.T) {
+		_, c := newTestClient(t)
+
+		got, err := c.GetUser(context.Background(), aliceDN)
+		assert.NoError(t, err)
+		assert.Equal(t, &User{
+			DN:             aliceDN,
+			UserName:       "alice",
+			Email:          "alice@example.com",
+			GivenName:      "Alice",
+			FamilyName:     "Smith",
+			DisplayName:    "Alice Smith",
+			Title:          "Engineer",
+			PostalAddress:  "1 Main St, Springfield",
+			EmployeeNumber: "42",
+			ManagerDN:      bobDN,
+		}, got)
+	})
+
+	t.Run("should return the active directory user", func(t *testing.T) {
+		_, c := newTestClient(t)
+
+		got, err := c.GetUser(context.Background(), daveDN)
+		assert.NoError(t, err)
+		assert.Equal(t, &User{
+			DN:          daveDN,
+			UserName:    "dave@example.com",
+			Email:       "dave@example.com",
+			GivenName:   "Dave",
+			FamilyName:  "Brown",
+			DisplayName: "",
+			Disabled:    true,
+		}, got)
+	})
+}
+
+func TestListGroupMembers(t *testing.T) {
+	t.Run("should return error when group dn is empty", func(t *testing.T) {
+		_, c := newTestClient(t)
+
+		got, err := c.ListGroupMembers(context.Background(), "")
+		assert.ErrorIs(t, err, ErrGroupDNEmpty)
+		assert.Nil(t, got)
+	})
+
+	for _, strategy := range []string{MembersStrategyMember, MembersStrategyMemberOf, MembersStrategyInChain} {
+		t.Run("should expand the nested groups with the "+strategy+" strategy", func(t *testing.T) {
+			srv, c := newTestClient(t, WithMembersStrategy(strategy))
+
+			got, err := c.ListGroupMembers(context.Background(), adminsDN)
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, []string{"alice", "bob"}, userNames(got))
+
+			got, err = c.ListGroupMembers(context.Background(), otherDN)
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"carol"}, userNames(got))
+
+			if strategy == MembersStrategyInChain {
+				assert.Contains(t, srv.filters(), "(&(|(objectClass=inetOrgPerson)(&(objectClass=user)(objectCategory=person)))(memberOf:1.2.840.113556.1.4.1941:="+adminsDN+"))")
+			}
+		})
+	}
+}
@@ -0,0 +1,12 @@
+// Package okta provides an Okta Management API client to read groups, users and
+// group memberships from an Okta organization.
+//
+// Basic usage:
+//
+//	client, err := okta.NewClient(httpClient, "https://example.okta.com", apiToken)
+//	if err != nil {
+//	    return err
+//	}
+//
+//	groups, err := client.ListGroups(ctx, []string{`profile.name sw "AWS"`})
+package okta
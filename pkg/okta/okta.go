@@ -0,0 +1,424 @@
+package okta
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Okta Management API
+// reference: https://developer.okta.com/docs/api/openapi/okta-management/guides/overview/
+
+const (
+	// DefaultMaxRetries is the default number of retries of a rate limited request.
+	DefaultMaxRetries = 5
+
+	// pageSize is the number of resources requested per page.
+	pageSize = "200"
+
+	// defaultRateLimitWait is used when a rate limited response doesn't include the reset header.
+	defaultRateLimitWait = time.Second
+
+	// Rate limit headers
+	// reference: https://developer.okta.com/docs/reference/rl-best-practices/
+	headerRateLimitRemaining = "X-Rate-Limit-Remaining"
+	headerRateLimitReset     = "X-Rate-Limit-Reset"
+)
+
+var (
+	// ErrOrgURLEmpty is returned when the org url is empty.
+	ErrOrgURLEmpty = errors.New("okta: org url may not be empty")
+
+	// ErrAPITokenEmpty is returned when the api token is empty.
+	ErrAPITokenEmpty = errors.New("okta: api token may not be empty")
+
+	// ErrGroupIDEmpty is returned when the group id is empty.
+	ErrGroupIDEmpty = errors.New("okta: group id may not be empty")
+
+	// ErrUserIDEmpty is returned when the user id is empty.
+	ErrUserIDEmpty = errors.New("okta: user id may not be empty")
+
+	// ErrRateLimitExceeded is returned when a request is still rate limited after all the retries.
+	ErrRateLimitExceeded = errors.New("okta: rate limit exceeded")
+)
+
+// HTTPClient is an interface for sending HTTP requests.
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Client is an Okta Management API client.
+type Client struct {
+	httpClient HTTPClient
+	url        *url.URL
+	apiToken   string
+	maxRetries int
+	UserAgent  string
+
+	// rate limit state shared by the concurrent requests
+	mu        sync.Mutex
+	waitUntil time.Time
+
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// ClientOption is a function that configures a Client.
+type ClientOption func(*Client)
+
+// WithMaxRetries configures the number of retries of a rate limited request.
+func WithMaxRetries(maxRetries int) ClientOption {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+	}
+}
+
+// NewClient returns a new Okta Management API client for the given org url,
+// e.g. https://example.okta.com, authenticated with an API token.
+func NewClient(httpClient HTTPClient, orgURL, apiToken string, opts ...ClientOption) (*Client, error) {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	if orgURL == "" {
+		return nil, ErrOrgURLEmpty
+	}
+
+	u, err := url.Parse(orgURL)
+	if err != nil {
+		return nil, fmt.Errorf("okta: error parsing url: %w", err)
+	}
+
+	if apiToken == "" {
+		return nil, ErrAPITokenEmpty
+	}
+
+	c := &Client{
+		httpClient: httpClient,
+		url:        u,
+		apiToken:   apiToken,
+		maxRetries: DefaultMaxRetries,
+		now:        time.Now,
+		sleep:      sleep,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c, nil
+}
+
+// sleep waits for the given duration or until the context is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// buildURL returns the url of the given API path with the given query parameters.
+func (c *Client) buildURL(apiPath string, query url.Values) string {
+	u := *c.url
+	u.Path = path.Join(u.Path, apiPath)
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
+
+// get sends a GET request to the given url, decodes the JSON response into v and
+// returns the url of the next page, if any.
+//
+// The rate limit headers are honored: when the remaining requests reach zero, the
+// following requests wait until the rate limit window is reset, and rate limited
+// requests are retried up to maxRetries times.
+func (c *Client) get(ctx context.Context, reqURL string, v any) (string, error) {
+	for attempt := 0; ; attempt++ {
+		if err := c.waitRateLimit(ctx); err != nil {
+			return "", err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+		if err != nil {
+			return "", fmt.Errorf("okta: error creating request, url: %s, error: %w", reqURL, err)
+		}
+
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Authorization", "SSWS "+c.apiToken)
+		if c.UserAgent != "" {
+			req.Header.Set("User-Agent", c.UserAgent)
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return "", fmt.Errorf("okta: error sending request, url: %s, error: %w", reqURL, err)
+		}
+
+		reset := c.updateRateLimit(resp.Header)
+
+		if resp.StatusCode == http.StatusTooManyRequests {
+			resp.Body.Close()
+
+			if attempt >= c.maxRetries {
+				return "", fmt.Errorf("%w, url: %s", ErrRateLimitExceeded, reqURL)
+			}
+
+			wait := reset.Sub(c.now())
+			if reset.IsZero() || wait <= 0 {
+				wait = defaultRateLimitWait
+			}
+
+			slog.Warn("okta: rate limited, waiting before retrying", "url", reqURL, "wait", wait.String(), "attempt", attempt+1)
+			if err := c.sleep(ctx, wait); err != nil {
+				return "", err
+			}
+			continue
+		}
+
+		return decodeResponse(resp, reqURL, v)
+	}
+}
+
+// decodeResponse decodes the JSON response into v and returns the url of the next page, if any.
+func decodeResponse(resp *http.Response, reqURL string, v any) (string, error) {
+	defer resp.Body.Close()
+
+	if err := checkHTTPResponse(resp); err != nil {
+		return "", err
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return "", fmt.Errorf("okta: error decoding response body, url: %s, error: %w", reqURL, err)
+	}
+
+	return nextLink(resp.Header), nil
+}
+
+// waitRateLimit waits until the rate limit window is reset when there are no remaining requests.
+func (c *Client) waitRateLimit(ctx context.Context) error {
+	c.mu.Lock()
+	wait := c.waitUntil.Sub(c.now())
+	c.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+
+	slog.Debug("okta: rate limit reached, waiting for the reset", "wait", wait.String())
+	return c.sleep(ctx, wait)
+}
+
+// updateRateLimit reads the rate limit headers and returns the reset time, if any.
+func (c *Client) updateRateLimit(h http.Header) time.Time {
+	var reset time.Time
+	if epoch, err := strconv.ParseInt(h.Get(headerRateLimitReset), 10, 64); err == nil {
+		reset = time.Unix(epoch, 0)
+	}
+
+	if h.Get(headerRateLimitRemaining) == "0" && !reset.IsZero() {
+		c.mu.Lock()
+		if reset.After(c.waitUntil) {
+			c.waitUntil = reset
+		}
+		c.mu.Unlock()
+	}
+
+	return reset
+}
+
+// checkHTTPResponse returns an APIError when the status code of the response is not successful.
+func checkHTTPResponse(resp *http.Response) error {
+	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusBadRequest {
+		return nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("okta: error reading response body: %w", err)
+	}
+
+	apiErr := &APIError{}
+	if json.Unmarshal(body, apiErr) != nil || apiErr.Code == "" {
+		apiErr = &APIError{Code: resp.Status, Summary: string(body)}
+	}
+	apiErr.StatusCode = resp.StatusCode
+
+	slog.Debug("okta checkHTTPResponse()", "statusCode", resp.StatusCode, "code", apiErr.Code)
+
+	return apiErr
+}
+
+// nextLink returns the url of the next page from the Link headers.
+// reference: https://developer.okta.com/docs/api/#pagination
+func nextLink(h http.Header) string {
+	for _, link := range h.Values("Link") {
+		for part := range strings.SplitSeq(link, ",") {
+			segments := strings.Split(part, ";")
+			if len(segments) < 2 {
+				continue
+			}
+
+			for _, param := range segments[1:] {
+				if strings.TrimSpace(param) == `rel="next"` {
+					return strings.Trim(strings.TrimSpace(segments[0]), "<>")
+				}
+			}
+		}
+	}
+
+	return ""
+}
+
+// list follows the next Link headers starting at the given url and returns all the resources.
+func list[T any](ctx context.Context, c *Client, reqURL string) ([]T, error) {
+	items := make([]T, 0, 50)
+
+	for reqURL != "" {
+		var p []T
+
+		next, err := c.get(ctx, reqURL, &p)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, p...)
+		reqURL = next
+	}
+
+	return items, nil
+}
+
+// ListGroups returns the groups matching the given search expressions, all the groups when filter is empty.
+// Groups matching more than one expression are returned once.
+// References:
+// - https://developer.okta.com/docs/api/openapi/okta-management/management/tag/Group/#tag/Group/operation/listGroups
+// - https://developer.okta.com/docs/reference/core-okta-api/#filter
+func (c *Client) ListGroups(ctx context.Context, filter []string) ([]*Group, error) {
+	filters := filter
+	if len(filters) == 0 {
+		filters = []string{""}
+	}
+
+	uniq := make(map[string]struct{})
+	groups := make([]*Group, 0, 50)
+
+	for _, f := range filters {
+		q := url.Values{}
+		q.Set("limit", pageSize)
+		if f != "" {
+			q.Set("search", f)
+		}
+
+		slog.Debug("okta: listing groups", "search", f)
+
+		gs, err := list[*Group](ctx, c, c.buildURL("/api/v1/groups", q))
+		if err != nil {
+			return nil, fmt.Errorf("okta: failed to list groups with search %q: %w", f, err)
+		}
+
+		for _, g := range gs {
+			if _, ok := uniq[g.ID]; ok {
+				continue
+			}
+			uniq[g.ID] = struct{}{}
+			groups = append(groups, g)
+		}
+	}
+
+	return groups, nil
+}
+
+// ListUsers returns the users matching the given search expressions, all the users when filter is empty.
+// Users matching more than one expression are returned once.
+// reference: https://developer.okta.com/docs/api/openapi/okta-management/management/tag/User/#tag/User/operation/listUsers
+func (c *Client) ListUsers(ctx context.Context, filter []string) ([]*User, error) {
+	filters := filter
+	if len(filters) == 0 {
+		filters = []string{""}
+	}
+
+	uniq := make(map[string]struct{})
+	users := make([]*User, 0, 50)
+
+	for _, f := range filters {
+		q := url.Values{}
+		q.Set("limit", pageSize)
+		if f != "" {
+			q.Set("search", f)
+		}
+
+		slog.Debug("okta: listing users", "search", f)
+
+		us, err := list[*User](ctx, c, c.buildURL("/api/v1/users", q))
+		if err != nil {
+			return nil, fmt.Errorf("okta: failed to list users with search %q: %w", f, err)
+		}
+
+		for _, u := range us {
+			if _, ok := uniq[u.ID]; ok {
+				continue
+			}
+			uniq[u.ID] = struct{}{}
+			users = append(users, u)
+		}
+	}
+
+	return users, nil
+}
+
+// ListGroupMembers returns the active users that are members of the given group.
+// reference: https://developer.okta.com/docs/api/openapi/okta-management/management/tag/Group/#tag/Group/operation/listGroupUsers
+func (c *Client) ListGroupMembers(ctx context.Context, groupID string) ([]*User, error) {
+	if groupID == "" {
+		return nil, ErrGroupIDEmpty
+	}
+
+	q := url.Values{}
+	q.Set("limit", pageSize)
+
+	us, err := list[*User](ctx, c, c.buildURL("/api/v1/groups/"+url.PathEscape(groupID)+"/users", q))
+	if err != nil {
+		return nil, fmt.Errorf("okta: failed to list members of group %s: %w", groupID, err)
+	}
+
+	members := make([]*User, 0, len(us))
+	for _, u := range us {
+		if !u.Active() {
+			slog.Warn("okta: member not included in group because status is not active", "id", u.ID, "login", u.Profile.Login, "status", u.Status, "groupID", groupID)
+			continue
+		}
+		members = append(members, u)
+	}
+
+	return members, nil
+}
+
+// GetUser returns a user given its id or login.
+// reference: https://developer.okta.com/docs/api/openapi/okta-management/management/tag/User/#tag/User/operation/getUser
+func (c *Client) GetUser(ctx context.Context, userID string) (*User, error) {
+	if userID == "" {
+		return nil, ErrUserIDEmpty
+	}
+
+	var u User
+	if _, err := c.get(ctx, c.buildURL("/api/v1/users/"+url.PathEscape(userID), nil), &u); err != nil {
+		return nil, fmt.Errorf("okta: error getting user %s: %w", userID, err)
+	}
+
+	return &u, nil
+}
@@ -0,0 +1,101 @@
+package okta
+
+import "fmt"
+
+// Group represents an Okta group.
+// reference: https://developer.okta.com/docs/api/openapi/okta-management/management/tag/Group/
+type Group struct {
+	ID      string       `json:"id"`
+	Type    string       `json:"type,omitempty"`
+	Profile GroupProfile `json:"profile"`
+}
+
+// GroupProfile is the profile of an Okta group.
+type GroupProfile struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// User represents an Okta user.
+// reference: https://developer.okta.com/docs/api/openapi/okta-management/management/tag/User/
+type User struct {
+	ID      string      `json:"id"`
+	Status  string      `json:"status"`
+	Profile UserProfile `json:"profile"`
+}
+
+// User statuses
+// reference: https://developer.okta.com/docs/reference/api/users/#user-status
+const (
+	UserStatusActive        = "ACTIVE"
+	UserStatusStaged        = "STAGED"
+	UserStatusSuspended     = "SUSPENDED"
+	UserStatusDeprovisioned = "DEPROVISIONED"
+)
+
+// Active returns true when the user can sign in, i.e. it is not staged, suspended or deprovisioned.
+func (u *User) Active() bool {
+	switch u.Status {
+	case UserStatusStaged, UserStatusSuspended, UserStatusDeprovisioned:
+		return false
+	default:
+		return true
+	}
+}
+
+// Email returns the email address of the user, the email attribute when it is defined
+// or the login otherwise.
+func (u *User) Email() string {
+	if u.Profile.Email != "" {
+		return u.Profile.Email
+	}
+	return u.Profile.Login
+}
+
+// UserProfile is the profile of an Okta user with the default Okta user profile attributes.
+// reference: https://developer.okta.com/docs/reference/api/users/#default-profile-properties
+type UserProfile struct {
+	Login             string `json:"login"`
+	Email             string `json:"email"`
+	FirstName         string `json:"firstName"`
+	LastName          string `json:"lastName"`
+	MiddleName        string `json:"middleName,omitempty"`
+	HonorificPrefix   string `json:"honorificPrefix,omitempty"`
+	HonorificSuffix   string `json:"honorificSuffix,omitempty"`
+	DisplayName       string `json:"displayName,omitempty"`
+	NickName          string `json:"nickName,omitempty"`
+	ProfileURL        string `json:"profileUrl,omitempty"`
+	Title             string `json:"title,omitempty"`
+	UserType          string `json:"userType,omitempty"`
+	PreferredLanguage string `json:"preferredLanguage,omitempty"`
+	Locale            string `json:"locale,omitempty"`
+	Timezone          string `json:"timezone,omitempty"`
+	MobilePhone       string `json:"mobilePhone,omitempty"`
+	PrimaryPhone      string `json:"primaryPhone,omitempty"`
+	StreetAddress     string `json:"streetAddress,omitempty"`
+	City              string `json:"city,omitempty"`
+	State             string `json:"state,omitempty"`
+	ZipCode           string `json:"zipCode,omitempty"`
+	CountryCode       string `json:"countryCode,omitempty"`
+	PostalAddress     string `json:"postalAddress,omitempty"`
+	EmployeeNumber    string `json:"employeeNumber,omitempty"`
+	CostCenter        string `json:"costCenter,omitempty"`
+	Organization      string `json:"organization,omitempty"`
+	Division          string `json:"division,omitempty"`
+	Department        string `json:"department,omitempty"`
+	ManagerID         string `json:"managerId,omitempty"`
+	Manager           string `json:"manager,omitempty"`
+}
+
+// APIError is returned when the Okta API responds with an error.
+// reference: https://developer.okta.com/docs/reference/error-codes/
+type APIError struct {
+	StatusCode int    `json:"-"`
+	Code       string `json:"errorCode"`
+	Summary    string `json:"errorSummary"`
+	ID         string `json:"errorId,omitempty"`
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("statusCode: %d, errCode: %s, errMsg: %s", e.StatusCode, e.Code, e.Summary)
+}
@@ -0,0 +1,264 @@
+package okta
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newOktaServer returns an httptest Okta stand-in serving the given handlers and a client
+// whose clock and sleep function are controlled by the test.
+func newOktaServer(t *testing.T, handlers map[string]http.HandlerFunc) (*httptest.Server, *Client, *[]time.Duration) {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	for p, h := range handlers {
+		mux.HandleFunc(p, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "SSWS token", r.Header.Get("Authorization"))
+			h(w, r)
+		})
+	}
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	c, err := NewClient(srv.Client(), srv.URL, "token")
+	assert.NoError(t, err)
+
+	now := time.Unix(1000, 0)
+	sleeps := make([]time.Duration, 0)
+	c.now = func() time.Time { return now }
+	c.sleep = func(_ context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		now = now.Add(d)
+		return nil
+	}
+
+	return srv, c, &sleeps
+}
+
+func writeJSON(t *testing.T, w http.ResponseWriter, v any) {
+	t.Helper()
+
+	w.Header().Set("Content-Type", "application/json")
+	assert.NoError(t, json.NewEncoder(w).Encode(v))
+}
+
+func TestNewClient(t *testing.T) {
+	t.Run("should return error when org url is empty", func(t *testing.T) {
+		got, err := NewClient(nil, "", "token")
+		assert.ErrorIs(t, err, ErrOrgURLEmpty)
+		assert.Nil(t, got)
+	})
+
+	t.Run("should return error when api token is empty", func(t *testing.T) {
+		got, err := NewClient(nil, "https://example.okta.com", "")
+		assert.ErrorIs(t, err, ErrAPITokenEmpty)
+		assert.Nil(t, got)
+	})
+
+	t.Run("should return error when url is bad formed", func(t *testing.T) {
+		got, err := NewClient(nil, "https://%%example.okta.com", "token")
+		assert.Error(t, err)
+		assert.Nil(t, got)
+	})
+
+	t.Run("should return client", func(t *testing.T) {
+		got, err := NewClient(nil, "https://example.okta.com", "token", WithMaxRetries(1))
+		assert.NoError(t, err)
+		assert.Equal(t, 1, got.maxRetries)
+	})
+}
+
+func TestNextLink(t *testing.T) {
+	h := http.Header{}
+	h.Add("Link", `<https://example.okta.com/api/v1/groups?limit=200>; rel="self"`)
+	h.Add("Link", `<https://example.okta.com/api/v1/groups?after=00g2&limit=200>; rel="next"`)
+	assert.Equal(t, "https://example.okta.com/api/v1/groups?after=00g2&limit=200", nextLink(h))
+
+	h = http.Header{}
+	h.Set("Link", `<https://example.okta.com/api/v1/users?limit=200>; rel="self", <https://example.okta.com/api/v1/users?after=u2>; rel="next"`)
+	assert.Equal(t, "https://example.okta.com/api/v1/users?after=u2", nextLink(h))
+
+	assert.Empty(t, nextLink(http.Header{}))
+}
+
+func TestListGroups(t *testing.T) {
+	t.Run("should follow the Link headers and dedupe groups across searches", func(t *testing.T) {
+		var srv *httptest.Server
+		srv, c, _ := newOktaServer(t, map[string]http.HandlerFunc{
+			"/api/v1/groups": func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, pageSize, r.URL.Query().Get("limit"))
+
+				switch {
+				case r.URL.Query().Get("after") == "g1":
+					writeJSON(t, w, []*Group{{ID: "g2", Profile: GroupProfile{Name: "AWS-2"}}})
+				case r.URL.Query().Get("search") == `profile.name sw "AWS"`:
+					w.Header().Add("Link", "<"+srv.URL+"/api/v1/groups?after=g1&limit=200>; rel=\"next\"")
+					writeJSON(t, w, []*Group{{ID: "g1", Profile: GroupProfile{Name: "AWS-1"}}})
+				default:
+					writeJSON(t, w, []*Group{{ID: "g2", Profile: GroupProfile{Name: "AWS-2"}}, {ID: "g3", Profile: GroupProfile{Name: "Admins"}}})
+				}
+			},
+		})
+
+		got, err := c.ListGroups(context.Background(), []string{`profile.name sw "AWS"`, `profile.name eq "Admins"`})
+		assert.NoError(t, err)
+		assert.Equal(t, []*Group{
+			{ID: "g1", Profile: GroupProfile{Name: "AWS-1"}},
+			{ID: "g2", Profile: GroupProfile{Name: "AWS-2"}},
+			{ID: "g3", Profile: GroupProfile{Name: "Admins"}},
+		}, got)
+	})
+
+	t.Run("should return the api error", func(t *testing.T) {
+		_, c, _ := newOktaServer(t, map[string]http.HandlerFunc{
+			"/api/v1/groups": func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusUnauthorized)
+				writeJSON(t, w, APIError{Code: "E0000011", Summary: "Invalid token provided"})
+			},
+		})
+
+		got, err := c.ListGroups(context.Background(), nil)
+		assert.Nil(t, got)
+
+		var apiErr *APIError
+		assert.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+		assert.Equal(t, "E0000011", apiErr.Code)
+	})
+}
+
+func TestRateLimit(t *testing.T) {
+	t.Run("should retry the rate limited requests after the reset", func(t *testing.T) {
+		var calls atomic.Int32
+		_, c, sleeps := newOktaServer(t, map[string]http.HandlerFunc{
+			"/api/v1/users/u1": func(w http.ResponseWriter, r *http.Request) {
+				if calls.Add(1) == 1 {
+					w.Header().Set(headerRateLimitRemaining, "0")
+					w.Header().Set(headerRateLimitReset, strconv.FormatInt(1030, 10))
+					w.WriteHeader(http.StatusTooManyRequests)
+					return
+				}
+				writeJSON(t, w, User{ID: "u1"})
+			},
+		})
+
+		got, err := c.GetUser(context.Background(), "u1")
+		assert.NoError(t, err)
+		assert.Equal(t, "u1", got.ID)
+		assert.Equal(t, []time.Duration{30 * time.Second}, *sleeps)
+	})
+
+	t.Run("should wait for the reset when there are no remaining requests", func(t *testing.T) {
+		_, c, sleeps := newOktaServer(t, map[string]http.HandlerFunc{
+			"/api/v1/users/u1": func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set(headerRateLimitRemaining, "0")
+				w.Header().Set(headerRateLimitReset, strconv.FormatInt(1010, 10))
+				writeJSON(t, w, User{ID: "u1"})
+			},
+		})
+
+		_, err := c.GetUser(context.Background(), "u1")
+		assert.NoError(t, err)
+		assert.Empty(t, *sleeps)
+
+		_, err = c.GetUser(context.Background(), "u1")
+		assert.NoError(t, err)
+		assert.Equal(t, []time.Duration{10 * time.Second}, *sleeps)
+	})
+
+	t.Run("should return error after the max retries", func(t *testing.T) {
+		_, c, sleeps := newOktaServer(t, map[string]http.HandlerFunc{
+			"/api/v1/users/u1": func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusTooManyRequests)
+			},
+		})
+		c.maxRetries = 2
+
+		got, err := c.GetUser(context.Background(), "u1")
+		assert.ErrorIs(t, err, ErrRateLimitExceeded)
+		assert.Nil(t, got)
+		assert.Equal(t, []time.Duration{defaultRateLimitWait, defaultRateLimitWait}, *sleeps)
+	})
+}
+
+func TestListGroupMembers(t *testing.T) {
+	t.Run("should return error when group id is empty", func(t *testing.T) {
+		c, err := NewClient(nil, "https://example.okta.com", "token")
+		assert.NoError(t, err)
+
+		got, err := c.ListGroupMembers(context.Background(), "")
+		assert.ErrorIs(t, err, ErrGroupIDEmpty)
+		assert.Nil(t, got)
+	})
+
+	t.Run("should return only the active members", func(t *testing.T) {
+		_, c, _ := newOktaServer(t, map[string]http.HandlerFunc{
+			"/api/v1/groups/g1/users": func(w http.ResponseWriter, r *http.Request) {
+				writeJSON(t, w, []*User{
+					{ID: "u1", Status: UserStatusActive},
+					{ID: "u2", Status: UserStatusSuspended},
+					{ID: "u3", Status: "PASSWORD_EXPIRED"},
+					{ID: "u4", Status: UserStatusDeprovisioned},
+				})
+			},
+		})
+
+		got, err := c.ListGroupMembers(context.Background(), "g1")
+		assert.NoError(t, err)
+		assert.Equal(t, []*User{{ID: "u1", Status: UserStatusActive}, {ID: "u3", Status: "PASSWORD_EXPIRED"}}, got)
+	})
+}
+
+func TestListUsers(t *testing.T) {
+	_, c, _ := newOktaServer(t, map[string]http.HandlerFunc{
+		"/api/v1/users": func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, `status eq "ACTIVE"`, r.URL.Query().Get("search"))
+			writeJSON(t, w, []*User{{ID: "u1", Profile: UserProfile{Login: "u1@example.com"}}})
+		},
+	})
+
+	got, err := c.ListUsers(context.Background(), []string{`status eq "ACTIVE"`})
+	assert.NoError(t, err)
+	assert.Equal(t, []*User{{ID: "u1", Profile: UserProfile{Login: "u1@example.com"}}}, got)
+}
+
+func TestGetUser(t *testing.T) {
+	t.Run("should return error when user id is empty", func(t *testing.T) {
+		c, err := NewClient(nil, "https://example.okta.com", "token")
+		assert.NoError(t, err)
+
+		got, err := c.GetUser(context.Background(), "")
+		assert.ErrorIs(t, err, ErrUserIDEmpty)
+		assert.Nil(t, got)
+	})
+
+	t.Run("should return the user profile", func(t *testing.T) {
+		_, c, _ := newOktaServer(t, map[string]http.HandlerFunc{
+			"/api/v1/users/u1": func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, err := w.Write([]byte(`{"id":"u1","status":"ACTIVE","profile":{"login":"u1@example.com","email":"user.one@example.com","firstName":"User","lastName":"One","employeeNumber":"42","managerId":"m1"}}`))
+				assert.NoError(t, err)
+			},
+		})
+
+		got, err := c.GetUser(context.Background(), "u1")
+		assert.NoError(t, err)
+		assert.Equal(t, &User{ID: "u1", Status: UserStatusActive, Profile: UserProfile{
+			Login:          "u1@example.com",
+			Email:          "user.one@example.com",
+			FirstName:      "User",
+			LastName:       "One",
+			EmployeeNumber: "42",
+			ManagerID:      "m1",
+		}}, got)
+	})
+}
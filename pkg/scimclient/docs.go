@@ -0,0 +1,12 @@
+// Package scimclient provides a SCIM 2.0 (RFC 7643, RFC 7644) client to read users,
+// groups and group memberships from any standards compliant SCIM service provider.
+//
+// Basic usage:
+//
+//	client, err := scimclient.NewClient(httpClient, "https://scim.example.com/scim/v2", scimclient.WithBearerToken(token))
+//	if err != nil {
+//	    return err
+//	}
+//
+//	groups, err := client.ListGroups(ctx, []string{`displayName sw "AWS"`})
+package scimclient
@@ -0,0 +1,548 @@
+package scimclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+)
+
+// SCIM 2.0 protocol
+// reference: https://datatracker.ietf.org/doc/html/rfc7644
+
+const (
+	// DefaultPageSize is the default number of resources requested per page.
+	DefaultPageSize = 100
+
+	// SCIM API paths
+	UsersPath  = "/Users"
+	GroupsPath = "/Groups"
+
+	// Content types
+	ContentTypeSCIMJSON = "application/scim+json"
+	ContentTypeJSON     = "application/json"
+)
+
+var (
+	// ErrURLEmpty is returned when the URL is empty.
+	ErrURLEmpty = errors.New("scimclient: url may not be empty")
+
+	// ErrGroupIDEmpty is returned when the group id is empty.
+	ErrGroupIDEmpty = errors.New("scimclient: group id may not be empty")
+
+	// ErrUserIDEmpty is returned when the user id is empty.
+	ErrUserIDEmpty = errors.New("scimclient: user id may not be empty")
+
+	// ErrUserNil is returned when the user is nil.
+	ErrUserNil = errors.New("scimclient: user may not be nil")
+
+	// ErrGroupNil is returned when the group is nil.
+	ErrGroupNil = errors.New("scimclient: group may not be nil")
+
+	// ErrResourceNotFound is returned when no resource matches the filter.
+	ErrResourceNotFound = errors.New("scimclient: resource not found")
+)
+
+// HTTPClient is an interface for sending HTTP requests.
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Client is a SCIM 2.0 client.
+type Client struct {
+	httpClient HTTPClient
+	url        *url.URL
+	pageSize   int
+	UserAgent  string
+
+	// authorize sets the credentials of the requests
+	authorize func(req *http.Request)
+}
+
+// ClientOption is a function that configures a Client.
+type ClientOption func(*Client)
+
+// WithBearerToken configures the bearer token sent in the Authorization header.
+func WithBearerToken(token string) ClientOption {
+	return func(c *Client) {
+		if token == "" {
+			return
+		}
+		c.authorize = func(req *http.Request) {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+}
+
+// WithBasicAuth configures the user name and password sent with HTTP basic authentication.
+func WithBasicAuth(username, password string) ClientOption {
+	return func(c *Client) {
+		if username == "" {
+			return
+		}
+		c.authorize = func(req *http.Request) {
+			req.SetBasicAuth(username, password)
+		}
+	}
+}
+
+// WithPageSize configures the number of resources requested per page, DefaultPageSize when zero.
+func WithPageSize(pageSize int) ClientOption {
+	return func(c *Client) {
+		if pageSize > 0 {
+			c.pageSize = pageSize
+		}
+	}
+}
+
+// NewClient returns a new SCIM client for the given base url, e.g. https://scim.example.com/scim/v2.
+func NewClient(httpClient HTTPClient, baseURL string, opts ...ClientOption) (*Client, error) {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	if baseURL == "" {
+		return nil, ErrURLEmpty
+	}
+
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("scimclient: error parsing url: %w", err)
+	}
+
+	c := &Client{
+		httpClient: httpClient,
+		url:        u,
+		pageSize:   DefaultPageSize,
+		authorize:  func(*http.Request) {},
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c, nil
+}
+
+// buildURL returns the url of the given resource path with the given query parameters.
+func (c *Client) buildURL(resourcePath string, query url.Values) string {
+	u := *c.url
+	u.Path = path.Join(u.Path, resourcePath)
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
+
+// get sends a GET request to the given url and decodes the JSON response into v.
+func (c *Client) get(ctx context.Context, reqURL string, v any) error {
+	_, err := c.do(ctx, http.MethodGet, reqURL, "", nil, v)
+	return err
+}
+
+// do sends a request to the given url with the JSON encoded body, if any, and decodes the JSON
+// response into v, if any. The request is conditional to the given ETag when it is not empty.
+// It returns the ETag of the response, empty when the service provider doesn't send it.
+// reference: https://datatracker.ietf.org/doc/html/rfc7644#section-3.14
+func (c *Client) do(ctx context.Context, method, reqURL, ifMatch string, body, v any) (string, error) {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return "", fmt.Errorf("scimclient: error encoding request body, url: %s, error: %w", reqURL, err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, reqBody)
+	if err != nil {
+		return "", fmt.Errorf("scimclient: error creating request, url: %s, error: %w", reqURL, err)
+	}
+
+	req.Header.Set("Accept", ContentTypeSCIMJSON+", "+ContentTypeJSON)
+	if body != nil {
+		req.Header.Set("Content-Type", ContentTypeSCIMJSON)
+	}
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+	c.authorize(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("scimclient: error sending request, url: %s, error: %w", reqURL, err)
+	}
+	defer resp.Body.Close()
+
+	if err := checkHTTPResponse(resp); err != nil {
+		return "", err
+	}
+
+	// the service providers may answer the modifications with 204 No Content
+	if v != nil && resp.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil && !errors.Is(err, io.EOF) {
+			return "", fmt.Errorf("scimclient: error decoding response body, url: %s, error: %w", reqURL, err)
+		}
+	}
+
+	return resp.Header.Get("ETag"), nil
+}
+
+// checkHTTPResponse returns an APIError when the status code of the response is not successful.
+func checkHTTPResponse(resp *http.Response) error {
+	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusBadRequest {
+		return nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("scimclient: error reading response body: %w", err)
+	}
+
+	apiErr := &APIError{}
+	if json.Unmarshal(body, apiErr) != nil || apiErr.Detail == "" {
+		apiErr = &APIError{ScimType: resp.Status, Detail: string(body)}
+	}
+	apiErr.StatusCode = resp.StatusCode
+
+	slog.Debug("scimclient checkHTTPResponse()", "statusCode", resp.StatusCode, "scimType", apiErr.ScimType)
+
+	return apiErr
+}
+
+// list queries the given resource path with the given filter and returns the resources
+// of all the pages, requested with the startIndex and count parameters.
+// reference: https://datatracker.ietf.org/doc/html/rfc7644#section-3.4.2.4
+func list[T any](ctx context.Context, c *Client, resourcePath string, query url.Values) ([]T, error) {
+	items := make([]T, 0, c.pageSize)
+
+	for startIndex := 1; ; {
+		q := url.Values{}
+		for k, v := range query {
+			q[k] = v
+		}
+		q.Set("startIndex", strconv.Itoa(startIndex))
+		q.Set("count", strconv.Itoa(c.pageSize))
+
+		var page ListResponse[T]
+		if err := c.get(ctx, c.buildURL(resourcePath, q), &page); err != nil {
+			return nil, err
+		}
+
+		items = append(items, page.Resources...)
+		startIndex += len(page.Resources)
+
+		// an empty page ends the query even when the total results is not reached, it
+		// protects from the service providers that count resources they don't return
+		if len(page.Resources) == 0 || startIndex > page.TotalResults {
+			break
+		}
+	}
+
+	return items, nil
+}
+
+// ListGroups returns the groups matching the given filter expressions, all the groups when filter is empty.
+// Groups matching more than one expression are returned once. The members are not requested.
+// reference: https://datatracker.ietf.org/doc/html/rfc7644#section-3.4.2.2
+func (c *Client) ListGroups(ctx context.Context, filter []string) ([]*Group, error) {
+	return listUnique(ctx, c, GroupsPath, filter, "members", func(g *Group) string { return g.ID })
+}
+
+// ListUsers returns the users matching the given filter expressions, all the users when filter is empty.
+// Users matching more than one expression are returned once.
+// reference: https://datatracker.ietf.org/doc/html/rfc7644#section-3.4.2.2
+func (c *Client) ListUsers(ctx context.Context, filter []string) ([]*User, error) {
+	return listUnique(ctx, c, UsersPath, filter, "", func(u *User) string { return u.ID })
+}
+
+// listUnique queries the given resource path once per filter expression and returns the
+// resources without duplicates.
+func listUnique[T any](ctx context.Context, c *Client, resourcePath string, filter []string, excludedAttributes string, id func(T) string) ([]T, error) {
+	filters := filter
+	if len(filters) == 0 {
+		filters = []string{""}
+	}
+
+	uniq := make(map[string]struct{})
+	items := make([]T, 0, c.pageSize)
+
+	for _, f := range filters {
+		q := url.Values{}
+		if f != "" {
+			q.Set("filter", f)
+		}
+		if excludedAttributes != "" {
+			q.Set("excludedAttributes", excludedAttributes)
+		}
+
+		slog.Debug("scimclient: listing resources", "path", resourcePath, "filter", f)
+
+		rs, err := list[T](ctx, c, resourcePath, q)
+		if err != nil {
+			return nil, fmt.Errorf("scimclient: failed to list %s with filter %q: %w", resourcePath, f, err)
+		}
+
+		for _, r := range rs {
+			if _, ok := uniq[id(r)]; ok {
+				continue
+			}
+			uniq[id(r)] = struct{}{}
+			items = append(items, r)
+		}
+	}
+
+	return items, nil
+}
+
+// GetGroup returns a group, with its members, given its id.
+// reference: https://datatracker.ietf.org/doc/html/rfc7644#section-3.4.1
+func (c *Client) GetGroup(ctx context.Context, groupID string) (*Group, error) {
+	if groupID == "" {
+		return nil, ErrGroupIDEmpty
+	}
+
+	var g Group
+	if err := c.get(ctx, c.buildURL(GroupsPath+"/"+url.PathEscape(groupID), nil), &g); err != nil {
+		return nil, fmt.Errorf("scimclient: error getting group %s: %w", groupID, err)
+	}
+
+	return &g, nil
+}
+
+// ListGroupMembers returns the user members of the given group, the members of the nested
+// groups are included and every user is returned once.
+func (c *Client) ListGroupMembers(ctx context.Context, groupID string) ([]*Member, error) {
+	if groupID == "" {
+		return nil, ErrGroupIDEmpty
+	}
+
+	visited := map[string]struct{}{groupID: {}}
+	uniq := make(map[string]struct{})
+	members := make([]*Member, 0)
+
+	for pending := []string{groupID}; len(pending) > 0; {
+		id := pending[0]
+		pending = pending[1:]
+
+		g, err := c.GetGroup(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("scimclient: failed to list members of group %s: %w", groupID, err)
+		}
+
+		for _, m := range g.Members {
+			if m.Value == "" {
+				continue
+			}
+
+			if m.IsGroup() {
+				if _, ok := visited[m.Value]; !ok {
+					visited[m.Value] = struct{}{}
+					pending = append(pending, m.Value)
+				}
+				continue
+			}
+
+			if _, ok := uniq[m.Value]; ok {
+				continue
+			}
+			uniq[m.Value] = struct{}{}
+			members = append(members, m)
+		}
+	}
+
+	return members, nil
+}
+
+// GetUser returns a user given its id.
+// reference: https://datatracker.ietf.org/doc/html/rfc7644#section-3.4.1
+func (c *Client) GetUser(ctx context.Context, userID string) (*User, error) {
+	if userID == "" {
+		return nil, ErrUserIDEmpty
+	}
+
+	var u User
+	if err := c.get(ctx, c.buildURL(UsersPath+"/"+url.PathEscape(userID), nil), &u); err != nil {
+		return nil, fmt.Errorf("scimclient: error getting user %s: %w", userID, err)
+	}
+
+	return &u, nil
+}
+
+// GetUserByUserName returns the user with the given user name.
+// ErrResourceNotFound is returned when the user doesn't exist.
+func (c *Client) GetUserByUserName(ctx context.Context, userName string) (*User, error) {
+	users, err := list[*User](ctx, c, UsersPath, url.Values{"filter": {fmt.Sprintf("userName eq %q", userName)}})
+	if err != nil {
+		return nil, fmt.Errorf("scimclient: error getting user %s: %w", userName, err)
+	}
+
+	if len(users) == 0 {
+		return nil, fmt.Errorf("%w: user %s", ErrResourceNotFound, userName)
+	}
+
+	return users[0], nil
+}
+
+// GetGroupByDisplayName returns the group, without its members, with the given display name.
+// ErrResourceNotFound is returned when the group doesn't exist.
+func (c *Client) GetGroupByDisplayName(ctx context.Context, displayName string) (*Group, error) {
+	q := url.Values{
+		"filter":             {fmt.Sprintf("displayName eq %q", displayName)},
+		"excludedAttributes": {"members"},
+	}
+
+	groups, err := list[*Group](ctx, c, GroupsPath, q)
+	if err != nil {
+		return nil, fmt.Errorf("scimclient: error getting group %s: %w", displayName, err)
+	}
+
+	if len(groups) == 0 {
+		return nil, fmt.Errorf("%w: group %s", ErrResourceNotFound, displayName)
+	}
+
+	return groups[0], nil
+}
+
+// CreateUser creates the given user and returns it as created by the service provider.
+// reference: https://datatracker.ietf.org/doc/html/rfc7644#section-3.3
+func (c *Client) CreateUser(ctx context.Context, u *User) (*User, error) {
+	if u == nil {
+		return nil, ErrUserNil
+	}
+
+	if len(u.Schemas) == 0 {
+		u.Schemas = userSchemas(u)
+	}
+
+	var created User
+	etag, err := c.do(ctx, http.MethodPost, c.buildURL(UsersPath, nil), "", u, &created)
+	if err != nil {
+		return nil, fmt.Errorf("scimclient: error creating user %s: %w", u.UserName, err)
+	}
+	created.Meta = withVersion(created.Meta, etag)
+
+	return &created, nil
+}
+
+// ReplaceUser replaces the attributes of the given user, the request is conditional to the
+// version of the user when it is defined in its meta attribute.
+// reference: https://datatracker.ietf.org/doc/html/rfc7644#section-3.5.1
+func (c *Client) ReplaceUser(ctx context.Context, u *User) (*User, error) {
+	if u == nil {
+		return nil, ErrUserNil
+	}
+
+	if u.ID == "" {
+		return nil, ErrUserIDEmpty
+	}
+
+	if len(u.Schemas) == 0 {
+		u.Schemas = userSchemas(u)
+	}
+
+	var replaced User
+	etag, err := c.do(ctx, http.MethodPut, c.buildURL(UsersPath+"/"+url.PathEscape(u.ID), nil), versionOf(u.Meta), u, &replaced)
+	if err != nil {
+		return nil, fmt.Errorf("scimclient: error replacing user %s: %w", u.ID, err)
+	}
+
+	// the service providers may not return the user
+	if replaced.ID == "" {
+		replaced = *u
+		replaced.Meta = nil
+	}
+	replaced.Meta = withVersion(replaced.Meta, etag)
+
+	return &replaced, nil
+}
+
+// DeleteUser deletes the user with the given id.
+// reference: https://datatracker.ietf.org/doc/html/rfc7644#section-3.6
+func (c *Client) DeleteUser(ctx context.Context, userID string) error {
+	if userID == "" {
+		return ErrUserIDEmpty
+	}
+
+	if _, err := c.do(ctx, http.MethodDelete, c.buildURL(UsersPath+"/"+url.PathEscape(userID), nil), "", nil, nil); err != nil {
+		return fmt.Errorf("scimclient: error deleting user %s: %w", userID, err)
+	}
+
+	return nil
+}
+
+// CreateGroup creates the given group and returns it as created by the service provider.
+// reference: https://datatracker.ietf.org/doc/html/rfc7644#section-3.3
+func (c *Client) CreateGroup(ctx context.Context, g *Group) (*Group, error) {
+	if g == nil {
+		return nil, ErrGroupNil
+	}
+
+	if len(g.Schemas) == 0 {
+		g.Schemas = []string{SchemaGroup}
+	}
+
+	var created Group
+	etag, err := c.do(ctx, http.MethodPost, c.buildURL(GroupsPath, nil), "", g, &created)
+	if err != nil {
+		return nil, fmt.Errorf("scimclient: error creating group %s: %w", g.DisplayName, err)
+	}
+	created.Meta = withVersion(created.Meta, etag)
+
+	return &created, nil
+}
+
+// PatchGroup applies the given operations to the group with the given id, the request is
+// conditional to the given version when it is not empty. It returns the version of the group
+// after the operations, empty when the service provider doesn't send it.
+// reference: https://datatracker.ietf.org/doc/html/rfc7644#section-3.5.2
+func (c *Client) PatchGroup(ctx context.Context, groupID, version string, ops []*PatchOperation) (string, error) {
+	if groupID == "" {
+		return "", ErrGroupIDEmpty
+	}
+
+	patch := &PatchOp{
+		Schemas:    []string{SchemaPatchOp},
+		Operations: ops,
+	}
+
+	// the body is not decoded, the service providers may answer with the group and all its members
+	etag, err := c.do(ctx, http.MethodPatch, c.buildURL(GroupsPath+"/"+url.PathEscape(groupID), nil), version, patch, nil)
+	if err != nil {
+		return "", fmt.Errorf("scimclient: error patching group %s: %w", groupID, err)
+	}
+
+	return etag, nil
+}
+
+// DeleteGroup deletes the group with the given id.
+// reference: https://datatracker.ietf.org/doc/html/rfc7644#section-3.6
+func (c *Client) DeleteGroup(ctx context.Context, groupID string) error {
+	if groupID == "" {
+		return ErrGroupIDEmpty
+	}
+
+	if _, err := c.do(ctx, http.MethodDelete, c.buildURL(GroupsPath+"/"+url.PathEscape(groupID), nil), "", nil, nil); err != nil {
+		return fmt.Errorf("scimclient: error deleting group %s: %w", groupID, err)
+	}
+
+	return nil
+}
+
+// userSchemas returns the schemas of the given user.
+func userSchemas(u *User) []string {
+	if u.EnterpriseUser != nil {
+		return []string{SchemaUser, SchemaEnterpriseUser}
+	}
+
+	return []string{SchemaUser}
+}
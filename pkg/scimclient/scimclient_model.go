@@ -0,0 +1,235 @@
+package scimclient
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SCIM schemas
+// reference: https://datatracker.ietf.org/doc/html/rfc7643#section-8.7.1
+const (
+	SchemaUser           = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SchemaGroup          = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	SchemaEnterpriseUser = "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"
+	SchemaListResponse   = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SchemaError          = "urn:ietf:params:scim:api:messages:2.0:Error"
+	SchemaPatchOp        = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+)
+
+// Member types
+// reference: https://datatracker.ietf.org/doc/html/rfc7643#section-4.2
+const (
+	MemberTypeUser  = "User"
+	MemberTypeGroup = "Group"
+)
+
+// Name is the components of the user's name.
+type Name struct {
+	Formatted       string `json:"formatted,omitempty"`
+	FamilyName      string `json:"familyName,omitempty"`
+	GivenName       string `json:"givenName,omitempty"`
+	MiddleName      string `json:"middleName,omitempty"`
+	HonorificPrefix string `json:"honorificPrefix,omitempty"`
+	HonorificSuffix string `json:"honorificSuffix,omitempty"`
+}
+
+// Email is an email address of the user.
+type Email struct {
+	Value   string `json:"value,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+// PhoneNumber is a phone number of the user.
+type PhoneNumber struct {
+	Value   string `json:"value,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+// Address is a physical mailing address of the user.
+type Address struct {
+	Formatted     string `json:"formatted,omitempty"`
+	StreetAddress string `json:"streetAddress,omitempty"`
+	Locality      string `json:"locality,omitempty"`
+	Region        string `json:"region,omitempty"`
+	PostalCode    string `json:"postalCode,omitempty"`
+	Country       string `json:"country,omitempty"`
+	Type          string `json:"type,omitempty"`
+	Primary       bool   `json:"primary,omitempty"`
+}
+
+// Manager is the user's manager.
+type Manager struct {
+	Value       string `json:"value,omitempty"`
+	Ref         string `json:"$ref,omitempty"`
+	DisplayName string `json:"displayName,omitempty"`
+}
+
+// EnterpriseUser is the enterprise user schema extension.
+// reference: https://datatracker.ietf.org/doc/html/rfc7643#section-4.3
+type EnterpriseUser struct {
+	EmployeeNumber string   `json:"employeeNumber,omitempty"`
+	CostCenter     string   `json:"costCenter,omitempty"`
+	Organization   string   `json:"organization,omitempty"`
+	Division       string   `json:"division,omitempty"`
+	Department     string   `json:"department,omitempty"`
+	Manager        *Manager `json:"manager,omitempty"`
+}
+
+// Meta is the resource metadata.
+type Meta struct {
+	ResourceType string `json:"resourceType,omitempty"`
+	Created      string `json:"created,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	Location     string `json:"location,omitempty"`
+	Version      string `json:"version,omitempty"`
+}
+
+// versionOf returns the version of the resource with the given metadata, empty when unknown.
+func versionOf(m *Meta) string {
+	if m == nil {
+		return ""
+	}
+
+	return m.Version
+}
+
+// withVersion returns the given metadata with the version of the ETag, the version of the
+// metadata is kept when the ETag is empty.
+// reference: https://datatracker.ietf.org/doc/html/rfc7644#section-3.14
+func withVersion(m *Meta, etag string) *Meta {
+	if etag == "" {
+		return m
+	}
+
+	if m == nil {
+		m = &Meta{}
+	}
+	m.Version = etag
+
+	return m
+}
+
+// Member is a member of a group, a user or a nested group.
+type Member struct {
+	Value   string `json:"value,omitempty"`
+	Ref     string `json:"$ref,omitempty"`
+	Display string `json:"display,omitempty"`
+	Type    string `json:"type,omitempty"`
+}
+
+// IsGroup returns true when the member is a nested group.
+// The member type is optional, members without type are users.
+func (m *Member) IsGroup() bool {
+	return strings.EqualFold(m.Type, MemberTypeGroup)
+}
+
+// User is a SCIM user resource.
+// reference: https://datatracker.ietf.org/doc/html/rfc7643#section-4.1
+type User struct {
+	Schemas           []string        `json:"schemas,omitempty"`
+	ID                string          `json:"id"`
+	ExternalID        string          `json:"externalId,omitempty"`
+	UserName          string          `json:"userName"`
+	Name              *Name           `json:"name,omitempty"`
+	DisplayName       string          `json:"displayName,omitempty"`
+	NickName          string          `json:"nickName,omitempty"`
+	ProfileURL        string          `json:"profileUrl,omitempty"`
+	Title             string          `json:"title,omitempty"`
+	UserType          string          `json:"userType,omitempty"`
+	PreferredLanguage string          `json:"preferredLanguage,omitempty"`
+	Locale            string          `json:"locale,omitempty"`
+	Timezone          string          `json:"timezone,omitempty"`
+	Active            *bool           `json:"active,omitempty"`
+	Emails            []*Email        `json:"emails,omitempty"`
+	PhoneNumbers      []*PhoneNumber  `json:"phoneNumbers,omitempty"`
+	Addresses         []*Address      `json:"addresses,omitempty"`
+	EnterpriseUser    *EnterpriseUser `json:"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User,omitempty"`
+	Meta              *Meta           `json:"meta,omitempty"`
+}
+
+// IsActive returns true when the user is active, the active attribute is optional
+// and users without it are active.
+func (u *User) IsActive() bool {
+	return u.Active == nil || *u.Active
+}
+
+// PrimaryEmail returns the primary email address of the user, the first email when
+// none is primary, or the user name when it is an email address.
+func (u *User) PrimaryEmail() string {
+	for _, e := range u.Emails {
+		if e.Primary && e.Value != "" {
+			return e.Value
+		}
+	}
+
+	for _, e := range u.Emails {
+		if e.Value != "" {
+			return e.Value
+		}
+	}
+
+	if strings.Contains(u.UserName, "@") {
+		return u.UserName
+	}
+
+	return ""
+}
+
+// Group is a SCIM group resource.
+// reference: https://datatracker.ietf.org/doc/html/rfc7643#section-4.2
+type Group struct {
+	Schemas     []string  `json:"schemas,omitempty"`
+	ID          string    `json:"id"`
+	ExternalID  string    `json:"externalId,omitempty"`
+	DisplayName string    `json:"displayName"`
+	Members     []*Member `json:"members,omitempty"`
+	Meta        *Meta     `json:"meta,omitempty"`
+}
+
+// Patch operations
+// reference: https://datatracker.ietf.org/doc/html/rfc7644#section-3.5.2
+const (
+	PatchOpAdd     = "add"
+	PatchOpRemove  = "remove"
+	PatchOpReplace = "replace"
+)
+
+// PatchOp is a request to modify some attributes of a resource.
+// reference: https://datatracker.ietf.org/doc/html/rfc7644#section-3.5.2
+type PatchOp struct {
+	Schemas    []string          `json:"schemas"`
+	Operations []*PatchOperation `json:"Operations"`
+}
+
+// PatchOperation is an operation of a PatchOp request.
+type PatchOperation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path,omitempty"`
+	Value any    `json:"value,omitempty"`
+}
+
+// ListResponse is a page of a query response.
+// reference: https://datatracker.ietf.org/doc/html/rfc7644#section-3.4.2
+type ListResponse[T any] struct {
+	Schemas      []string `json:"schemas"`
+	TotalResults int      `json:"totalResults"`
+	StartIndex   int      `json:"startIndex"`
+	ItemsPerPage int      `json:"itemsPerPage"`
+	Resources    []T      `json:"Resources"`
+}
+
+// APIError is returned when the SCIM service provider responds with an error.
+// The status attribute of the error response is not decoded, it is a string by the RFC
+// but some service providers send a number, the response status code is used instead.
+// reference: https://datatracker.ietf.org/doc/html/rfc7644#section-3.12
+type APIError struct {
+	StatusCode int    `json:"-"`
+	ScimType   string `json:"scimType,omitempty"`
+	Detail     string `json:"detail,omitempty"`
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("statusCode: %d, scimType: %s, detail: %s", e.StatusCode, e.ScimType, e.Detail)
+}
@@ -0,0 +1,352 @@
+package scimclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newSCIMServer returns an httptest SCIM service provider stand-in serving the given handlers
+// under /scim/v2 and a client authenticated with a bearer token. The handler patterns may be
+// prefixed with the method, e.g. "POST /Users".
+func newSCIMServer(t *testing.T, handlers map[string]http.HandlerFunc, opts ...ClientOption) *Client {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	for p, h := range handlers {
+		method, p, ok := strings.Cut(p, " ")
+		if !ok {
+			method, p = "", method
+		} else {
+			method += " "
+		}
+		mux.HandleFunc(method+"/scim/v2"+p, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+			h(w, r)
+		})
+	}
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	c, err := NewClient(srv.Client(), srv.URL+"/scim/v2", append([]ClientOption{WithBearerToken("token")}, opts...)...)
+	assert.NoError(t, err)
+
+	return c
+}
+
+func writeJSON(t *testing.T, w http.ResponseWriter, v any) {
+	t.Helper()
+
+	w.Header().Set("Content-Type", ContentTypeSCIMJSON)
+	assert.NoError(t, json.NewEncoder(w).Encode(v))
+}
+
+// pagedHandler serves the given resources honoring the startIndex and count parameters.
+func pagedHandler[T any](t *testing.T, resources []T, filter string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, filter, r.URL.Query().Get("filter"))
+
+		startIndex, _ := strconv.Atoi(r.URL.Query().Get("startIndex"))
+		count, _ := strconv.Atoi(r.URL.Query().Get("count"))
+
+		from := min(startIndex-1, len(resources))
+		to := min(from+count, len(resources))
+
+		writeJSON(t, w, ListResponse[T]{
+			Schemas:      []string{SchemaListResponse},
+			TotalResults: len(resources),
+			StartIndex:   startIndex,
+			ItemsPerPage: to - from,
+			Resources:    resources[from:to],
+		})
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	t.Run("should return error when url is empty", func(t *testing.T) {
+		got, err := NewClient(nil, "")
+		assert.ErrorIs(t, err, ErrURLEmpty)
+		assert.Nil(t, got)
+	})
+
+	t.Run("should return error when url is bad formed", func(t *testing.T) {
+		got, err := NewClient(nil, "https://%%scim.example.com")
+		assert.Error(t, err)
+		assert.Nil(t, got)
+	})
+
+	t.Run("should return client", func(t *testing.T) {
+		got, err := NewClient(nil, "https://scim.example.com", WithPageSize(10))
+		assert.NoError(t, err)
+		assert.Equal(t, 10, got.pageSize)
+	})
+}
+
+func TestBasicAuth(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "sync", user)
+		assert.Equal(t, "secret", pass)
+		writeJSON(t, w, User{ID: "u1", UserName: "alice@example.com"})
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(srv.Client(), srv.URL, WithBasicAuth("sync", "secret"))
+	assert.NoError(t, err)
+
+	got, err := c.GetUser(context.Background(), "u1")
+	assert.NoError(t, err)
+	assert.Equal(t, "alice@example.com", got.UserName)
+}
+
+func TestListUsers(t *testing.T) {
+	users := make([]*User, 0, 5)
+	for i := range 5 {
+		users = append(users, &User{ID: fmt.Sprintf("u%d", i), UserName: fmt.Sprintf("user%d@example.com", i)})
+	}
+
+	t.Run("should follow the pages", func(t *testing.T) {
+		c := newSCIMServer(t, map[string]http.HandlerFunc{
+			UsersPath: pagedHandler(t, users, ""),
+		}, WithPageSize(2))
+
+		got, err := c.ListUsers(context.Background(), nil)
+		assert.NoError(t, err)
+		assert.Equal(t, users, got)
+	})
+
+	t.Run("should return the users of every filter once", func(t *testing.T) {
+		calls := 0
+		c := newSCIMServer(t, map[string]http.HandlerFunc{
+			UsersPath: func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				if r.URL.Query().Get("filter") == `userName sw "user1"` {
+					pagedHandler(t, users[1:2], `userName sw "user1"`)(w, r)
+					return
+				}
+				pagedHandler(t, users[:2], `title eq "Engineer"`)(w, r)
+			},
+		})
+
+		got, err := c.ListUsers(context.Background(), []string{`title eq "Engineer"`, `userName sw "user1"`})
+		assert.NoError(t, err)
+		assert.Equal(t, users[:2], got)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("should return the api error", func(t *testing.T) {
+		c := newSCIMServer(t, map[string]http.HandlerFunc{
+			UsersPath: func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+				writeJSON(t, w, map[string]any{"schemas": []string{SchemaError}, "scimType": "invalidFilter", "detail": "bad filter", "status": 400})
+			},
+		})
+
+		got, err := c.ListUsers(context.Background(), []string{"bad"})
+		var apiErr *APIError
+		assert.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, &APIError{StatusCode: http.StatusBadRequest, ScimType: "invalidFilter", Detail: "bad filter"}, apiErr)
+		assert.Nil(t, got)
+	})
+}
+
+func TestListGroups(t *testing.T) {
+	groups := []*Group{{ID: "g1", DisplayName: "AWS-1"}, {ID: "g2", DisplayName: "AWS-2"}}
+
+	c := newSCIMServer(t, map[string]http.HandlerFunc{
+		GroupsPath: func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "members", r.URL.Query().Get("excludedAttributes"))
+			pagedHandler(t, groups, `displayName sw "AWS"`)(w, r)
+		},
+	})
+
+	got, err := c.ListGroups(context.Background(), []string{`displayName sw "AWS"`})
+	assert.NoError(t, err)
+	assert.Equal(t, groups, got)
+}
+
+func TestListGroupMembers(t *testing.T) {
+	groups := map[string]*Group{
+		"g1": {ID: "g1", Members: []*Member{
+			{Value: "u1", Type: MemberTypeUser},
+			{Value: "g2", Type: MemberTypeGroup},
+		}},
+		"g2": {ID: "g2", Members: []*Member{
+			{Value: "u1"},
+			{Value: "u2"},
+			{Value: "g1", Type: MemberTypeGroup},
+		}},
+	}
+
+	c := newSCIMServer(t, map[string]http.HandlerFunc{
+		GroupsPath + "/{id}": func(w http.ResponseWriter, r *http.Request) {
+			g, ok := groups[r.PathValue("id")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				writeJSON(t, w, map[string]any{"detail": "not found", "status": "404"})
+				return
+			}
+			writeJSON(t, w, g)
+		},
+	})
+
+	t.Run("should expand the nested groups", func(t *testing.T) {
+		got, err := c.ListGroupMembers(context.Background(), "g1")
+		assert.NoError(t, err)
+		assert.Equal(t, []*Member{{Value: "u1", Type: MemberTypeUser}, {Value: "u2"}}, got)
+	})
+
+	t.Run("should return error when the group doesn't exist", func(t *testing.T) {
+		got, err := c.ListGroupMembers(context.Background(), "missing")
+		var apiErr *APIError
+		assert.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+		assert.Nil(t, got)
+	})
+
+	t.Run("should return error when group id is empty", func(t *testing.T) {
+		got, err := c.ListGroupMembers(context.Background(), "")
+		assert.ErrorIs(t, err, ErrGroupIDEmpty)
+		assert.Nil(t, got)
+	})
+}
+
+func TestUserPrimaryEmail(t *testing.T) {
+	assert.Equal(t, "b@example.com", (&User{Emails: []*Email{{Value: "a@example.com"}, {Value: "b@example.com", Primary: true}}}).PrimaryEmail())
+	assert.Equal(t, "a@example.com", (&User{Emails: []*Email{{Value: "a@example.com"}}}).PrimaryEmail())
+	assert.Equal(t, "c@example.com", (&User{UserName: "c@example.com"}).PrimaryEmail())
+	assert.Empty(t, (&User{UserName: "c"}).PrimaryEmail())
+}
+
+func TestGetUserByUserName(t *testing.T) {
+	users := []*User{{ID: "u1", UserName: "alice@example.com"}}
+
+	t.Run("should return the user", func(t *testing.T) {
+		c := newSCIMServer(t, map[string]http.HandlerFunc{
+			UsersPath: pagedHandler(t, users, `userName eq "alice@example.com"`),
+		})
+
+		got, err := c.GetUserByUserName(context.Background(), "alice@example.com")
+		assert.NoError(t, err)
+		assert.Equal(t, users[0], got)
+	})
+
+	t.Run("should return error when the user doesn't exist", func(t *testing.T) {
+		c := newSCIMServer(t, map[string]http.HandlerFunc{
+			UsersPath: pagedHandler(t, []*User{}, `userName eq "bob@example.com"`),
+		})
+
+		got, err := c.GetUserByUserName(context.Background(), "bob@example.com")
+		assert.ErrorIs(t, err, ErrResourceNotFound)
+		assert.Nil(t, got)
+	})
+}
+
+func TestCreateUser(t *testing.T) {
+	c := newSCIMServer(t, map[string]http.HandlerFunc{
+		"POST " + UsersPath: func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, ContentTypeSCIMJSON, r.Header.Get("Content-Type"))
+
+			var u User
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&u))
+			assert.Equal(t, []string{SchemaUser, SchemaEnterpriseUser}, u.Schemas)
+
+			u.ID = "u1"
+			w.Header().Set("ETag", `W/"1"`)
+			w.WriteHeader(http.StatusCreated)
+			writeJSON(t, w, u)
+		},
+	})
+
+	got, err := c.CreateUser(context.Background(), &User{UserName: "alice@example.com", EnterpriseUser: &EnterpriseUser{Department: "IT"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "u1", got.ID)
+	assert.Equal(t, `W/"1"`, got.Meta.Version)
+
+	_, err = c.CreateUser(context.Background(), nil)
+	assert.ErrorIs(t, err, ErrUserNil)
+}
+
+func TestReplaceUser(t *testing.T) {
+	c := newSCIMServer(t, map[string]http.HandlerFunc{
+		"PUT " + UsersPath + "/{id}": func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("If-Match") != `W/"1"` {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				writeJSON(t, w, map[string]any{"detail": "version mismatch"})
+				return
+			}
+			w.Header().Set("ETag", `W/"2"`)
+			w.WriteHeader(http.StatusNoContent)
+		},
+	})
+
+	t.Run("should replace the user of the version", func(t *testing.T) {
+		got, err := c.ReplaceUser(context.Background(), &User{ID: "u1", UserName: "alice@example.com", Meta: &Meta{Version: `W/"1"`}})
+		assert.NoError(t, err)
+		assert.Equal(t, "alice@example.com", got.UserName)
+		assert.Equal(t, &Meta{Version: `W/"2"`}, got.Meta)
+	})
+
+	t.Run("should return error when the user was modified", func(t *testing.T) {
+		got, err := c.ReplaceUser(context.Background(), &User{ID: "u1", UserName: "alice@example.com", Meta: &Meta{Version: `W/"0"`}})
+		var apiErr *APIError
+		assert.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusPreconditionFailed, apiErr.StatusCode)
+		assert.Nil(t, got)
+	})
+
+	t.Run("should return error when user id is empty", func(t *testing.T) {
+		_, err := c.ReplaceUser(context.Background(), &User{UserName: "alice@example.com"})
+		assert.ErrorIs(t, err, ErrUserIDEmpty)
+	})
+}
+
+func TestPatchGroup(t *testing.T) {
+	c := newSCIMServer(t, map[string]http.HandlerFunc{
+		"PATCH " + GroupsPath + "/{id}": func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "g1", r.PathValue("id"))
+			assert.Empty(t, r.Header.Get("If-Match"))
+
+			var p PatchOp
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&p))
+			assert.Equal(t, []string{SchemaPatchOp}, p.Schemas)
+			assert.Equal(t, []*PatchOperation{{Op: PatchOpRemove, Path: `members[value eq "u1"]`}}, p.Operations)
+
+			// the group is returned with all its members
+			writeJSON(t, w, Group{ID: "g1", Members: []*Member{{Value: "u2"}}})
+		},
+	})
+
+	version, err := c.PatchGroup(context.Background(), "g1", "", []*PatchOperation{{Op: PatchOpRemove, Path: `members[value eq "u1"]`}})
+	assert.NoError(t, err)
+	assert.Empty(t, version)
+
+	_, err = c.PatchGroup(context.Background(), "", "", nil)
+	assert.ErrorIs(t, err, ErrGroupIDEmpty)
+}
+
+func TestDelete(t *testing.T) {
+	deleted := make([]string, 0, 2)
+	c := newSCIMServer(t, map[string]http.HandlerFunc{
+		"DELETE /{resource}/{id}": func(w http.ResponseWriter, r *http.Request) {
+			deleted = append(deleted, r.PathValue("resource")+"/"+r.PathValue("id"))
+			w.WriteHeader(http.StatusNoContent)
+		},
+	})
+
+	assert.NoError(t, c.DeleteUser(context.Background(), "u1"))
+	assert.NoError(t, c.DeleteGroup(context.Background(), "g1"))
+	assert.Equal(t, []string{"Users/u1", "Groups/g1"}, deleted)
+
+	assert.ErrorIs(t, c.DeleteUser(context.Background(), ""), ErrUserIDEmpty)
+	assert.ErrorIs(t, c.DeleteGroup(context.Background(), ""), ErrGroupIDEmpty)
+}